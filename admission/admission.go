@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package admission helps admission webhooks protect fields of objects.
+// Given the old and new object of a request along with their managed
+// fields, Analyze computes which fields the request changes and which it
+// takes ownership of, and Evaluate checks them against field-level
+// policies.
+package admission
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Request is the part of an admission request that the package needs.
+type Request struct {
+	// OldObject is the object before the request, or nil if the request
+	// creates it.
+	OldObject *typed.TypedValue
+	// NewObject is the object after the request.
+	NewObject *typed.TypedValue
+	// OldManagedFields and NewManagedFields are the managed fields of
+	// the objects.
+	OldManagedFields fieldpath.ManagedFields
+	NewManagedFields fieldpath.ManagedFields
+	// Manager is the manager making the request, as named in the managed
+	// fields.
+	Manager string
+}
+
+// Attempt is what a request attempts to do to an object.
+type Attempt struct {
+	// Manager is the manager making the request, and Identity its
+	// parsed name.
+	Manager  string
+	Identity merge.ManagerIdentity
+	// Added, Modified and Removed are the fields whose values the
+	// request changes.
+	Added    *fieldpath.Set
+	Modified *fieldpath.Set
+	Removed  *fieldpath.Set
+	// Acquired are the fields the manager owns after the request but not
+	// before, and Released those it owned before but not after.
+	Acquired *fieldpath.Set
+	Released *fieldpath.Set
+	// TakenFrom are the fields acquired by the manager that other
+	// managers owned before the request and no longer do, by manager.
+	TakenFrom map[string]*fieldpath.Set
+}
+
+// Changed returns the fields whose values the request changes.
+func (a *Attempt) Changed() *fieldpath.Set {
+	return a.Added.Union(a.Modified).Union(a.Removed)
+}
+
+// Analyze returns what the request attempts to do. Managed fields are
+// compared as sets, regardless of their versions.
+func Analyze(req Request) (*Attempt, error) {
+	if req.NewObject == nil {
+		return nil, fmt.Errorf("new object is required")
+	}
+	oldObject := req.OldObject
+	if oldObject == nil {
+		var err error
+		oldObject, err = typed.AsTyped(value.NewValueInterface(nil), req.NewObject.Schema(), req.NewObject.TypeRef())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create empty object: %v", err)
+		}
+	}
+	comparison, err := oldObject.Compare(req.NewObject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare objects: %v", err)
+	}
+
+	a := &Attempt{
+		Manager:   req.Manager,
+		Identity:  merge.ParseManagerIdentity(req.Manager),
+		Added:     comparison.Added,
+		Modified:  comparison.Modified,
+		Removed:   comparison.Removed,
+		TakenFrom: map[string]*fieldpath.Set{},
+	}
+	before, after := managedSet(req.OldManagedFields, req.Manager), managedSet(req.NewManagedFields, req.Manager)
+	a.Acquired = after.Difference(before)
+	a.Released = before.Difference(after)
+	for manager, vs := range req.OldManagedFields {
+		if manager == req.Manager {
+			continue
+		}
+		lost := vs.Set().Difference(managedSet(req.NewManagedFields, manager))
+		if taken := lost.Intersection(a.Acquired); !taken.Empty() {
+			a.TakenFrom[manager] = taken
+		}
+	}
+	return a, nil
+}
+
+func managedSet(managers fieldpath.ManagedFields, manager string) *fieldpath.Set {
+	if vs, ok := managers[manager]; ok {
+		return vs.Set()
+	}
+	return fieldpath.NewSet()
+}
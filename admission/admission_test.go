@@ -0,0 +1,202 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/admission"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+var parser = func() *typed.Parser {
+	p, err := typed.NewParser(`types:
+- name: deployment
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: paused
+      type:
+        scalar: boolean
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+`)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+func object(t *testing.T, y typed.YAMLObject) *typed.TypedValue {
+	tv, err := parser.Type("deployment").FromYAML(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tv
+}
+
+func managed(manager string, applied bool, paths ...fieldpath.Path) fieldpath.ManagedFields {
+	return fieldpath.ManagedFields{
+		manager: fieldpath.NewVersionedSet(fieldpath.NewSet(paths...), "v1", applied),
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	hpa := merge.ManagerIdentity{Manager: "hpa", Operation: "Update"}.String()
+	old := managed("kubectl", true, fieldpath.MakePathOrDie("replicas"), fieldpath.MakePathOrDie("labels", "app"))
+	newManaged := managed("kubectl", true, fieldpath.MakePathOrDie("labels", "app"))
+	newManaged[hpa] = fieldpath.NewVersionedSet(fieldpath.NewSet(fieldpath.MakePathOrDie("replicas"), fieldpath.MakePathOrDie("paused")), "v1", false)
+
+	a, err := admission.Analyze(admission.Request{
+		OldObject:        object(t, `{"replicas": 1, "labels": {"app": "a"}}`),
+		NewObject:        object(t, `{"replicas": 3, "paused": true, "labels": {"app": "a"}}`),
+		OldManagedFields: old,
+		NewManagedFields: newManaged,
+		Manager:          hpa,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Identity.Manager != "hpa" || a.Identity.Operation != "Update" {
+		t.Errorf("unexpected identity %+v", a.Identity)
+	}
+	for _, f := range []struct {
+		name          string
+		got, expected *fieldpath.Set
+	}{
+		{"added", a.Added, fieldpath.NewSet(fieldpath.MakePathOrDie("paused"))},
+		{"modified", a.Modified, fieldpath.NewSet(fieldpath.MakePathOrDie("replicas"))},
+		{"removed", a.Removed, fieldpath.NewSet()},
+		{"acquired", a.Acquired, fieldpath.NewSet(fieldpath.MakePathOrDie("replicas"), fieldpath.MakePathOrDie("paused"))},
+		{"released", a.Released, fieldpath.NewSet()},
+	} {
+		if !f.got.Equals(f.expected) {
+			t.Errorf("expected %v fields:\n%v\ngot:\n%v", f.name, f.expected, f.got)
+		}
+	}
+	if len(a.TakenFrom) != 1 || !a.TakenFrom["kubectl"].Equals(fieldpath.NewSet(fieldpath.MakePathOrDie("replicas"))) {
+		t.Errorf("unexpected taken fields %v", a.TakenFrom)
+	}
+}
+
+func TestAnalyzeCreate(t *testing.T) {
+	a, err := admission.Analyze(admission.Request{
+		NewObject:        object(t, `{"replicas": 1}`),
+		NewManagedFields: managed("kubectl", true, fieldpath.MakePathOrDie("replicas")),
+		Manager:          "kubectl",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas := fieldpath.NewSet(fieldpath.MakePathOrDie("replicas"))
+	if !a.Added.Equals(replicas) || !a.Acquired.Equals(replicas) {
+		t.Errorf("expected replicas to be added and acquired, got %v and %v", a.Added, a.Acquired)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	protected := fieldpath.NewSet(fieldpath.MakePathOrDie("labels"))
+	policies := []admission.Policy{
+		admission.ProtectValues("labels", protected, admission.ManagerNamed("admin")),
+		admission.ProtectOwnership("replicas", fieldpath.NewSet(fieldpath.MakePathOrDie("replicas")), admission.ManagerNamed("hpa")),
+		admission.ProtectOwners("hpa", fieldpath.NewSet(fieldpath.MakePathOrDie("replicas")), admission.ManagerNamed("hpa"), nil),
+	}
+	oldObject := object(t, `{"replicas": 1, "labels": {"app": "a"}}`)
+	oldManaged := managed("hpa", false, fieldpath.MakePathOrDie("replicas"))
+
+	for _, tc := range []struct {
+		name       string
+		manager    string
+		newObject  typed.YAMLObject
+		newManaged fieldpath.ManagedFields
+		expected   []string
+	}{
+		{
+			name:      "allowed",
+			manager:   "kubectl",
+			newObject: `{"replicas": 1, "labels": {"app": "a"}, "paused": true}`,
+			newManaged: func() fieldpath.ManagedFields {
+				m := managed("kubectl", true, fieldpath.MakePathOrDie("paused"))
+				m["hpa"] = oldManaged["hpa"]
+				return m
+			}(),
+		},
+		{
+			name:       "exempt",
+			manager:    "admin",
+			newObject:  `{"replicas": 1, "labels": {"app": "b"}}`,
+			newManaged: oldManaged,
+		},
+		{
+			name:       "changes labels",
+			manager:    "kubectl",
+			newObject:  `{"replicas": 1, "labels": {"app": "b", "tier": "c"}}`,
+			newManaged: oldManaged,
+			expected: []string{
+				`.labels.app: may not be changed by kubectl (policy labels)`,
+				`.labels.tier: may not be changed by kubectl (policy labels)`,
+			},
+		},
+		{
+			name:       "takes replicas",
+			manager:    "kubectl",
+			newObject:  `{"replicas": 2, "labels": {"app": "a"}}`,
+			newManaged: managed("kubectl", true, fieldpath.MakePathOrDie("replicas")),
+			expected: []string{
+				`.replicas: may not be owned by kubectl (policy replicas)`,
+				`.replicas: may not be taken from hpa (policy hpa)`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := admission.Analyze(admission.Request{
+				OldObject:        oldObject,
+				NewObject:        object(t, tc.newObject),
+				OldManagedFields: oldManaged,
+				NewManagedFields: tc.newManaged,
+				Manager:          tc.manager,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = admission.Evaluate(a, policies...)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("expected no violation, got %v", err)
+				}
+				return
+			}
+			violations, ok := err.(admission.Violations)
+			if !ok || len(violations) != len(tc.expected) {
+				t.Fatalf("expected %d violations, got %v", len(tc.expected), err)
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected %q in:\n%v", expected, err)
+				}
+			}
+		})
+	}
+}
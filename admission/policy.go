@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+)
+
+// Violation is a field that a request isn't allowed to change or own.
+type Violation struct {
+	// Policy is the name of the violated policy.
+	Policy string
+	// Path is the field.
+	Path fieldpath.Path
+	// Reason explains what the request isn't allowed to do.
+	Reason string
+}
+
+// Violations is the set of violations of a request. It is an error.
+type Violations []Violation
+
+// Error returns a message listing the violations.
+func (violations Violations) Error() string {
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, fmt.Sprintf("%v: %v (policy %v)", v.Path, v.Reason, v.Policy))
+	}
+	sort.Strings(messages)
+	return fmt.Sprintf("%d field policy violation(s):\n  %v", len(violations), strings.Join(messages, "\n  "))
+}
+
+// Policy returns the violations of the attempt, if any.
+type Policy func(*Attempt) Violations
+
+// ManagerMatcher returns whether a manager is subject to a policy.
+type ManagerMatcher func(merge.ManagerIdentity) bool
+
+// ProtectValues returns a policy that denies the managers that aren't
+// exempt from changing the values of fields, or of anything below them.
+func ProtectValues(name string, fields *fieldpath.Set, exempt ManagerMatcher) Policy {
+	return func(a *Attempt) Violations {
+		if exempt != nil && exempt(a.Identity) {
+			return nil
+		}
+		return covered(name, fields, a.Changed(), "may not be changed by "+a.Manager)
+	}
+}
+
+// ProtectOwnership returns a policy that denies the managers that aren't
+// exempt from acquiring fields, or anything below them, whether by
+// setting them or by taking them from other managers.
+func ProtectOwnership(name string, fields *fieldpath.Set, exempt ManagerMatcher) Policy {
+	return func(a *Attempt) Violations {
+		if exempt != nil && exempt(a.Identity) {
+			return nil
+		}
+		return covered(name, fields, a.Acquired, "may not be owned by "+a.Manager)
+	}
+}
+
+// ProtectOwners returns a policy that denies taking fields, or anything
+// below them, from the managers that are protected, unless the request is
+// made by an exempt manager.
+func ProtectOwners(name string, fields *fieldpath.Set, protected, exempt ManagerMatcher) Policy {
+	return func(a *Attempt) Violations {
+		if exempt != nil && exempt(a.Identity) {
+			return nil
+		}
+		var violations Violations
+		for manager, taken := range a.TakenFrom {
+			if protected(merge.ParseManagerIdentity(manager)) {
+				violations = append(violations, covered(name, fields, taken, "may not be taken from "+manager)...)
+			}
+		}
+		return violations
+	}
+}
+
+// ManagerNamed returns a matcher of the managers with any of the names,
+// regardless of their operation and subresource.
+func ManagerNamed(names ...string) ManagerMatcher {
+	return func(id merge.ManagerIdentity) bool {
+		for _, name := range names {
+			if id.Manager == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Evaluate returns the violations of the attempt of all the policies, as
+// an error, or nil if there are none.
+func Evaluate(a *Attempt, policies ...Policy) error {
+	var violations Violations
+	for _, policy := range policies {
+		violations = append(violations, policy(a)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// covered returns a violation for each of the leaves of paths covered by
+// fields.
+func covered(name string, fields, paths *fieldpath.Set, reason string) Violations {
+	var violations Violations
+	paths.Leaves().Iterate(func(p fieldpath.Path) {
+		if fields.CoversPath(p) {
+			violations = append(violations, Violation{Policy: name, Path: p.Copy(), Reason: reason})
+		}
+	})
+	return violations
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmarks_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/benchmarks"
+)
+
+func TestOperations(t *testing.T) {
+	for _, c := range benchmarks.Corpus() {
+		for _, op := range benchmarks.Operations() {
+			fn, err := op.Prepare(c)
+			if err != nil {
+				t.Fatalf("%v/%v: failed to prepare: %v", c.Name, op.Name, err)
+			}
+			if err := fn(); err != nil {
+				t.Errorf("%v/%v: failed: %v", c.Name, op.Name, err)
+			}
+		}
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	baseline := []benchmarks.Result{
+		{Case: "a", Operation: "Merge", NsPerOp: 100, AllocsPerOp: 10, BytesPerOp: 1000},
+		{Case: "b", Operation: "Merge", NsPerOp: 100, AllocsPerOp: 10, BytesPerOp: 1000},
+	}
+	current := []benchmarks.Result{
+		{Case: "a", Operation: "Merge", NsPerOp: 105, AllocsPerOp: 10, BytesPerOp: 1000},
+		{Case: "b", Operation: "Merge", NsPerOp: 100, AllocsPerOp: 12, BytesPerOp: 1000},
+		{Case: "c", Operation: "Merge", NsPerOp: 1000, AllocsPerOp: 100, BytesPerOp: 10000},
+	}
+	regressions := benchmarks.Regressions(baseline, current, 0.1)
+	if len(regressions) != 1 || regressions[0].Current != current[1] || regressions[0].Baseline != baseline[1] {
+		t.Errorf("expected a regression of b, got %v", regressions)
+	}
+}
+
+func BenchmarkCorpus(b *testing.B) {
+	benchmarks.Run(b, benchmarks.Corpus(), benchmarks.Operations())
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package benchmarks provides a fixed corpus of realistic inputs, and
+// helpers to measure the operations of structured-merge-diff against it,
+// so that performance changes can be compared over time, both in the
+// library and in its consumers.
+package benchmarks
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// Case is an input of the corpus.
+type Case struct {
+	Name string
+	Type typed.ParseableType
+	// Object is an unstructured object of the type, and Modified is the
+	// same object with a small fraction of its fields changed, added and
+	// removed.
+	Object, Modified interface{}
+}
+
+const schemaYAML = `types:
+- name: pod
+  map:
+    fields:
+    - name: metadata
+      type:
+        namedType: metadata
+    - name: spec
+      type:
+        namedType: podSpec
+- name: metadata
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+    - name: annotations
+      type:
+        map:
+          elementType:
+            scalar: string
+- name: podSpec
+  map:
+    fields:
+    - name: containers
+      type:
+        list:
+          elementType:
+            namedType: container
+          elementRelationship: associative
+          keys:
+          - name
+    - name: volumes
+      type:
+        list:
+          elementType:
+            namedType: volume
+          elementRelationship: associative
+          keys:
+          - name
+- name: container
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: image
+      type:
+        scalar: string
+    - name: args
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: env
+      type:
+        list:
+          elementType:
+            namedType: envVar
+          elementRelationship: associative
+          keys:
+          - name
+    - name: ports
+      type:
+        list:
+          elementType:
+            namedType: port
+          elementRelationship: associative
+          keys:
+          - containerPort
+          - protocol
+- name: envVar
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: value
+      type:
+        scalar: string
+- name: port
+  map:
+    fields:
+    - name: containerPort
+      type:
+        scalar: numeric
+    - name: protocol
+      type:
+        scalar: string
+- name: volume
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: configMap
+      type:
+        map:
+          fields:
+          - name: name
+            type:
+              scalar: string
+- name: customResource
+  map:
+    fields:
+    - name: metadata
+      type:
+        namedType: metadata
+    - name: spec
+      type:
+        map:
+          fields:
+          - name: entries
+            type:
+              list:
+                elementType:
+                  namedType: entry
+                elementRelationship: associative
+                keys:
+                - name
+- name: entry
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: value
+      type:
+        scalar: numeric
+    - name: tags
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+- name: tree
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: value
+      type:
+        scalar: numeric
+    - name: child
+      type:
+        namedType: tree
+`
+
+// Parser parses the types of the corpus: "pod", "customResource" and
+// "tree".
+var Parser = func() *typed.Parser {
+	parser, err := typed.NewParser(schemaYAML)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+// Corpus returns the cases of the corpus. The objects are generated, so
+// the same version of this package always returns the same cases.
+func Corpus() []Case {
+	return []Case{
+		{
+			Name:     "LargePod",
+			Type:     Parser.Type("pod"),
+			Object:   pod(50, 0),
+			Modified: pod(51, 7),
+		},
+		{
+			Name:     "HugeListCR",
+			Type:     Parser.Type("customResource"),
+			Object:   customResource(0, 5000, 0),
+			Modified: customResource(50, 5050, 10),
+		},
+		{
+			Name:     "DeepRecursion",
+			Type:     Parser.Type("tree"),
+			Object:   tree(500, 0),
+			Modified: tree(500, 1),
+		},
+	}
+}
+
+func metadata(name string, labels int) map[string]interface{} {
+	l := map[string]interface{}{}
+	for i := 0; i < labels; i++ {
+		l[fmt.Sprintf("label-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	return map[string]interface{}{
+		"name":        name,
+		"labels":      l,
+		"annotations": map[string]interface{}{"description": "generated for benchmarks"},
+	}
+}
+
+// pod returns a pod with the given number of containers. The image of
+// every container whose index is a multiple of change, if not zero, is
+// changed.
+func pod(containers, change int) interface{} {
+	var cs, vs []interface{}
+	for i := 0; i < containers; i++ {
+		image := "registry.example/app:v1"
+		if change != 0 && i%change == 0 {
+			image = "registry.example/app:v2"
+		}
+		var env, ports []interface{}
+		for j := 0; j < 20; j++ {
+			env = append(env, map[string]interface{}{"name": fmt.Sprintf("VAR_%d", j), "value": fmt.Sprintf("%d", i*j)})
+		}
+		for j := 0; j < 3; j++ {
+			ports = append(ports, map[string]interface{}{"containerPort": 8000 + j, "protocol": "TCP"})
+		}
+		cs = append(cs, map[string]interface{}{
+			"name":  fmt.Sprintf("container-%d", i),
+			"image": image,
+			"args":  []interface{}{"--verbose", fmt.Sprintf("--id=%d", i)},
+			"env":   env,
+			"ports": ports,
+		})
+		vs = append(vs, map[string]interface{}{
+			"name":      fmt.Sprintf("volume-%d", i),
+			"configMap": map[string]interface{}{"name": fmt.Sprintf("config-%d", i)},
+		})
+	}
+	return map[string]interface{}{
+		"metadata": metadata("large-pod", 20),
+		"spec":     map[string]interface{}{"containers": cs, "volumes": vs},
+	}
+}
+
+// customResource returns a custom resource with the entries in the range.
+// The value of every entry whose index is a multiple of change, if not
+// zero, is changed.
+func customResource(from, to, change int) interface{} {
+	var entries []interface{}
+	for i := from; i < to; i++ {
+		value := i
+		if change != 0 && i%change == 0 {
+			value = -i
+		}
+		entries = append(entries, map[string]interface{}{
+			"name":  fmt.Sprintf("entry-%d", i),
+			"value": value,
+			"tags":  []interface{}{"a", "b", fmt.Sprintf("tag-%d", i%10)},
+		})
+	}
+	return map[string]interface{}{
+		"metadata": metadata("huge-list", 5),
+		"spec":     map[string]interface{}{"entries": entries},
+	}
+}
+
+// tree returns a tree of the given depth, whose deepest value is offset.
+func tree(depth, offset int) interface{} {
+	var t map[string]interface{}
+	for i := depth; i > 0; i-- {
+		node := map[string]interface{}{"name": fmt.Sprintf("node-%d", i), "value": i}
+		if t != nil {
+			node["child"] = t
+		} else {
+			node["value"] = i + offset
+		}
+		t = node
+	}
+	return t
+}
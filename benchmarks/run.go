@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmarks
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// Operation is an operation measured against the cases of the corpus.
+type Operation struct {
+	Name string
+	// Prepare sets up the operation for a case, outside of the
+	// measurement, and returns the function that is measured.
+	Prepare func(c Case) (func() error, error)
+}
+
+// Operations returns the standard operations: parsing the object,
+// computing its field set, and merging, comparing and applying the
+// modified object onto it.
+func Operations() []Operation {
+	return []Operation{
+		{Name: "Parse", Prepare: func(c Case) (func() error, error) {
+			return func() error {
+				_, err := c.Type.FromUnstructured(c.Object)
+				return err
+			}, nil
+		}},
+		{Name: "ToFieldSet", Prepare: func(c Case) (func() error, error) {
+			tv, _, err := parse(c)
+			if err != nil {
+				return nil, err
+			}
+			return func() error {
+				_, err := tv.ToFieldSet()
+				return err
+			}, nil
+		}},
+		{Name: "Merge", Prepare: func(c Case) (func() error, error) {
+			tv, modified, err := parse(c)
+			if err != nil {
+				return nil, err
+			}
+			return func() error {
+				_, err := tv.Merge(modified)
+				return err
+			}, nil
+		}},
+		{Name: "Compare", Prepare: func(c Case) (func() error, error) {
+			tv, modified, err := parse(c)
+			if err != nil {
+				return nil, err
+			}
+			return func() error {
+				_, err := tv.Compare(modified)
+				return err
+			}, nil
+		}},
+		{Name: "Apply", Prepare: func(c Case) (func() error, error) {
+			tv, modified, err := parse(c)
+			if err != nil {
+				return nil, err
+			}
+			updater := &merge.Updater{Converter: sameVersionConverter{}}
+			live, managers, err := updater.Apply(tv.Empty(), tv, "v1", fieldpath.ManagedFields{}, "creator", false)
+			if err != nil {
+				return nil, err
+			}
+			return func() error {
+				_, _, err := updater.Apply(live, modified, "v1", managers.Copy(), "applier", true)
+				return err
+			}, nil
+		}},
+	}
+}
+
+func parse(c Case) (object, modified *typed.TypedValue, err error) {
+	if object, err = c.Type.FromUnstructured(c.Object); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse object of %v: %v", c.Name, err)
+	}
+	if modified, err = c.Type.FromUnstructured(c.Modified); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse modified object of %v: %v", c.Name, err)
+	}
+	return object, modified, nil
+}
+
+type sameVersionConverter struct{}
+
+func (sameVersionConverter) Convert(object *typed.TypedValue, _ fieldpath.APIVersion) (*typed.TypedValue, error) {
+	return object, nil
+}
+
+func (sameVersionConverter) IsMissingVersionError(error) bool {
+	return false
+}
+
+// Run runs every operation against every case as a sub-benchmark of b,
+// named after the case and the operation.
+func Run(b *testing.B, cases []Case, operations []Operation) {
+	for _, c := range cases {
+		for _, op := range operations {
+			c, op := c, op
+			b.Run(c.Name+"/"+op.Name, func(b *testing.B) {
+				fn, err := op.Prepare(c)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := fn(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Result is the measurement of an operation on a case.
+type Result struct {
+	Case        string
+	Operation   string
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// Measure runs every operation against every case, outside of a test, and
+// returns the results.
+func Measure(cases []Case, operations []Operation) ([]Result, error) {
+	var results []Result
+	for _, c := range cases {
+		for _, op := range operations {
+			fn, err := op.Prepare(c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare %v on %v: %v", op.Name, c.Name, err)
+			}
+			if err := fn(); err != nil {
+				return nil, fmt.Errorf("failed to run %v on %v: %v", op.Name, c.Name, err)
+			}
+			r := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					fn()
+				}
+			})
+			results = append(results, Result{
+				Case:        c.Name,
+				Operation:   op.Name,
+				NsPerOp:     r.NsPerOp(),
+				AllocsPerOp: r.AllocsPerOp(),
+				BytesPerOp:  r.AllocedBytesPerOp(),
+			})
+		}
+	}
+	return results, nil
+}
+
+// Regression is a result that got worse than its baseline.
+type Regression struct {
+	Baseline, Current Result
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%v/%v: %v ns/op, %v allocs/op, %v B/op (was %v ns/op, %v allocs/op, %v B/op)",
+		r.Current.Case, r.Current.Operation,
+		r.Current.NsPerOp, r.Current.AllocsPerOp, r.Current.BytesPerOp,
+		r.Baseline.NsPerOp, r.Baseline.AllocsPerOp, r.Baseline.BytesPerOp)
+}
+
+// Regressions returns the current results whose time, allocations or
+// allocated bytes per operation exceed those of the baseline result for
+// the same case and operation by more than the given tolerance, e.g. 0.1
+// for 10%. Results without a baseline are ignored.
+func Regressions(baseline, current []Result, tolerance float64) []Regression {
+	base := map[[2]string]Result{}
+	for _, r := range baseline {
+		base[[2]string{r.Case, r.Operation}] = r
+	}
+	exceeds := func(current, baseline int64) bool {
+		return float64(current) > float64(baseline)*(1+tolerance)
+	}
+	var regressions []Regression
+	for _, r := range current {
+		b, ok := base[[2]string{r.Case, r.Operation}]
+		if !ok {
+			continue
+		}
+		if exceeds(r.NsPerOp, b.NsPerOp) || exceeds(r.AllocsPerOp, b.AllocsPerOp) || exceeds(r.BytesPerOp, b.BytesPerOp) {
+			regressions = append(regressions, Regression{Baseline: b, Current: r})
+		}
+	}
+	return regressions
+}
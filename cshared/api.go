@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// abiVersion is the version of the functions, requests and responses of
+// the library.
+const abiVersion = 1
+
+// server serves the requests naming a registered schema.
+var server = smdserver.NewServer()
+
+// request holds the fields of the requests of all the operations.
+type request struct {
+	Schema     string `json:"schema,omitempty"`
+	SchemaName string `json:"schemaName,omitempty"`
+	Type       string `json:"type"`
+
+	LHS json.RawMessage `json:"lhs,omitempty"`
+	RHS json.RawMessage `json:"rhs,omitempty"`
+
+	Object        json.RawMessage      `json:"object,omitempty"`
+	ManagedFields []managedFieldsEntry `json:"managedFields,omitempty"`
+	Manager       string               `json:"manager,omitempty"`
+}
+
+type managedFieldsEntry struct {
+	Manager    string          `json:"manager"`
+	APIVersion string          `json:"apiVersion"`
+	Applied    bool            `json:"applied,omitempty"`
+	FieldsV1   json.RawMessage `json:"fieldsV1,omitempty"`
+}
+
+type response struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// comparison is the result of compare, with the sets serialized as in
+// managed fields.
+type comparison struct {
+	Added    json.RawMessage `json:"added"`
+	Modified json.RawMessage `json:"modified"`
+	Removed  json.RawMessage `json:"removed"`
+}
+
+// registerSchema registers the schema under name.
+func registerSchema(name, schema string) []byte {
+	if err := server.RegisterSchema(name, typed.YAMLObject(schema)); err != nil {
+		return encodeResponse(nil, err)
+	}
+	return encodeResponse(true, nil)
+}
+
+// merge merges the object "rhs" into "lhs", and returns the merged
+// object.
+func merge(data []byte) []byte {
+	return serve(data, func(s *smdserver.Server, t smdserver.ObjectType, req *request) (interface{}, error) {
+		resp, err := s.Merge(context.Background(), &smdserver.MergeRequest{ObjectType: t, LHS: req.LHS, RHS: req.RHS})
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(resp.Object), nil
+	})
+}
+
+// compare compares the object "lhs" to "rhs", and returns the fields
+// "added", "modified" and "removed" from lhs to rhs.
+func compare(data []byte) []byte {
+	return serve(data, func(s *smdserver.Server, t smdserver.ObjectType, req *request) (interface{}, error) {
+		resp, err := s.Compare(context.Background(), &smdserver.CompareRequest{ObjectType: t, LHS: req.LHS, RHS: req.RHS})
+		if err != nil {
+			return nil, err
+		}
+		return comparison{Added: resp.Added, Modified: resp.Modified, Removed: resp.Removed}, nil
+	})
+}
+
+// extract returns the fields of "object" owned by "manager" according to
+// "managedFields".
+func extract(data []byte) []byte {
+	return serve(data, func(s *smdserver.Server, t smdserver.ObjectType, req *request) (interface{}, error) {
+		extractReq := &smdserver.ExtractRequest{ObjectType: t, Object: req.Object, Manager: req.Manager}
+		for _, entry := range req.ManagedFields {
+			extractReq.ManagedFields = append(extractReq.ManagedFields, smdserver.ManagedFieldsEntry{
+				Manager:    entry.Manager,
+				APIVersion: entry.APIVersion,
+				Applied:    entry.Applied,
+				FieldsV1:   entry.FieldsV1,
+			})
+		}
+		resp, err := s.Extract(context.Background(), extractReq)
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(resp.Object), nil
+	})
+}
+
+// serve decodes the request in data, resolves its schema and returns the
+// encoded response of op.
+func serve(data []byte, op func(s *smdserver.Server, t smdserver.ObjectType, req *request) (interface{}, error)) []byte {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return encodeResponse(nil, fmt.Errorf("failed to decode request: %v", err))
+	}
+	s := server
+	t := smdserver.ObjectType{Schema: req.SchemaName, Type: req.Type}
+	if req.Schema != "" {
+		s = smdserver.NewServer()
+		t.Schema = "inline"
+		if err := s.RegisterSchema(t.Schema, typed.YAMLObject(req.Schema)); err != nil {
+			return encodeResponse(nil, err)
+		}
+	}
+	return encodeResponse(op(s, t, &req))
+}
+
+// recovered returns the response of op, or an error response if op
+// panics, so that a malformed input can't crash the host process: the
+// panic would otherwise unwind through the C frames of the caller.
+func recovered(op func() []byte) (out []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			out = encodeResponse(nil, fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return op()
+}
+
+func encodeResponse(result interface{}, err error) []byte {
+	resp := response{Result: result}
+	if err != nil {
+		resp = response{Error: err.Error()}
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		out, _ = json.Marshal(response{Error: fmt.Sprintf("failed to encode response: %v", err)})
+	}
+	return out
+}
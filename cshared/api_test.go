@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver"
+)
+
+const schema = `types:
+- name: deployment
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: image
+      type:
+        scalar: string
+`
+
+func TestOperations(t *testing.T) {
+	if resp := string(registerSchema("apps", schema)); resp != `{"result":true}` {
+		t.Fatalf("failed to register schema: %s", resp)
+	}
+	for _, tc := range []struct {
+		name     string
+		op       func([]byte) []byte
+		request  string
+		expected string
+	}{
+		{
+			name:     "merge",
+			op:       merge,
+			request:  `{"schemaName": "apps", "type": "deployment", "lhs": {"replicas": 1, "image": "a:1"}, "rhs": {"image": "a:2"}}`,
+			expected: `{"result":{"image":"a:2","replicas":1}}`,
+		},
+		{
+			name:     "compare",
+			op:       compare,
+			request:  `{"schemaName": "apps", "type": "deployment", "lhs": {"replicas": 1, "image": "a:1"}, "rhs": {"image": "a:2"}}`,
+			expected: `{"result":{"added":{},"modified":{"f:image":{}},"removed":{"f:replicas":{}}}}`,
+		},
+		{
+			name:     "extract",
+			op:       extract,
+			request:  `{"schemaName": "apps", "type": "deployment", "object": {"replicas": 1, "image": "a:1"}, "managedFields": [{"manager": "one", "apiVersion": "v1", "applied": true, "fieldsV1": {"f:image": {}}}], "manager": "one"}`,
+			expected: `{"result":{"image":"a:1"}}`,
+		},
+		{
+			name:     "inline schema",
+			op:       merge,
+			request:  `{"schema": "types:\n- name: t\n  scalar: string\n", "type": "t", "lhs": "a", "rhs": "b"}`,
+			expected: `{"result":"b"}`,
+		},
+		{
+			name:     "malformed request",
+			op:       merge,
+			request:  `{`,
+			expected: `{"error":"failed to decode request: unexpected end of JSON input"}`,
+		},
+		{
+			name:     "unknown schema",
+			op:       compare,
+			request:  `{"schemaName": "batch", "type": "job", "lhs": {}, "rhs": {}}`,
+			expected: `{"error":"unknown schema \"batch\""}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if resp := string(tc.op([]byte(tc.request))); resp != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, resp)
+			}
+		})
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	data := []byte(`{"schema": "types:\n- name: t\n  scalar: string\n", "type": "t", "lhs": "a", "rhs": "b"}`)
+	resp := recovered(func() []byte {
+		return serve(data, func(s *smdserver.Server, _ smdserver.ObjectType, _ *request) (interface{}, error) {
+			var m map[string]int
+			m["boom"]++
+			return nil, nil
+		})
+	})
+	expected := `{"error":"panic: assignment to entry in nil map"}`
+	if string(resp) != expected {
+		t.Errorf("expected %s, got %s", expected, resp)
+	}
+	if resp := string(recovered(func() []byte { return merge(data) })); resp != `{"result":"b"}` {
+		t.Errorf("expected the response of the operation, got %s", resp)
+	}
+}
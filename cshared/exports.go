@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"unsafe"
+)
+
+//export smd_abi_version
+func smd_abi_version() C.int {
+	return abiVersion
+}
+
+//export smd_register_schema
+func smd_register_schema(name, schema *C.char) *C.char {
+	return toC(recovered(func() []byte {
+		return registerSchema(C.GoString(name), C.GoString(schema))
+	}))
+}
+
+//export smd_merge
+func smd_merge(request *C.char) *C.char {
+	return toC(recovered(func() []byte {
+		return merge(fromC(request))
+	}))
+}
+
+//export smd_compare
+func smd_compare(request *C.char) *C.char {
+	return toC(recovered(func() []byte {
+		return compare(fromC(request))
+	}))
+}
+
+//export smd_extract
+func smd_extract(request *C.char) *C.char {
+	return toC(recovered(func() []byte {
+		return extract(fromC(request))
+	}))
+}
+
+//export smd_free
+func smd_free(response *C.char) {
+	C.free(unsafe.Pointer(response))
+}
+
+func fromC(s *C.char) []byte {
+	if s == nil {
+		return nil
+	}
+	return []byte(C.GoString(s))
+}
+
+// toC copies the response to the C heap, where the caller frees it with
+// smd_free.
+func toC(response []byte) *C.char {
+	return C.CString(string(response))
+}
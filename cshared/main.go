@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main builds libsmd, a C shared library exposing the merge
+// operations of structured-merge-diff, so that tools in other languages
+// can call them rather than reimplementing them. Build it with:
+//
+//	go build -buildmode=c-shared -o libsmd.so ./cshared
+//
+// which also writes its header, libsmd.h. Every operation takes a
+// NUL-terminated JSON request and returns a NUL-terminated JSON response
+// that the caller must release with smd_free:
+//
+//	char *smd_merge(char *request);
+//	char *smd_compare(char *request);
+//	char *smd_extract(char *request);
+//	void smd_free(char *response);
+//	int smd_abi_version(void);
+//
+// Requests name the type of their objects and carry their schema, either
+// as YAML or JSON in "schema", or as the name of a schema registered
+// with smd_register_schema in "schemaName":
+//
+//	char *smd_register_schema(char *name, char *schema);
+//
+// Responses are {"result": ...} on success and {"error": "..."} on
+// failure. The requests and results of each operation are documented on
+// the Go functions implementing them. Changes to the functions or to
+// their requests and responses that aren't backward compatible increment
+// the version returned by smd_abi_version.
+package main
+
+func main() {}
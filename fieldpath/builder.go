@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"hash/fnv"
+	"runtime"
+	"sync"
+)
+
+// SetBuilder accumulates paths into a Set, and is safe to Insert into from
+// multiple goroutines at the same time. Paths are sharded by a hash of all
+// their path elements, so that goroutines rarely contend on the same lock,
+// even when they all work on the items of the same huge list.
+//
+// The zero value is not usable; create builders with NewSetBuilder.
+type SetBuilder struct {
+	shards []setBuilderShard
+}
+
+type setBuilderShard struct {
+	lock sync.Mutex
+	set  Set
+}
+
+// NewSetBuilder creates a builder with the given number of shards. If shards
+// is not positive, the number of CPUs is used.
+func NewSetBuilder(shards int) *SetBuilder {
+	if shards <= 0 {
+		shards = runtime.NumCPU()
+	}
+	return &SetBuilder{shards: make([]setBuilderShard, shards)}
+}
+
+// Insert adds the field identified by `p` to the set being built. See
+// Set.Insert.
+func (b *SetBuilder) Insert(p Path) {
+	if len(p) == 0 {
+		return
+	}
+	shard := &b.shards[b.shardFor(p)]
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	shard.set.Insert(p)
+}
+
+// Set returns the union of everything inserted so far. The builder remains
+// usable, and the returned set shares no state with it.
+func (b *SetBuilder) Set() *Set {
+	out := NewSet()
+	for i := range b.shards {
+		shard := &b.shards[i]
+		shard.lock.Lock()
+		// Union shares subtrees with its operands, so copy the shard to
+		// keep further inserts from changing the returned set.
+		out = out.Union(shard.set.deepCopy())
+		shard.lock.Unlock()
+	}
+	return out
+}
+
+func (b *SetBuilder) shardFor(p Path) int {
+	if len(b.shards) == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	for _, pe := range p {
+		if pe.FieldName != nil {
+			h.Write([]byte(*pe.FieldName))
+		} else {
+			h.Write([]byte(pe.String()))
+		}
+		// Keeps "a"."b" apart from "ab".
+		h.Write([]byte{0})
+	}
+	return int(h.Sum32() % uint32(len(b.shards)))
+}
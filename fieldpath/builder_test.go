@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetBuilderConcurrentInsert(t *testing.T) {
+	for _, shards := range []int{0, 1, 7} {
+		b := NewSetBuilder(shards)
+		expected := NewSet()
+		paths := make([]Path, 1000)
+		for i := range paths {
+			paths[i] = randomPathMaker.makePath(1, 5)
+			expected.Insert(paths[i])
+		}
+
+		var wg sync.WaitGroup
+		workers := 8
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(paths); i += workers {
+					b.Insert(paths[i])
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		got := b.Set()
+		if !got.Equals(expected) {
+			t.Errorf("shards=%v: expected:\n%v\ngot:\n%v", shards, expected, got)
+		}
+
+		// The returned set must not be affected by later inserts.
+		b.Insert(_P("not", "yet", "there"))
+		if got.Has(_P("not", "yet", "there")) {
+			t.Errorf("shards=%v: returned set changed after insert", shards)
+		}
+		if !b.Set().Has(_P("not", "yet", "there")) {
+			t.Errorf("shards=%v: builder lost insert", shards)
+		}
+	}
+}
+
+func TestSetBuilderSharedPrefix(t *testing.T) {
+	b := NewSetBuilder(8)
+	used := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		p := MakePathOrDie("spec", "containers", i, "image")
+		used[b.shardFor(p)] = true
+		b.Insert(p)
+	}
+	// Items of the same list must not all contend on one shard.
+	if len(used) < len(b.shards)/2 {
+		t.Errorf("expected paths under a shared prefix to spread across shards, used %v of %v", len(used), len(b.shards))
+	}
+	if got := b.Set().Size(); got != 100 {
+		t.Errorf("expected 100 paths, got %v", got)
+	}
+}
+
+func BenchmarkSetBuilderSharedPrefix(b *testing.B) {
+	paths := make([]Path, 1000)
+	for i := range paths {
+		paths[i] = MakePathOrDie("spec", "containers", i, "image")
+	}
+	builder := NewSetBuilder(0)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			builder.Insert(paths[i%len(paths)])
+			i++
+		}
+	})
+}
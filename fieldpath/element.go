@@ -237,6 +237,46 @@ func (s *PathElementSet) Union(s2 *PathElementSet) *PathElementSet {
 	return out
 }
 
+// UnionInPlace adds the elements of s2 to s.
+func (s *PathElementSet) UnionInPlace(s2 *PathElementSet) {
+	added := 0
+	i, j := 0, 0
+	for i < len(s.members) && j < len(s2.members) {
+		if c := s.members[i].Compare(s2.members[j]); c < 0 {
+			i++
+		} else {
+			if c == 0 {
+				i++
+			} else {
+				added++
+			}
+			j++
+		}
+	}
+	added += len(s2.members) - j
+	if added == 0 {
+		return
+	}
+
+	// Merge from the back so that elements of s are moved at most once.
+	i, j = len(s.members)-1, len(s2.members)-1
+	s.members = append(s.members, make(sortedPathElements, added)...)
+	for k := len(s.members) - 1; j >= 0; k-- {
+		if i >= 0 {
+			if c := s.members[i].Compare(s2.members[j]); c >= 0 {
+				s.members[k] = s.members[i]
+				i--
+				if c == 0 {
+					j--
+				}
+				continue
+			}
+		}
+		s.members[k] = s2.members[j]
+		j--
+	}
+}
+
 // Intersection returns a set containing elements which appear in both s and s2.
 func (s *PathElementSet) Intersection(s2 *PathElementSet) *PathElementSet {
 	out := &PathElementSet{}
@@ -279,6 +319,25 @@ func (s *PathElementSet) Difference(s2 *PathElementSet) *PathElementSet {
 	return out
 }
 
+// DifferenceInPlace removes the elements of s2 from s.
+func (s *PathElementSet) DifferenceInPlace(s2 *PathElementSet) {
+	out := s.members[:0]
+	j := 0
+	for _, pe := range s.members {
+		for j < len(s2.members) && s2.members[j].Less(pe) {
+			j++
+		}
+		if j < len(s2.members) && s2.members[j].Equals(pe) {
+			continue
+		}
+		out = append(out, pe)
+	}
+	for k := len(out); k < len(s.members); k++ {
+		s.members[k] = PathElement{}
+	}
+	s.members = out
+}
+
 // Size retuns the number of elements in the set.
 func (s *PathElementSet) Size() int { return len(s.members) }
 
@@ -296,6 +355,24 @@ func (s *PathElementSet) Has(pe PathElement) bool {
 	return false
 }
 
+// IsSubsetOf returns true if every element of s is also in s2.
+func (s *PathElementSet) IsSubsetOf(s2 *PathElementSet) bool {
+	if len(s.members) > len(s2.members) {
+		return false
+	}
+	j := 0
+	for _, pe := range s.members {
+		for j < len(s2.members) && s2.members[j].Less(pe) {
+			j++
+		}
+		if j == len(s2.members) || !s2.members[j].Equals(pe) {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
 // Equals returns true if s and s2 have exactly the same members.
 func (s *PathElementSet) Equals(s2 *PathElementSet) bool {
 	if len(s.members) != len(s2.members) {
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// SetFormat identifies a format of serialized Sets, so that new formats
+// can be introduced while the older ones are still read.
+type SetFormat byte
+
+const (
+	// SetFormatV1 is the JSON format of Set.ToJSON and FieldsV1.
+	SetFormatV1 SetFormat = 1
+
+	// LatestSetFormat is the newest format this version of the library
+	// can read and write.
+	LatestSetFormat = SetFormatV1
+)
+
+// String returns the name of the format, e.g. "v1".
+func (f SetFormat) String() string {
+	return fmt.Sprintf("v%d", byte(f))
+}
+
+// setEnvelopeMagic starts every versioned serialization, followed by the
+// format and the payload. It starts with a byte that can't start a JSON
+// document, so that versioned and bare v1 serializations can be told
+// apart.
+var setEnvelopeMagic = []byte("\x00smd")
+
+// ErrUnknownSetFormat is returned when reading data that isn't a
+// serialized Set in any known format, versioned or not.
+var ErrUnknownSetFormat = errors.New("unknown set format")
+
+// UnsupportedSetFormatError is returned when reading a Set serialized in a
+// format newer than this version of the library supports.
+type UnsupportedSetFormatError struct {
+	Format SetFormat
+}
+
+// Error returns a human readable error message.
+func (e *UnsupportedSetFormatError) Error() string {
+	return fmt.Sprintf("set serialized in format %v, but this version of the library only supports up to %v: it must be upgraded to read it", e.Format, LatestSetFormat)
+}
+
+// ToVersioned serializes s in the given format, in an envelope recording
+// the format, which SetFromVersioned reads back.
+func (s *Set) ToVersioned(format SetFormat) ([]byte, error) {
+	if format != SetFormatV1 {
+		return nil, &UnsupportedSetFormatError{Format: format}
+	}
+	buf := bytes.Buffer{}
+	buf.Write(setEnvelopeMagic)
+	buf.WriteByte(byte(format))
+	if err := s.ToJSONStream(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SniffSetFormat returns the format of a serialized Set, which is either
+// read from its envelope, or SetFormatV1 for bare JSON, as written by
+// Set.ToJSON. The format may be newer than LatestSetFormat.
+func SniffSetFormat(data []byte) (SetFormat, error) {
+	if bytes.HasPrefix(data, setEnvelopeMagic) {
+		if len(data) == len(setEnvelopeMagic) || data[len(setEnvelopeMagic)] == 0 {
+			return 0, fmt.Errorf("%w: missing format in the envelope", ErrUnknownSetFormat)
+		}
+		return SetFormat(data[len(setEnvelopeMagic)]), nil
+	}
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		return SetFormatV1, nil
+	}
+	return 0, ErrUnknownSetFormat
+}
+
+// SetFromVersioned deserializes a Set written by ToVersioned, or by
+// Set.ToJSON. It fails with an *UnsupportedSetFormatError if the format is
+// newer than LatestSetFormat. Empty data is an empty set.
+func SetFromVersioned(data []byte) (*Set, error) {
+	s := NewSet()
+	if len(data) == 0 {
+		return s, nil
+	}
+	format, err := SniffSetFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	if format != SetFormatV1 {
+		return nil, &UnsupportedSetFormatError{Format: format}
+	}
+	if bytes.HasPrefix(data, setEnvelopeMagic) {
+		data = data[len(setEnvelopeMagic)+1:]
+	}
+	if err := s.FromJSON(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersionedRoundTrip(t *testing.T) {
+	s := NewSet(
+		MakePathOrDie("a", "b"),
+		MakePathOrDie("list", KeyByFields("name", "x"), "value"),
+	)
+	data, err := s.ToVersioned(SetFormatV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format, err := SniffSetFormat(data); err != nil || format != SetFormatV1 {
+		t.Errorf("expected to sniff v1, got %v, %v", format, err)
+	}
+	got, err := SetFromVersioned(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equals(s) {
+		t.Errorf("expected %v, got %v", s, got)
+	}
+}
+
+func TestVersionedReadsBareJSON(t *testing.T) {
+	s := NewSet(MakePathOrDie("a", "b"))
+	data, err := s.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := SetFromVersioned(append([]byte("\n "), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equals(s) {
+		t.Errorf("expected %v, got %v", s, got)
+	}
+	if got, err := SetFromVersioned(nil); err != nil || !got.Empty() {
+		t.Errorf("expected an empty set, got %v, %v", got, err)
+	}
+}
+
+func TestVersionedErrors(t *testing.T) {
+	_, err := SetFromVersioned([]byte("\x00smd\x02\xa1\x00"))
+	var unsupported *UnsupportedSetFormatError
+	if !errors.As(err, &unsupported) || unsupported.Format != 2 {
+		t.Errorf("expected format v2 to be unsupported, got %v", err)
+	}
+	if _, err := NewSet().ToVersioned(2); !errors.As(err, &unsupported) {
+		t.Errorf("expected writing format v2 to be unsupported, got %v", err)
+	}
+	for _, data := range []string{"\x00smd", "\x00smd\x00{}", "[]", "\xa1"} {
+		if _, err := SetFromVersioned([]byte(data)); !errors.Is(err, ErrUnknownSetFormat) {
+			t.Errorf("%q: expected an unknown format, got %v", data, err)
+		}
+	}
+	if _, err := SetFromVersioned([]byte("\x00smd\x01{")); !errors.Is(err, ErrInvalidSetJSON) {
+		t.Errorf("expected invalid JSON, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"bytes"
+)
+
+// FieldsV1 has the same layout as the Kubernetes metav1.FieldsV1 type: it
+// holds a Set in the JSON format produced by Set.ToJSON. The format is
+// defined by this package, so conversions live here rather than in every
+// consumer. Convert with a plain type conversion:
+//
+//	set, err := fieldpath.SetFromFieldsV1(fieldpath.FieldsV1(*entry.FieldsV1))
+type FieldsV1 struct {
+	// Raw is the JSON representation of the set.
+	Raw []byte
+}
+
+// SetFromFieldsV1 deserializes f into a new Set. An empty or missing Raw
+// results in an empty set.
+func SetFromFieldsV1(f FieldsV1) (*Set, error) {
+	s := NewSet()
+	if len(f.Raw) == 0 {
+		return s, nil
+	}
+	if err := s.FromJSON(bytes.NewReader(f.Raw)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ToFieldsV1 serializes s into its FieldsV1 representation.
+func (s *Set) ToFieldsV1() (FieldsV1, error) {
+	raw, err := s.ToJSON()
+	if err != nil {
+		return FieldsV1{}, err
+	}
+	return FieldsV1{Raw: raw}, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"fmt"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// fuzzPathElement produces random path elements of every kind. Values are
+// limited to types that survive a JSON round trip unchanged.
+func fuzzPathElement(pe *PathElement, c fuzz.Continue) {
+	switch c.Intn(4) {
+	case 0:
+		var name string
+		c.Fuzz(&name)
+		pe.FieldName = &name
+	case 1:
+		fields := value.FieldList{}
+		for i := 0; i < 1+c.Intn(3); i++ {
+			var name string
+			c.Fuzz(&name)
+			fields = append(fields, value.Field{Name: fmt.Sprintf("%d%s", i, name), Value: fuzzScalar(c)})
+		}
+		fields.Sort()
+		pe.Key = &fields
+	case 2:
+		v := fuzzScalar(c)
+		pe.Value = &v
+	case 3:
+		i := c.Intn(1000)
+		pe.Index = &i
+	}
+}
+
+func fuzzScalar(c fuzz.Continue) value.Value {
+	switch c.Intn(3) {
+	case 0:
+		var s string
+		c.Fuzz(&s)
+		return value.NewValueInterface(s)
+	case 1:
+		return value.NewValueInterface(c.Int63())
+	default:
+		return value.NewValueInterface(c.RandBool())
+	}
+}
+
+func TestFieldsV1RoundTrip(t *testing.T) {
+	f := fuzz.New().NilChance(0).NumElements(1, 5).Funcs(fuzzPathElement)
+	for i := 0; i < 200; i++ {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var paths []Path
+			f.Fuzz(&paths)
+			x := NewSet(paths...)
+
+			fields, err := x.ToFieldsV1()
+			if err != nil {
+				t.Fatalf("Failed to convert %v to FieldsV1: %v", x, err)
+			}
+			x2, err := SetFromFieldsV1(fields)
+			if err != nil {
+				t.Fatalf("Failed to convert %s to a set: %v", fields.Raw, err)
+			}
+			if !x2.Equals(x) {
+				t.Fatalf("failed to reproduce original:\n\n%v\n\n%s\n\n%v\n", x, fields.Raw, x2)
+			}
+		})
+	}
+}
+
+func TestSetFromFieldsV1Empty(t *testing.T) {
+	for _, raw := range [][]byte{nil, []byte(`{}`)} {
+		s, err := SetFromFieldsV1(FieldsV1{Raw: raw})
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", raw, err)
+		}
+		if !s.Empty() {
+			t.Errorf("expected empty set for %q, got %v", raw, s)
+		}
+	}
+}
+
+func TestSetFromFieldsV1Error(t *testing.T) {
+	if _, err := SetFromFieldsV1(FieldsV1{Raw: []byte(`{"f:a":`)}); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// MakePathFromJSONPointer converts an RFC 6901 JSON pointer into a Path.
+// Since there is no schema, segments made only of digits are assumed to be
+// list indices and all other segments are assumed to be field names. A
+// trailing "-" (the JSON Patch "end of list" marker) is dropped, so the
+// resulting path refers to the list itself.
+//
+// Since the items of associative lists and sets are identified by their
+// keys or values in managed fields, never by their index, the resulting
+// paths only match ownership within atomic maps and lists, or of fields
+// outside of any list. Use typed.TypedValue.FieldSetFromJSONPatch to
+// resolve indices against the object being patched.
+func MakePathFromJSONPointer(pointer string) (Path, error) {
+	if pointer == "" {
+		return Path{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with '/'", pointer)
+	}
+	segments := strings.Split(pointer[1:], "/")
+	p := make(Path, 0, len(segments))
+	for i, segment := range segments {
+		if segment == "-" && i == len(segments)-1 {
+			break
+		}
+		segment = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		if isIndex(segment) {
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, fmt.Errorf("JSON pointer %q has invalid index %q: %v", pointer, segment, err)
+			}
+			p = append(p, PathElement{Index: &index})
+			continue
+		}
+		name := segment
+		p = append(p, PathElement{FieldName: &name})
+	}
+	return p, nil
+}
+
+func isIndex(segment string) bool {
+	if segment == "" || (len(segment) > 1 && segment[0] == '0') {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetFromPointers creates a set containing the path of each JSON pointer,
+// as converted by MakePathFromJSONPointer.
+func SetFromPointers(pointers []string) (*Set, error) {
+	s := NewSet()
+	for _, pointer := range pointers {
+		p, err := MakePathFromJSONPointer(pointer)
+		if err != nil {
+			return nil, err
+		}
+		s.Insert(p)
+	}
+	return s, nil
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// SetFromJSONPatch creates a set containing every field touched by an RFC
+// 6902 JSON Patch document:
+//   - "add" and "replace" contribute their path, and every leaf field of
+//     their value under that path, except for an "add" appending to a
+//     list with "-", which only contributes the path of the list,
+//   - "remove" and "copy" contribute their path,
+//   - "move" contributes both its from and its path,
+//   - "test" doesn't modify anything and contributes nothing.
+//
+// The paths are converted by MakePathFromJSONPointer, so the set doesn't
+// intersect with the ownership of associative list or set items. Use
+// typed.TypedValue.FieldSetFromJSONPatch instead when the schema and the
+// object being patched are available.
+func SetFromJSONPatch(patch []byte) (*Set, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON patch: %v", err)
+	}
+	s := NewSet()
+	for i, op := range ops {
+		p, err := MakePathFromJSONPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %v", i, err)
+		}
+		switch op.Op {
+		case "test":
+			continue
+		case "add", "replace":
+			if len(op.Value) == 0 {
+				return nil, fmt.Errorf("operation %d: %q requires a value", i, op.Op)
+			}
+			if strings.HasSuffix(op.Path, "/-") {
+				// The appended element has no path without a
+				// schema, so only the list is touched.
+				break
+			}
+			v, err := value.FromJSON(op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: failed to parse value: %v", i, err)
+			}
+			w := objectWalker{
+				path:      p,
+				value:     v,
+				allocator: value.NewFreelistAllocator(),
+				do:        func(p Path) { s.Insert(p) },
+			}
+			w.walk()
+		case "move":
+			from, err := MakePathFromJSONPointer(op.From)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %v", i, err)
+			}
+			s.Insert(from)
+		case "remove", "copy":
+		default:
+			return nil, fmt.Errorf("operation %d: unknown operation %q", i, op.Op)
+		}
+		s.Insert(p)
+	}
+	return s, nil
+}
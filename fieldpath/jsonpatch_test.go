@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"testing"
+)
+
+func TestMakePathFromJSONPointer(t *testing.T) {
+	table := []struct {
+		pointer   string
+		expected  Path
+		expectErr bool
+	}{
+		{pointer: "", expected: Path{}},
+		{pointer: "/a", expected: _P("a")},
+		{pointer: "/spec/containers/0/image", expected: _P("spec", "containers", 0, "image")},
+		{pointer: "/metadata/annotations/a~1b~0c", expected: _P("metadata", "annotations", "a/b~c")},
+		{pointer: "/spec/list/-", expected: _P("spec", "list")},
+		{pointer: "/spec/01", expected: _P("spec", "01")},
+		{pointer: "/", expected: _P("")},
+		{pointer: "a/b", expectErr: true},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := MakePathFromJSONPointer(tt.pointer)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equals(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetFromPointers(t *testing.T) {
+	got, err := SetFromPointers([]string{"/a/b", "/a/c", "/d/1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := NewSet(_P("a", "b"), _P("a", "c"), _P("d", 1))
+	if !got.Equals(expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+
+	if _, err := SetFromPointers([]string{"/a", "b"}); err == nil {
+		t.Errorf("expected error for invalid pointer")
+	}
+}
+
+func TestSetFromJSONPatch(t *testing.T) {
+	table := []struct {
+		name      string
+		patch     string
+		expected  *Set
+		expectErr bool
+	}{
+		{
+			name: "all operations",
+			patch: `[
+				{"op": "test", "path": "/metadata/name", "value": "foo"},
+				{"op": "replace", "path": "/spec/replicas", "value": 3},
+				{"op": "add", "path": "/metadata/labels", "value": {"app": "foo", "tier": "web"}},
+				{"op": "add", "path": "/spec/args/-", "value": "--verbose"},
+				{"op": "remove", "path": "/spec/paused"},
+				{"op": "move", "from": "/spec/old", "path": "/spec/new"},
+				{"op": "copy", "from": "/spec/a", "path": "/spec/b"}
+			]`,
+			expected: NewSet(
+				_P("spec", "replicas"),
+				_P("metadata", "labels"),
+				_P("metadata", "labels", "app"),
+				_P("metadata", "labels", "tier"),
+				_P("spec", "args"),
+				_P("spec", "paused"),
+				_P("spec", "old"),
+				_P("spec", "new"),
+				_P("spec", "b"),
+			),
+		}, {
+			name:     "append object",
+			patch:    `[{"op": "add", "path": "/spec/containers/-", "value": {"name": "a", "image": "a:1"}}]`,
+			expected: NewSet(_P("spec", "containers")),
+		}, {
+			name:     "empty",
+			patch:    `[]`,
+			expected: NewSet(),
+		}, {
+			name:      "invalid document",
+			patch:     `{"op": "add"}`,
+			expectErr: true,
+		}, {
+			name:      "unknown operation",
+			patch:     `[{"op": "frobnicate", "path": "/a"}]`,
+			expectErr: true,
+		}, {
+			name:      "missing value",
+			patch:     `[{"op": "add", "path": "/a"}]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetFromJSONPatch([]byte(tt.patch))
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equals(tt.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", tt.expected, got)
+			}
+		})
+	}
+}
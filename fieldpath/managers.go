@@ -15,6 +15,7 @@ package fieldpath
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -132,9 +133,52 @@ func (lhs ManagedFields) Difference(rhs ManagedFields) ManagedFields {
 	return diff
 }
 
+// Transfer returns a copy of the managed fields in which the given fields
+// owned by manager "from" are owned by manager "to" instead. Fields that
+// "from" doesn't own are ignored, and "from" is removed if it ends up not
+// owning anything. If "to" doesn't exist yet, it is created with the
+// version and applied state of "from".
+//
+// Sets can't be converted between versions, so the transfer fails if both
+// managers exist at different versions. The object itself is unchanged,
+// but note that if "to" is an applier, it may prune the transferred fields
+// the next time it applies without them.
+func (lhs ManagedFields) Transfer(from, to string, fields *Set) (ManagedFields, error) {
+	source, ok := lhs[from]
+	if !ok {
+		return nil, fmt.Errorf("manager %q not found", from)
+	}
+	target, ok := lhs[to]
+	if !ok {
+		target = NewVersionedSet(NewSet(), source.APIVersion(), source.Applied())
+	}
+	if target.APIVersion() != source.APIVersion() {
+		return nil, fmt.Errorf("can't transfer fields from %q at version %v to %q at version %v", from, source.APIVersion(), to, target.APIVersion())
+	}
+
+	moved := source.Set().Intersection(fields)
+	result := lhs.Copy()
+	if remaining := source.Set().Difference(moved); remaining.Empty() {
+		delete(result, from)
+	} else {
+		result[from] = NewVersionedSet(remaining, source.APIVersion(), source.Applied())
+	}
+	if owned := target.Set().Union(moved); !owned.Empty() {
+		result[to] = NewVersionedSet(owned, target.APIVersion(), target.Applied())
+	}
+	return result, nil
+}
+
+// String returns the managers and their sets, sorted by manager.
 func (lhs ManagedFields) String() string {
+	managers := make([]string, 0, len(lhs))
+	for k := range lhs {
+		managers = append(managers, k)
+	}
+	sort.Strings(managers)
 	s := strings.Builder{}
-	for k, v := range lhs {
+	for _, k := range managers {
+		v := lhs[k]
 		fmt.Fprintf(&s, "%s:\n", k)
 		fmt.Fprintf(&s, "- Applied: %v\n", v.Applied())
 		fmt.Fprintf(&s, "- APIVersion: %v\n", v.APIVersion())
@@ -284,3 +284,95 @@ func TestManagersDifference(t *testing.T) {
 		})
 	}
 }
+
+func TestManagersTransfer(t *testing.T) {
+	managers := fieldpath.ManagedFields{
+		"before-first-apply": fieldpath.NewVersionedSet(
+			_NS(_P("numeric"), _P("string")),
+			"v1",
+			false,
+		),
+		"applier": fieldpath.NewVersionedSet(
+			_NS(_P("bool")),
+			"v1",
+			true,
+		),
+		"other-version": fieldpath.NewVersionedSet(
+			_NS(_P("float")),
+			"v2",
+			false,
+		),
+	}
+	tests := []struct {
+		name     string
+		from, to string
+		fields   *fieldpath.Set
+		expected fieldpath.ManagedFields
+		err      bool
+	}{
+		{
+			name:   "Partial transfer",
+			from:   "before-first-apply",
+			to:     "applier",
+			fields: _NS(_P("numeric"), _P("bool"), _P("unowned")),
+			expected: fieldpath.ManagedFields{
+				"before-first-apply": fieldpath.NewVersionedSet(_NS(_P("string")), "v1", false),
+				"applier":            fieldpath.NewVersionedSet(_NS(_P("numeric"), _P("bool")), "v1", true),
+				"other-version":      managers["other-version"],
+			},
+		},
+		{
+			name:   "Full transfer to new manager",
+			from:   "before-first-apply",
+			to:     "new",
+			fields: _NS(_P("numeric"), _P("string")),
+			expected: fieldpath.ManagedFields{
+				"new":           fieldpath.NewVersionedSet(_NS(_P("numeric"), _P("string")), "v1", false),
+				"applier":       managers["applier"],
+				"other-version": managers["other-version"],
+			},
+		},
+		{
+			name:     "Nothing owned",
+			from:     "applier",
+			to:       "new",
+			fields:   _NS(_P("numeric")),
+			expected: managers,
+		},
+		{
+			name:   "Unknown manager",
+			from:   "unknown",
+			to:     "applier",
+			fields: _NS(_P("numeric")),
+			err:    true,
+		},
+		{
+			name:   "Different versions",
+			from:   "applier",
+			to:     "other-version",
+			fields: _NS(_P("bool")),
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := managers.Transfer(test.from, test.to, test.fields)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equals(test.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", test.expected, got)
+			}
+		})
+	}
+	if len(managers) != 3 || managers["before-first-apply"].Set().Size() != 2 {
+		t.Errorf("Transfer modified its receiver: %v", managers)
+	}
+}
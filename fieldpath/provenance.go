@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"sort"
+)
+
+// FieldOwners associates a field with the managers that own it.
+type FieldOwners struct {
+	Path Path
+	// Managers is sorted by name.
+	Managers []string
+}
+
+// Provenance returns the owners of every leaf field in the managed
+// fields, sorted by path. A field is a leaf if no manager owns anything
+// below it.
+//
+// All the sets are walked together, so every field is visited once no
+// matter how many managers there are. Paths are compared as they are,
+// regardless of the version of the sets.
+func (lhs ManagedFields) Provenance() []FieldOwners {
+	managers := make([]string, 0, len(lhs))
+	for manager := range lhs {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+
+	sets := make([]ownedSet, 0, len(managers))
+	for _, manager := range managers {
+		sets = append(sets, ownedSet{manager: manager, set: lhs[manager].Set()})
+	}
+	var result []FieldOwners
+	provenance(Path{}, sets, &result)
+	return result
+}
+
+// ownedSet is the part of a manager's set found at some path.
+type ownedSet struct {
+	manager string
+	set     *Set
+}
+
+func provenance(prefix Path, sets []ownedSet, result *[]FieldOwners) {
+	var elements sortedPathElements
+	for _, s := range sets {
+		elements = append(elements, s.set.Members.members...)
+		for _, n := range s.set.Children.members {
+			elements = append(elements, n.pathElement)
+		}
+	}
+	sort.Sort(elements)
+
+	for i, pe := range elements {
+		if i > 0 && elements[i-1].Equals(pe) {
+			continue
+		}
+		var children []ownedSet
+		for _, s := range sets {
+			if child, ok := s.set.Children.Get(pe); ok {
+				children = append(children, ownedSet{manager: s.manager, set: child})
+			}
+		}
+		path := append(prefix.Copy(), pe)
+		if len(children) > 0 {
+			provenance(path, children, result)
+			continue
+		}
+		owners := FieldOwners{Path: path}
+		for _, s := range sets {
+			if s.set.Members.Has(pe) {
+				owners.Managers = append(owners.Managers, s.manager)
+			}
+		}
+		*result = append(*result, owners)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath_test
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+func TestProvenance(t *testing.T) {
+	managers := fieldpath.ManagedFields{
+		"bob": fieldpath.NewVersionedSet(
+			_NS(
+				_P("spec", "replicas"),
+				_P("spec", "list", fieldpath.KeyByFields("name", "a")),
+				_P("spec", "list", fieldpath.KeyByFields("name", "a"), "name"),
+				_P("spec", "list", fieldpath.KeyByFields("name", "a"), "value"),
+			),
+			"v1",
+			true,
+		),
+		"alice": fieldpath.NewVersionedSet(
+			_NS(
+				_P("spec", "list", fieldpath.KeyByFields("name", "a")),
+				_P("spec", "list", fieldpath.KeyByFields("name", "a"), "name"),
+				_P("spec", "paused"),
+				_P("status"),
+			),
+			"v1",
+			false,
+		),
+		"empty": fieldpath.NewVersionedSet(_NS(), "v1", false),
+	}
+	expected := []fieldpath.FieldOwners{
+		{Path: _P("spec", "list", fieldpath.KeyByFields("name", "a"), "name"), Managers: []string{"alice", "bob"}},
+		{Path: _P("spec", "list", fieldpath.KeyByFields("name", "a"), "value"), Managers: []string{"bob"}},
+		{Path: _P("spec", "paused"), Managers: []string{"alice"}},
+		{Path: _P("spec", "replicas"), Managers: []string{"bob"}},
+		{Path: _P("status"), Managers: []string{"alice"}},
+	}
+
+	got := managers.Provenance()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if !got[i].Path.Equals(expected[i].Path) || !reflect.DeepEqual(got[i].Managers, expected[i].Managers) {
+			t.Errorf("expected %v: %v, got %v: %v", expected[i].Path, expected[i].Managers, got[i].Path, got[i].Managers)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Redact returns v with the values at the paths of s replaced with the
+// placeholder, e.g. to scrub secrets from an object before logging it or
+// a diff of it. The rest of the structure of v is preserved, and the
+// subtrees of v without any path of s are shared with the result.
+//
+// Without a schema, list items are matched with the path elements of s
+// by index, by the values of their key fields, or by their value, for
+// items of sets. typed.TypedValue.Redact uses the schema instead.
+func Redact(v value.Value, s *Set, placeholder interface{}) value.Value {
+	if s.Empty() {
+		return v
+	}
+	return value.NewValueInterface(redact(v, s, placeholder))
+}
+
+func redact(v value.Value, s *Set, placeholder interface{}) interface{} {
+	switch {
+	case v.IsMap():
+		m := v.AsMap()
+		out := make(map[string]interface{}, m.Length())
+		m.Iterate(func(k string, val value.Value) bool {
+			out[k] = redactChild(PathElement{FieldName: &k}, val, s, placeholder)
+			return true
+		})
+		return out
+	case v.IsList():
+		l := v.AsList()
+		out := make([]interface{}, l.Length())
+		for i := range out {
+			item := l.At(i)
+			out[i] = item.Unstructured()
+			// The path elements of s selecting the item can't be looked
+			// up without knowing its keys, so they are all tried.
+			redacted := false
+			s.Members.Iterate(func(pe PathElement) {
+				if !redacted && selects(pe, i, item) {
+					out[i], redacted = placeholder, true
+				}
+			})
+			s.Children.Iterate(func(pe PathElement) {
+				if !redacted && selects(pe, i, item) {
+					out[i], redacted = redact(item, s.WithPrefix(pe), placeholder), true
+				}
+			})
+		}
+		return out
+	}
+	return v.Unstructured()
+}
+
+func redactChild(pe PathElement, v value.Value, s *Set, placeholder interface{}) interface{} {
+	if s.Members.Has(pe) {
+		return placeholder
+	}
+	if child, ok := s.Children.Get(pe); ok {
+		return redact(v, child, placeholder)
+	}
+	return v.Unstructured()
+}
+
+// selects returns whether pe selects the item at index i of a list.
+func selects(pe PathElement, i int, item value.Value) bool {
+	switch {
+	case pe.Index != nil:
+		return *pe.Index == i
+	case pe.Value != nil:
+		return value.Equals(*pe.Value, item)
+	case pe.Key != nil:
+		if !item.IsMap() {
+			return false
+		}
+		m := item.AsMap()
+		for _, f := range *pe.Key {
+			field, ok := m.Get(f.Name)
+			if !ok || !value.Equals(field, f.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestRedact(t *testing.T) {
+	in, err := value.FromJSON([]byte(`{"data": {"password": "p", "user": "u"}, "env": [{"name": "A", "value": "1"}, {"name": "B", "value": "2"}], "list": [1, 2, 3], "set": ["a", "b"], "secret": {"k": "v"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := value.ToJSON(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := NewSet(
+		MakePathOrDie("data", "password"),
+		MakePathOrDie("env", KeyByFields("name", "B"), "value"),
+		MakePathOrDie("list", 1),
+		MakePathOrDie("set", _V("a")),
+		MakePathOrDie("secret"),
+		MakePathOrDie("missing", "field"),
+	)
+	got, err := value.ToJSON(Redact(in, set, "REDACTED"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"password":"REDACTED","user":"u"},"env":[{"name":"A","value":"1"},{"name":"B","value":"REDACTED"}],"list":[1,"REDACTED",3],"secret":"REDACTED","set":["REDACTED","b"]}`
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+	if after, _ := value.ToJSON(in); string(after) != string(original) {
+		t.Errorf("expected the input not to be modified, got %s", after)
+	}
+	if got, _ := value.ToJSON(Redact(in, NewSet(), "REDACTED")); string(got) != string(original) {
+		t.Errorf("expected an empty set to redact nothing, got %s", got)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+)
+
+// FilterBySchema splits the set into the members that can be reached by
+// following the given type in the schema, and those that can't. This is
+// typically used on persisted managed fields after a schema has evolved and
+// some of the fields they reference have been deleted.
+//
+// A path element is considered valid if:
+//   - it is a field name and the containing type is a non-atomic map which
+//     either declares that field or accepts unknown fields,
+//   - it is a key and the containing type is a non-atomic associative list
+//     whose keys are exactly the fields of the key,
+//   - it is a value and the containing type is a non-atomic associative list
+//     without keys,
+//   - it is an index and the containing type is a non-atomic, non-associative
+//     list.
+//
+// Anything below an invalid path element is invalid as well. Neither of the
+// returned sets is ever nil.
+func (s *Set) FilterBySchema(sc *schema.Schema, tr schema.TypeRef) (valid, invalid *Set) {
+	valid, invalid = &Set{}, &Set{}
+	s.filterBySchema(sc, tr, valid, invalid)
+	return valid, invalid
+}
+
+// PruneToSchema returns a copy of the set without the members that are not
+// valid for the given type, as defined by FilterBySchema.
+func (s *Set) PruneToSchema(sc *schema.Schema, tr schema.TypeRef) *Set {
+	valid, _ := s.FilterBySchema(sc, tr)
+	return valid
+}
+
+func (s *Set) filterBySchema(sc *schema.Schema, tr schema.TypeRef, valid, invalid *Set) {
+	atom, ok := sc.Resolve(tr)
+	for _, pe := range s.Members.members {
+		if _, childOK := childTypeRef(atom, pe); ok && childOK {
+			valid.Members.members = append(valid.Members.members, pe)
+		} else {
+			invalid.Members.members = append(invalid.Members.members, pe)
+		}
+	}
+	for _, n := range s.Children.members {
+		childTR, childOK := childTypeRef(atom, n.pathElement)
+		if !ok || !childOK {
+			invalid.Children.members = append(invalid.Children.members, n)
+			continue
+		}
+		v, i := &Set{}, &Set{}
+		n.set.filterBySchema(sc, childTR, v, i)
+		if !v.Empty() {
+			valid.Children.members = append(valid.Children.members, setNode{pathElement: n.pathElement, set: v})
+		}
+		if !i.Empty() {
+			invalid.Children.members = append(invalid.Children.members, setNode{pathElement: n.pathElement, set: i})
+		}
+	}
+}
+
+// childTypeRef returns the type of the child selected by pe in a value of
+// type atom, or false if pe can't select anything in such a value.
+func childTypeRef(atom schema.Atom, pe PathElement) (schema.TypeRef, bool) {
+	switch {
+	case pe.FieldName != nil:
+		if atom.Map == nil || atom.Map.ElementRelationship == schema.Atomic {
+			return schema.TypeRef{}, false
+		}
+		if sf, ok := atom.Map.FindField(*pe.FieldName); ok {
+			return sf.Type, true
+		}
+		return atom.Map.ElementType, atom.Map.ElementType != schema.TypeRef{}
+	case pe.Key != nil:
+		if atom.List == nil || atom.List.ElementRelationship != schema.Associative {
+			return schema.TypeRef{}, false
+		}
+		listKeys := atom.List.IdentityKeys()
+		if len(*pe.Key) != len(listKeys) {
+			return schema.TypeRef{}, false
+		}
+	keys:
+		for _, key := range listKeys {
+			for _, f := range *pe.Key {
+				if f.Name == key {
+					continue keys
+				}
+			}
+			return schema.TypeRef{}, false
+		}
+		return atom.List.ElementType, true
+	case pe.Value != nil:
+		if atom.List == nil || atom.List.ElementRelationship != schema.Associative || len(atom.List.Keys) != 0 {
+			return schema.TypeRef{}, false
+		}
+		return atom.List.ElementType, true
+	case pe.Index != nil:
+		if atom.List == nil || atom.List.ElementRelationship == schema.Atomic || atom.List.ElementRelationship == schema.Associative {
+			return schema.TypeRef{}, false
+		}
+		return atom.List.ElementType, true
+	}
+	return schema.TypeRef{}, false
+}
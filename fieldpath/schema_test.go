@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+)
+
+var filterSchema = func() (*schema.Schema, schema.TypeRef) {
+	sc := &schema.Schema{}
+	name := "root"
+	err := yaml.Unmarshal([]byte(`types:
+- name: root
+  map:
+    fields:
+      - name: spec
+        type:
+          namedType: spec
+      - name: labels
+        type:
+          map:
+            elementType:
+              scalar: string
+- name: spec
+  map:
+    fields:
+      - name: replicas
+        type:
+          scalar: numeric
+      - name: containers
+        type:
+          list:
+            elementRelationship: associative
+            keys: ["name"]
+            elementType:
+              namedType: container
+      - name: finalizers
+        type:
+          list:
+            elementRelationship: associative
+            elementType:
+              scalar: string
+      - name: args
+        type:
+          list:
+            elementRelationship: atomic
+            elementType:
+              scalar: string
+- name: container
+  map:
+    fields:
+      - name: name
+        type:
+          scalar: string
+      - name: image
+        type:
+          scalar: string
+`), &sc)
+	if err != nil {
+		panic(err)
+	}
+	return sc, schema.TypeRef{NamedType: &name}
+}
+
+func TestFilterBySchema(t *testing.T) {
+	table := []struct {
+		name    string
+		set     *Set
+		valid   *Set
+		invalid *Set
+	}{
+		{
+			name: "all valid",
+			set: NewSet(
+				_P("spec", "replicas"),
+				_P("spec", "containers", KeyByFields("name", "a"), "image"),
+				_P("spec", "finalizers", _V("x")),
+				_P("spec", "args"),
+				_P("labels", "anything"),
+			),
+			valid: NewSet(
+				_P("spec", "replicas"),
+				_P("spec", "containers", KeyByFields("name", "a"), "image"),
+				_P("spec", "finalizers", _V("x")),
+				_P("spec", "args"),
+				_P("labels", "anything"),
+			),
+			invalid: NewSet(),
+		},
+		{
+			name: "removed fields",
+			set: NewSet(
+				_P("spec", "replicas"),
+				_P("spec", "paused"),
+				_P("status", "phase"),
+				_P("spec", "containers", KeyByFields("name", "a"), "command"),
+			),
+			valid: NewSet(
+				_P("spec", "replicas"),
+			),
+			invalid: NewSet(
+				_P("spec", "paused"),
+				_P("status", "phase"),
+				_P("spec", "containers", KeyByFields("name", "a"), "command"),
+			),
+		},
+		{
+			name: "mismatched path elements",
+			set: NewSet(
+				_P("spec", "containers", KeyByFields("id", "a")),
+				_P("spec", "containers", 0),
+				_P("spec", "finalizers", KeyByFields("name", "a")),
+				_P("spec", "args", 0),
+				_P("spec", "replicas", "nested"),
+			),
+			valid: NewSet(),
+			invalid: NewSet(
+				_P("spec", "containers", KeyByFields("id", "a")),
+				_P("spec", "containers", 0),
+				_P("spec", "finalizers", KeyByFields("name", "a")),
+				_P("spec", "args", 0),
+				_P("spec", "replicas", "nested"),
+			),
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, invalid := tt.set.FilterBySchema(filterSchema())
+			if !valid.Equals(tt.valid) {
+				t.Errorf("expected valid:\n%v\ngot:\n%v", tt.valid, valid)
+			}
+			if !invalid.Equals(tt.invalid) {
+				t.Errorf("expected invalid:\n%v\ngot:\n%v", tt.invalid, invalid)
+			}
+			if pruned := tt.set.PruneToSchema(filterSchema()); !pruned.Equals(tt.valid) {
+				t.Errorf("expected pruned set:\n%v\ngot:\n%v", tt.valid, pruned)
+			}
+			if union := valid.Union(invalid); !union.Equals(tt.set) {
+				t.Errorf("expected valid and invalid to partition the set, got:\n%v", union)
+			}
+		})
+	}
+}
@@ -18,6 +18,8 @@ package fieldpath
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"unsafe"
 
@@ -164,7 +166,12 @@ func (s *Set) emitContentsV1(includeSelf bool, stream *jsoniter.Stream, r *reusa
 	return manageMemory(stream)
 }
 
-// FromJSON clears s and reads a JSON formatted set structure.
+// ErrInvalidSetJSON is wrapped by the errors of reading sets from invalid
+// JSON.
+var ErrInvalidSetJSON = errors.New("invalid set JSON")
+
+// FromJSON clears s and reads a JSON formatted set structure. It fails
+// with an error wrapping ErrInvalidSetJSON if the JSON is invalid.
 func (s *Set) FromJSON(r io.Reader) error {
 	// The iterator pool is completely useless for memory management, grrr.
 	iter := jsoniter.Parse(jsoniter.ConfigCompatibleWithStandardLibrary, r, 4096)
@@ -175,7 +182,10 @@ func (s *Set) FromJSON(r io.Reader) error {
 	} else {
 		*s = *found
 	}
-	return iter.Error
+	if iter.Error != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSetJSON, iter.Error)
+	}
+	return nil
 }
 
 // returns true if this subtree is also (or only) a member of parent; s is nil
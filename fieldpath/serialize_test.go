@@ -18,6 +18,7 @@ package fieldpath
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -87,3 +88,17 @@ func TestDropUnknown(t *testing.T) {
 		t.Errorf("Failed;\ngot:  %s\nwant: %s\n", b, expect)
 	}
 }
+
+func TestFromJSONInvalid(t *testing.T) {
+	for _, input := range []string{`{"f:a":`, `{"k:{":{}}`, `[]`} {
+		t.Run(input, func(t *testing.T) {
+			err := NewSet().FromJSON(strings.NewReader(input))
+			if !errors.Is(err, ErrInvalidSetJSON) {
+				t.Errorf("expected an error wrapping ErrInvalidSetJSON, got %v", err)
+			}
+			if _, err := SetFromFieldsV1(FieldsV1{Raw: []byte(input)}); !errors.Is(err, ErrInvalidSetJSON) {
+				t.Errorf("expected an error wrapping ErrInvalidSetJSON, got %v", err)
+			}
+		})
+	}
+}
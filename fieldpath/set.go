@@ -70,6 +70,34 @@ func (s *Set) Union(s2 *Set) *Set {
 	}
 }
 
+// UnionInPlace adds the elements of s2 to s, reusing s's nodes rather than
+// allocating a new set. Nodes adopted from s2 are copied, so s2 is never
+// modified, neither now nor by later in-place operations on s.
+//
+// Union and Difference can share nodes between their result and their
+// operands: only call this on a set which doesn't share nodes with any
+// other set that is still in use, e.g. an intermediate result that is
+// about to be discarded.
+func (s *Set) UnionInPlace(s2 *Set) {
+	s.Members.UnionInPlace(&s2.Members)
+	s.Children.UnionInPlace(&s2.Children)
+}
+
+// deepCopy returns a copy of s which shares no nodes with s.
+func (s *Set) deepCopy() *Set {
+	out := &Set{
+		Members: PathElementSet{members: make(sortedPathElements, len(s.Members.members))},
+	}
+	copy(out.Members.members, s.Members.members)
+	if len(s.Children.members) > 0 {
+		out.Children.members = make(sortedSetNode, len(s.Children.members))
+		for i, n := range s.Children.members {
+			out.Children.members[i] = setNode{pathElement: n.pathElement, set: n.set.deepCopy()}
+		}
+	}
+	return out
+}
+
 // Intersection returns a Set containing leaf elements which appear in both s
 // and s2. Intersection can be constructed from Union and Difference operations
 // (example in the tests) but it's much faster to do it in one pass.
@@ -96,6 +124,14 @@ func (s *Set) Difference(s2 *Set) *Set {
 	}
 }
 
+// DifferenceInPlace removes the elements of s2 from s, like Difference, but
+// reuses s's nodes rather than allocating a new set. The same restrictions
+// as UnionInPlace apply.
+func (s *Set) DifferenceInPlace(s2 *Set) {
+	s.Members.DifferenceInPlace(&s2.Members)
+	s.Children.DifferenceInPlace(s2)
+}
+
 // RecursiveDifference returns a Set containing elements which:
 // * appear in s
 // * do not appear in s2
@@ -112,6 +148,19 @@ func (s *Set) RecursiveDifference(s2 *Set) *Set {
 	}
 }
 
+// RecursiveIntersection returns a Set containing the fields of s that are
+// either contained in s2 or children of a field contained in s2. This is
+// the complement of RecursiveDifference.
+//
+// For example, with s containing `a.b.c` and `a.d` and s2 containing `a.b`,
+// a RecursiveIntersection will result in `a.b.c`.
+func (s *Set) RecursiveIntersection(s2 *Set) *Set {
+	return &Set{
+		Members:  *s.Members.Intersection(&s2.Members),
+		Children: *s.Children.RecursiveIntersection(s2),
+	}
+}
+
 // EnsureNamedFieldsAreMembers returns a Set that contains all the
 // fields in s, as well as all the named fields that are typically not
 // included. For example, a set made of "a.b.c" will end-up also owning
@@ -170,6 +219,49 @@ func (s *Set) Has(p Path) bool {
 	}
 }
 
+// IsSubsetOf returns true if every member of s is also a member of s2. It
+// returns as soon as a member missing from s2 is found, without building
+// any intermediate set.
+func (s *Set) IsSubsetOf(s2 *Set) bool {
+	if !s.Members.IsSubsetOf(&s2.Members) {
+		return false
+	}
+	j := 0
+	for _, n := range s.Children.members {
+		for j < len(s2.Children.members) && s2.Children.members[j].pathElement.Less(n.pathElement) {
+			j++
+		}
+		if j < len(s2.Children.members) && s2.Children.members[j].pathElement.Equals(n.pathElement) {
+			if !n.set.IsSubsetOf(s2.Children.members[j].set) {
+				return false
+			}
+			continue
+		}
+		if !n.set.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// CoversPath returns true if p, or any of its parents, is a member of the
+// set. This is the case when p is owned either directly or through one of
+// its ancestors, e.g. an atomic map or list.
+func (s *Set) CoversPath(p Path) bool {
+	for len(p) > 0 {
+		if s.Members.Has(p[0]) {
+			return true
+		}
+		var ok bool
+		s, ok = s.Children.Get(p[0])
+		if !ok {
+			return false
+		}
+		p = p[1:]
+	}
+	return false
+}
+
 // Equals returns true if s and s2 have exactly the same members.
 func (s *Set) Equals(s2 *Set) bool {
 	return s.Members.Equals(&s2.Members) && s.Children.Equals(&s2.Children)
@@ -187,6 +279,10 @@ func (s *Set) String() string {
 // Iterate calls f once for each field that is a member of the set (preorder
 // DFS). The path passed to f will be reused so make a copy if you wish to keep
 // it.
+//
+// The order is deterministic: the members of a set come before those of its
+// children, and both are visited in the order of PathElement.Less. String
+// and the serializations of sets follow the same order.
 func (s *Set) Iterate(f func(Path)) {
 	s.iteratePrefix(Path{}, f)
 }
@@ -206,6 +302,25 @@ func (s *Set) WithPrefix(pe PathElement) *Set {
 	return subset
 }
 
+// WithListKeys returns s with the key fields of the keyed list items it
+// holds, e.g. .list[name="a"].name for .list[name="a"].value, so that
+// values extracted with the set can still be matched with their items.
+func (s *Set) WithListKeys() *Set {
+	keys := NewSet()
+	s.Iterate(func(p Path) {
+		for i, pe := range p {
+			if pe.Key == nil {
+				continue
+			}
+			for _, field := range *pe.Key {
+				name := field.Name
+				keys.Insert(append(p[:i+1:i+1], PathElement{FieldName: &name}))
+			}
+		}
+	})
+	return s.Union(keys)
+}
+
 // Leaves returns a set containing only the leaf paths
 // of a set.
 func (s *Set) Leaves() *Set {
@@ -357,6 +472,48 @@ func (s *SetNodeMap) Union(s2 *SetNodeMap) *SetNodeMap {
 	return out
 }
 
+// UnionInPlace adds the members of s2 to s, merging common subsets in
+// place. See Set.UnionInPlace.
+func (s *SetNodeMap) UnionInPlace(s2 *SetNodeMap) {
+	added := 0
+	i, j := 0, 0
+	for i < len(s.members) && j < len(s2.members) {
+		if c := s.members[i].pathElement.Compare(s2.members[j].pathElement); c < 0 {
+			i++
+		} else {
+			if c == 0 {
+				s.members[i].set.UnionInPlace(s2.members[j].set)
+				i++
+			} else {
+				added++
+			}
+			j++
+		}
+	}
+	added += len(s2.members) - j
+	if added == 0 {
+		return
+	}
+
+	// Merge from the back so that nodes of s are moved at most once.
+	i, j = len(s.members)-1, len(s2.members)-1
+	s.members = append(s.members, make(sortedSetNode, added)...)
+	for k := len(s.members) - 1; j >= 0; k-- {
+		if i >= 0 {
+			if c := s.members[i].pathElement.Compare(s2.members[j].pathElement); c >= 0 {
+				s.members[k] = s.members[i]
+				i--
+				if c == 0 {
+					j--
+				}
+				continue
+			}
+		}
+		s.members[k] = setNode{pathElement: s2.members[j].pathElement, set: s2.members[j].set.deepCopy()}
+		j--
+	}
+}
+
 // Intersection returns a SetNodeMap with members that appear in both s and s2.
 func (s *SetNodeMap) Intersection(s2 *SetNodeMap) *SetNodeMap {
 	out := &SetNodeMap{}
@@ -409,6 +566,29 @@ func (s *SetNodeMap) Difference(s2 *Set) *SetNodeMap {
 	return out
 }
 
+// DifferenceInPlace removes the members of s2 from s, dropping subsets which
+// become empty. See Set.DifferenceInPlace.
+func (s *SetNodeMap) DifferenceInPlace(s2 *Set) {
+	out := s.members[:0]
+	j := 0
+	for _, n := range s.members {
+		for j < len(s2.Children.members) && s2.Children.members[j].pathElement.Less(n.pathElement) {
+			j++
+		}
+		if j < len(s2.Children.members) && s2.Children.members[j].pathElement.Equals(n.pathElement) {
+			n.set.DifferenceInPlace(s2.Children.members[j].set)
+			if n.set.Empty() {
+				continue
+			}
+		}
+		out = append(out, n)
+	}
+	for k := len(out); k < len(s.members); k++ {
+		s.members[k] = setNode{}
+	}
+	s.members = out
+}
+
 // RecursiveDifference returns a SetNodeMap with members that appear in s but not in s2.
 //
 // Compared to a regular difference,
@@ -451,6 +631,25 @@ func (s *SetNodeMap) RecursiveDifference(s2 *Set) *SetNodeMap {
 	return out
 }
 
+// RecursiveIntersection returns a SetNodeMap with the nodes of s that are
+// members of s2, as well as the recursive intersection of the nodes that
+// are children of s2.
+func (s *SetNodeMap) RecursiveIntersection(s2 *Set) *SetNodeMap {
+	out := &SetNodeMap{}
+	for _, n := range s.members {
+		if s2.Members.Has(n.pathElement) {
+			out.members = append(out.members, n)
+			continue
+		}
+		if child, ok := s2.Children.Get(n.pathElement); ok {
+			if in := n.set.RecursiveIntersection(child); !in.Empty() {
+				out.members = append(out.members, setNode{pathElement: n.pathElement, set: in})
+			}
+		}
+	}
+	return out
+}
+
 // EnsureNamedFieldsAreMembers returns a set that contains all the named fields along with the leaves.
 func (s *SetNodeMap) EnsureNamedFieldsAreMembers(sc *schema.Schema, tr schema.TypeRef) *SetNodeMap {
 	out := make(sortedSetNode, 0, s.Size())
@@ -367,6 +367,99 @@ func TestSetUnion(t *testing.T) {
 	}
 }
 
+func TestSetInPlace(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			makeSet := func() *Set {
+				x := NewSet()
+				for j := 0; j < 30; j++ {
+					x.Insert(randomPathMaker.makePath(1, 4))
+				}
+				return x
+			}
+			a, b := makeSet(), makeSet()
+			bCopy := b.deepCopy()
+
+			union := a.deepCopy()
+			union.UnionInPlace(b)
+			if expected := a.Union(b); !union.Equals(expected) {
+				t.Fatalf("UnionInPlace: expected:\n%v\ngot:\n%v", expected, union)
+			}
+
+			diff := a.deepCopy()
+			diff.DifferenceInPlace(b)
+			if expected := a.Difference(b); !diff.Equals(expected) {
+				t.Fatalf("DifferenceInPlace: expected:\n%v\ngot:\n%v", expected, diff)
+			}
+
+			// Further in-place changes to the union must not leak
+			// into the nodes it adopted from b.
+			union.DifferenceInPlace(union)
+			if !union.Empty() {
+				t.Fatalf("expected empty set, got:\n%v", union)
+			}
+			if !b.Equals(bCopy) {
+				t.Fatalf("operand was modified:\n%v\nexpected:\n%v", b, bCopy)
+			}
+		})
+	}
+}
+
+func TestSetIsSubsetOf(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			a, b := NewSet(), NewSet()
+			for j := 0; j < 10; j++ {
+				a.Insert(randomPathMaker.makePath(1, 3))
+				b.Insert(randomPathMaker.makePath(1, 3))
+			}
+			if expected, got := a.Difference(b).Empty(), a.IsSubsetOf(b); expected != got {
+				t.Errorf("expected %v, got %v for\n%v\nsubset of\n%v", expected, got, a, b)
+			}
+			if !a.IsSubsetOf(a.Union(b)) {
+				t.Errorf("expected set to be a subset of its union")
+			}
+			if !a.Intersection(b).IsSubsetOf(b) {
+				t.Errorf("expected intersection to be a subset of its operand")
+			}
+		})
+	}
+	if !NewSet().IsSubsetOf(NewSet()) {
+		t.Errorf("expected empty set to be a subset of itself")
+	}
+	if NewSet(_P("a", "b")).IsSubsetOf(NewSet(_P("a"))) {
+		t.Errorf("expected a child not to be a subset of its parent")
+	}
+}
+
+func TestSetCoversPath(t *testing.T) {
+	s := NewSet(
+		_P("atomic"),
+		_P("parent", "child"),
+		_P("list", KeyByFields("name", "a"), "value"),
+	)
+	table := []struct {
+		path   Path
+		expect bool
+	}{
+		{_P("atomic"), true},
+		{_P("atomic", "nested", "field"), true},
+		{_P("parent"), false},
+		{_P("parent", "child"), true},
+		{_P("parent", "child", 0), true},
+		{_P("parent", "sibling"), false},
+		{_P("list", KeyByFields("name", "a")), false},
+		{_P("list", KeyByFields("name", "a"), "value"), true},
+		{_P("list", KeyByFields("name", "b"), "value"), false},
+		{Path{}, false},
+	}
+	for _, tt := range table {
+		if got := s.CoversPath(tt.path); got != tt.expect {
+			t.Errorf("%v: expected %v, got %v", tt.path, tt.expect, got)
+		}
+	}
+}
+
 func TestSetIntersectionDifference(t *testing.T) {
 	// Even though this is not a table driven test, since the thing under
 	// test is recursive, we should be able to craft a single input that is
@@ -562,6 +655,25 @@ func TestSetLeaves(t *testing.T) {
 
 }
 
+func TestSetWithListKeys(t *testing.T) {
+	input := NewSet(
+		_P("root", KeyByFields("name", "a"), "value"),
+		_P("root", KeyByFields("name", "a"), "sub", KeyByFields("port", 443, "protocol", "tcp")),
+		_P("other", 0, "value"),
+	)
+	expected := NewSet(
+		_P("root", KeyByFields("name", "a"), "value"),
+		_P("root", KeyByFields("name", "a"), "name"),
+		_P("root", KeyByFields("name", "a"), "sub", KeyByFields("port", 443, "protocol", "tcp")),
+		_P("root", KeyByFields("name", "a"), "sub", KeyByFields("port", 443, "protocol", "tcp"), "port"),
+		_P("root", KeyByFields("name", "a"), "sub", KeyByFields("port", 443, "protocol", "tcp"), "protocol"),
+		_P("other", 0, "value"),
+	)
+	if got := input.WithListKeys(); !got.Equals(expected) {
+		t.Errorf("expected %v, got %v (missing: %v, superfluous: %v)", expected, got, expected.Difference(got), got.Difference(expected))
+	}
+}
+
 func TestSetDifference(t *testing.T) {
 	table := []struct {
 		name                      string
@@ -650,6 +762,52 @@ func TestSetDifference(t *testing.T) {
 	}
 }
 
+func TestSetRecursiveIntersection(t *testing.T) {
+	table := []struct {
+		name     string
+		a        *Set
+		b        *Set
+		expected *Set
+	}{
+		{
+			name:     "keeps simple path",
+			a:        NewSet(MakePathOrDie("a"), MakePathOrDie("b")),
+			b:        NewSet(MakePathOrDie("a")),
+			expected: NewSet(MakePathOrDie("a")),
+		},
+		{
+			name:     "keeps nested paths",
+			a:        NewSet(MakePathOrDie("a", "b", "c"), MakePathOrDie("a", "d")),
+			b:        NewSet(MakePathOrDie("a", "b")),
+			expected: NewSet(MakePathOrDie("a", "b", "c")),
+		},
+		{
+			name:     "keeps the whole subtree",
+			a:        NewSet(MakePathOrDie("a"), MakePathOrDie("a", "b", "c"), MakePathOrDie("a", "d")),
+			b:        NewSet(MakePathOrDie("a")),
+			expected: NewSet(MakePathOrDie("a"), MakePathOrDie("a", "b", "c"), MakePathOrDie("a", "d")),
+		},
+		{
+			name:     "does not keep parent of specific path",
+			a:        NewSet(MakePathOrDie("a")),
+			b:        NewSet(MakePathOrDie("a", "aa")),
+			expected: NewSet(),
+		},
+	}
+
+	for _, c := range table {
+		t.Run(c.name, func(t *testing.T) {
+			result := c.a.RecursiveIntersection(c.b)
+			if !result.Equals(c.expected) {
+				t.Fatalf("RecursiveIntersection expected: \n%v\n, got: \n%v\n", c.expected, result)
+			}
+			if union := result.Union(c.a.RecursiveDifference(c.b)); !union.Equals(c.a) {
+				t.Errorf("expected RecursiveIntersection and RecursiveDifference to partition the set, got: \n%v\n", union)
+			}
+		})
+	}
+}
+
 var nestedSchema = func() (*schema.Schema, schema.TypeRef) {
 	sc := &schema.Schema{}
 	name := "type"
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"unsafe"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// SetStats describes the shape and approximate memory footprint of a Set.
+type SetStats struct {
+	// Members is the number of fields that are members of the set, as
+	// returned by Size.
+	Members int
+	// Nodes is the number of child nodes in the set's tree, that is, the
+	// number of path elements which have children of their own.
+	Nodes int
+	// Depth is the length of the longest path in the set.
+	Depth int
+	// ApproximateBytes is an estimate of the heap memory retained by the
+	// set. It accounts for the backing arrays and the path element
+	// contents, but not for allocator overhead.
+	ApproximateBytes int
+}
+
+var (
+	setSize         = int(unsafe.Sizeof(Set{}))
+	setNodeSize     = int(unsafe.Sizeof(setNode{}))
+	pathElementSize = int(unsafe.Sizeof(PathElement{}))
+	fieldSize       = int(unsafe.Sizeof(value.Field{}))
+	valueSize       = int(unsafe.Sizeof(value.Value(nil)))
+	intSize         = int(unsafe.Sizeof(int(0)))
+)
+
+// Stats walks the set once and returns its statistics.
+func (s *Set) Stats() SetStats {
+	var stats SetStats
+	s.stats(1, &stats)
+	stats.ApproximateBytes += setSize
+	return stats
+}
+
+func (s *Set) stats(depth int, stats *SetStats) {
+	if len(s.Members.members) > 0 && depth > stats.Depth {
+		stats.Depth = depth
+	}
+	stats.Members += len(s.Members.members)
+	stats.ApproximateBytes += cap(s.Members.members) * pathElementSize
+	for _, pe := range s.Members.members {
+		stats.ApproximateBytes += pathElementBytes(pe)
+	}
+
+	stats.Nodes += len(s.Children.members)
+	stats.ApproximateBytes += cap(s.Children.members) * setNodeSize
+	for _, n := range s.Children.members {
+		stats.ApproximateBytes += pathElementBytes(n.pathElement) + setSize
+		n.set.stats(depth+1, stats)
+	}
+}
+
+// pathElementBytes estimates the memory referenced by a path element,
+// excluding the PathElement struct itself.
+func pathElementBytes(pe PathElement) int {
+	switch {
+	case pe.FieldName != nil:
+		return len(*pe.FieldName)
+	case pe.Key != nil:
+		n := cap(*pe.Key) * fieldSize
+		for _, f := range *pe.Key {
+			n += len(f.Name) + valueBytes(f.Value)
+		}
+		return n
+	case pe.Value != nil:
+		return valueSize + valueBytes(*pe.Value)
+	case pe.Index != nil:
+		return intSize
+	}
+	return 0
+}
+
+// valueBytes estimates the memory referenced by a scalar value. Path
+// elements only ever hold scalars, so containers are not accounted for.
+func valueBytes(v value.Value) int {
+	if v == nil {
+		return 0
+	}
+	if v.IsString() {
+		return len(v.AsString())
+	}
+	return 8
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"testing"
+)
+
+func TestSetStats(t *testing.T) {
+	table := []struct {
+		name    string
+		set     *Set
+		members int
+		nodes   int
+		depth   int
+	}{
+		{
+			name: "empty",
+			set:  NewSet(),
+		}, {
+			name:    "flat",
+			set:     NewSet(MakePathOrDie("a"), MakePathOrDie("b")),
+			members: 2,
+			depth:   1,
+		}, {
+			name: "nested",
+			set: NewSet(
+				MakePathOrDie("foo", 0, "bar", "baz"),
+				MakePathOrDie("foo", 0, "bar"),
+				MakePathOrDie("foo", 1, "bar"),
+				MakePathOrDie("qux", KeyByFields("name", "first")),
+				MakePathOrDie("qux", KeyByFields("name", "first"), "bar"),
+			),
+			members: 5,
+			// foo, foo[0], foo[0].bar, foo[1], qux, qux[name=first]
+			nodes: 6,
+			depth: 4,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.set.Stats()
+			if got.Members != tt.members {
+				t.Errorf("expected %v members, got %v", tt.members, got.Members)
+			}
+			if got.Members != tt.set.Size() {
+				t.Errorf("expected members to match Size() %v, got %v", tt.set.Size(), got.Members)
+			}
+			if got.Nodes != tt.nodes {
+				t.Errorf("expected %v nodes, got %v", tt.nodes, got.Nodes)
+			}
+			if got.Depth != tt.depth {
+				t.Errorf("expected depth %v, got %v", tt.depth, got.Depth)
+			}
+			if got.ApproximateBytes <= 0 {
+				t.Errorf("expected positive byte estimate, got %v", got.ApproximateBytes)
+			}
+		})
+	}
+}
+
+func TestSetStatsGrows(t *testing.T) {
+	small := NewSet(MakePathOrDie("a"))
+	big := NewSet(MakePathOrDie("a"), MakePathOrDie("b", "c", KeyByFields("name", "a-rather-long-key-value")))
+	if s, b := small.Stats().ApproximateBytes, big.Stats().ApproximateBytes; s >= b {
+		t.Errorf("expected larger set to use more bytes, got %v >= %v", s, b)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// TranslationRule describes how paths recorded at one version map to
+// another version. A rule applies to every path which starts with From.
+type TranslationRule struct {
+	// From is the prefix of the paths this rule applies to.
+	From Path
+
+	// To replaces From in matching paths, which renames or moves the
+	// field and everything below it. If To is nil, From is kept.
+	To Path
+
+	// RenameKeyFields renames the fields of the associative list key
+	// which directly follows the (possibly moved) prefix, e.g. when a
+	// list's key changed from "name" to "id" between versions.
+	RenameKeyFields map[string]string
+
+	// Drop removes matching paths entirely, e.g. for fields which don't
+	// exist in the target version. To and RenameKeyFields are ignored.
+	Drop bool
+}
+
+// TranslationRules is an ordered list of rules. Every rule is applied, in
+// order, to the result of the previous ones.
+type TranslationRules []TranslationRule
+
+// Translate returns a new set with the rules applied to each of its members.
+func (rules TranslationRules) Translate(s *Set) *Set {
+	out := NewSet()
+	s.Iterate(func(p Path) {
+		if translated, ok := rules.translatePath(p); ok {
+			out.Insert(translated)
+		}
+	})
+	return out
+}
+
+// TranslateVersionedSet translates the set with Translate and records it at
+// the given version.
+func (rules TranslationRules) TranslateVersionedSet(vs VersionedSet, to APIVersion) VersionedSet {
+	return NewVersionedSet(rules.Translate(vs.Set()), to, vs.Applied())
+}
+
+// translatePath returns the path with all the rules applied, or false if the
+// path was dropped.
+func (rules TranslationRules) translatePath(p Path) (Path, bool) {
+	for _, rule := range rules {
+		if !p.hasPrefix(rule.From) {
+			continue
+		}
+		if rule.Drop {
+			return nil, false
+		}
+		prefix := rule.From
+		if rule.To != nil {
+			prefix = rule.To
+		}
+		translated := make(Path, 0, len(prefix)+len(p)-len(rule.From))
+		translated = append(translated, prefix...)
+		translated = append(translated, p[len(rule.From):]...)
+		if len(rule.RenameKeyFields) > 0 && len(translated) > len(prefix) {
+			translated[len(prefix)] = renameKeyFields(translated[len(prefix)], rule.RenameKeyFields)
+		}
+		p = translated
+	}
+	return p, true
+}
+
+func renameKeyFields(pe PathElement, renames map[string]string) PathElement {
+	if pe.Key == nil {
+		return pe
+	}
+	fields := make(value.FieldList, len(*pe.Key))
+	for i, f := range *pe.Key {
+		if name, ok := renames[f.Name]; ok {
+			f.Name = name
+		}
+		fields[i] = f
+	}
+	fields.Sort()
+	return PathElement{Key: &fields}
+}
+
+// hasPrefix returns true if the first elements of fp are equal to prefix.
+func (fp Path) hasPrefix(prefix Path) bool {
+	if len(prefix) > len(fp) {
+		return false
+	}
+	return fp[:len(prefix)].Equals(prefix)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"testing"
+)
+
+func TestTranslationRules(t *testing.T) {
+	table := []struct {
+		name     string
+		rules    TranslationRules
+		set      *Set
+		expected *Set
+	}{
+		{
+			name:     "no rules",
+			set:      NewSet(_P("a", "b"), _P("c")),
+			expected: NewSet(_P("a", "b"), _P("c")),
+		}, {
+			name:  "rename",
+			rules: TranslationRules{{From: _P("spec", "replicas"), To: _P("spec", "size")}},
+			set: NewSet(
+				_P("spec", "replicas"),
+				_P("spec", "paused"),
+			),
+			expected: NewSet(
+				_P("spec", "size"),
+				_P("spec", "paused"),
+			),
+		}, {
+			name:  "move subtree",
+			rules: TranslationRules{{From: _P("spec", "template"), To: _P("template")}},
+			set: NewSet(
+				_P("spec", "template"),
+				_P("spec", "template", "metadata", "name"),
+				_P("spec", "other"),
+			),
+			expected: NewSet(
+				_P("template"),
+				_P("template", "metadata", "name"),
+				_P("spec", "other"),
+			),
+		}, {
+			name:  "drop",
+			rules: TranslationRules{{From: _P("spec", "deprecated"), Drop: true}},
+			set: NewSet(
+				_P("spec", "deprecated", "a"),
+				_P("spec", "kept"),
+			),
+			expected: NewSet(
+				_P("spec", "kept"),
+			),
+		}, {
+			name: "key change",
+			rules: TranslationRules{{
+				From:            _P("spec", "ports"),
+				RenameKeyFields: map[string]string{"port": "containerPort"},
+			}},
+			set: NewSet(
+				_P("spec", "ports", KeyByFields("port", 80, "protocol", "TCP")),
+				_P("spec", "ports", KeyByFields("port", 80, "protocol", "TCP"), "name"),
+			),
+			expected: NewSet(
+				_P("spec", "ports", KeyByFields("containerPort", 80, "protocol", "TCP")),
+				_P("spec", "ports", KeyByFields("containerPort", 80, "protocol", "TCP"), "name"),
+			),
+		}, {
+			name: "chained rules",
+			rules: TranslationRules{
+				{From: _P("a"), To: _P("b")},
+				{From: _P("b", "x"), To: _P("c")},
+			},
+			set:      NewSet(_P("a", "x", "y"), _P("a", "z")),
+			expected: NewSet(_P("c", "y"), _P("b", "z")),
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rules.Translate(tt.set)
+			if !got.Equals(tt.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTranslateVersionedSet(t *testing.T) {
+	rules := TranslationRules{{From: _P("old"), To: _P("new")}}
+	vs := rules.TranslateVersionedSet(NewVersionedSet(NewSet(_P("old")), "v1", true), "v2")
+	if vs.APIVersion() != "v2" {
+		t.Errorf("expected version v2, got %v", vs.APIVersion())
+	}
+	if !vs.Applied() {
+		t.Errorf("expected applied to be preserved")
+	}
+	if !vs.Set().Equals(NewSet(_P("new"))) {
+		t.Errorf("unexpected set: %v", vs.Set())
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fuzz exposes the fuzz targets of structured-merge-diff, so that
+// they can be built by go-fuzz, OSS-Fuzz and the like without copying
+// them. Each target has the go-fuzz signature: it returns 1 for inputs
+// that should be prioritized, 0 for the others, -1 for inputs that must
+// not be added to the corpus, and panics when it finds a bug.
+//
+// Values are parsed with Limits, so that the targets find bugs in this
+// module rather than the stack depth of the fuzzer.
+package fuzz
+
+import (
+	"bytes"
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Limits bound the values parsed by the targets.
+var Limits = value.Limits{MaxDepth: 64, MaxElements: 1000}
+
+// parse parses a YAML or JSON document of the deduced type, or returns nil
+// if it's invalid.
+func parse(data []byte) *typed.TypedValue {
+	tv, err := typed.DeducedParseableType.FromYAMLWithLimits(typed.YAMLObject(data), Limits)
+	if err != nil {
+		return nil
+	}
+	return tv
+}
+
+// ParseRoundTrip checks that values survive being serialized to and parsed
+// from JSON and YAML.
+func ParseRoundTrip(data []byte) int {
+	v, err := value.FromJSONWithLimits(data, Limits)
+	if err != nil {
+		return 0
+	}
+	j, err := value.ToJSON(v)
+	if err != nil {
+		panic(fmt.Sprintf("unable to serialize %v to JSON: %v", value.ToString(v), err))
+	}
+	v2, err := value.FromJSON(j)
+	if err != nil {
+		panic(fmt.Sprintf("unable to parse %s: %v", j, err))
+	}
+	if !value.Equals(v, v2) {
+		panic(fmt.Sprintf("JSON round trip changed %v to %v", value.ToString(v), value.ToString(v2)))
+	}
+
+	y, err := value.ToYAML(v)
+	if err != nil {
+		panic(fmt.Sprintf("unable to serialize %v to YAML: %v", value.ToString(v), err))
+	}
+	tv := parse(y)
+	if tv == nil {
+		panic(fmt.Sprintf("unable to parse %s", y))
+	}
+	if !value.Equals(v, tv.AsValue()) {
+		panic(fmt.Sprintf("YAML round trip changed %v to %v", value.ToString(v), value.ToString(tv.AsValue())))
+	}
+	return 1
+}
+
+// Merge checks invariants of merging two YAML or JSON documents, separated
+// by a "---" line: merging is idempotent, and merging the second document
+// again into the result doesn't change it.
+func Merge(data []byte) int {
+	parts := bytes.SplitN(data, []byte("\n---\n"), 2)
+	if len(parts) != 2 {
+		return -1
+	}
+	lhs, rhs := parse(parts[0]), parse(parts[1])
+	if lhs == nil || rhs == nil {
+		return 0
+	}
+
+	merged := mustMerge(lhs, rhs)
+	mustEqual("merging the rhs again", merged, mustMerge(merged, rhs))
+	mustEqual("merging the lhs with itself", lhs, mustMerge(lhs, lhs))
+
+	c, err := merged.Compare(merged)
+	if err != nil {
+		panic(fmt.Sprintf("unable to compare %v with itself: %v", value.ToString(merged.AsValue()), err))
+	}
+	if !c.IsSame() {
+		panic(fmt.Sprintf("%v differs from itself: %v", value.ToString(merged.AsValue()), c))
+	}
+	return 1
+}
+
+func mustMerge(lhs, rhs *typed.TypedValue) *typed.TypedValue {
+	merged, err := lhs.Merge(rhs)
+	if err != nil {
+		panic(fmt.Sprintf("unable to merge %v into %v: %v", value.ToString(rhs.AsValue()), value.ToString(lhs.AsValue()), err))
+	}
+	return merged
+}
+
+func mustEqual(what string, want, got *typed.TypedValue) {
+	if !value.Equals(want.AsValue(), got.AsValue()) {
+		panic(fmt.Sprintf("%v changed %v to %v", what, value.ToString(want.AsValue()), value.ToString(got.AsValue())))
+	}
+}
+
+// SetRoundTrip checks that sets of fields in the FieldsV1 format of managed
+// fields survive being parsed and serialized.
+func SetRoundTrip(data []byte) int {
+	s, err := fieldpath.SetFromFieldsV1(fieldpath.FieldsV1{Raw: data})
+	if err != nil {
+		return 0
+	}
+	f, err := s.ToFieldsV1()
+	if err != nil {
+		panic(fmt.Sprintf("unable to serialize %v: %v", s, err))
+	}
+	s2, err := fieldpath.SetFromFieldsV1(f)
+	if err != nil {
+		panic(fmt.Sprintf("unable to parse %s: %v", f.Raw, err))
+	}
+	if !s.Equals(s2) {
+		panic(fmt.Sprintf("round trip changed %v to %v", s, s2))
+	}
+	return 1
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fuzz
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// generate returns random JSON documents of the deduced type.
+func generate(t *testing.T, n int) []string {
+	r := rand.New(rand.NewSource(1))
+	docs := make([]string, n)
+	for i := range docs {
+		tv, err := smdtest.Generate(r, typed.DeducedParseableType, smdtest.GeneratorOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := value.ToJSON(tv.AsValue())
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs[i] = string(j)
+	}
+	return docs
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	seeds := []string{`null`, `1`, `1.5`, `"a"`, `[]`, `{}`, `{"a": [1, {"b": null}], "c": "é"}`}
+	for _, data := range append(seeds, generate(t, 100)...) {
+		if ParseRoundTrip([]byte(data)) != 1 {
+			t.Errorf("expected %s to be parsed", data)
+		}
+	}
+	for _, data := range []string{`{`, `[1,`, strings.Repeat("[", 100) + strings.Repeat("]", 100)} {
+		if ParseRoundTrip([]byte(data)) != 0 {
+			t.Errorf("expected %s to be rejected", data)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	seeds := []string{
+		"{}\n---\n{}",
+		"a: 1\n---\nb: 2",
+		"a: {b: 1}\n---\na: [1]",
+		"a: [1, 2]\n---\na: {b: null}",
+	}
+	docs := generate(t, 100)
+	for i := 0; i+1 < len(docs); i += 2 {
+		seeds = append(seeds, docs[i]+"\n---\n"+docs[i+1])
+	}
+	for _, data := range seeds {
+		if Merge([]byte(data)) != 1 {
+			t.Errorf("expected %q to be merged", data)
+		}
+	}
+	if Merge([]byte("a: 1")) != -1 {
+		t.Error("expected a single document to be rejected")
+	}
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	seeds := []string{
+		`{}`,
+		`{"f:a":{}}`,
+		`{"f:a":{".":{},"f:b":{}},"k:{\"name\":\"x\"}":{"f:c":{}},"v:1":{},"i:2":{}}`,
+	}
+	for _, data := range seeds {
+		if SetRoundTrip([]byte(data)) != 1 {
+			t.Errorf("expected %s to round trip", data)
+		}
+	}
+	if SetRoundTrip([]byte(`{"f:a":`)) != 0 {
+		t.Error("expected an invalid set to be rejected")
+	}
+}
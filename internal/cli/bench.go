@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+type bench struct {
+	operationBase
+
+	paths      []string
+	iterations int
+}
+
+// benchResult is the cost of an operation, per iteration.
+type benchResult struct {
+	name   string
+	ns     int64
+	bytes  uint64
+	allocs uint64
+}
+
+// measure runs f the given number of times, and returns its average
+// duration and allocations.
+func measure(name string, iterations int, f func() error) (benchResult, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		if err := f(); err != nil {
+			return benchResult{}, fmt.Errorf("%v: %v", name, err)
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	n := uint64(iterations)
+	return benchResult{
+		name:   name,
+		ns:     elapsed.Nanoseconds() / int64(iterations),
+		bytes:  (after.TotalAlloc - before.TotalAlloc) / n,
+		allocs: (after.Mallocs - before.Mallocs) / n,
+	}, nil
+}
+
+func (b bench) Execute(w io.Writer) error {
+	if b.iterations <= 0 {
+		return fmt.Errorf("the number of iterations must be positive, got %v", b.iterations)
+	}
+	for i, path := range b.paths {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := b.run(w, path); err != nil {
+			return fmt.Errorf("%v: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// run measures the operations server-side apply does on an object: it is
+// parsed, its fields are computed as those of an apply, it is merged into
+// and compared with itself, which visits all its fields, and its fields
+// are extracted from it.
+func (b bench) run(w io.Writer, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read file: %v", err)
+	}
+	t := b.parser.Type(b.typeName)
+	tv, err := t.FromYAML(typed.YAMLObject(data))
+	if err != nil {
+		return fmt.Errorf("unable to parse object: %v", err)
+	}
+	set, err := tv.ToFieldSet()
+	if err != nil {
+		return err
+	}
+
+	benchmarks := []struct {
+		name string
+		f    func() error
+	}{{
+		"parse", func() error {
+			_, err := t.FromYAML(typed.YAMLObject(data))
+			return err
+		},
+	}, {
+		"fieldset", func() error {
+			_, err := tv.ToFieldSet()
+			return err
+		},
+	}, {
+		"merge", func() error {
+			_, err := tv.Merge(tv)
+			return err
+		},
+	}, {
+		"compare", func() error {
+			_, err := tv.Compare(tv)
+			return err
+		},
+	}, {
+		"extract", func() error {
+			tv.ExtractItems(set)
+			return nil
+		},
+	}}
+
+	fmt.Fprintf(w, "%v: %v bytes, %v fields\n", path, len(data), set.Size())
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\tns/op\tB/op\tallocs/op\t")
+	for _, bm := range benchmarks {
+		r, err := measure(bm.name, b.iterations, bm.f)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t\n", r.name, r.ns, r.bytes, r.allocs)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
+)
+
+// Command is a subcommand of smd, such as "smd merge". Unlike the flags of
+// Options, the files to operate on are given as positional arguments.
+type Command struct {
+	// Name is the name of the command on the command line.
+	Name string
+	// Usage describes the positional arguments of the command.
+	Usage string
+	// Short is a one line description of the command.
+	Short string
+
+	// args is the number of positional arguments the command requires,
+	// or the minimum number if variadic is set.
+	args     int
+	variadic bool
+	// onSchemas is set by the commands operating on the schemas given as
+	// arguments, which take neither --schema nor --type.
+	onSchemas bool
+	// addFlags, if set, adds the flags specific to the command.
+	addFlags func(fs *flag.FlagSet, o *commandOptions)
+	resolve  func(o *commandOptions, args []string) Operation
+}
+
+// commandOptions holds the values of the flags of the commands.
+type commandOptions struct {
+	operationBase
+
+	// values and color are the flags of diff.
+	values bool
+	color  bool
+
+	// fieldsV1 is the flag of fieldset.
+	fieldsV1 bool
+
+	// tree is the flag of owners.
+	tree bool
+
+	// format is the flag of schema lint.
+	format string
+
+	// from, to and root are the flags of schema convert.
+	from string
+	to   string
+	root string
+
+	// count, seed and generator are the flags of generate.
+	count     int
+	seed      int64
+	generator smdtest.GeneratorOptions
+
+	// iterations is the flag of bench.
+	iterations int
+}
+
+var (
+	// ErrDifferent is returned by the commands comparing objects when
+	// they differ, once the differences have been written.
+	ErrDifferent = errors.New("objects differ")
+	// ErrInvalid is returned by validate and schema lint when documents
+	// or schemas are invalid, once their errors have been written.
+	ErrInvalid = errors.New("invalid documents")
+)
+
+// ExitCode returns the exit status of smd for the error returned by Run,
+// following diff(1): 0 on success, 1 if the objects differ or are
+// invalid, and 2 for other errors.
+func ExitCode(err error) int {
+	switch err {
+	case nil, flag.ErrHelp:
+		return 0
+	case ErrDifferent, ErrInvalid:
+		return 1
+	}
+	return 2
+}
+
+var commands = []*Command{{
+	Name:  "merge",
+	Usage: "<lhs.yaml> <rhs.yaml>",
+	Short: "Merge rhs into lhs and print the merged object.",
+	args:  2,
+	resolve: func(o *commandOptions, args []string) Operation {
+		return merge{o.operationBase, args[0], args[1]}
+	},
+}, {
+	Name:  "diff",
+	Usage: "<lhs.yaml> <rhs.yaml>",
+	Short: "Print the fields added, modified and removed from lhs to rhs. Exits with 1 if there are any.",
+	args:  2,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.BoolVar(&o.values, "values", false, "Also print a unified diff of the values.")
+		fs.BoolVar(&o.color, "color", false, "Color the diff of the values.")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return diff{o.operationBase, args[0], args[1], o.values, o.color}
+	},
+}, {
+	Name:     "validate",
+	Usage:    "<file or directory>...",
+	Short:    "Validate all the documents of the files, and of the YAML and JSON files of the directories, and print their errors. Exits with 1 if there are any.",
+	args:     1,
+	variadic: true,
+	resolve: func(o *commandOptions, args []string) Operation {
+		return validateFiles{o.operationBase, args}
+	},
+}, {
+	Name:  "fieldset",
+	Usage: "<object.yaml>",
+	Short: "Print the set of fields of the object, which an apply of it would own.",
+	args:  1,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.BoolVar(&o.fieldsV1, "fields-v1", false, "Print the set in the FieldsV1 JSON format of managed fields.")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return appliedFieldSet{o.operationBase, args[0], o.fieldsV1}
+	},
+}, {
+	Name:  "owners",
+	Usage: "<object.yaml>",
+	Short: "Print the object annotated with the managers owning each line, according to its metadata.managedFields.",
+	args:  1,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.BoolVar(&o.tree, "tree", false, "Print the tree of the owned fields instead of the object.")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return owners{o.operationBase, args[0], o.tree}
+	},
+}, {
+	Name:  "simulate",
+	Usage: "<scenario.yaml>",
+	Short: "Replay the applies and updates of a scenario, and print the object, its managed fields and the conflicts after each of them.",
+	args:  1,
+	resolve: func(o *commandOptions, args []string) Operation {
+		return simulate{o.operationBase, args[0]}
+	},
+}, {
+	Name:  "generate",
+	Usage: "",
+	Short: "Print random valid objects of the type, separated by ---, to fuzz or load test server-side apply.",
+	args:  0,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.IntVar(&o.count, "count", 1, "Number of objects to generate.")
+		fs.Int64Var(&o.seed, "seed", 1, "Seed of the random objects. The same seed generates the same objects.")
+		fs.IntVar(&o.generator.MaxDepth, "max-depth", 5, "Maximum nesting of the maps and lists of the objects.")
+		fs.IntVar(&o.generator.MaxListLength, "max-list-length", 4, "Maximum number of items of lists.")
+		fs.IntVar(&o.generator.MaxMapSize, "max-map-size", 4, "Maximum number of keys of maps, beyond the fields of their struct.")
+		fs.Float64Var(&o.generator.FieldProbability, "field-probability", 0.5, "Probability of setting each field of a struct.")
+		fs.IntVar(&o.generator.MaxStringLength, "max-string-length", 12, "Maximum length of strings.")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return generate{o.operationBase, o.count, o.seed, o.generator}
+	},
+}, {
+	Name:     "bench",
+	Usage:    "<object.yaml>...",
+	Short:    "Print the time and allocations taken to parse, compute the fields of, merge, compare and extract each object.",
+	args:     1,
+	variadic: true,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.IntVar(&o.iterations, "iterations", 100, "Number of times each operation is run.")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return bench{o.operationBase, args, o.iterations}
+	},
+}, {
+	Name:      "schema lint",
+	Usage:     "<schema.yaml>",
+	Short:     "Print the problems of the schema. Exits with 1 if any of them is an error.",
+	args:      1,
+	onSchemas: true,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.StringVar(&o.format, "format", "text", "Output format, either text or json.")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return lintSchema{args[0], o.format}
+	},
+}, {
+	Name:      "schema convert",
+	Usage:     "<schema>",
+	Short:     "Convert a schema between the formats of this module, OpenAPI v2 and v3, CustomResourceDefinitions and JSON Schema.",
+	args:      1,
+	onSchemas: true,
+	addFlags: func(fs *flag.FlagSet, o *commandOptions) {
+		fs.StringVar(&o.from, "from", "", "Format of the input: smd, openapi, crd or jsonschema. Detected if empty.")
+		fs.StringVar(&o.to, "to", "", "Format of the output: smd, openapi, crd (the openAPIV3Schema of a version) or jsonschema. Defaults to smd, or openapi for smd inputs.")
+		fs.StringVar(&o.root, "type", "", "Name of the type to convert to crd or jsonschema, or to give to the root of a jsonschema input. If empty, the first type of the schema, or \"root\".")
+	},
+	resolve: func(o *commandOptions, args []string) Operation {
+		return convertSchema{args[0], o.from, o.to, o.root}
+	},
+}}
+
+// LookupCommand returns the subcommand with the given name, or nil if
+// there is none.
+func LookupCommand(name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// FindCommand returns the subcommand named by the first arguments, like
+// "merge" or "schema lint", and the arguments following its name, or nil
+// if there is none.
+func FindCommand(args []string) (*Command, []string) {
+	for n := 2; n > 0; n-- {
+		if len(args) < n {
+			continue
+		}
+		if c := LookupCommand(strings.Join(args[:n], " ")); c != nil {
+			return c, args[n:]
+		}
+	}
+	return nil, nil
+}
+
+// Run parses args, the arguments following the name of the command, and
+// executes it. Its output goes to stdout unless --output is given, and the
+// usage of the command to stderr if args can't be parsed. ExitCode maps the
+// returned error to an exit status.
+func (c *Command) Run(args []string, stdout, stderr io.Writer) error {
+	var schemaPath, typeName, output string
+	var o commandOptions
+	fs := flag.NewFlagSet("smd "+c.Name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if !c.onSchemas {
+		fs.StringVar(&schemaPath, "schema", "", "Path to the schema file for this operation. Required.")
+		fs.StringVar(&typeName, "type", "", "Name of type in the schema to use. If empty, the first type in the schema will be used.")
+	}
+	fs.StringVar(&output, "output", "-", "Output location. '-' means stdout.")
+	if c.addFlags != nil {
+		c.addFlags(fs, &o)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: smd %v [flags] %v\n\n%v\n\nFlags:\n", c.Name, c.Usage, c.Short)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if c.variadic && fs.NArg() < c.args {
+		fs.Usage()
+		return fmt.Errorf("%v requires at least %v arguments, got %v", c.Name, c.args, fs.NArg())
+	} else if !c.variadic && fs.NArg() != c.args {
+		fs.Usage()
+		return fmt.Errorf("%v requires %v arguments, got %v", c.Name, c.args, fs.NArg())
+	}
+
+	if !c.onSchemas {
+		var err error
+		o.operationBase, err = loadBase(schemaPath, typeName)
+		if err != nil {
+			return err
+		}
+	}
+	op := c.resolve(&o, fs.Args())
+
+	w := stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("unable to open %q for writing: %v", output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	return op.Execute(w)
+}
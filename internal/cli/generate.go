@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+type generate struct {
+	operationBase
+
+	count int
+	seed  int64
+	opts  smdtest.GeneratorOptions
+}
+
+func (g generate) Execute(w io.Writer) error {
+	r := rand.New(rand.NewSource(g.seed))
+	t := g.parser.Type(g.typeName)
+	for i := 0; i < g.count; i++ {
+		tv, err := smdtest.Generate(r, t, g.opts)
+		if err != nil {
+			return fmt.Errorf("object %v: %v", i+1, err)
+		}
+		y, err := value.ToYAML(tv.AsValue())
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		if _, err := w.Write(y); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -20,7 +20,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -249,3 +251,451 @@ func TestFieldSet(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeCommand(t *testing.T) {
+	cases := []struct {
+		args      []string
+		expectErr bool
+
+		expectedOutputPath string
+	}{{
+		args:               []string{"--schema", testdata("schema.yaml"), "--type", "schema", testdata("scalar.yaml"), testdata("bad-scalar.yaml")},
+		expectedOutputPath: testdata("bad-scalar.yaml"),
+	}, {
+		args:               []string{"--schema", testdata("schema.yaml"), testdata("bad-scalar.yaml"), testdata("scalar.yaml")},
+		expectedOutputPath: testdata("scalar.yaml"),
+	}, {
+		args:      []string{"--schema", testdata("schema.yaml"), testdata("struct.yaml")},
+		expectErr: true,
+	}, {
+		args:      []string{testdata("struct.yaml"), testdata("list.yaml")},
+		expectErr: true,
+	}, {
+		args:      []string{"--schema", testdata("schema.yaml"), testdata("struct.yaml"), testdata("bad-schema.yaml")},
+		expectErr: true,
+	}}
+
+	c := LookupCommand("merge")
+	if c == nil {
+		t.Fatal("merge command not found")
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("unexpected success")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			(&testCase{expectedOutputPath: tt.expectedOutputPath}).checkOutput(t, stdout.Bytes())
+		})
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	cases := []struct {
+		args         []string
+		expectedCode int
+
+		expectedOutput string
+	}{{
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("scalar.yaml"), testdata("scalar.yaml")},
+		expectedCode: 0,
+	}, {
+		args:           []string{"--schema", testdata("schema.yaml"), testdata("scalar.yaml"), testdata("bad-scalar.yaml")},
+		expectedCode:   1,
+		expectedOutput: "- Modified Fields:\n.types[name=\"scalar\"].scalar\n",
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml"), "--values", testdata("scalar.yaml"), testdata("bad-scalar.yaml")},
+		expectedCode: 1,
+		expectedOutput: "- Modified Fields:\n.types[name=\"scalar\"].scalar\n" +
+			"\n--- " + testdata("scalar.yaml") + "\n+++ " + testdata("bad-scalar.yaml") + "\n" +
+			"@@ -1,3 +1,3 @@\n" +
+			" types:\n" +
+			" - name: \"scalar\"\n" +
+			"-  scalar: \"string\"\n" +
+			"+  scalar: \"numeric\"\n",
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("struct.yaml"), testdata("bad-schema.yaml")},
+		expectedCode: 2,
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("struct.yaml")},
+		expectedCode: 2,
+	}}
+
+	c := LookupCommand("diff")
+	if c == nil {
+		t.Fatal("diff command not found")
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if code := ExitCode(err); code != tt.expectedCode {
+				t.Fatalf("expected exit code %v, got %v (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedCode < 2 && stdout.String() != tt.expectedOutput {
+				t.Errorf("expected output:\n%v\ngot:\n%v", tt.expectedOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	cases := []struct {
+		args         []string
+		expectedCode int
+
+		expectedOutput string
+	}{{
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("schema.yaml"), testdata("struct.yaml")},
+		expectedCode: 0,
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("schema.yaml"), testdata("bad-schemas.yaml")},
+		expectedCode: 1,
+		expectedOutput: testdata("bad-schemas.yaml") + `:13: .types[name="b"].map.fields[name="x"].type.scalar: expected string, got &value.valueUnstructured{Value:[]interface {}{"oops"}}
+` + testdata("bad-schemas.yaml") + `:11: .types[name="b"].map.fields[name="x"].typo: field not declared in schema
+` + testdata("bad-schemas.yaml") + `:15: .types[name="c"].bogus: field not declared in schema
+` + testdata("bad-schemas.yaml") + `:17: .types: expected list, got &{map[bad:1]}
+`,
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("missing.yaml")},
+		expectedCode: 2,
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml")},
+		expectedCode: 2,
+	}}
+
+	c := LookupCommand("validate")
+	if c == nil {
+		t.Fatal("validate command not found")
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if code := ExitCode(err); code != tt.expectedCode {
+				t.Fatalf("expected exit code %v, got %v (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedCode < 2 && stdout.String() != tt.expectedOutput {
+				t.Errorf("expected output:\n%v\ngot:\n%v", tt.expectedOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestFieldSetCommand(t *testing.T) {
+	cases := []struct {
+		args      []string
+		expectErr bool
+
+		expectedOutput string
+	}{{
+		args:           []string{"--schema", testdata("schema.yaml"), testdata("scalar.yaml")},
+		expectedOutput: ".types[name=\"scalar\"]\n.types[name=\"scalar\"].name\n.types[name=\"scalar\"].scalar\n",
+	}, {
+		args:           []string{"--schema", testdata("schema.yaml"), "--fields-v1", testdata("scalar.yaml")},
+		expectedOutput: `{"f:types":{"k:{\"name\":\"scalar\"}":{".":{},"f:name":{},"f:scalar":{}}}}`,
+	}, {
+		args:      []string{"--schema", testdata("schema.yaml"), testdata("bad-schema.yaml")},
+		expectErr: true,
+	}}
+
+	c := LookupCommand("fieldset")
+	if c == nil {
+		t.Fatal("fieldset command not found")
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("unexpected success")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.expectedOutput {
+				t.Errorf("expected output:\n%v\ngot:\n%v", tt.expectedOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestOwnersCommand(t *testing.T) {
+	cases := []struct {
+		args      []string
+		expectErr bool
+
+		expectedOutputPath string
+	}{{
+		args:               []string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.apps.v1.Deployment", testdata("managed-deployment.yaml")},
+		expectedOutputPath: testdata("managed-deployment-owners.txt"),
+	}, {
+		args:               []string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.apps.v1.Deployment", "--tree", testdata("managed-deployment.yaml")},
+		expectedOutputPath: testdata("managed-deployment-owners-tree.txt"),
+	}, {
+		args:      []string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.core.v1.Pod", testdata("pod.yaml")},
+		expectErr: true,
+	}}
+
+	c := LookupCommand("owners")
+	if c == nil {
+		t.Fatal("owners command not found")
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("unexpected success")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			(&testCase{expectedOutputPath: tt.expectedOutputPath}).checkOutput(t, stdout.Bytes())
+		})
+	}
+}
+
+func TestSchemaLintCommand(t *testing.T) {
+	cases := []struct {
+		args         []string
+		expectedCode int
+
+		expectedOutput string
+	}{{
+		args:         []string{testdata("schema.yaml")},
+		expectedCode: 0,
+	}, {
+		args:           []string{"--format", "json", testdata("schema.yaml")},
+		expectedCode:   0,
+		expectedOutput: "[]\n",
+	}, {
+		args:         []string{testdata("bad-schema.yaml")},
+		expectedCode: 1,
+		expectedOutput: `error: types[name=]: type has no name
+warning: types[name=list].map.fields[name=keys].type.list: elementRelationship is not set, the list is atomic
+`,
+	}, {
+		args:         []string{"--format", "json", testdata("bad-schema.yaml")},
+		expectedCode: 1,
+		expectedOutput: `[
+  {
+    "severity": "error",
+    "path": "types[name=]",
+    "message": "type has no name"
+  },
+  {
+    "severity": "warning",
+    "path": "types[name=list].map.fields[name=keys].type.list",
+    "message": "elementRelationship is not set, the list is atomic"
+  }
+]
+`,
+	}, {
+		args:         []string{"--format", "xml", testdata("schema.yaml")},
+		expectedCode: 2,
+	}, {
+		args:         []string{"--schema", testdata("schema.yaml"), testdata("schema.yaml")},
+		expectedCode: 2,
+	}}
+
+	c, rest := FindCommand([]string{"schema", "lint", "x"})
+	if c == nil || len(rest) != 1 {
+		t.Fatalf("schema lint command not found: %v, %v", c, rest)
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if code := ExitCode(err); code != tt.expectedCode {
+				t.Fatalf("expected exit code %v, got %v (%v)", tt.expectedCode, code, err)
+			}
+			if tt.expectedCode < 2 && stdout.String() != tt.expectedOutput {
+				t.Errorf("expected output:\n%v\ngot:\n%v", tt.expectedOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestSchemaConvertCommand(t *testing.T) {
+	cases := []struct {
+		args      []string
+		expectErr bool
+
+		expectedOutput string
+	}{{
+		args: []string{testdata("widget-crd.yaml")},
+		expectedOutput: `types:
+- name: com.example.v1.Widget
+  map:
+    fields:
+    - name: spec
+      type:
+        map:
+          fields:
+          - name: ports
+            type:
+              list:
+                elementType:
+                  map:
+                    fields:
+                    - name: port
+                      type:
+                        scalar: numeric
+                elementRelationship: associative
+                keys:
+                - port
+          - name: replicas
+            type:
+              scalar: numeric
+`,
+	}, {
+		args: []string{"--from", "crd", "--to", "crd", testdata("widget-crd.yaml")},
+		expectedOutput: `type: object
+properties:
+  spec:
+    type: object
+    properties:
+      ports:
+        type: array
+        items:
+          type: object
+          properties:
+            port:
+              type: number
+        x-kubernetes-list-type: map
+        x-kubernetes-list-map-keys:
+        - port
+      replicas:
+        type: number
+`,
+	}, {
+		args:      []string{"--to", "crd", testdata("schema.yaml")},
+		expectErr: true,
+	}, {
+		args:      []string{"--to", "xml", testdata("schema.yaml")},
+		expectErr: true,
+	}}
+
+	c := LookupCommand("schema convert")
+	if c == nil {
+		t.Fatal("schema convert command not found")
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := c.Run(tt.args, &stdout, &stderr)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("unexpected success")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tt.expectedOutput {
+				t.Errorf("expected output:\n%v\ngot:\n%v", tt.expectedOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestSimulateCommand(t *testing.T) {
+	c := LookupCommand("simulate")
+	if c == nil {
+		t.Fatal("simulate command not found")
+	}
+	var stdout, stderr bytes.Buffer
+	err := c.Run([]string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.apps.v1.Deployment", testdata("scenario.yaml")}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	(&testCase{expectedOutputPath: testdata("scenario-output.txt")}).checkOutput(t, stdout.Bytes())
+
+	// Scenarios are parsed strictly, so that typos aren't ignored.
+	err = c.Run([]string{"--schema", testdata("k8s-schema.yaml"), testdata("pod.yaml")}, &stdout, &stderr)
+	if err == nil {
+		t.Error("unexpected success on an invalid scenario")
+	}
+}
+
+func TestGenerateCommand(t *testing.T) {
+	c := LookupCommand("generate")
+	if c == nil {
+		t.Fatal("generate command not found")
+	}
+	run := func(seed string) string {
+		var stdout, stderr bytes.Buffer
+		err := c.Run([]string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.apps.v1.Deployment", "--count", "5", "--seed", seed}, &stdout, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return stdout.String()
+	}
+	out := run("3")
+	if docs := strings.Split(out, "---\n"); len(docs) != 5 {
+		t.Errorf("expected 5 objects, got %v:\n%v", len(docs), out)
+	}
+	if again := run("3"); again != out {
+		t.Errorf("expected the same objects with the same seed, got:\n%v\nand:\n%v", out, again)
+	}
+
+	// The generated objects are valid.
+	dir, err := ioutil.TempDir("", "smd-generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f := filepath.Join(dir, "objects.yaml")
+	if err := ioutil.WriteFile(f, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := LookupCommand("validate").Run([]string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.apps.v1.Deployment", f}, &stdout, &stderr); err != nil {
+		t.Errorf("generated objects are invalid: %v\n%v", err, stdout.String())
+	}
+}
+
+func TestBenchCommand(t *testing.T) {
+	c := LookupCommand("bench")
+	if c == nil {
+		t.Fatal("bench command not found")
+	}
+	var stdout, stderr bytes.Buffer
+	err := c.Run([]string{"--schema", testdata("k8s-schema.yaml"), "--type", "io.k8s.api.apps.v1.Deployment", "--iterations", "2", testdata("k8s-deployment.yaml")}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The measures vary, but there is one line per operation.
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 7 || !strings.Contains(lines[0], "99 fields") {
+		t.Fatalf("unexpected output:\n%v", stdout.String())
+	}
+	for i, op := range []string{"parse", "fieldset", "merge", "compare", "extract"} {
+		if fields := strings.Fields(lines[i+2]); len(fields) != 4 || fields[0] != op {
+			t.Errorf("expected measures of %v, got %q", op, lines[i+2])
+		}
+	}
+
+	err = c.Run([]string{"--schema", testdata("k8s-schema.yaml"), "--iterations", "0", testdata("k8s-deployment.yaml")}, &stdout, &stderr)
+	if err == nil {
+		t.Error("unexpected success with no iterations")
+	}
+}
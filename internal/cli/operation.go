@@ -81,6 +81,33 @@ func (f fieldset) Execute(w io.Writer) error {
 	return c.Added.ToJSONStream(w)
 }
 
+// appliedFieldSet prints the set of fields an apply of the file would own.
+type appliedFieldSet struct {
+	operationBase
+
+	fileToUse string
+	fieldsV1  bool
+}
+
+func (f appliedFieldSet) Execute(w io.Writer) error {
+	tv, err := f.parseFile(f.fileToUse)
+	if err != nil {
+		return err
+	}
+	set, err := tv.ToFieldSet()
+	if err != nil {
+		return err
+	}
+	if f.fieldsV1 {
+		return set.ToJSONStream(w)
+	}
+	if set.Empty() {
+		return nil
+	}
+	_, err = fmt.Fprintln(w, set.String())
+	return err
+}
+
 type listTypes struct {
 	operationBase
 }
@@ -157,3 +184,46 @@ func (c compare) Execute(w io.Writer) error {
 
 	return err
 }
+
+type diff struct {
+	operationBase
+
+	lhs string
+	rhs string
+
+	values bool
+	color  bool
+}
+
+func (d diff) Execute(w io.Writer) error {
+	lhs, err := d.parseFile(d.lhs)
+	if err != nil {
+		return err
+	}
+	rhs, err := d.parseFile(d.rhs)
+	if err != nil {
+		return err
+	}
+
+	got, err := lhs.Compare(rhs)
+	if err != nil {
+		return err
+	}
+	if got.IsSame() {
+		return nil
+	}
+
+	if _, err := io.WriteString(w, got.String()); err != nil {
+		return err
+	}
+	if d.values {
+		out, err := typed.UnifiedDiff(lhs, rhs, typed.DiffOptions{LHSName: d.lhs, RHSName: d.rhs, Color: d.color})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "\n%v", out); err != nil {
+			return err
+		}
+	}
+	return ErrDifferent
+}
@@ -72,27 +72,9 @@ func (o *Options) AddFlags(fs *flag.FlagSet) {
 
 // resolve turns options in to an operation that can be executed.
 func (o *Options) Resolve() (Operation, error) {
-	var base operationBase
-	if o.schemaPath == "" {
-		return nil, errors.New("a schema is required")
-	}
-	b, err := ioutil.ReadFile(o.schemaPath)
+	base, err := loadBase(o.schemaPath, o.typeName)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read schema %q: %v", o.schemaPath, err)
-	}
-	base.parser, err = typed.NewParser(typed.YAMLObject(b))
-	if err != nil {
-		return nil, fmt.Errorf("schema %q has errors:\n%v", o.schemaPath, err)
-	}
-
-	if o.typeName == "" {
-		types := base.parser.Schema.Types
-		if len(types) == 0 {
-			return nil, errors.New("no types were given in the schema")
-		}
-		base.typeName = types[0].Name
-	} else {
-		base.typeName = o.typeName
+		return nil, err
 	}
 
 	// Count how many operations were requested
@@ -123,6 +105,33 @@ func (o *Options) Resolve() (Operation, error) {
 	return nil, errors.New("no operation requested")
 }
 
+// loadBase reads the schema at schemaPath and picks the type to use in it.
+func loadBase(schemaPath, typeName string) (operationBase, error) {
+	var base operationBase
+	if schemaPath == "" {
+		return base, errors.New("a schema is required")
+	}
+	b, err := ioutil.ReadFile(schemaPath)
+	if err != nil {
+		return base, fmt.Errorf("unable to read schema %q: %v", schemaPath, err)
+	}
+	base.parser, err = typed.NewParser(typed.YAMLObject(b))
+	if err != nil {
+		return base, fmt.Errorf("schema %q has errors:\n%v", schemaPath, err)
+	}
+
+	if typeName == "" {
+		types := base.parser.Schema.Types
+		if len(types) == 0 {
+			return base, errors.New("no types were given in the schema")
+		}
+		base.typeName = types[0].Name
+	} else {
+		base.typeName = typeName
+	}
+	return base, nil
+}
+
 func (o *Options) OpenOutput() (io.WriteCloser, error) {
 	if o.output == "-" {
 		return os.Stdout, nil
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// owners renders which managers own the fields of a Kubernetes object,
+// according to its metadata.managedFields.
+type owners struct {
+	operationBase
+
+	fileToUse string
+	tree      bool
+}
+
+// fieldOwner is a manager and the fields it owns.
+type fieldOwner struct {
+	name   string
+	set    *fieldpath.Set
+	leaves *fieldpath.Set
+}
+
+func (o owners) Execute(w io.Writer) error {
+	b, err := ioutil.ReadFile(o.fileToUse)
+	if err != nil {
+		return fmt.Errorf("unable to read file %q: %v", o.fileToUse, err)
+	}
+	var obj interface{}
+	if err := yaml.Unmarshal(b, &obj); err != nil {
+		return fmt.Errorf("unable to parse file %q: %v", o.fileToUse, err)
+	}
+	managers, err := removeManagedFields(obj)
+	if err != nil {
+		return fmt.Errorf("unable to read the managed fields of %q: %v", o.fileToUse, err)
+	}
+	if o.tree {
+		return writeOwnersTree(w, managers)
+	}
+
+	tv, err := o.parser.Type(o.typeName).FromUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("unable to validate file %q:\n%v", o.fileToUse, err)
+	}
+	lines := tv.RenderYAML()
+	width := 0
+	for _, l := range lines {
+		if len(l.Text) > width {
+			width = len(l.Text)
+		}
+	}
+	for _, l := range lines {
+		names := ownersOf(managers, l.Path)
+		if len(names) == 0 {
+			_, err = fmt.Fprintln(w, l.Text)
+		} else {
+			_, err = fmt.Fprintf(w, "%-*v  # %v\n", width, l.Text, strings.Join(names, ", "))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownersOf returns the managers owning the path, or else the closest of
+// its ancestors owned as a leaf, so that the items of atomic lists and
+// maps are attributed to the owners of the list or map.
+func ownersOf(managers []fieldOwner, path fieldpath.Path) []string {
+	for i := len(path); i > 0; i-- {
+		var names []string
+		for _, m := range managers {
+			if (i == len(path) && m.set.Has(path)) || m.leaves.Has(path[:i]) {
+				names = append(names, m.name)
+			}
+		}
+		if len(names) > 0 {
+			return names
+		}
+	}
+	return nil
+}
+
+// writeOwnersTree writes the fields owned by any manager as a tree, with
+// their owners.
+func writeOwnersTree(w io.Writer, managers []fieldOwner) error {
+	all := &fieldpath.Set{}
+	for _, m := range managers {
+		all = all.Union(m.set)
+	}
+	var err error
+	var previous fieldpath.Path
+	all.Iterate(func(p fieldpath.Path) {
+		if err != nil || len(p) == 0 {
+			return
+		}
+		// Write the ancestors that aren't owned, and so not iterated.
+		common := 0
+		for common < len(previous) && common < len(p)-1 && previous[common].Equals(p[common]) {
+			common++
+		}
+		for i := common; i < len(p)-1 && err == nil; i++ {
+			_, err = fmt.Fprintf(w, "%v%v\n", strings.Repeat("  ", i), p[i])
+		}
+		previous = p.Copy()
+
+		var names []string
+		for _, m := range managers {
+			if m.set.Has(p) {
+				names = append(names, m.name)
+			}
+		}
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%v%v  # %v\n", strings.Repeat("  ", len(p)-1), p[len(p)-1], strings.Join(names, ", "))
+		}
+	})
+	return err
+}
+
+// removeManagedFields removes metadata.managedFields from obj and returns
+// the managers they list, named after their manager, operation and
+// subresource, and sorted by name.
+func removeManagedFields(obj interface{}) ([]fieldOwner, error) {
+	m, ok := obj.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", obj)
+	}
+	metadata, ok := m["metadata"].(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("object has no metadata")
+	}
+	entries, ok := metadata["managedFields"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("object has no managedFields")
+	}
+	delete(metadata, "managedFields")
+
+	var managers []fieldOwner
+	for i, e := range entries {
+		entry, ok := e.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("entry %v: expected an object, got %T", i, e)
+		}
+		name := fmt.Sprint(entry["manager"])
+		details := []string{fmt.Sprint(entry["operation"])}
+		if subresource, ok := entry["subresource"].(string); ok && subresource != "" {
+			details = append(details, subresource)
+		}
+		name += " (" + strings.Join(details, ", ") + ")"
+
+		fields, err := value.ToJSON(value.NewValueInterface(entry["fieldsV1"]))
+		if err != nil {
+			return nil, fmt.Errorf("entry %v: %v", i, err)
+		}
+		set := &fieldpath.Set{}
+		if err := set.FromJSON(bytes.NewReader(fields)); err != nil {
+			return nil, fmt.Errorf("entry %v: invalid fieldsV1: %v", i, err)
+		}
+		managers = append(managers, fieldOwner{name, set, set.Leaves()})
+	}
+	sort.Slice(managers, func(i, j int) bool { return managers[i].name < managers[j].name })
+	return managers, nil
+}
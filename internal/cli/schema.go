@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/schemaconv"
+)
+
+// readSchema reads the schema at path, without the checks of a Parser.
+func readSchema(path string) (*schema.Schema, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schema %q: %v", path, err)
+	}
+	var s schema.Schema
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("unable to parse schema %q: %v", path, err)
+	}
+	return &s, nil
+}
+
+// lintProblem is the JSON representation of a schema.LintProblem.
+type lintProblem struct {
+	Severity schema.Severity `json:"severity"`
+	Path     string          `json:"path"`
+	Message  string          `json:"message"`
+}
+
+type lintSchema struct {
+	schemaPath string
+	format     string
+}
+
+func (l lintSchema) Execute(w io.Writer) error {
+	if l.format != "text" && l.format != "json" {
+		return fmt.Errorf("unknown format %q, expected text or json", l.format)
+	}
+	s, err := readSchema(l.schemaPath)
+	if err != nil {
+		return err
+	}
+	problems := schema.Lint(s)
+
+	if l.format == "json" {
+		out := make([]lintProblem, 0, len(problems))
+		for _, p := range problems {
+			out = append(out, lintProblem{p.Severity, p.Path, p.Message})
+		}
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		if err := e.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			if _, err := fmt.Fprintln(w, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range problems {
+		if p.Severity == schema.SeverityError {
+			return ErrInvalid
+		}
+	}
+	return nil
+}
+
+type convertSchema struct {
+	schemaPath string
+	from       string
+	to         string
+	root       string
+}
+
+func (c convertSchema) Execute(w io.Writer) error {
+	b, err := ioutil.ReadFile(c.schemaPath)
+	if err != nil {
+		return fmt.Errorf("unable to read schema %q: %v", c.schemaPath, err)
+	}
+	from := c.from
+	if from == "" {
+		if from, err = detectSchemaFormat(b); err != nil {
+			return fmt.Errorf("unable to parse schema %q: %v", c.schemaPath, err)
+		}
+	}
+	to := c.to
+	if to == "" {
+		to = "smd"
+		if from == "smd" {
+			to = "openapi"
+		}
+	}
+
+	s, err := c.read(b, from)
+	if err != nil {
+		return fmt.Errorf("unable to convert schema %q: %v", c.schemaPath, err)
+	}
+	root := c.root
+	if root == "" && len(s.Types) > 0 {
+		root = s.Types[0].Name
+	}
+	var out interface{}
+	switch to {
+	case "smd":
+		out = s
+	case "openapi":
+		out, err = schemaconv.ToOpenAPI(s)
+	case "crd":
+		out, err = schemaconv.ToStructural(s, root)
+	case "jsonschema":
+		out, err = schemaconv.ToJSONSchema(s, root)
+	default:
+		return fmt.Errorf("unknown output format %q", to)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to convert schema %q: %v", c.schemaPath, err)
+	}
+	b, err = yaml.Marshal(out)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (c convertSchema) read(b []byte, from string) (*schema.Schema, error) {
+	switch from {
+	case "smd":
+		var s schema.Schema
+		if err := yaml.Unmarshal(b, &s); err != nil {
+			return nil, err
+		}
+		return &s, schema.ApplyMarkers(&s)
+	case "openapi":
+		var doc schemaconv.OpenAPI
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		return schemaconv.FromOpenAPI(&doc)
+	case "crd":
+		var crd schemaconv.CustomResourceDefinition
+		if err := yaml.Unmarshal(b, &crd); err != nil {
+			return nil, err
+		}
+		return schemaconv.FromCRD(&crd)
+	case "jsonschema":
+		var doc schemaconv.JSONSchemaDocument
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		root := c.root
+		if root == "" {
+			root = "root"
+		}
+		return schemaconv.FromJSONSchema(&doc, root)
+	}
+	return nil, fmt.Errorf("unknown input format %q", from)
+}
+
+// detectSchemaFormat returns the format of a schema from its top-level
+// fields.
+func detectSchemaFormat(b []byte) (string, error) {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(b, &fields); err != nil {
+		return "", err
+	}
+	switch {
+	case fields["types"] != nil:
+		return "smd", nil
+	case fields["kind"] == "CustomResourceDefinition":
+		return "crd", nil
+	case fields["openapi"] != nil, fields["swagger"] != nil, fields["components"] != nil:
+		return "openapi", nil
+	}
+	return "jsonschema", nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	smdmerge "sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Scenario is a sequence of operations on an object, which simulate
+// replays to show how server-side apply handles them.
+type Scenario struct {
+	Steps []ScenarioStep `yaml:"steps"`
+}
+
+// ScenarioStep is an operation of a Scenario.
+type ScenarioStep struct {
+	Manager string `yaml:"manager"`
+	// Operation is either Apply or Update.
+	Operation string `yaml:"operation"`
+	// Force forces the conflicts of an Apply.
+	Force bool `yaml:"force,omitempty"`
+	// APIVersion defaults to v1. All the versions share the type.
+	APIVersion string      `yaml:"apiVersion,omitempty"`
+	Object     interface{} `yaml:"object"`
+}
+
+type simulate struct {
+	operationBase
+
+	scenarioPath string
+}
+
+func (s simulate) Execute(w io.Writer) error {
+	b, err := ioutil.ReadFile(s.scenarioPath)
+	if err != nil {
+		return fmt.Errorf("unable to read scenario %q: %v", s.scenarioPath, err)
+	}
+	var scenario Scenario
+	if err := yaml.UnmarshalStrict(b, &scenario); err != nil {
+		return fmt.Errorf("unable to parse scenario %q: %v", s.scenarioPath, err)
+	}
+
+	state := &smdtest.State{
+		Parser: smdtest.SameVersionParser{T: s.parser.Type(s.typeName)},
+		Updater: (&smdmerge.UpdaterBuilder{
+			Converter:             sameVersionConverter{},
+			IncludeConflictValues: true,
+		}).BuildUpdater(),
+	}
+	for i, step := range scenario.Steps {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := s.run(w, state, i+1, step); err != nil {
+			return fmt.Errorf("step %v: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// run runs a step and writes the state it leaves. Conflicts are reported
+// and leave the state unchanged, as they would on a server.
+func (s simulate) run(w io.Writer, state *smdtest.State, number int, step ScenarioStep) error {
+	version := fieldpath.APIVersion(step.APIVersion)
+	if version == "" {
+		version = "v1"
+	}
+	tv, err := state.Parser.Type(string(version)).FromUnstructured(step.Object)
+	if err != nil {
+		return err
+	}
+	verb := ""
+	switch strings.ToLower(step.Operation) {
+	case "apply":
+		verb = "applies"
+		if step.Force {
+			verb = "force-applies"
+		}
+		err = state.ApplyObject(tv, version, step.Manager, step.Force)
+	case "update":
+		verb = "updates"
+		err = state.UpdateObject(tv, version, step.Manager)
+	default:
+		return fmt.Errorf("unknown operation %q, expected Apply or Update", step.Operation)
+	}
+	fmt.Fprintf(w, "Step %v: %v %v\n", number, step.Manager, verb)
+	if conflicts, ok := err.(smdmerge.Conflicts); ok {
+		fmt.Fprintln(w, "Conflicts:")
+		for _, c := range conflicts {
+			fmt.Fprintf(w, "  %v\n", c.Error())
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if state.Live != nil {
+		y, err := value.ToYAML(state.Live.AsValue())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Object:\n%v", indent(string(y), "  "))
+	}
+	fmt.Fprintln(w, "Managed fields:")
+	managers := make([]string, 0, len(state.Managers))
+	for m := range state.Managers {
+		managers = append(managers, m)
+	}
+	sort.Strings(managers)
+	for _, m := range managers {
+		vs := state.Managers[m]
+		operation := "Update"
+		if vs.Applied() {
+			operation = "Apply"
+		}
+		fmt.Fprintf(w, "  %v (%v, %v):\n%v\n", m, operation, vs.APIVersion(), indent(vs.Set().String(), "    "))
+	}
+	return nil
+}
+
+// indent indents the lines of s.
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// sameVersionConverter doesn't convert, since all the versions of the
+// simulated type share its schema.
+type sameVersionConverter struct{}
+
+func (sameVersionConverter) Convert(v *typed.TypedValue, _ fieldpath.APIVersion) (*typed.TypedValue, error) {
+	return v, nil
+}
+
+func (sameVersionConverter) IsMissingVersionError(error) bool {
+	return false
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// validateFiles validates all the documents of files and directories, and
+// reports all their errors, instead of stopping at the first invalid one.
+type validateFiles struct {
+	operationBase
+
+	paths []string
+}
+
+// document is a document of a file, whose first line is numbered line.
+type document struct {
+	line int
+	text string
+}
+
+func (v validateFiles) Execute(w io.Writer) error {
+	files, err := yamlFiles(v.paths)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("unable to read file %q: %v", file, err)
+		}
+		for _, doc := range splitDocuments(string(b)) {
+			for _, msg := range v.validateDocument(doc) {
+				invalid++
+				if _, err := fmt.Fprintf(w, "%v:%v\n", file, msg); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if invalid > 0 {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// yamlParseErrorRE matches the errors of yaml.v2, to make their lines
+// relative to the file.
+var yamlParseErrorRE = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// validateDocument returns the errors of the document, prefixed with their
+// line.
+func (v validateFiles) validateDocument(doc document) []string {
+	_, err := v.parser.Type(v.typeName).FromYAML(typed.YAMLObject(doc.text))
+	if err == nil {
+		return nil
+	}
+	errs, ok := err.(typed.ValidationErrors)
+	if !ok {
+		if m := yamlParseErrorRE.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return []string{fmt.Sprintf("%v: %v", doc.line+line-1, m[2])}
+		}
+		return []string{fmt.Sprintf("%v: %v", doc.line, err)}
+	}
+	root := scanYAML(doc.text, doc.line)
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, fmt.Sprintf("%v: %v", root.lineOf(e.Path), e.Error()))
+	}
+	return msgs
+}
+
+// splitDocuments returns the documents of a multi-document YAML file,
+// skipping the empty ones.
+func splitDocuments(file string) []document {
+	var docs []document
+	var b strings.Builder
+	start, empty := 1, true
+	flush := func(next int) {
+		if !empty {
+			docs = append(docs, document{line: start, text: b.String()})
+		}
+		b.Reset()
+		start, empty = next, true
+	}
+	for i, l := range strings.Split(file, "\n") {
+		if l == "---" || strings.HasPrefix(l, "--- ") || l == "..." {
+			flush(i + 2)
+			continue
+		}
+		if trimmed := strings.TrimSpace(l); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			empty = false
+		}
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	flush(0)
+	return docs
+}
+
+// yamlFiles returns the files, and the YAML and JSON files in the
+// directories, of paths.
+func yamlFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".yaml", ".yml", ".json":
+				if !info.IsDir() {
+					files = append(files, path)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"strconv"
+	"strings"
+)
+
+// yaml.v2 doesn't report the positions of the values it decodes, so the
+// functions in this file recover them from the text of the documents, to
+// point validation errors at the right lines. They only understand the
+// block style: a flow collection is a leaf, and the errors inside it are
+// reported at its first line.
+
+// yamlNode is a node of a YAML document, with the line it starts at.
+type yamlNode struct {
+	line   int
+	scalar string
+	fields []yamlField
+	items  []*yamlNode
+}
+
+type yamlField struct {
+	key  string
+	node *yamlNode
+}
+
+type yamlLine struct {
+	number  int
+	indent  int
+	content string
+}
+
+// scanYAML returns the tree of the block collections of doc, whose first
+// line is numbered first.
+func scanYAML(doc string, first int) *yamlNode {
+	var lines []yamlLine
+	for i, l := range strings.Split(doc, "\n") {
+		content := strings.TrimLeft(l, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		lines = append(lines, yamlLine{
+			number:  first + i,
+			indent:  len(l) - len(content),
+			content: strings.TrimRight(content, " \r"),
+		})
+	}
+	s := &yamlScanner{lines: lines}
+	if len(lines) == 0 {
+		return &yamlNode{line: first}
+	}
+	return s.node(-1)
+}
+
+type yamlScanner struct {
+	lines []yamlLine
+	next  int
+}
+
+// node scans the node at the next line, which must be indented more than
+// parent.
+func (s *yamlScanner) node(parent int) *yamlNode {
+	if s.next >= len(s.lines) || s.lines[s.next].indent <= parent {
+		return &yamlNode{}
+	}
+	l := s.lines[s.next]
+	if isItem(l.content) {
+		return s.sequence(l.indent)
+	}
+	if _, _, ok := splitKey(l.content); ok {
+		return s.mapping(l.indent)
+	}
+	// Plain scalars can span several lines.
+	s.next++
+	s.skip(parent)
+	return &yamlNode{line: l.number, scalar: l.content}
+}
+
+func (s *yamlScanner) sequence(indent int) *yamlNode {
+	n := &yamlNode{line: s.lines[s.next].number}
+	for s.next < len(s.lines) && s.lines[s.next].indent == indent && isItem(s.lines[s.next].content) {
+		l := &s.lines[s.next]
+		rest := strings.TrimLeft(l.content[1:], " ")
+		if rest == "" {
+			s.next++
+			item := s.node(indent)
+			item.line = l.number
+			n.items = append(n.items, item)
+			continue
+		}
+		// The content of the item continues on the same line, as if it
+		// was on its own, at the column it starts at.
+		l.indent += len(l.content) - len(rest)
+		l.content = rest
+		n.items = append(n.items, s.node(indent))
+	}
+	return n
+}
+
+func (s *yamlScanner) mapping(indent int) *yamlNode {
+	n := &yamlNode{line: s.lines[s.next].number}
+	for s.next < len(s.lines) && s.lines[s.next].indent == indent {
+		l := s.lines[s.next]
+		key, rest, ok := splitKey(l.content)
+		if !ok {
+			break
+		}
+		s.next++
+		var child *yamlNode
+		switch {
+		case rest == "" && s.next < len(s.lines) && s.lines[s.next].indent == indent && isItem(s.lines[s.next].content):
+			// Sequences can be indented as much as the key holding them.
+			child = s.sequence(indent)
+		case rest == "":
+			child = s.node(indent)
+		default:
+			child = &yamlNode{scalar: rest}
+			if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+				s.skip(indent)
+			}
+		}
+		child.line = l.number
+		n.fields = append(n.fields, yamlField{key, child})
+	}
+	return n
+}
+
+// skip skips the lines indented more than parent.
+func (s *yamlScanner) skip(parent int) {
+	for s.next < len(s.lines) && s.lines[s.next].indent > parent {
+		s.next++
+	}
+}
+
+func isItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// splitKey splits a line of a mapping into its key and the rest of the
+// line, if it is one.
+func splitKey(content string) (key, rest string, ok bool) {
+	if strings.HasPrefix(content, `"`) || strings.HasPrefix(content, "'") {
+		end := strings.IndexByte(content[1:], content[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key, rest = unquote(content[:end+2]), content[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimLeft(rest[1:], " "), true
+	}
+	if strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[") {
+		return "", "", false
+	}
+	i := strings.Index(content, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(content, ":") {
+			return "", "", false
+		}
+		i = len(content) - 1
+	}
+	return content[:i], strings.TrimLeft(content[i+1:], " "), true
+}
+
+// unquote returns the value of a scalar, without its quotes.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
+
+// lineOf returns the line of the deepest node of the path, as formatted in
+// validation errors, that can be found in the tree.
+func (n *yamlNode) lineOf(path string) int {
+	line := n.line
+	for path != "" {
+		child, rest := n.child(path)
+		if child == nil {
+			break
+		}
+		n, path = child, rest
+		line = n.line
+	}
+	return line
+}
+
+// child returns the child of n designated by the first element of path,
+// and the rest of the path.
+func (n *yamlNode) child(path string) (*yamlNode, string) {
+	switch path[0] {
+	case '.':
+		// Field names can contain dots, so pick the longest one
+		// matching the path.
+		var found *yamlNode
+		var rest string
+		for _, f := range n.fields {
+			r := strings.TrimPrefix(path[1:], f.key)
+			if len(r) == len(path)-1 || (r != "" && r[0] != '.' && r[0] != '[') {
+				continue
+			}
+			if found == nil || len(r) < len(rest) {
+				found, rest = f.node, r
+			}
+		}
+		return found, rest
+	case '[':
+		end := closingBracket(path)
+		if end < 0 {
+			return nil, ""
+		}
+		element, rest := path[1:end], path[end+1:]
+		if i, err := strconv.Atoi(element); err == nil {
+			if i < 0 || i >= len(n.items) {
+				return nil, ""
+			}
+			return n.items[i], rest
+		}
+		for _, item := range n.items {
+			if item.matches(element) {
+				return item, rest
+			}
+		}
+	}
+	return nil, ""
+}
+
+// matches returns whether the item is the one designated by the path
+// element, either [=value] or [key=value,...].
+func (n *yamlNode) matches(element string) bool {
+	if strings.HasPrefix(element, "=") {
+		return unquote(n.scalar) == unquote(element[1:])
+	}
+	for _, kv := range splitOutsideQuotes(element, ',') {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 {
+			return false
+		}
+		found := false
+		for _, f := range n.fields {
+			if f.key == kv[:i] {
+				found = unquote(f.node.scalar) == unquote(kv[i+1:])
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// closingBracket returns the index of the bracket closing the one path
+// starts with, skipping the quoted strings.
+func closingBracket(path string) int {
+	quoted := false
+	for i := 1; i < len(path); i++ {
+		switch {
+		case quoted && path[i] == '\\':
+			i++
+		case path[i] == '"':
+			quoted = !quoted
+		case !quoted && path[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
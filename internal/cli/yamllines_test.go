@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import "testing"
+
+func TestYAMLLineOf(t *testing.T) {
+	doc := `# comment
+metadata:
+  name: a
+  annotations:
+    example.com/a.b: "x"
+spec:
+  description: |
+    name: not a field
+  containers:
+  - name: a
+    image: a
+  - name: "b"
+    ports: [80, 81]
+    env:
+      - name: X
+        value: "1"
+  args:
+  - --flag
+  - 'quoted'
+  flow: {a: 1,
+    b: 2}
+`
+	root := scanYAML(doc, 10)
+	for _, tc := range []struct {
+		path string
+		line int
+	}{
+		{"", 11},
+		{".metadata", 11},
+		{".metadata.name", 12},
+		{".metadata.annotations.example.com/a.b", 14},
+		{".spec.description", 16},
+		{".spec.description.name", 16},
+		{`.spec.containers[name="a"]`, 19},
+		{`.spec.containers[name="a"].image`, 20},
+		{`.spec.containers[name="b"].ports`, 22},
+		{`.spec.containers[name="b"].ports[=80]`, 22},
+		{`.spec.containers[name="b"].env[name="X"].value`, 25},
+		{`.spec.containers[name="c"].image`, 18},
+		{`.spec.containers[1]`, 21},
+		{`.spec.args[="quoted"]`, 28},
+		{`.spec.args[=1]`, 26},
+		{`.spec.flow.b`, 29},
+		{`.spec.missing`, 15},
+	} {
+		if got := root.lineOf(tc.path); got != tc.line {
+			t.Errorf("%q: expected line %v, got %v", tc.path, tc.line, got)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsapi implements the operations exposed to JavaScript by
+// smd-wasm. They take and return strings, and are kept apart from the
+// syscall/js bindings so that they can be tested on any platform.
+package jsapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// API holds the schemas registered from JavaScript.
+type API struct {
+	lock    sync.RWMutex
+	schemas map[string]*typed.Parser
+}
+
+// New returns an API without any schema.
+func New() *API {
+	return &API{schemas: map[string]*typed.Parser{}}
+}
+
+// RegisterSchema parses the YAML or JSON schema and registers it under
+// name, replacing the schema previously registered with that name.
+func (a *API) RegisterSchema(name, schema string) error {
+	parser, err := typed.NewParser(typed.YAMLObject(schema))
+	if err != nil {
+		return fmt.Errorf("failed to parse schema %q: %v", name, err)
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.schemas[name] = parser
+	return nil
+}
+
+// Parse validates the JSON object against the type of the schema, and
+// returns it in canonical form, with sorted keys.
+func (a *API) Parse(schema, typeName, object string) (string, error) {
+	tv, err := a.parse(schema, typeName, "object", object)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(tv)
+}
+
+// Merge merges the JSON object rhs into lhs.
+func (a *API) Merge(schema, typeName, lhs, rhs string) (string, error) {
+	l, err := a.parse(schema, typeName, "lhs", lhs)
+	if err != nil {
+		return "", err
+	}
+	r, err := a.parse(schema, typeName, "rhs", rhs)
+	if err != nil {
+		return "", err
+	}
+	merged, err := l.Merge(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge: %v", err)
+	}
+	return toJSON(merged)
+}
+
+// comparison is the JSON representation of a typed.Comparison, with the
+// sets serialized as in managed fields.
+type comparison struct {
+	Added    json.RawMessage `json:"added"`
+	Modified json.RawMessage `json:"modified"`
+	Removed  json.RawMessage `json:"removed"`
+}
+
+// Compare compares the JSON objects lhs and rhs, and returns the fields
+// added, modified and removed from lhs to rhs.
+func (a *API) Compare(schema, typeName, lhs, rhs string) (string, error) {
+	l, err := a.parse(schema, typeName, "lhs", lhs)
+	if err != nil {
+		return "", err
+	}
+	r, err := a.parse(schema, typeName, "rhs", rhs)
+	if err != nil {
+		return "", err
+	}
+	c, err := l.Compare(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to compare: %v", err)
+	}
+	var out comparison
+	if out.Added, err = c.Added.ToJSON(); err != nil {
+		return "", err
+	}
+	if out.Modified, err = c.Modified.ToJSON(); err != nil {
+		return "", err
+	}
+	if out.Removed, err = c.Removed.ToJSON(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (a *API) parse(schema, typeName, name, object string) (*typed.TypedValue, error) {
+	a.lock.RLock()
+	parser, ok := a.schemas[schema]
+	a.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", schema)
+	}
+	pt := parser.Type(typeName)
+	if !pt.IsValid() {
+		return nil, fmt.Errorf("schema %q has no type %q", schema, typeName)
+	}
+	v, err := value.FromJSON([]byte(object))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %v: %v", name, err)
+	}
+	tv, err := typed.AsTyped(v, pt.Schema, pt.TypeRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %v", name, err)
+	}
+	return tv, nil
+}
+
+func toJSON(tv *typed.TypedValue) (string, error) {
+	data, err := value.ToJSON(tv.AsValue())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode object: %v", err)
+	}
+	return string(data), nil
+}
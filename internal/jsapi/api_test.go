@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsapi
+
+import (
+	"go/build"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const schema = `types:
+- name: deployment
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: containers
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: name
+                type:
+                  scalar: string
+              - name: image
+                type:
+                  scalar: string
+          elementRelationship: associative
+          keys:
+          - name
+`
+
+func TestAPI(t *testing.T) {
+	a := New()
+	if err := a.RegisterSchema("apps", schema); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := a.Parse("apps", "deployment", `{"replicas": 1, "containers": []}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"containers":[],"replicas":1}`; parsed != expected {
+		t.Errorf("expected %s, got %s", expected, parsed)
+	}
+
+	lhs := `{"replicas": 1, "containers": [{"name": "a", "image": "a:1"}]}`
+	rhs := `{"containers": [{"name": "a", "image": "a:2"}, {"name": "b"}]}`
+	merged, err := a.Merge("apps", "deployment", lhs, rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"containers":[{"image":"a:2","name":"a"},{"name":"b"}],"replicas":1}`; merged != expected {
+		t.Errorf("expected %s, got %s", expected, merged)
+	}
+
+	compared, err := a.Compare("apps", "deployment", lhs, rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"added":{"f:containers":{"k:{\"name\":\"b\"}":{".":{},"f:name":{}}}},` +
+		`"modified":{"f:containers":{"k:{\"name\":\"a\"}":{"f:image":{}}}},"removed":{"f:replicas":{}}}`
+	if compared != expected {
+		t.Errorf("expected %s, got %s", expected, compared)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	a := New()
+	if err := a.RegisterSchema("apps", "types: 1"); err == nil {
+		t.Error("expected an invalid schema to fail")
+	}
+	if err := a.RegisterSchema("apps", schema); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, schema, typeName, object, expected string
+	}{
+		{"unknown schema", "batch", "job", `{}`, `unknown schema "batch"`},
+		{"unknown type", "apps", "job", `{}`, `schema "apps" has no type "job"`},
+		{"malformed object", "apps", "deployment", `{`, `failed to decode object`},
+		{"invalid object", "apps", "deployment", `{"replicas": "one"}`, `invalid object`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := a.Parse(tc.schema, tc.typeName, tc.object)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// TestWASMPortability checks that the packages built into smd-wasm don't
+// use the file system, processes or the network, which browsers don't
+// provide.
+func TestWASMPortability(t *testing.T) {
+	const module = "sigs.k8s.io/structured-merge-diff/v4/"
+	forbidden := map[string]bool{
+		"io/ioutil": true,
+		"net":       true,
+		"net/http":  true,
+		"os":        true,
+		"os/exec":   true,
+		"os/signal": true,
+		"syscall":   true,
+	}
+	ctx := build.Default
+	ctx.GOOS, ctx.GOARCH = "js", "wasm"
+
+	seen := map[string]bool{}
+	var check func(dir string)
+	check = func(dir string) {
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		pkg, err := ctx.ImportDir(dir, 0)
+		if err != nil {
+			t.Fatalf("failed to import %v: %v", dir, err)
+		}
+		for _, imp := range pkg.Imports {
+			if forbidden[imp] {
+				t.Errorf("%v imports %v", pkg.Name, imp)
+			}
+			if strings.HasPrefix(imp, module) {
+				check(filepath.Join("..", "..", strings.TrimPrefix(imp, module)))
+			}
+		}
+	}
+	check(filepath.Join("..", "..", "smd-wasm"))
+	if !seen[filepath.Join("..", "..", "typed")] {
+		t.Errorf("expected smd-wasm to use typed, checked %v", seen)
+	}
+}
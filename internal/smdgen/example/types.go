@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package example declares types covering the features of smd-gen, whose
+// generated code is checked in and tested against the reflection.
+package example
+
+//go:generate go run ../../../smd-gen -types Deployment
+
+type Phase string
+
+type Labels map[string]string
+
+type Deployment struct {
+	TypeMeta   `json:",inline"`
+	*Extra     `json:",inline"`
+	Metadata   Metadata         `json:"metadata"`
+	Spec       *DeploymentSpec  `json:"spec,omitempty"`
+	Status     DeploymentStatus `json:"status,omitempty"`
+	Internal   string           `json:"-"`
+	Unexported int              `json:"unexported,omitempty"`
+}
+
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+type Extra struct {
+	Note string `json:"note"`
+}
+
+type Metadata struct {
+	Name        string            `json:"name"`
+	Labels      Labels            `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations"`
+	Generation  int64             `json:"generation,omitempty"`
+}
+
+type DeploymentSpec struct {
+	Replicas   *int32              `json:"replicas,omitempty"`
+	Paused     bool                `json:"paused,omitempty"`
+	Ratio      float32             `json:"ratio"`
+	Priority   uint8               `json:"priority"`
+	Data       []byte              `json:"data,omitempty"`
+	Containers []Container         `json:"containers"`
+	Volumes    map[string]*Volume  `json:"volumes,omitempty"`
+	Args       [][]string          `json:"args,omitempty"`
+	Selector   map[string][]string `json:"selector,omitempty"`
+}
+
+type Container struct {
+	Name  string   `json:"name"`
+	Image *string  `json:"image"`
+	Ports []int    `json:"ports,omitempty"`
+	Env   []EnvVar `json:"env,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+type Volume struct {
+	Path string `json:"path"`
+}
+
+type DeploymentStatus struct {
+	Phase      Phase    `json:"phase,omitempty"`
+	Conditions []string `json:"conditions"`
+}
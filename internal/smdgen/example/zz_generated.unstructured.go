@@ -0,0 +1,715 @@
+// Code generated by smd-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+)
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *Container) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 4)
+	if len(in.Env) != 0 {
+		l0 := make([]interface{}, len(in.Env))
+		for i0 := range in.Env {
+			l0[i0] = in.Env[i0].ToUnstructured()
+		}
+		out["env"] = l0
+	}
+	if in.Image == nil {
+		out["image"] = nil
+	} else {
+		out["image"] = string((*in.Image))
+	}
+	out["name"] = string(in.Name)
+	if len(in.Ports) != 0 {
+		l0 := make([]interface{}, len(in.Ports))
+		for i0 := range in.Ports {
+			l0[i0] = int64(in.Ports[i0])
+		}
+		out["ports"] = l0
+	}
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *Container) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["env"]; ok {
+		if v == nil {
+			out.Env = nil
+		} else if l0, err := unstructuredList(v); err != nil {
+			return fmt.Errorf("env: %v", err)
+		} else {
+			s0 := make([]EnvVar, len(l0))
+			for i0 := range l0 {
+				if err := s0[i0].FromUnstructured(l0[i0]); err != nil {
+					return fmt.Errorf("env[%d]: %v", i0, err)
+				}
+			}
+			out.Env = s0
+		}
+	}
+	if v, ok := m["image"]; ok {
+		if v == nil {
+			out.Image = nil
+		} else {
+			if out.Image == nil {
+				out.Image = new(string)
+			}
+			if x1, err := unstructuredString(v); err != nil {
+				return fmt.Errorf("image: %v", err)
+			} else {
+				(*out.Image) = string(x1)
+			}
+		}
+	}
+	if v, ok := m["name"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("name: %v", err)
+		} else {
+			out.Name = string(x0)
+		}
+	}
+	if v, ok := m["ports"]; ok {
+		if v == nil {
+			out.Ports = nil
+		} else if l0, err := unstructuredList(v); err != nil {
+			return fmt.Errorf("ports: %v", err)
+		} else {
+			s0 := make([]int, len(l0))
+			for i0 := range l0 {
+				if x1, err := unstructuredInt64(l0[i0]); err != nil {
+					return fmt.Errorf("ports[%d]: %v", i0, err)
+				} else {
+					s0[i0] = int(x1)
+				}
+			}
+			out.Ports = s0
+		}
+	}
+	return nil
+}
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *Deployment) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 7)
+	if len(in.TypeMeta.APIVersion) != 0 {
+		out["apiVersion"] = string(in.TypeMeta.APIVersion)
+	}
+	if len(in.TypeMeta.Kind) != 0 {
+		out["kind"] = string(in.TypeMeta.Kind)
+	}
+	out["metadata"] = in.Metadata.ToUnstructured()
+	if in.Extra != nil {
+		out["note"] = string(in.Extra.Note)
+	}
+	if in.Spec != nil {
+		out["spec"] = (*in.Spec).ToUnstructured()
+	}
+	out["status"] = in.Status.ToUnstructured()
+	if in.Unexported != 0 {
+		out["unexported"] = int64(in.Unexported)
+	}
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *Deployment) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["apiVersion"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("apiVersion: %v", err)
+		} else {
+			out.TypeMeta.APIVersion = string(x0)
+		}
+	}
+	if v, ok := m["kind"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("kind: %v", err)
+		} else {
+			out.TypeMeta.Kind = string(x0)
+		}
+	}
+	if v, ok := m["metadata"]; ok {
+		if err := out.Metadata.FromUnstructured(v); err != nil {
+			return fmt.Errorf("metadata: %v", err)
+		}
+	}
+	if v, ok := m["note"]; ok {
+		if out.Extra == nil {
+			out.Extra = new(Extra)
+		}
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("note: %v", err)
+		} else {
+			out.Extra.Note = string(x0)
+		}
+	}
+	if v, ok := m["spec"]; ok {
+		if v == nil {
+			out.Spec = nil
+		} else {
+			if out.Spec == nil {
+				out.Spec = new(DeploymentSpec)
+			}
+			if err := (*out.Spec).FromUnstructured(v); err != nil {
+				return fmt.Errorf("spec: %v", err)
+			}
+		}
+	}
+	if v, ok := m["status"]; ok {
+		if err := out.Status.FromUnstructured(v); err != nil {
+			return fmt.Errorf("status: %v", err)
+		}
+	}
+	if v, ok := m["unexported"]; ok {
+		if x0, err := unstructuredInt64(v); err != nil {
+			return fmt.Errorf("unexported: %v", err)
+		} else {
+			out.Unexported = int(x0)
+		}
+	}
+	return nil
+}
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *DeploymentSpec) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 9)
+	if len(in.Args) != 0 {
+		l0 := make([]interface{}, len(in.Args))
+		for i0 := range in.Args {
+			if in.Args[i0] == nil {
+				l0[i0] = nil
+			} else {
+				l1 := make([]interface{}, len(in.Args[i0]))
+				for i1 := range in.Args[i0] {
+					l1[i1] = string(in.Args[i0][i1])
+				}
+				l0[i0] = l1
+			}
+		}
+		out["args"] = l0
+	}
+	if in.Containers == nil {
+		out["containers"] = nil
+	} else {
+		l0 := make([]interface{}, len(in.Containers))
+		for i0 := range in.Containers {
+			l0[i0] = in.Containers[i0].ToUnstructured()
+		}
+		out["containers"] = l0
+	}
+	if len(in.Data) != 0 {
+		out["data"] = base64.StdEncoding.EncodeToString(in.Data)
+	}
+	if in.Paused {
+		out["paused"] = bool(in.Paused)
+	}
+	out["priority"] = int64(in.Priority)
+	out["ratio"] = float64(in.Ratio)
+	if in.Replicas != nil {
+		out["replicas"] = int64((*in.Replicas))
+	}
+	if len(in.Selector) != 0 {
+		m0 := make(map[string]interface{}, len(in.Selector))
+		for k0, v0 := range in.Selector {
+			if v0 == nil {
+				m0[string(k0)] = nil
+			} else {
+				l1 := make([]interface{}, len(v0))
+				for i1 := range v0 {
+					l1[i1] = string(v0[i1])
+				}
+				m0[string(k0)] = l1
+			}
+		}
+		out["selector"] = m0
+	}
+	if len(in.Volumes) != 0 {
+		m0 := make(map[string]interface{}, len(in.Volumes))
+		for k0, v0 := range in.Volumes {
+			if v0 == nil {
+				m0[string(k0)] = nil
+			} else {
+				m0[string(k0)] = (*v0).ToUnstructured()
+			}
+		}
+		out["volumes"] = m0
+	}
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *DeploymentSpec) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["args"]; ok {
+		if v == nil {
+			out.Args = nil
+		} else if l0, err := unstructuredList(v); err != nil {
+			return fmt.Errorf("args: %v", err)
+		} else {
+			s0 := make([][]string, len(l0))
+			for i0 := range l0 {
+				if l0[i0] == nil {
+					s0[i0] = nil
+				} else if l1, err := unstructuredList(l0[i0]); err != nil {
+					return fmt.Errorf("args[%d]: %v", i0, err)
+				} else {
+					s1 := make([]string, len(l1))
+					for i1 := range l1 {
+						if x2, err := unstructuredString(l1[i1]); err != nil {
+							return fmt.Errorf("args[%d][%d]: %v", i0, i1, err)
+						} else {
+							s1[i1] = string(x2)
+						}
+					}
+					s0[i0] = s1
+				}
+			}
+			out.Args = s0
+		}
+	}
+	if v, ok := m["containers"]; ok {
+		if v == nil {
+			out.Containers = nil
+		} else if l0, err := unstructuredList(v); err != nil {
+			return fmt.Errorf("containers: %v", err)
+		} else {
+			s0 := make([]Container, len(l0))
+			for i0 := range l0 {
+				if err := s0[i0].FromUnstructured(l0[i0]); err != nil {
+					return fmt.Errorf("containers[%d]: %v", i0, err)
+				}
+			}
+			out.Containers = s0
+		}
+	}
+	if v, ok := m["data"]; ok {
+		if v == nil {
+			out.Data = nil
+		} else if x0, err := unstructuredBytes(v); err != nil {
+			return fmt.Errorf("data: %v", err)
+		} else {
+			out.Data = []byte(x0)
+		}
+	}
+	if v, ok := m["paused"]; ok {
+		if x0, err := unstructuredBool(v); err != nil {
+			return fmt.Errorf("paused: %v", err)
+		} else {
+			out.Paused = bool(x0)
+		}
+	}
+	if v, ok := m["priority"]; ok {
+		if x0, err := unstructuredInt64(v); err != nil {
+			return fmt.Errorf("priority: %v", err)
+		} else {
+			out.Priority = uint8(x0)
+		}
+	}
+	if v, ok := m["ratio"]; ok {
+		if x0, err := unstructuredFloat64(v); err != nil {
+			return fmt.Errorf("ratio: %v", err)
+		} else {
+			out.Ratio = float32(x0)
+		}
+	}
+	if v, ok := m["replicas"]; ok {
+		if v == nil {
+			out.Replicas = nil
+		} else {
+			if out.Replicas == nil {
+				out.Replicas = new(int32)
+			}
+			if x1, err := unstructuredInt64(v); err != nil {
+				return fmt.Errorf("replicas: %v", err)
+			} else {
+				(*out.Replicas) = int32(x1)
+			}
+		}
+	}
+	if v, ok := m["selector"]; ok {
+		if v == nil {
+			out.Selector = nil
+		} else if m0, err := unstructuredMap(v); err != nil {
+			return fmt.Errorf("selector: %v", err)
+		} else {
+			mm0 := make(map[string][]string, len(m0))
+			for k0, v0 := range m0 {
+				var x0 []string
+				if v0 == nil {
+					x0 = nil
+				} else if l1, err := unstructuredList(v0); err != nil {
+					return fmt.Errorf("selector[%q]: %v", k0, err)
+				} else {
+					s1 := make([]string, len(l1))
+					for i1 := range l1 {
+						if x2, err := unstructuredString(l1[i1]); err != nil {
+							return fmt.Errorf("selector[%q][%d]: %v", k0, i1, err)
+						} else {
+							s1[i1] = string(x2)
+						}
+					}
+					x0 = s1
+				}
+				mm0[string(k0)] = x0
+			}
+			out.Selector = mm0
+		}
+	}
+	if v, ok := m["volumes"]; ok {
+		if v == nil {
+			out.Volumes = nil
+		} else if m0, err := unstructuredMap(v); err != nil {
+			return fmt.Errorf("volumes: %v", err)
+		} else {
+			mm0 := make(map[string]*Volume, len(m0))
+			for k0, v0 := range m0 {
+				var x0 *Volume
+				if v0 == nil {
+					x0 = nil
+				} else {
+					if x0 == nil {
+						x0 = new(Volume)
+					}
+					if err := (*x0).FromUnstructured(v0); err != nil {
+						return fmt.Errorf("volumes[%q]: %v", k0, err)
+					}
+				}
+				mm0[string(k0)] = x0
+			}
+			out.Volumes = mm0
+		}
+	}
+	return nil
+}
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *DeploymentStatus) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 2)
+	if in.Conditions == nil {
+		out["conditions"] = nil
+	} else {
+		l0 := make([]interface{}, len(in.Conditions))
+		for i0 := range in.Conditions {
+			l0[i0] = string(in.Conditions[i0])
+		}
+		out["conditions"] = l0
+	}
+	if len(in.Phase) != 0 {
+		out["phase"] = string(in.Phase)
+	}
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *DeploymentStatus) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["conditions"]; ok {
+		if v == nil {
+			out.Conditions = nil
+		} else if l0, err := unstructuredList(v); err != nil {
+			return fmt.Errorf("conditions: %v", err)
+		} else {
+			s0 := make([]string, len(l0))
+			for i0 := range l0 {
+				if x1, err := unstructuredString(l0[i0]); err != nil {
+					return fmt.Errorf("conditions[%d]: %v", i0, err)
+				} else {
+					s0[i0] = string(x1)
+				}
+			}
+			out.Conditions = s0
+		}
+	}
+	if v, ok := m["phase"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("phase: %v", err)
+		} else {
+			out.Phase = Phase(x0)
+		}
+	}
+	return nil
+}
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *EnvVar) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 2)
+	out["name"] = string(in.Name)
+	if len(in.Value) != 0 {
+		out["value"] = string(in.Value)
+	}
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *EnvVar) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["name"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("name: %v", err)
+		} else {
+			out.Name = string(x0)
+		}
+	}
+	if v, ok := m["value"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("value: %v", err)
+		} else {
+			out.Value = string(x0)
+		}
+	}
+	return nil
+}
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *Metadata) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 4)
+	if in.Annotations == nil {
+		out["annotations"] = nil
+	} else {
+		m0 := make(map[string]interface{}, len(in.Annotations))
+		for k0, v0 := range in.Annotations {
+			m0[string(k0)] = string(v0)
+		}
+		out["annotations"] = m0
+	}
+	if in.Generation != 0 {
+		out["generation"] = int64(in.Generation)
+	}
+	if len(in.Labels) != 0 {
+		m0 := make(map[string]interface{}, len(in.Labels))
+		for k0, v0 := range in.Labels {
+			m0[string(k0)] = string(v0)
+		}
+		out["labels"] = m0
+	}
+	out["name"] = string(in.Name)
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *Metadata) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["annotations"]; ok {
+		if v == nil {
+			out.Annotations = nil
+		} else if m0, err := unstructuredMap(v); err != nil {
+			return fmt.Errorf("annotations: %v", err)
+		} else {
+			mm0 := make(map[string]string, len(m0))
+			for k0, v0 := range m0 {
+				var x0 string
+				if x1, err := unstructuredString(v0); err != nil {
+					return fmt.Errorf("annotations[%q]: %v", k0, err)
+				} else {
+					x0 = string(x1)
+				}
+				mm0[string(k0)] = x0
+			}
+			out.Annotations = mm0
+		}
+	}
+	if v, ok := m["generation"]; ok {
+		if x0, err := unstructuredInt64(v); err != nil {
+			return fmt.Errorf("generation: %v", err)
+		} else {
+			out.Generation = int64(x0)
+		}
+	}
+	if v, ok := m["labels"]; ok {
+		if v == nil {
+			out.Labels = nil
+		} else if m0, err := unstructuredMap(v); err != nil {
+			return fmt.Errorf("labels: %v", err)
+		} else {
+			mm0 := make(Labels, len(m0))
+			for k0, v0 := range m0 {
+				var x0 string
+				if x1, err := unstructuredString(v0); err != nil {
+					return fmt.Errorf("labels[%q]: %v", k0, err)
+				} else {
+					x0 = string(x1)
+				}
+				mm0[string(k0)] = x0
+			}
+			out.Labels = mm0
+		}
+	}
+	if v, ok := m["name"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("name: %v", err)
+		} else {
+			out.Name = string(x0)
+		}
+	}
+	return nil
+}
+
+// ToUnstructured returns the unstructured representation of in.
+func (in *Volume) ToUnstructured() interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, 1)
+	out["path"] = string(in.Path)
+	return out
+}
+
+// FromUnstructured sets the fields of out that are set in their
+// unstructured representation u. Null values are ignored, except
+// for pointers, slices and maps which are then set to nil.
+func (out *Volume) FromUnstructured(u interface{}) error {
+	if u == nil {
+		return nil
+	}
+	m, ok := u.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected map, got %T", u)
+	}
+	if v, ok := m["path"]; ok {
+		if x0, err := unstructuredString(v); err != nil {
+			return fmt.Errorf("path: %v", err)
+		} else {
+			out.Path = string(x0)
+		}
+	}
+	return nil
+}
+
+func unstructuredString(u interface{}) (string, error) {
+	if s, ok := u.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("expected string, got %T", u)
+}
+
+func unstructuredBool(u interface{}) (bool, error) {
+	if b, ok := u.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("expected bool, got %T", u)
+}
+
+func unstructuredInt64(u interface{}) (int64, error) {
+	switch n := u.(type) {
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int64(n), nil
+		}
+	}
+	return 0, fmt.Errorf("expected integer, got %T", u)
+}
+
+func unstructuredFloat64(u interface{}) (float64, error) {
+	switch n := u.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("expected number, got %T", u)
+}
+
+func unstructuredBytes(u interface{}) ([]byte, error) {
+	s, ok := u.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected base64 string, got %T", u)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func unstructuredList(u interface{}) ([]interface{}, error) {
+	if l, ok := u.([]interface{}); ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("expected list, got %T", u)
+}
+
+func unstructuredMap(u interface{}) (map[string]interface{}, error) {
+	if m, ok := u.(map[string]interface{}); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("expected map, got %T", u)
+}
@@ -0,0 +1,758 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smdgen generates code converting Go types to and from their
+// unstructured representation without reflection. The generated
+// ToUnstructured methods return what value.NewValueReflect(...).Unstructured()
+// would, following the same json tag rules.
+package smdgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeneratedFilePrefix is the prefix of the names of generated files, which
+// are ignored when loading the package to generate code for.
+const GeneratedFilePrefix = "zz_generated."
+
+// DefaultOutputFile is the name of the generated file by default.
+const DefaultOutputFile = GeneratedFilePrefix + "unstructured.go"
+
+// Options are the inputs of Generate.
+type Options struct {
+	// Dir is the directory of the package declaring the types.
+	Dir string
+	// Types are the names of the struct types to generate conversions
+	// for. The struct types of the package that they reference are
+	// included as well.
+	Types []string
+	// Header, if set, is written at the top of the generated file,
+	// typically a license.
+	Header []byte
+}
+
+// Generate returns the source of a file implementing ToUnstructured and
+// FromUnstructured for the types.
+//
+// The fields can be of boolean, numeric (except uint64) and string types,
+// byte slices, struct types of the same package, and pointers, slices and
+// maps with string keys of those. Types with custom JSON marshaling or
+// from other packages are not supported.
+func Generate(o Options) ([]byte, error) {
+	pkg, err := loadPackage(o.Dir)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{pkg: pkg, structs: map[string]*structInfo{}}
+	for _, name := range o.Types {
+		expr, ok := pkg.types[name]
+		if !ok {
+			return nil, fmt.Errorf("type %v not found in package %v", name, pkg.name)
+		}
+		if _, ok := expr.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("type %v is not a struct", name)
+		}
+		g.queue = append(g.queue, name)
+	}
+	for len(g.queue) > 0 {
+		name := g.queue[0]
+		g.queue = g.queue[1:]
+		if _, ok := g.structs[name]; ok {
+			continue
+		}
+		s, err := g.structInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("type %v: %v", name, err)
+		}
+		g.structs[name] = s
+	}
+	src := g.emit(o.Header)
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v\n%s", err, src)
+	}
+	return out, nil
+}
+
+type packageInfo struct {
+	name    string
+	types   map[string]ast.Expr
+	methods map[string]map[string]bool
+}
+
+func loadPackage(dir string) (*packageInfo, error) {
+	fset := token.NewFileSet()
+	filter := func(fi os.FileInfo) bool {
+		name := fi.Name()
+		return !strings.HasSuffix(name, "_test.go") && !strings.HasPrefix(name, GeneratedFilePrefix)
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %v, found %v", dir, len(pkgs))
+	}
+	pkg := &packageInfo{types: map[string]ast.Expr{}, methods: map[string]map[string]bool{}}
+	for name, p := range pkgs {
+		pkg.name = name
+		for _, f := range p.Files {
+			for _, decl := range f.Decls {
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						if ts, ok := spec.(*ast.TypeSpec); ok {
+							pkg.types[ts.Name.Name] = ts.Type
+						}
+					}
+				case *ast.FuncDecl:
+					if decl.Recv == nil || len(decl.Recv.List) != 1 {
+						continue
+					}
+					recv := decl.Recv.List[0].Type
+					if star, ok := recv.(*ast.StarExpr); ok {
+						recv = star.X
+					}
+					if id, ok := recv.(*ast.Ident); ok {
+						if pkg.methods[id.Name] == nil {
+							pkg.methods[id.Name] = map[string]bool{}
+						}
+						pkg.methods[id.Name][decl.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return pkg, nil
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindBytes
+	kindStruct
+	kindPointer
+	kindSlice
+	kindMap
+)
+
+type typeInfo struct {
+	kind kind
+	// goType is the Go expression of the type.
+	goType string
+	// elem is the type of the elements of pointers, slices and maps.
+	elem *typeInfo
+	// key is the Go type of the keys of maps.
+	key string
+}
+
+type field struct {
+	jsonName  string
+	omitEmpty bool
+	// inline are the inlined struct fields containing the field, and
+	// whether they are pointers.
+	inline []inlineField
+	goName string
+	typ    *typeInfo
+}
+
+type inlineField struct {
+	name     string
+	typeName string
+	pointer  bool
+}
+
+type structInfo struct {
+	name   string
+	fields []*field
+}
+
+type generator struct {
+	pkg     *packageInfo
+	structs map[string]*structInfo
+	queue   []string
+	// resolving are the named non-struct types being resolved, to detect
+	// cycles.
+	resolving map[string]bool
+}
+
+func (g *generator) structInfo(name string) (*structInfo, error) {
+	if err := g.checkMethods(name); err != nil {
+		return nil, err
+	}
+	fields := map[string]*field{}
+	if err := g.structFields(g.pkg.types[name].(*ast.StructType), nil, fields); err != nil {
+		return nil, err
+	}
+	s := &structInfo{name: name}
+	for _, f := range fields {
+		s.fields = append(s.fields, f)
+	}
+	sort.Slice(s.fields, func(i, j int) bool { return s.fields[i].jsonName < s.fields[j].jsonName })
+	return s, nil
+}
+
+// checkMethods rejects the types converted by the reflection with custom
+// methods, whose output can't be known.
+func (g *generator) checkMethods(name string) error {
+	for _, m := range []string{"MarshalJSON", "UnmarshalJSON"} {
+		if g.pkg.methods[name][m] {
+			return fmt.Errorf("custom JSON marshaling is not supported")
+		}
+	}
+	return nil
+}
+
+// structFields adds the fields of the struct to fields, by json name, as
+// the reflection does.
+func (g *generator) structFields(st *ast.StructType, inline []inlineField, fields map[string]*field) error {
+	for _, f := range st.Fields.List {
+		var names []string
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = []string{embeddedName(f.Type)}
+		}
+		var tag string
+		if f.Tag != nil {
+			t, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(t).Get("json")
+		}
+		for _, goName := range names {
+			jsonName, omit, isInline, omitEmpty := lookupJSONTags(goName, tag)
+			if omit {
+				continue
+			}
+			if isInline {
+				elem, pointer := f.Type, false
+				if star, ok := elem.(*ast.StarExpr); ok {
+					elem, pointer = star.X, true
+				}
+				id, ok := elem.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				st, ok := g.pkg.types[id.Name].(*ast.StructType)
+				if !ok {
+					continue
+				}
+				if err := g.checkMethods(id.Name); err != nil {
+					return fmt.Errorf("inlined field %v: %v", goName, err)
+				}
+				path := append(append([]inlineField(nil), inline...), inlineField{name: goName, typeName: id.Name, pointer: pointer})
+				if err := g.structFields(st, path, fields); err != nil {
+					return err
+				}
+				continue
+			}
+			typ, err := g.resolve(f.Type)
+			if err != nil {
+				return fmt.Errorf("field %v: %v", goName, err)
+			}
+			fields[jsonName] = &field{
+				jsonName:  jsonName,
+				omitEmpty: omitEmpty,
+				inline:    inline,
+				goName:    goName,
+				typ:       typ,
+			}
+		}
+	}
+	return nil
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// lookupJSONTags interprets the json tag of a field like the reflection
+// does.
+func lookupJSONTags(goName, tag string) (name string, omit, inline, omitEmpty bool) {
+	if tag == "-" {
+		return "", true, false, false
+	}
+	name, opts := tag, ""
+	if i := strings.Index(tag, ","); i != -1 {
+		name, opts = tag[:i], tag[i+1:]
+	}
+	if name == "" {
+		name = goName
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "inline":
+			inline = true
+		case "omitempty":
+			omitEmpty = true
+		}
+	}
+	return name, false, inline, omitEmpty
+}
+
+var basicKinds = map[string]kind{
+	"string":  kindString,
+	"bool":    kindBool,
+	"int":     kindInt,
+	"int8":    kindInt,
+	"int16":   kindInt,
+	"int32":   kindInt,
+	"int64":   kindInt,
+	"rune":    kindInt,
+	"uint":    kindInt,
+	"uint8":   kindInt,
+	"uint16":  kindInt,
+	"uint32":  kindInt,
+	"byte":    kindInt,
+	"float32": kindFloat,
+	"float64": kindFloat,
+}
+
+func (g *generator) resolve(expr ast.Expr) (*typeInfo, error) {
+	goType := types.ExprString(expr)
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if k, ok := basicKinds[e.Name]; ok {
+			return &typeInfo{kind: k, goType: goType}, nil
+		}
+		underlying, ok := g.pkg.types[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %v", goType)
+		}
+		if err := g.checkMethods(e.Name); err != nil {
+			return nil, fmt.Errorf("type %v: %v", e.Name, err)
+		}
+		if _, ok := underlying.(*ast.StructType); ok {
+			g.queue = append(g.queue, e.Name)
+			return &typeInfo{kind: kindStruct, goType: goType}, nil
+		}
+		if g.resolving[e.Name] {
+			return nil, fmt.Errorf("recursive type %v is not supported", e.Name)
+		}
+		if g.resolving == nil {
+			g.resolving = map[string]bool{}
+		}
+		g.resolving[e.Name] = true
+		defer delete(g.resolving, e.Name)
+		t, err := g.resolve(underlying)
+		if err != nil {
+			return nil, err
+		}
+		named := *t
+		named.goType = goType
+		return &named, nil
+	case *ast.StarExpr:
+		if _, ok := e.X.(*ast.StarExpr); ok {
+			return nil, fmt.Errorf("unsupported type %v", goType)
+		}
+		elem, err := g.resolve(e.X)
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: kindPointer, goType: goType, elem: elem}, nil
+	case *ast.ArrayType:
+		if e.Len != nil {
+			return nil, fmt.Errorf("unsupported array type %v", goType)
+		}
+		elem, err := g.resolve(e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if elem.kind == kindInt && (elem.goType == "byte" || elem.goType == "uint8") {
+			return &typeInfo{kind: kindBytes, goType: goType}, nil
+		}
+		return &typeInfo{kind: kindSlice, goType: goType, elem: elem}, nil
+	case *ast.MapType:
+		key, err := g.resolve(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		if key.kind != kindString {
+			return nil, fmt.Errorf("unsupported map type %v, keys must be strings", goType)
+		}
+		elem, err := g.resolve(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: kindMap, goType: goType, elem: elem, key: key.goType}, nil
+	case *ast.SelectorExpr:
+		return nil, fmt.Errorf("unsupported type %v from another package", goType)
+	}
+	return nil, fmt.Errorf("unsupported type %v", goType)
+}
+
+// writer accumulates generated code.
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) line(format string, args ...interface{}) {
+	fmt.Fprintf(w, format, args...)
+	w.WriteByte('\n')
+}
+
+func (g *generator) emit(header []byte) []byte {
+	w := &writer{}
+	if len(header) > 0 {
+		w.Write(header)
+		w.line("")
+	}
+	w.line("// Code generated by smd-gen. DO NOT EDIT.")
+	w.line("")
+	w.line("package %v", g.pkg.name)
+	w.line("")
+	w.line("import (")
+	w.line("%q", "encoding/base64")
+	w.line("%q", "fmt")
+	w.line("%q", "math")
+	w.line(")")
+
+	var names []string
+	for name := range g.structs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.emitToUnstructured(w, g.structs[name])
+		g.emitFromUnstructured(w, g.structs[name])
+	}
+	w.WriteString(helpers)
+	return w.Bytes()
+}
+
+func (g *generator) emitToUnstructured(w *writer, s *structInfo) {
+	w.line("")
+	w.line("// ToUnstructured returns the unstructured representation of in.")
+	w.line("func (in *%v) ToUnstructured() interface{} {", s.name)
+	w.line("if in == nil {")
+	w.line("return nil")
+	w.line("}")
+	w.line("out := make(map[string]interface{}, %d)", len(s.fields))
+	for _, f := range s.fields {
+		src := "in"
+		closing := 0
+		for _, in := range f.inline {
+			src += "." + in.name
+			if in.pointer {
+				w.line("if %v != nil {", src)
+				closing++
+			}
+		}
+		src += "." + f.goName
+		nonNil := false
+		if f.omitEmpty {
+			if cond := nonZero(f.typ, src); cond != "" {
+				w.line("if %v {", cond)
+				closing++
+				nonNil = true
+			}
+		}
+		emitTo(w, fmt.Sprintf("out[%q]", f.jsonName), src, f.typ, nonNil, 0)
+		for ; closing > 0; closing-- {
+			w.line("}")
+		}
+	}
+	w.line("return out")
+	w.line("}")
+}
+
+// nonZero returns the condition under which the value isn't omitted by
+// omitempty, if any.
+func nonZero(t *typeInfo, src string) string {
+	switch t.kind {
+	case kindString, kindBytes, kindSlice, kindMap:
+		return fmt.Sprintf("len(%v) != 0", src)
+	case kindBool:
+		return src
+	case kindInt, kindFloat:
+		return fmt.Sprintf("%v != 0", src)
+	case kindPointer:
+		return fmt.Sprintf("%v != nil", src)
+	}
+	// Structs are never empty.
+	return ""
+}
+
+// emitTo emits the code setting dst to the unstructured representation
+// of src, which must be addressable. If nonNil is set, src is known not to
+// be nil.
+func emitTo(w *writer, dst, src string, t *typeInfo, nonNil bool, depth int) {
+	// ifNotNil opens a block for when src isn't nil, and closeIf closes it.
+	ifNotNil := func() {
+		if !nonNil {
+			w.line("if %v == nil {", src)
+			w.line("%v = nil", dst)
+			w.line("} else {")
+		}
+	}
+	closeIf := func() {
+		if !nonNil {
+			w.line("}")
+		}
+	}
+	switch t.kind {
+	case kindString:
+		w.line("%v = string(%v)", dst, src)
+	case kindBool:
+		w.line("%v = bool(%v)", dst, src)
+	case kindInt:
+		w.line("%v = int64(%v)", dst, src)
+	case kindFloat:
+		w.line("%v = float64(%v)", dst, src)
+	case kindStruct:
+		w.line("%v = %v.ToUnstructured()", dst, src)
+	case kindBytes:
+		ifNotNil()
+		w.line("%v = base64.StdEncoding.EncodeToString(%v)", dst, src)
+		closeIf()
+	case kindPointer:
+		ifNotNil()
+		emitTo(w, dst, "(*"+src+")", t.elem, false, depth)
+		closeIf()
+	case kindSlice:
+		l, i := fmt.Sprintf("l%d", depth), fmt.Sprintf("i%d", depth)
+		ifNotNil()
+		w.line("%v := make([]interface{}, len(%v))", l, src)
+		w.line("for %v := range %v {", i, src)
+		emitTo(w, l+"["+i+"]", src+"["+i+"]", t.elem, false, depth+1)
+		w.line("}")
+		w.line("%v = %v", dst, l)
+		closeIf()
+	case kindMap:
+		m, k, v := fmt.Sprintf("m%d", depth), fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		ifNotNil()
+		w.line("%v := make(map[string]interface{}, len(%v))", m, src)
+		w.line("for %v, %v := range %v {", k, v, src)
+		emitTo(w, m+"[string("+k+")]", v, t.elem, false, depth+1)
+		w.line("}")
+		w.line("%v = %v", dst, m)
+		closeIf()
+	}
+}
+
+func (g *generator) emitFromUnstructured(w *writer, s *structInfo) {
+	w.line("")
+	w.line("// FromUnstructured sets the fields of out that are set in their")
+	w.line("// unstructured representation u. Null values are ignored, except")
+	w.line("// for pointers, slices and maps which are then set to nil.")
+	w.line("func (out *%v) FromUnstructured(u interface{}) error {", s.name)
+	w.line("if u == nil {")
+	w.line("return nil")
+	w.line("}")
+	w.line("m, ok := u.(map[string]interface{})")
+	w.line("if !ok {")
+	w.line("return fmt.Errorf(\"expected map, got %%T\", u)")
+	w.line("}")
+	for _, f := range s.fields {
+		w.line("if v, ok := m[%q]; ok {", f.jsonName)
+		dst := "out"
+		for _, in := range f.inline {
+			dst += "." + in.name
+			if in.pointer {
+				w.line("if %v == nil {", dst)
+				w.line("%v = new(%v)", dst, in.typeName)
+				w.line("}")
+			}
+		}
+		dst += "." + f.goName
+		emitFrom(w, dst, "v", f.typ, errorContext{format: strings.Replace(f.jsonName, "%", "%%", -1)}, 0)
+		w.line("}")
+	}
+	w.line("return nil")
+	w.line("}")
+}
+
+// errorContext locates the value being converted in the errors.
+type errorContext struct {
+	format string
+	args   []string
+}
+
+func (c errorContext) with(format, arg string) errorContext {
+	return errorContext{format: c.format + format, args: append(append([]string(nil), c.args...), arg)}
+}
+
+func (c errorContext) wrap(w *writer) {
+	args := append(append([]string(nil), c.args...), "err")
+	w.line("return fmt.Errorf(%q, %v)", c.format+": %v", strings.Join(args, ", "))
+}
+
+// emitFrom emits the code setting dst from the unstructured value src.
+func emitFrom(w *writer, dst, src string, t *typeInfo, ctx errorContext, depth int) {
+	x := fmt.Sprintf("x%d", depth)
+	scalar := func(helper string) {
+		w.line("if %v, err := %v(%v); err != nil {", x, helper, src)
+		ctx.wrap(w)
+		w.line("} else {")
+		w.line("%v = %v(%v)", dst, t.goType, x)
+		w.line("}")
+	}
+	switch t.kind {
+	case kindString:
+		scalar("unstructuredString")
+	case kindBool:
+		scalar("unstructuredBool")
+	case kindInt:
+		scalar("unstructuredInt64")
+	case kindFloat:
+		scalar("unstructuredFloat64")
+	case kindStruct:
+		w.line("if err := %v.FromUnstructured(%v); err != nil {", dst, src)
+		ctx.wrap(w)
+		w.line("}")
+	case kindBytes:
+		w.line("if %v == nil {", src)
+		w.line("%v = nil", dst)
+		w.line("} else if %v, err := unstructuredBytes(%v); err != nil {", x, src)
+		ctx.wrap(w)
+		w.line("} else {")
+		w.line("%v = %v(%v)", dst, t.goType, x)
+		w.line("}")
+	case kindPointer:
+		w.line("if %v == nil {", src)
+		w.line("%v = nil", dst)
+		w.line("} else {")
+		w.line("if %v == nil {", dst)
+		w.line("%v = new(%v)", dst, t.elem.goType)
+		w.line("}")
+		emitFrom(w, "(*"+dst+")", src, t.elem, ctx, depth+1)
+		w.line("}")
+	case kindSlice:
+		l, s, i := fmt.Sprintf("l%d", depth), fmt.Sprintf("s%d", depth), fmt.Sprintf("i%d", depth)
+		w.line("if %v == nil {", src)
+		w.line("%v = nil", dst)
+		w.line("} else if %v, err := unstructuredList(%v); err != nil {", l, src)
+		ctx.wrap(w)
+		w.line("} else {")
+		w.line("%v := make(%v, len(%v))", s, t.goType, l)
+		w.line("for %v := range %v {", i, l)
+		emitFrom(w, s+"["+i+"]", l+"["+i+"]", t.elem, ctx.with("[%d]", i), depth+1)
+		w.line("}")
+		w.line("%v = %v", dst, s)
+		w.line("}")
+	case kindMap:
+		m, mm, k, v := fmt.Sprintf("m%d", depth), fmt.Sprintf("mm%d", depth), fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		w.line("if %v == nil {", src)
+		w.line("%v = nil", dst)
+		w.line("} else if %v, err := unstructuredMap(%v); err != nil {", m, src)
+		ctx.wrap(w)
+		w.line("} else {")
+		w.line("%v := make(%v, len(%v))", mm, t.goType, m)
+		w.line("for %v, %v := range %v {", k, v, m)
+		w.line("var %v %v", x, t.elem.goType)
+		emitFrom(w, x, v, t.elem, ctx.with("[%q]", k), depth+1)
+		w.line("%v[%v(%v)] = %v", mm, t.key, k, x)
+		w.line("}")
+		w.line("%v = %v", dst, mm)
+		w.line("}")
+	}
+}
+
+const helpers = `
+func unstructuredString(u interface{}) (string, error) {
+	if s, ok := u.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("expected string, got %T", u)
+}
+
+func unstructuredBool(u interface{}) (bool, error) {
+	if b, ok := u.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("expected bool, got %T", u)
+}
+
+func unstructuredInt64(u interface{}) (int64, error) {
+	switch n := u.(type) {
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int64(n), nil
+		}
+	}
+	return 0, fmt.Errorf("expected integer, got %T", u)
+}
+
+func unstructuredFloat64(u interface{}) (float64, error) {
+	switch n := u.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("expected number, got %T", u)
+}
+
+func unstructuredBytes(u interface{}) ([]byte, error) {
+	s, ok := u.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected base64 string, got %T", u)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func unstructuredList(u interface{}) ([]interface{}, error) {
+	if l, ok := u.([]interface{}); ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("expected list, got %T", u)
+}
+
+func unstructuredMap(u interface{}) (map[string]interface{}, error) {
+	if m, ok := u.(map[string]interface{}); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("expected map, got %T", u)
+}
+`
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdgen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/smdgen"
+	"sigs.k8s.io/structured-merge-diff/v4/internal/smdgen/example"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	expected, err := ioutil.ReadFile(filepath.Join("example", smdgen.DefaultOutputFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := smdgen.Generate(smdgen.Options{Dir: "example", Types: []string{"Deployment"}})
+	if err != nil {
+		t.Fatalf("failed to generate code: %v", err)
+	}
+	if string(actual) != string(expected) {
+		t.Errorf("generated code is out of date, run go generate ./internal/smdgen/...")
+	}
+}
+
+func strptr(s string) *string { return &s }
+func int32ptr(i int32) *int32 { return &i }
+
+func deployments() map[string]example.Deployment {
+	return map[string]example.Deployment{
+		"full": {
+			TypeMeta: example.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			Extra:    &example.Extra{Note: "note"},
+			Metadata: example.Metadata{
+				Name:        "name",
+				Labels:      example.Labels{"app": "example"},
+				Annotations: map[string]string{"a": "b"},
+				Generation:  3,
+			},
+			Spec: &example.DeploymentSpec{
+				Replicas: int32ptr(2),
+				Paused:   true,
+				Ratio:    0.5,
+				Priority: 7,
+				Data:     []byte("data"),
+				Containers: []example.Container{{
+					Name:  "c",
+					Image: strptr("image"),
+					Ports: []int{80, 443},
+					Env:   []example.EnvVar{{Name: "A", Value: "1"}, {Name: "B"}},
+				}, {
+					Name: "d",
+				}},
+				Volumes:  map[string]*example.Volume{"v": {Path: "/v"}, "nil": nil},
+				Args:     [][]string{{"a", "b"}, nil},
+				Selector: map[string][]string{"s": {"x"}},
+			},
+			Status:     example.DeploymentStatus{Phase: "Running", Conditions: []string{"Ready"}},
+			Unexported: 1,
+		},
+		"empty": {
+			Extra: &example.Extra{},
+		},
+	}
+}
+
+func TestToUnstructuredMatchesReflection(t *testing.T) {
+	for name, d := range deployments() {
+		d := d
+		t.Run(name, func(t *testing.T) {
+			expected, err := value.NewValueReflect(&d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := value.NewValueInterface(d.ToUnstructured())
+			if !value.Equals(expected, actual) {
+				t.Errorf("expected %v, got %v", value.ToString(expected), value.ToString(actual))
+			}
+		})
+	}
+}
+
+func TestFromUnstructuredRoundTrips(t *testing.T) {
+	for name, d := range deployments() {
+		d := d
+		t.Run(name, func(t *testing.T) {
+			var out example.Deployment
+			if err := out.FromUnstructured(d.ToUnstructured()); err != nil {
+				t.Fatalf("failed to convert from unstructured: %v", err)
+			}
+			if !reflect.DeepEqual(d, out) {
+				t.Errorf("expected %#v, got %#v", d, out)
+			}
+		})
+	}
+}
+
+func TestFromUnstructuredErrors(t *testing.T) {
+	var d example.Deployment
+	err := d.FromUnstructured(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "c"},
+				map[string]interface{}{"name": int64(1)},
+			},
+		},
+	})
+	if err == nil || err.Error() != "spec: containers[1]: name: expected string, got int64" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = d.FromUnstructured(map[string]interface{}{
+		"spec": map[string]interface{}{"selector": map[string]interface{}{"s": []interface{}{true}}},
+	})
+	if err == nil || err.Error() != `spec: selector["s"][0]: expected string, got bool` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	table := []struct {
+		name  string
+		src   string
+		error string
+	}{{
+		name:  "uint64",
+		src:   "type T struct { F uint64 }",
+		error: "type T: field F: unsupported type uint64",
+	}, {
+		name:  "other package",
+		src:   "import \"time\"\ntype T struct { F time.Time }",
+		error: "type T: field F: unsupported type time.Time from another package",
+	}, {
+		name:  "marshaler",
+		src:   "type T struct { F U }\ntype U struct{}\nfunc (U) MarshalJSON() ([]byte, error) { return nil, nil }",
+		error: "type T: field F: type U: custom JSON marshaling is not supported",
+	}, {
+		name:  "not a struct",
+		src:   "type T string",
+		error: "type T is not a struct",
+	}}
+	for _, tt := range table {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "smdgen")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err := ioutil.WriteFile(filepath.Join(dir, "types.go"), []byte("package p\n"+tt.src+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err = smdgen.Generate(smdgen.Options{Dir: dir, Types: []string{"T"}})
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("expected error %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
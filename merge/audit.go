@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sort"
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// Operations, as found in OwnershipEvent and ManagerIdentity.
+const (
+	OperationApply  = "Apply"
+	OperationUpdate = "Update"
+)
+
+// OwnershipEvent records that the owners of a field have changed.
+type OwnershipEvent struct {
+	Time time.Time
+	// Operation is either OperationApply or OperationUpdate.
+	Operation string
+	// Manager is the manager that ran the operation.
+	Manager    string
+	APIVersion fieldpath.APIVersion
+	Path       fieldpath.Path
+	// PreviousOwners and Owners are the sorted managers owning the
+	// field before and after the operation.
+	PreviousOwners []string
+	Owners         []string
+}
+
+// OwnershipRecorder receives an event for each field whose owners are changed by a
+// successful Update or Apply. Dry-runs are not recorded.
+type OwnershipRecorder interface {
+	Record(OwnershipEvent)
+}
+
+// bufferedRecorder holds on to the events until they can be recorded.
+type bufferedRecorder []OwnershipEvent
+
+func (r *bufferedRecorder) Record(event OwnershipEvent) {
+	*r = append(*r, event)
+}
+
+// snapshotOwnership copies the managers before they get modified by an
+// operation, if there's a recorder to report the changes to.
+func (s *Updater) snapshotOwnership(managers fieldpath.ManagedFields) fieldpath.ManagedFields {
+	if s.recorder == nil {
+		return nil
+	}
+	return managers.Copy()
+}
+
+// recordOwnership records the changes between the managed fields before
+// and after an operation.
+func (s *Updater) recordOwnership(operation, manager string, before, after fieldpath.ManagedFields) {
+	if s.recorder == nil {
+		return
+	}
+	now := time.Now()
+	for _, version := range versionsOf(before, after) {
+		previous, current := managersAt(before, version), managersAt(after, version)
+		changed := fieldpath.NewSet()
+		for m, set := range previous {
+			if other, ok := current[m]; !ok || !other.Equals(set) {
+				changed = changed.Union(set.Difference(ownedOrEmpty(current, m)))
+			}
+		}
+		for m, set := range current {
+			changed = changed.Union(set.Difference(ownedOrEmpty(previous, m)))
+		}
+		changed.Iterate(func(p fieldpath.Path) {
+			s.recorder.Record(OwnershipEvent{
+				Time:           now,
+				Operation:      operation,
+				Manager:        manager,
+				APIVersion:     version,
+				Path:           p.Copy(),
+				PreviousOwners: ownersOf(previous, p),
+				Owners:         ownersOf(current, p),
+			})
+		})
+	}
+}
+
+// versionsOf returns the sorted versions found in the managed fields.
+func versionsOf(before, after fieldpath.ManagedFields) []fieldpath.APIVersion {
+	seen := map[fieldpath.APIVersion]bool{}
+	for _, mf := range []fieldpath.ManagedFields{before, after} {
+		for _, set := range mf {
+			seen[set.APIVersion()] = true
+		}
+	}
+	versions := make([]fieldpath.APIVersion, 0, len(seen))
+	for v := range seen {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// managersAt returns the sets of the managers at the given version.
+func managersAt(mf fieldpath.ManagedFields, version fieldpath.APIVersion) map[string]*fieldpath.Set {
+	sets := map[string]*fieldpath.Set{}
+	for m, set := range mf {
+		if set.APIVersion() == version {
+			sets[m] = set.Set()
+		}
+	}
+	return sets
+}
+
+func ownedOrEmpty(sets map[string]*fieldpath.Set, manager string) *fieldpath.Set {
+	if set, ok := sets[manager]; ok {
+		return set
+	}
+	return fieldpath.NewSet()
+}
+
+func ownersOf(sets map[string]*fieldpath.Set, p fieldpath.Path) []string {
+	var owners []string
+	for m, set := range sets {
+		if set.Has(p) {
+			owners = append(owners, m)
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
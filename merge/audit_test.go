@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+type eventRecorder []merge.OwnershipEvent
+
+func (r *eventRecorder) Record(event merge.OwnershipEvent) {
+	*r = append(*r, event)
+}
+
+func TestOwnershipRecorder(t *testing.T) {
+	events := &eventRecorder{}
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: &specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1"},
+			},
+			OwnershipRecorder: events,
+		}).BuildUpdater(),
+		Parser: DeducedParser,
+	}
+
+	type event struct {
+		operation, manager string
+		path               fieldpath.Path
+		previous, owners   []string
+	}
+	expect := func(expected ...event) {
+		t.Helper()
+		var actual []event
+		for _, e := range *events {
+			if e.Time.IsZero() || e.APIVersion != "v1" {
+				t.Errorf("unexpected event: %+v", e)
+			}
+			actual = append(actual, event{e.Operation, e.Manager, e.Path, e.PreviousOwners, e.Owners})
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected events:\n%v\ngot:\n%v", expected, actual)
+		}
+		*events = nil
+	}
+
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "b": 1}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	expect(
+		event{merge.OperationApply, "one", _P("a"), nil, []string{"one"}},
+		event{merge.OperationApply, "one", _P("b"), nil, []string{"one"}},
+	)
+
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "c": 2}`), "v1", "two", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	expect(
+		event{merge.OperationApply, "two", _P("a"), []string{"one"}, []string{"one", "two"}},
+		event{merge.OperationApply, "two", _P("c"), nil, []string{"two"}},
+	)
+
+	if err := state.Apply(typed.YAMLObject(`{"a": 2}`), "v1", "three", false); err == nil {
+		t.Fatal("Expected the apply to conflict")
+	}
+	expect()
+
+	if err := state.Update(typed.YAMLObject(`{"a": 1, "b": 3, "c": 2}`), "v1", "controller"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	expect(
+		event{merge.OperationUpdate, "controller", _P("b"), []string{"one"}, []string{"controller"}},
+	)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// BatchApply is one of the applies of ApplyBatch.
+type BatchApply struct {
+	Manager string
+	// Config is the applied configuration, at the version of the batch.
+	Config *typed.TypedValue
+	Force  bool
+}
+
+// BatchApplyError is returned by ApplyBatch when one of the applies fails.
+type BatchApplyError struct {
+	// Index of the apply that failed in the batch.
+	Index   int
+	Manager string
+	// Err is the error returned by the apply, typically Conflicts.
+	Err error
+}
+
+func (e *BatchApplyError) Error() string {
+	return fmt.Sprintf("apply %d by %q failed: %v", e.Index, e.Manager, e.Err)
+}
+
+// Unwrap returns the error of the failed apply.
+func (e *BatchApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyBatch runs the applies in order against the live object, as if
+// Apply was called for each of them in turn, and returns the final object
+// and managed fields. The live object and the configurations must all be
+// at the given version.
+//
+// The managed fields are copied once, then handed from one apply to the
+// next along with the intermediate objects, and the applies share their
+// conversions and allocator, as with ApplyMany, so that the intermediate
+// objects are only converted to the versions of the managers once. Like
+// Apply, the returned object is nil if the batch doesn't change anything,
+// unless the Updater returns its input on no-ops. If an apply fails,
+// nothing is returned but a *BatchApplyError, and the managed fields
+// passed in are left as they were.
+func (s *Updater) ApplyBatch(liveObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, applies []BatchApply) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	u := s.forBatch()
+	// Apply modifies the managed fields it is given.
+	managers = managers.Copy()
+	var events bufferedRecorder
+	if s.recorder != nil {
+		u.recorder = &events
+	}
+	object := liveObject
+	for i, apply := range applies {
+		newObject, newManagers, err := u.forBatchOperation().Apply(object, apply.Config, version, managers, apply.Manager, apply.Force)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, &BatchApplyError{Index: i, Manager: apply.Manager, Err: err}
+		}
+		if newObject != nil {
+			object = newObject
+		}
+		managers = newManagers
+	}
+	if !s.returnInputOnNoop && value.EqualsUsing(value.NewFreelistAllocator(), liveObject.AsValue(), object.AsValue()) {
+		object = nil
+	}
+	for _, event := range events {
+		s.recorder.Record(event)
+	}
+	return object, managers, nil
+}
+
+// ApplyTarget is one of the objects that ApplyMany applies to.
+type ApplyTarget struct {
+	// LiveObject is the object, at the version of the batch.
+	LiveObject *typed.TypedValue
+	Managers   fieldpath.ManagedFields
+	// Config, if set, is applied to this object instead of the
+	// configuration of the batch.
+	Config *typed.TypedValue
+}
+
+// ApplyTargetResult is the outcome of the apply of ApplyMany to one of its
+// targets, as returned by Apply.
+type ApplyTargetResult struct {
+	Object   *typed.TypedValue
+	Managers fieldpath.ManagedFields
+	Err      error
+}
+
+// ApplyMany applies a configuration, e.g. a template, to many objects, as
+// if Apply was called for each of them, and returns the results in the
+// order of the targets. The objects and the configurations must all be at
+// the given version. A failed apply only fails its own target.
+//
+// The applies share their state, which is otherwise built for each
+// operation: the configurations are converted to the versions of the
+// managers once for the whole batch, and the scratch values are allocated
+// with the same allocator, the one of the Updater if it has one. The
+// Updater must then not be used concurrently until ApplyMany returns.
+func (s *Updater) ApplyMany(targets []ApplyTarget, configObject *typed.TypedValue, version fieldpath.APIVersion, manager string, force bool) []ApplyTargetResult {
+	u := s.forBatch()
+	configs := map[*typed.TypedValue]bool{configObject: true}
+	for _, target := range targets {
+		if target.Config != nil {
+			configs[target.Config] = true
+		}
+	}
+
+	results := make([]ApplyTargetResult, len(targets))
+	for i, target := range targets {
+		config := target.Config
+		if config == nil {
+			config = configObject
+		}
+		object, managers, err := u.forBatchOperation().Apply(target.LiveObject, config, version, target.Managers, manager, force)
+		results[i] = ApplyTargetResult{Object: object, Managers: managers, Err: err}
+		// The conversions of the objects of this apply won't be
+		// needed again.
+		u.conversions.retain(configs)
+	}
+	return results
+}
+
+// forBatch returns a copy of the Updater holding the state shared by the
+// operations of a batch: their conversion cache and their allocator.
+func (s *Updater) forBatch() *Updater {
+	u := *s
+	u.conversions = &conversionCache{results: map[conversionKey]conversionResult{}}
+	if u.allocator == nil {
+		u.allocator = value.NewFreelistAllocator()
+	}
+	return &u
+}
+
+// forBatchOperation returns a copy of an Updater returned by forBatch for
+// one of the operations of the batch, which has its own budget.
+func (s *Updater) forBatchOperation() *Updater {
+	u := *s
+	if s.nodeBudget > 0 {
+		u.budget = typed.NewBudget(s.nodeBudget)
+	}
+	return &u
+}
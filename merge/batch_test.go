@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestApplyBatch(t *testing.T) {
+	updater := &merge.Updater{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}
+	parse := func(obj typed.YAMLObject) *typed.TypedValue {
+		tv, err := DeducedParser.Type("v1").FromYAML(obj)
+		if err != nil {
+			t.Fatalf("Failed to parse %v: %v", obj, err)
+		}
+		return tv
+	}
+	live := parse(`{}`)
+
+	object, managers, err := updater.ApplyBatch(live, "v1", fieldpath.ManagedFields{}, []merge.BatchApply{
+		{Manager: "base", Config: parse(`{"a": 1, "b": 1}`)},
+		{Manager: "overlay", Config: parse(`{"b": 2, "c": 2}`), Force: true},
+		{Manager: "base", Config: parse(`{"a": 3}`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to apply batch: %v", err)
+	}
+	if !value.Equals(object.AsValue(), parse(`{"a": 3, "b": 2, "c": 2}`).AsValue()) {
+		t.Errorf("unexpected object: %v", value.ToString(object.AsValue()))
+	}
+	expected := fieldpath.ManagedFields{
+		"base":    fieldpath.NewVersionedSet(_NS(_P("a")), "v1", true),
+		"overlay": fieldpath.NewVersionedSet(_NS(_P("b"), _P("c")), "v1", true),
+	}
+	if !managers.Equals(expected) {
+		t.Errorf("expected managers:\n%v\ngot:\n%v", expected, managers)
+	}
+
+	_, _, err = updater.ApplyBatch(object, "v1", managers, []merge.BatchApply{
+		{Manager: "overlay", Config: parse(`{"a": 4, "b": 2, "c": 2}`), Force: true},
+		{Manager: "base", Config: parse(`{"b": 4}`)},
+	})
+	batchErr, ok := err.(*merge.BatchApplyError)
+	if !ok || batchErr.Index != 1 || batchErr.Manager != "base" {
+		t.Fatalf("expected second apply to fail, got %v", err)
+	}
+	if _, ok := batchErr.Unwrap().(merge.Conflicts); !ok {
+		t.Errorf("expected conflicts, got %v", batchErr.Err)
+	}
+	// The forced apply that succeeded must not have changed the managed
+	// fields passed in.
+	if !managers.Equals(expected) {
+		t.Errorf("expected managers to be left as they were:\n%v\ngot:\n%v", expected, managers)
+	}
+}
+
+func TestApplyMany(t *testing.T) {
+	updater := &merge.Updater{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}
+	parse := func(obj typed.YAMLObject) *typed.TypedValue {
+		tv, err := DeducedParser.Type("v1").FromYAML(obj)
+		if err != nil {
+			t.Fatalf("Failed to parse %v: %v", obj, err)
+		}
+		return tv
+	}
+	targets := []merge.ApplyTarget{{
+		LiveObject: parse(`{}`),
+		Managers:   fieldpath.ManagedFields{},
+	}, {
+		LiveObject: parse(`{"a": 2, "b": 2}`),
+		Managers: fieldpath.ManagedFields{
+			"other": fieldpath.NewVersionedSet(_NS(_P("a"), _P("b")), "v1", false),
+		},
+	}, {
+		LiveObject: parse(`{"b": 2}`),
+		Managers: fieldpath.ManagedFields{
+			"other": fieldpath.NewVersionedSet(_NS(_P("b")), "v1", false),
+		},
+	}, {
+		LiveObject: parse(`{"b": 2}`),
+		Managers: fieldpath.ManagedFields{
+			"other": fieldpath.NewVersionedSet(_NS(_P("b")), "v1", false),
+		},
+		Config: parse(`{"c": 1}`),
+	}}
+
+	results := updater.ApplyMany(targets, parse(`{"a": 1}`), "v1", "fleet", false)
+	if len(results) != len(targets) {
+		t.Fatalf("expected %v results, got %v", len(targets), len(results))
+	}
+	for i, expected := range []struct {
+		object   typed.YAMLObject
+		managers fieldpath.ManagedFields
+		conflict bool
+	}{{
+		object: `{"a": 1}`,
+		managers: fieldpath.ManagedFields{
+			"fleet": fieldpath.NewVersionedSet(_NS(_P("a")), "v1", true),
+		},
+	}, {
+		conflict: true,
+	}, {
+		object: `{"a": 1, "b": 2}`,
+		managers: fieldpath.ManagedFields{
+			"other": fieldpath.NewVersionedSet(_NS(_P("b")), "v1", false),
+			"fleet": fieldpath.NewVersionedSet(_NS(_P("a")), "v1", true),
+		},
+	}, {
+		object: `{"b": 2, "c": 1}`,
+		managers: fieldpath.ManagedFields{
+			"other": fieldpath.NewVersionedSet(_NS(_P("b")), "v1", false),
+			"fleet": fieldpath.NewVersionedSet(_NS(_P("c")), "v1", true),
+		},
+	}} {
+		result := results[i]
+		if expected.conflict {
+			if _, ok := result.Err.(merge.Conflicts); !ok {
+				t.Errorf("target %v: expected conflicts, got %v", i, result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("target %v: failed to apply: %v", i, result.Err)
+			continue
+		}
+		if !value.Equals(result.Object.AsValue(), parse(expected.object).AsValue()) {
+			t.Errorf("target %v: unexpected object: %v", i, value.ToString(result.Object.AsValue()))
+		}
+		if !result.Managers.Equals(expected.managers) {
+			t.Errorf("target %v: expected managers:\n%v\ngot:\n%v", i, expected.managers, result.Managers)
+		}
+	}
+}
+
+func TestApplyBatchSharesConversions(t *testing.T) {
+	converter := &countingConverter{
+		specificVersionConverter: specificVersionConverter{
+			AcceptedVersions: []fieldpath.APIVersion{"v1", "v2"},
+		},
+		count: map[*typed.TypedValue]map[fieldpath.APIVersion]int{},
+	}
+	updater := &merge.Updater{Converter: converter}
+	parse := func(obj typed.YAMLObject) *typed.TypedValue {
+		tv, err := DeducedParser.Type("v1").FromYAML(obj)
+		if err != nil {
+			t.Fatalf("Failed to parse %v: %v", obj, err)
+		}
+		return tv
+	}
+	managers := fieldpath.ManagedFields{
+		"other": fieldpath.NewVersionedSet(_NS(_P("z")), "v2", false),
+	}
+
+	_, _, err := updater.ApplyBatch(parse(`{"z": 1}`), "v1", managers, []merge.BatchApply{
+		{Manager: "one", Config: parse(`{"a": 1}`)},
+		{Manager: "two", Config: parse(`{"b": 1}`)},
+		{Manager: "three", Config: parse(`{"c": 1}`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to apply batch: %v", err)
+	}
+	// The object resulting from an apply is the live object of the
+	// next one, which doesn't convert it again.
+	for object, versions := range converter.count {
+		for version, count := range versions {
+			if count != 1 {
+				t.Errorf("expected %v to be converted to %v once, got %v times", value.ToString(object.AsValue()), version, count)
+			}
+		}
+	}
+}
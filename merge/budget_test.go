@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestNodeBudget(t *testing.T) {
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: &specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1"},
+			},
+			NodeBudget: 20,
+		}).BuildUpdater(),
+		Parser: DeducedParser,
+	}
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "b": {"c": 1}}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply within the budget: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 2, "b": {"c": 1}}`), "v1", "two"); err != nil {
+		t.Fatalf("Failed to update within the budget: %v", err)
+	}
+
+	// Merging and comparing visit every node, both old and new.
+	err := state.Apply(typed.YAMLObject(`{"a": 2, "b": {"c": 1, "d": 1, "e": 1, "f": 1, "g": 1, "h": 1, "i": 1, "j": 1, "k": 1}}`), "v1", "one", true)
+	if !errors.Is(err, typed.ErrBudgetExceeded) {
+		t.Fatalf("Expected the apply to exceed its budget, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// ManagerExpiration returns true if the given manager is stale and should
+// be dropped from the managed fields, along with everything it owns.
+type ManagerExpiration func(manager string, set fieldpath.VersionedSet) bool
+
+// ExpireUpdatedBefore returns a ManagerExpiration that drops the managers
+// whose last update, as recorded in lastUpdated, is before the cutoff.
+// Managers without a recorded time are never dropped.
+func ExpireUpdatedBefore(lastUpdated map[string]time.Time, cutoff time.Time) ManagerExpiration {
+	return func(manager string, _ fieldpath.VersionedSet) bool {
+		t, ok := lastUpdated[manager]
+		return ok && t.Before(cutoff)
+	}
+}
+
+// CompactManagedFields returns a copy of the managed fields without the
+// managers that don't own anything, and without the managers that expire
+// considers stale. expire may be nil, in which case only empty managers
+// are dropped.
+func CompactManagedFields(managers fieldpath.ManagedFields, expire ManagerExpiration) fieldpath.ManagedFields {
+	compacted := fieldpath.ManagedFields{}
+	for manager, set := range managers {
+		if set.Set().Empty() || (expire != nil && expire(manager, set)) {
+			continue
+		}
+		compacted[manager] = set
+	}
+	return compacted
+}
+
+// NormalizationReport describes what NormalizeManagedFields changed.
+type NormalizationReport struct {
+	// Removed are the managers that were dropped for not owning anything.
+	Removed []string
+	// Merged maps the canonical name of the managers that were renamed
+	// or merged to their original names.
+	Merged map[string][]string
+	// Unmerged maps the canonical name of the managers that should have
+	// been merged, but weren't because their versions or their applied
+	// flags differ, to their names. They are kept as they were.
+	Unmerged map[string][]string
+	// Versions maps the managers whose version was canonicalized to
+	// their original version.
+	Versions map[string]fieldpath.APIVersion
+}
+
+// Changed returns true if the normalization changed anything.
+func (r NormalizationReport) Changed() bool {
+	return len(r.Removed) != 0 || len(r.Merged) != 0 || len(r.Versions) != 0
+}
+
+// NormalizeManagedFields returns a copy of the managed fields without
+// empty managers, with canonical versions, and with the managers whose
+// names encode the same ManagerIdentity merged under its canonical name,
+// along with a report of what changed. This repairs managed fields that
+// were bloated or made inconsistent by older or buggy clients.
+//
+// The version of a manager is canonicalized by trimming it of whitespace
+// and of the slash prefixing versions of the core group.
+func NormalizeManagedFields(managers fieldpath.ManagedFields) (fieldpath.ManagedFields, NormalizationReport) {
+	report := NormalizationReport{
+		Versions: map[string]fieldpath.APIVersion{},
+	}
+	canonical := fieldpath.ManagedFields{}
+	for manager, set := range managers {
+		if set.Set().Empty() {
+			report.Removed = append(report.Removed, manager)
+			continue
+		}
+		canonical[manager] = normalizeVersion(manager, set, &report)
+	}
+	sort.Strings(report.Removed)
+	normalized, merged, unmerged := mergeManagers(canonical, func(manager string) string {
+		return ParseManagerIdentity(manager).String()
+	})
+	report.Merged, report.Unmerged = merged, unmerged
+	for manager, version := range report.Versions {
+		if name := ParseManagerIdentity(manager).String(); name != manager && len(merged[name]) != 0 {
+			delete(report.Versions, manager)
+			report.Versions[name] = version
+		}
+	}
+	return normalized, report
+}
+
+// RenameManager returns a copy of the managed fields where the manager
+// named old is renamed to new, e.g. when a controller is renamed or a
+// chart is migrated to another tool. If new already owns fields, the two
+// are merged when merge is true, as long as they have the same version
+// and applied flag, and an error is returned otherwise. Renaming a manager
+// that isn't found returns an unchanged copy, so that migrations can be
+// run again.
+func RenameManager(managers fieldpath.ManagedFields, old, new string, merge bool) (fieldpath.ManagedFields, error) {
+	if _, ok := managers[old]; !ok || old == new {
+		return managers.Copy(), nil
+	}
+	if _, ok := managers[new]; ok && !merge {
+		return nil, fmt.Errorf("failed to rename manager %q: manager %q already exists", old, new)
+	}
+	renamed, _, unmerged := mergeManagers(managers, func(manager string) string {
+		if manager == old {
+			return new
+		}
+		return manager
+	})
+	if len(unmerged) != 0 {
+		return nil, fmt.Errorf("failed to merge manager %q into %q: their versions or applied flags differ", old, new)
+	}
+	return renamed, nil
+}
+
+// mergeManagers renames the managers, merging those that end up with the
+// same name. Managers are only merged if they have the same version and
+// applied flag, otherwise they are kept as they were. It returns the
+// merged managers, the original names of the managers that were renamed
+// or merged, and the names of those that couldn't be merged, keyed by
+// their new name.
+func mergeManagers(managers fieldpath.ManagedFields, rename func(string) string) (fieldpath.ManagedFields, map[string][]string, map[string][]string) {
+	names := make([]string, 0, len(managers))
+	for manager := range managers {
+		names = append(names, manager)
+	}
+	sort.Strings(names)
+
+	groups := map[string][]string{}
+	var newNames []string
+	for _, manager := range names {
+		name := rename(manager)
+		if _, ok := groups[name]; !ok {
+			newNames = append(newNames, name)
+		}
+		groups[name] = append(groups[name], manager)
+	}
+
+	result := fieldpath.ManagedFields{}
+	merged, unmerged := map[string][]string{}, map[string][]string{}
+	for _, name := range newNames {
+		group := groups[name]
+		first := managers[group[0]]
+		if len(group) == 1 {
+			if group[0] != name {
+				merged[name] = group
+			}
+			result[name] = first
+			continue
+		}
+		set := fieldpath.NewSet()
+		for _, manager := range group {
+			if managers[manager].APIVersion() != first.APIVersion() || managers[manager].Applied() != first.Applied() {
+				set = nil
+				break
+			}
+			set = set.Union(managers[manager].Set())
+		}
+		if set == nil {
+			unmerged[name] = group
+			for _, manager := range group {
+				result[manager] = managers[manager]
+			}
+			continue
+		}
+		merged[name] = group
+		result[name] = fieldpath.NewVersionedSet(set, first.APIVersion(), first.Applied())
+	}
+	return result, merged, unmerged
+}
+
+// normalizeVersion returns the set with its version canonicalized.
+func normalizeVersion(manager string, set fieldpath.VersionedSet, report *NormalizationReport) fieldpath.VersionedSet {
+	version := canonicalAPIVersion(set.APIVersion())
+	if version == set.APIVersion() {
+		return set
+	}
+	report.Versions[manager] = set.APIVersion()
+	return fieldpath.NewVersionedSet(set.Set(), version, set.Applied())
+}
+
+func canonicalAPIVersion(version fieldpath.APIVersion) fieldpath.APIVersion {
+	return fieldpath.APIVersion(strings.TrimPrefix(strings.TrimSpace(string(version)), "/"))
+}
+
+// normalizeManagers applies the Updater's manager normalizer to the
+// manager running an operation and to the managed fields.
+func (s *Updater) normalizeManagers(manager string, managers fieldpath.ManagedFields) (string, fieldpath.ManagedFields) {
+	if s.managerNormalizer == nil {
+		return manager, managers
+	}
+	managers, _, _ = mergeManagers(managers, s.managerNormalizer)
+	return s.managerNormalizer(manager), managers
+}
+
+// expireManagers drops the stale managers before an operation, so that
+// they neither conflict with it nor keep fields from being pruned. The
+// manager running the operation is always kept.
+func (s *Updater) expireManagers(managers fieldpath.ManagedFields, workflow string) fieldpath.ManagedFields {
+	if s.managerExpiration == nil {
+		return managers
+	}
+	compacted := CompactManagedFields(managers, func(manager string, set fieldpath.VersionedSet) bool {
+		return manager != workflow && s.managerExpiration(manager, set)
+	})
+	if set, ok := managers[workflow]; ok {
+		compacted[workflow] = set
+	}
+	return compacted
+}
+
+// reportDroppedManagers reports the managers dropped by an operation
+// because they no longer own any field, if any.
+func (s *Updater) reportDroppedManagers(manager string, dropped []string) {
+	if s.emptyManagersDropped == nil || len(dropped) == 0 {
+		return
+	}
+	sort.Strings(dropped)
+	s.emptyManagersDropped(manager, dropped)
+}
+
+// withoutManager returns the names without manager, and whether it was
+// among them.
+func withoutManager(names []string, manager string) ([]string, bool) {
+	for i, name := range names {
+		if name == manager {
+			return append(names[:i:i], names[i+1:]...), true
+		}
+	}
+	return names, false
+}
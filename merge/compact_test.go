@@ -0,0 +1,313 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestCompactManagedFields(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	managers := fieldpath.ManagedFields{
+		"empty":   fieldpath.NewVersionedSet(_NS(), "v1", false),
+		"stale":   fieldpath.NewVersionedSet(_NS(_P("a")), "v1", false),
+		"fresh":   fieldpath.NewVersionedSet(_NS(_P("b")), "v1", true),
+		"unknown": fieldpath.NewVersionedSet(_NS(_P("c")), "v1", false),
+	}
+	expire := merge.ExpireUpdatedBefore(map[string]time.Time{
+		"empty": now,
+		"stale": now.Add(-2 * time.Hour),
+		"fresh": now,
+	}, now.Add(-time.Hour))
+
+	got := merge.CompactManagedFields(managers, expire)
+	expected := fieldpath.ManagedFields{
+		"fresh":   managers["fresh"],
+		"unknown": managers["unknown"],
+	}
+	if !got.Equals(expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+
+	got = merge.CompactManagedFields(managers, nil)
+	delete(managers, "empty")
+	if !got.Equals(managers) {
+		t.Errorf("expected:\n%v\ngot:\n%v", managers, got)
+	}
+}
+
+func TestNormalizeManagedFields(t *testing.T) {
+	a := `{"manager":"a","operation":"Update"}`
+	b := `{"manager":"b","subresource":"status"}`
+	c := `{"manager":"c","operation":"Apply"}`
+	managers := fieldpath.ManagedFields{
+		"empty":                                fieldpath.NewVersionedSet(_NS(), "v1", false),
+		a:                                      fieldpath.NewVersionedSet(_NS(_P("a")), "v1", false),
+		`{"operation":"Update","manager":"a"}`: fieldpath.NewVersionedSet(_NS(_P("b")), " /v1", false),
+		`{ "manager": "b", "subresource": "status" }`: fieldpath.NewVersionedSet(_NS(_P("c")), "v1", true),
+		c:                                     fieldpath.NewVersionedSet(_NS(_P("d")), "v1", true),
+		`{"operation":"Apply","manager":"c"}`: fieldpath.NewVersionedSet(_NS(_P("e")), "v2", true),
+		"d":                                   fieldpath.NewVersionedSet(_NS(_P("f")), "/v1", false),
+		"e":                                   fieldpath.NewVersionedSet(_NS(_P("g")), "v1", true),
+	}
+	got, report := merge.NormalizeManagedFields(managers)
+	expected := fieldpath.ManagedFields{
+		a:                                     fieldpath.NewVersionedSet(_NS(_P("a"), _P("b")), "v1", false),
+		b:                                     managers[`{ "manager": "b", "subresource": "status" }`],
+		c:                                     managers[c],
+		`{"operation":"Apply","manager":"c"}`: managers[`{"operation":"Apply","manager":"c"}`],
+		"d":                                   fieldpath.NewVersionedSet(_NS(_P("f")), "v1", false),
+		"e":                                   managers["e"],
+	}
+	if !got.Equals(expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+	expectedReport := merge.NormalizationReport{
+		Removed: []string{"empty"},
+		Merged: map[string][]string{
+			a: {a, `{"operation":"Update","manager":"a"}`},
+			b: {`{ "manager": "b", "subresource": "status" }`},
+		},
+		Unmerged: map[string][]string{
+			c: {c, `{"operation":"Apply","manager":"c"}`},
+		},
+		Versions: map[string]fieldpath.APIVersion{
+			a:   " /v1",
+			"d": "/v1",
+		},
+	}
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Errorf("expected report:\n%+v\ngot:\n%+v", expectedReport, report)
+	}
+	if !report.Changed() {
+		t.Error("expected the report to show changes")
+	}
+
+	if _, report := merge.NormalizeManagedFields(got); report.Changed() {
+		t.Errorf("expected normalized managers to be left unchanged, got %+v", report)
+	}
+}
+
+func TestManagerExpiration(t *testing.T) {
+	test := TestCase{
+		Ops: []Operation{
+			Apply{
+				Manager:    "stale",
+				APIVersion: "v1",
+				Object: `
+					a: 1
+					b: 1
+				`,
+			},
+			Apply{
+				Manager:    "fresh",
+				APIVersion: "v1",
+				Object: `
+					a: 2
+				`,
+			},
+		},
+		Object: `
+			a: 2
+			b: 1
+		`,
+		APIVersion: "v1",
+		Managed: fieldpath.ManagedFields{
+			"fresh": fieldpath.NewVersionedSet(
+				_NS(
+					_P("a"),
+				),
+				"v1",
+				true,
+			),
+		},
+		ManagerExpiration: func(manager string, _ fieldpath.VersionedSet) bool {
+			return manager == "stale"
+		},
+	}
+
+	if err := test.Test(DeducedParser); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerNormalizer(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: &specificVersionConverter{
+			AcceptedVersions: []fieldpath.APIVersion{"v1"},
+		}},
+		Parser: DeducedParser,
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 1}`), "v1", "ctl-1"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 1, "b": 1}`), "v1", "ctl-2"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+
+	state.Updater = (&merge.UpdaterBuilder{
+		Converter: state.Updater.Converter,
+		ManagerNormalizer: func(manager string) string {
+			return strings.SplitN(manager, "-", 2)[0]
+		},
+	}).BuildUpdater()
+	if err := state.Update(typed.YAMLObject(`{"a": 1, "b": 1, "c": 1}`), "v1", "ctl-3"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	expected := fieldpath.ManagedFields{
+		"ctl": fieldpath.NewVersionedSet(_NS(_P("a"), _P("b"), _P("c")), "v1", false),
+	}
+	if !state.Managers.Equals(expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, state.Managers)
+	}
+
+	err := state.Apply(typed.YAMLObject(`{"a": 2}`), "v1", "other", false)
+	expectedConflicts := merge.Conflicts{merge.Conflict{Manager: "ctl", Path: _P("a")}}
+	if conflicts, ok := err.(merge.Conflicts); !ok || !conflicts.Equals(expectedConflicts) {
+		t.Errorf("expected conflicts %v, got %v", expectedConflicts, err)
+	}
+}
+
+func TestEmptyManagersDropped(t *testing.T) {
+	var reports []string
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: &specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1"},
+			},
+			EmptyManagersDropped: func(manager string, dropped []string) {
+				reports = append(reports, manager+": "+strings.Join(dropped, ","))
+			},
+		}).BuildUpdater(),
+		Parser: DeducedParser,
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 1}`), "v1", "ctl-1"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{"b": 1}`), "v1", "applier", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 2, "b": 1}`), "v1", "ctl-2"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{}`), "v1", "applier", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{}`), "v1", "new-applier", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 2}`), "v1", "ctl-2"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+
+	expected := []string{"ctl-2: ctl-1", "applier: applier"}
+	if !reflect.DeepEqual(reports, expected) {
+		t.Errorf("expected reports %v, got %v", expected, reports)
+	}
+	expectedManagers := fieldpath.ManagedFields{
+		"ctl-2": fieldpath.NewVersionedSet(_NS(_P("a")), "v1", false),
+	}
+	if !state.Managers.Equals(expectedManagers) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expectedManagers, state.Managers)
+	}
+}
+
+func TestRenameManager(t *testing.T) {
+	managers := fieldpath.ManagedFields{
+		"old":     fieldpath.NewVersionedSet(_NS(_P("a")), "v1", false),
+		"new":     fieldpath.NewVersionedSet(_NS(_P("b")), "v1", false),
+		"applier": fieldpath.NewVersionedSet(_NS(_P("c")), "v1", true),
+		"other":   fieldpath.NewVersionedSet(_NS(_P("d")), "v2", false),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		old, new string
+		merge    bool
+		expected fieldpath.ManagedFields
+		err      bool
+	}{{
+		name: "rename",
+		old:  "old",
+		new:  "renamed",
+		expected: fieldpath.ManagedFields{
+			"renamed": managers["old"],
+			"new":     managers["new"],
+			"applier": managers["applier"],
+			"other":   managers["other"],
+		},
+	}, {
+		name:  "merge",
+		old:   "old",
+		new:   "new",
+		merge: true,
+		expected: fieldpath.ManagedFields{
+			"new":     fieldpath.NewVersionedSet(_NS(_P("a"), _P("b")), "v1", false),
+			"applier": managers["applier"],
+			"other":   managers["other"],
+		},
+	}, {
+		name: "existing-without-merge",
+		old:  "old",
+		new:  "new",
+		err:  true,
+	}, {
+		name:  "different-applied-flag",
+		old:   "old",
+		new:   "applier",
+		merge: true,
+		err:   true,
+	}, {
+		name:  "different-version",
+		old:   "other",
+		new:   "new",
+		merge: true,
+		err:   true,
+	}, {
+		name:     "missing",
+		old:      "missing",
+		new:      "new",
+		expected: managers,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := merge.RenameManager(managers, tc.old, tc.new, tc.merge)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equals(tc.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", tc.expected, got)
+			}
+		})
+	}
+	if len(managers) != 4 {
+		t.Errorf("the managers that were passed in were modified: %v", managers)
+	}
+}
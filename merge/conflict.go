@@ -17,19 +17,32 @@ limitations under the License.
 package merge
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
+// ErrConflict classifies the Conflict and Conflicts errors, so that
+// errors.Is(err, ErrConflict) tells whether an apply failed because of
+// conflicts, even once wrapped.
+var ErrConflict = errors.New("conflict")
+
 // Conflict is a conflict on a specific field with the current manager of
 // that field. It does implement the error interface so that it can be
 // used as an error.
 type Conflict struct {
 	Manager string
 	Path    fieldpath.Path
+
+	// Current and Desired are the live and applied values of the field,
+	// if known. They're only used to describe the conflict.
+	Current value.Value
+	Desired value.Value
 }
 
 // Conflict is an error.
@@ -37,7 +50,39 @@ var _ error = Conflict{}
 
 // Error formats the conflict as an error.
 func (c Conflict) Error() string {
-	return fmt.Sprintf("conflict with %q: %v", c.Manager, c.Path)
+	return fmt.Sprintf("conflict with %q: %v%v", c.Manager, c.Path, c.describeValues())
+}
+
+// Is returns whether target is ErrConflict.
+func (c Conflict) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// maxConflictValueLength caps the rendering of each value of a conflict.
+const maxConflictValueLength = 64
+
+// describeValues renders the values of the conflict, if any.
+func (c Conflict) describeValues() string {
+	if c.Current == nil && c.Desired == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (current: %v, desired: %v)", renderConflictValue(c.Current), renderConflictValue(c.Desired))
+}
+
+func renderConflictValue(v value.Value) string {
+	if v == nil {
+		return "<unset>"
+	}
+	s := value.ToString(v)
+	if len(s) <= maxConflictValueLength {
+		return s
+	}
+	// Don't cut a multi-byte character in half.
+	end := maxConflictValueLength
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "..."
 }
 
 // Equals returns true if c == c2
@@ -59,9 +104,9 @@ func (conflicts Conflicts) Error() string {
 		return conflicts[0].Error()
 	}
 
-	m := map[string][]fieldpath.Path{}
+	m := map[string][]Conflict{}
 	for _, conflict := range conflicts {
-		m[conflict.Manager] = append(m[conflict.Manager], conflict.Path)
+		m[conflict.Manager] = append(m[conflict.Manager], conflict)
 	}
 
 	managers := []string{}
@@ -75,13 +120,18 @@ func (conflicts Conflicts) Error() string {
 	messages := []string{}
 	for _, manager := range managers {
 		messages = append(messages, fmt.Sprintf("conflicts with %q:", manager))
-		for _, path := range m[manager] {
-			messages = append(messages, fmt.Sprintf("- %v", path))
+		for _, conflict := range m[manager] {
+			messages = append(messages, fmt.Sprintf("- %v%v", conflict.Path, conflict.describeValues()))
 		}
 	}
 	return strings.Join(messages, "\n")
 }
 
+// Is returns whether target is ErrConflict.
+func (conflicts Conflicts) Is(target error) bool {
+	return target == ErrConflict
+}
+
 // Equals returns true if the lists of conflicts are the same.
 func (c Conflicts) Equals(c2 Conflicts) bool {
 	if len(c) != len(c2) {
@@ -104,12 +154,18 @@ func (c Conflicts) ToSet() *fieldpath.Set {
 	return set
 }
 
-// ConflictsFromManagers creates a list of conflicts given Managers sets.
+// ConflictsFromManagers creates a list of conflicts given Managers sets,
+// sorted by manager, then in the order the sets iterate their paths.
 func ConflictsFromManagers(sets fieldpath.ManagedFields) Conflicts {
 	conflicts := []Conflict{}
 
-	for manager, set := range sets {
-		set.Set().Iterate(func(p fieldpath.Path) {
+	managers := make([]string, 0, len(sets))
+	for manager := range sets {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+	for _, manager := range managers {
+		sets[manager].Set().Iterate(func(p fieldpath.Path) {
 			conflicts = append(conflicts, Conflict{
 				Manager: manager,
 				Path:    p.Copy(),
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// conflictJSON is the stable JSON representation of a Conflict. The
+// manager is split into its identity, and the path is given both as a
+// string for display and as its elements, serialized as in managed fields.
+type conflictJSON struct {
+	ManagerIdentity
+	Path         string          `json:"path"`
+	PathElements []string        `json:"pathElements"`
+	Current      json.RawMessage `json:"current,omitempty"`
+	Desired      json.RawMessage `json:"desired,omitempty"`
+}
+
+// MarshalJSON encodes the conflict as a JSON object.
+func (c Conflict) MarshalJSON() ([]byte, error) {
+	out := conflictJSON{
+		ManagerIdentity: ParseManagerIdentity(c.Manager),
+		Path:            c.Path.String(),
+		PathElements:    make([]string, 0, len(c.Path)),
+	}
+	for _, pe := range c.Path {
+		s, err := fieldpath.SerializePathElement(pe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize path %v: %w", c.Path, err)
+		}
+		out.PathElements = append(out.PathElements, s)
+	}
+	var err error
+	if c.Current != nil {
+		if out.Current, err = value.ToJSON(c.Current); err != nil {
+			return nil, fmt.Errorf("failed to serialize current value: %w", err)
+		}
+	}
+	if c.Desired != nil {
+		if out.Desired, err = value.ToJSON(c.Desired); err != nil {
+			return nil, fmt.Errorf("failed to serialize desired value: %w", err)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a conflict encoded by MarshalJSON.
+func (c *Conflict) UnmarshalJSON(data []byte) error {
+	var in conflictJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	path := make(fieldpath.Path, 0, len(in.PathElements))
+	for _, s := range in.PathElements {
+		pe, err := fieldpath.DeserializePathElement(s)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize path element %q: %w", s, err)
+		}
+		path = append(path, pe)
+	}
+	*c = Conflict{Manager: in.ManagerIdentity.String(), Path: path}
+	var err error
+	if len(in.Current) != 0 {
+		if c.Current, err = value.FromJSON(in.Current); err != nil {
+			return fmt.Errorf("failed to deserialize current value: %w", err)
+		}
+	}
+	if len(in.Desired) != 0 {
+		if c.Desired, err = value.FromJSON(in.Desired); err != nil {
+			return fmt.Errorf("failed to deserialize desired value: %w", err)
+		}
+	}
+	return nil
+}
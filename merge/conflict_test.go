@@ -17,10 +17,15 @@ limitations under the License.
 package merge_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
@@ -111,3 +116,152 @@ func TestConflictsFromManagers(t *testing.T) {
 		t.Errorf("Got %v, wanted %v", got.Error(), wanted)
 	}
 }
+
+func TestConflictValues(t *testing.T) {
+	long := strings.Repeat("é", 40)
+	conflicts := merge.Conflicts{
+		merge.Conflict{Manager: "Bob", Path: _P("key"), Current: _V("a"), Desired: _V("b")},
+		merge.Conflict{Manager: "Bob", Path: _P("unset"), Desired: _V(1)},
+		merge.Conflict{Manager: "Alice", Path: _P("long"), Current: _V(long), Desired: _V(nil)},
+	}
+	wanted := `conflicts with "Alice":
+- .long (current: "` + strings.Repeat("é", 31) + `..., desired: null)
+conflicts with "Bob":
+- .key (current: "a", desired: "b")
+- .unset (current: <unset>, desired: 1)`
+	if got := conflicts.Error(); got != wanted {
+		t.Errorf("Got %v, wanted %v", got, wanted)
+	}
+	wanted = `conflict with "Bob": .key (current: "a", desired: "b")`
+	if got := conflicts[0].Error(); got != wanted {
+		t.Errorf("Got %v, wanted %v", got, wanted)
+	}
+}
+
+func TestIncludeConflictValues(t *testing.T) {
+	updater := (&merge.UpdaterBuilder{
+		Converter:             &specificVersionConverter{AcceptedVersions: []fieldpath.APIVersion{"v1"}},
+		IncludeConflictValues: true,
+	}).BuildUpdater()
+	state := State{Updater: updater, Parser: DeducedParser}
+	if err := state.Apply(`{"a": 1, "b": {"c": "x"}}`, "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	err := state.Apply(`{"a": 2, "b": {"c": "y"}}`, "v1", "two", false)
+	conflicts, ok := err.(merge.Conflicts)
+	if !ok || len(conflicts) != 2 {
+		t.Fatalf("expected two conflicts, got %v", err)
+	}
+	for _, c := range conflicts {
+		if c.Current == nil || c.Desired == nil {
+			t.Errorf("expected conflict %v to have values", c)
+		}
+	}
+	wanted := `conflicts with "one":
+- .a (current: 1, desired: 2)
+- .b.c (current: "x", desired: "y")`
+	if got := err.Error(); got != wanted {
+		t.Errorf("Got %v, wanted %v", got, wanted)
+	}
+}
+
+func TestConflictsJSON(t *testing.T) {
+	conflicts := merge.Conflicts{
+		merge.Conflict{
+			Manager: "kubectl",
+			Path:    _P("list", _KBF("name", "a"), "value"),
+		},
+		merge.Conflict{
+			Manager: merge.ManagerIdentity{Manager: "controller", Operation: "Apply", Subresource: "status"}.String(),
+			Path:    _P("status", "ready"),
+			Current: _V(true),
+			Desired: _V(nil),
+		},
+	}
+	data, err := json.Marshal(conflicts)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	wanted := `[{"manager":"kubectl","path":".list[name=\"a\"].value","pathElements":["f:list","k:{\"name\":\"a\"}","f:value"]},` +
+		`{"manager":"controller","operation":"Apply","subresource":"status","path":".status.ready","pathElements":["f:status","f:ready"],"current":true,"desired":null}]`
+	if string(data) != wanted {
+		t.Errorf("Got %s, wanted %s", data, wanted)
+	}
+
+	var got merge.Conflicts
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !got.Equals(conflicts) {
+		t.Errorf("Got %v, wanted %v", got, conflicts)
+	}
+	if got[0].Current != nil || !value.Equals(got[1].Current, conflicts[1].Current) || !value.Equals(got[1].Desired, conflicts[1].Desired) {
+		t.Errorf("Values didn't round-trip: %v", got)
+	}
+}
+
+func TestConflictIs(t *testing.T) {
+	conflicts := merge.Conflicts{
+		{Manager: "Bob", Path: _P("key")},
+		{Manager: "Alice", Path: _P("value")},
+	}
+	for _, err := range []error{conflicts, conflicts[0], fmt.Errorf("apply failed: %w", conflicts)} {
+		if !errors.Is(err, merge.ErrConflict) {
+			t.Errorf("expected %q to be a conflict", err)
+		}
+	}
+	var got merge.Conflicts
+	if !errors.As(fmt.Errorf("apply failed: %w", conflicts), &got) || !got.Equals(conflicts) {
+		t.Errorf("expected to find %v, got %v", conflicts, got)
+	}
+	if errors.Is(errors.New("conflict"), merge.ErrConflict) {
+		t.Error("expected other errors not to be conflicts")
+	}
+}
+
+func TestConflictGroups(t *testing.T) {
+	kubectl := merge.ManagerIdentity{Manager: "kubectl", Operation: "Update"}.String()
+	conflicts := merge.Conflicts{
+		merge.Conflict{Manager: "bob", Path: _P("key"), Current: _V("a"), Desired: _V("b")},
+		merge.Conflict{Manager: kubectl, Path: _P("unset"), Desired: _V(1)},
+		merge.Conflict{Manager: "bob", Path: _P("other")},
+	}
+	groups := conflicts.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %v", len(groups))
+	}
+
+	bob := groups[0]
+	if bob.Manager != "bob" || bob.Identity.Manager != "bob" || !bob.Conflicts.Equals(merge.Conflicts{conflicts[0], conflicts[2]}) {
+		t.Errorf("unexpected group %+v", bob)
+	}
+	if len(bob.Remediations) != 3 {
+		t.Fatalf("expected 3 remediations, got %+v", bob.Remediations)
+	}
+	for i, want := range []struct {
+		action merge.RemediationAction
+		fields *fieldpath.Set
+	}{
+		{merge.ForceOwnership, _NS(_P("key"), _P("other"))},
+		{merge.StopManaging, _NS(_P("key"), _P("other"))},
+		{merge.ShareOwnership, _NS(_P("key"))},
+	} {
+		r := bob.Remediations[i]
+		if r.Action != want.action || !r.Fields.Equals(want.fields) || !strings.Contains(r.Message, `"bob"`) {
+			t.Errorf("unexpected remediation %v: %+v", i, r)
+		}
+	}
+
+	update := groups[1]
+	if update.Manager != kubectl || update.Identity.Manager != "kubectl" || update.Identity.Operation != "Update" {
+		t.Errorf("unexpected group %+v", update)
+	}
+	// The field is unset, so its ownership can't be shared.
+	if len(update.Remediations) != 2 || !strings.Contains(update.Remediations[0].Message, `"kubectl"`) {
+		t.Errorf("unexpected remediations %+v", update.Remediations)
+	}
+
+	if groups := (merge.Conflicts{}).Groups(); len(groups) != 0 {
+		t.Errorf("expected no groups, got %v", groups)
+	}
+}
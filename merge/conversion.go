@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+type conversionKey struct {
+	object  *typed.TypedValue
+	version fieldpath.APIVersion
+}
+
+type conversionResult struct {
+	object *typed.TypedValue
+	err    error
+}
+
+// conversionCache memoizes the conversions of an operation. Objects are
+// never modified once built, so they are identified by their address.
+type conversionCache struct {
+	lock    sync.Mutex
+	results map[conversionKey]conversionResult
+}
+
+func (s *Updater) convert(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	if s.conversions == nil {
+		return s.convertUncached(object, version)
+	}
+	key := conversionKey{object: object, version: version}
+	s.conversions.lock.Lock()
+	result, ok := s.conversions.results[key]
+	s.conversions.lock.Unlock()
+	if ok {
+		return result.object, result.err
+	}
+	result.object, result.err = s.convertUncached(object, version)
+	s.conversions.lock.Lock()
+	s.conversions.results[key] = result
+	s.conversions.lock.Unlock()
+	return result.object, result.err
+}
+
+func (s *Updater) convertUncached(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	end := s.startPhase(PhaseConvert, Attribute{Key: AttributeVersion, Value: string(version)})
+	converted, err := s.Converter.Convert(object, version)
+	if err == nil {
+		converted, err = s.withFieldOverrides(converted, version)
+	}
+	end(err)
+	return converted, err
+}
+
+// prefetchConversions converts the objects to the versions of all the
+// managers concurrently, if the Updater converts in parallel, so that the
+// conversions that follow are served from the cache.
+func (s *Updater) prefetchConversions(managers fieldpath.ManagedFields, objects ...*typed.TypedValue) {
+	if !s.parallelConversions || s.conversions == nil {
+		return
+	}
+	versions := map[fieldpath.APIVersion]bool{}
+	for _, set := range managers {
+		versions[set.APIVersion()] = true
+	}
+	if len(versions) < 2 {
+		return
+	}
+	var wg sync.WaitGroup
+	for version := range versions {
+		for _, object := range objects {
+			wg.Add(1)
+			go func(object *typed.TypedValue, version fieldpath.APIVersion) {
+				defer wg.Done()
+				if s.recoverPanics {
+					// A conversion that panics isn't cached, and
+					// panics again where it's needed, where the
+					// panic is recovered.
+					defer func() { recover() }()
+				}
+				s.convert(object, version)
+			}(object, version)
+		}
+	}
+	wg.Wait()
+}
+
+// retain drops the conversions of every object but the given ones, so that
+// a cache shared by several operations only keeps what they share.
+func (c *conversionCache) retain(objects map[*typed.TypedValue]bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key := range c.results {
+		if !objects[key.object] {
+			delete(c.results, key)
+		}
+	}
+}
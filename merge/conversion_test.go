@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"sync"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// countingConverter counts the conversions to each version of each object.
+type countingConverter struct {
+	specificVersionConverter
+	lock  sync.Mutex
+	count map[*typed.TypedValue]map[fieldpath.APIVersion]int
+}
+
+func (c *countingConverter) Convert(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	c.lock.Lock()
+	if c.count[object] == nil {
+		c.count[object] = map[fieldpath.APIVersion]int{}
+	}
+	c.count[object][version]++
+	c.lock.Unlock()
+	return c.specificVersionConverter.Convert(object, version)
+}
+
+func TestConversionCache(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		converter := &countingConverter{
+			specificVersionConverter: specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1", "v2", "v3"},
+			},
+			count: map[*typed.TypedValue]map[fieldpath.APIVersion]int{},
+		}
+		updater := (&merge.UpdaterBuilder{
+			Converter:           converter,
+			ParallelConversions: parallel,
+		}).BuildUpdater()
+		live, err := DeducedParser.Type("v1").FromYAML(`{}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		managers := fieldpath.ManagedFields{}
+
+		// The deduced types are the same at every version, so objects
+		// don't need to be converted before being handed to the Updater.
+		run := func(operation func(config *typed.TypedValue) (*typed.TypedValue, fieldpath.ManagedFields, error), config typed.YAMLObject) {
+			t.Helper()
+			tv, err := DeducedParser.Type("v1").FromYAML(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			converter.count = map[*typed.TypedValue]map[fieldpath.APIVersion]int{}
+			newObject, newManagers, err := operation(tv)
+			if err != nil {
+				t.Fatalf("parallel=%v: operation failed: %v", parallel, err)
+			}
+			for object, versions := range converter.count {
+				for version, count := range versions {
+					if count != 1 {
+						t.Errorf("parallel=%v: expected %v to be converted to %v once, got %v times", parallel, object, version, count)
+					}
+				}
+			}
+			if newObject != nil {
+				live = newObject
+			}
+			managers = newManagers
+		}
+		apply := func(version fieldpath.APIVersion, manager string) func(*typed.TypedValue) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+			return func(config *typed.TypedValue) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+				return updater.Apply(live, config, version, managers, manager, false)
+			}
+		}
+		update := func(version fieldpath.APIVersion, manager string) func(*typed.TypedValue) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+			return func(object *typed.TypedValue) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+				return updater.Update(live, object, version, managers, manager)
+			}
+		}
+		run(apply("v1", "one"), `{"a": 1}`)
+		run(update("v2", "two"), `{"a": 1, "b": 1}`)
+		run(apply("v3", "three"), `{"c": 1}`)
+		run(update("v3", "four"), `{"a": 2, "b": 2, "c": 1}`)
+
+		expected := fieldpath.ManagedFields{
+			"three": fieldpath.NewVersionedSet(_NS(_P("c")), "v3", true),
+			"four":  fieldpath.NewVersionedSet(_NS(_P("a"), _P("b")), "v3", false),
+		}
+		if !managers.Equals(expected) {
+			t.Errorf("parallel=%v: expected:\n%v\ngot:\n%v", parallel, expected, managers)
+		}
+	}
+}
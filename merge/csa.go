@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// AppliedSetFromLastApplied returns the managed fields entry of an applier
+// whose last applied configuration, as recorded by client-side apply, is
+// lastApplied. Only the fields that are still in the live object are
+// included. Both objects must be at the given version.
+func AppliedSetFromLastApplied(liveObject, lastApplied *typed.TypedValue, version fieldpath.APIVersion) (fieldpath.VersionedSet, error) {
+	applied, err := lastApplied.ToFieldSet()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get field set from last applied configuration: %w", err)
+	}
+	live, err := liveObject.ToFieldSet()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get field set from live object: %w", err)
+	}
+	return fieldpath.NewVersionedSet(applied.Intersection(live), version, true), nil
+}
+
+// UpgradeClientSideApply migrates an object from client-side apply to
+// server-side apply. The fields of the last applied configuration are
+// given to manager as an applier, and taken away from the managers that
+// client-side apply used to update the object with, so that the next
+// server-side apply by manager prunes them as client-side apply would
+// have. Both objects must be at the given version, as must the existing
+// entries of these managers.
+func UpgradeClientSideApply(liveObject, lastApplied *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, csaManagers []string, manager string) (fieldpath.ManagedFields, error) {
+	entry, err := AppliedSetFromLastApplied(liveObject, lastApplied, version)
+	if err != nil {
+		return nil, err
+	}
+	result := managers.Copy()
+	for _, csaManager := range csaManagers {
+		set, ok := result[csaManager]
+		if !ok || csaManager == manager {
+			continue
+		}
+		if set.APIVersion() != version {
+			return nil, fmt.Errorf("manager %q is at version %v, not %v", csaManager, set.APIVersion(), version)
+		}
+		if owned := set.Set().Difference(entry.Set()); owned.Empty() {
+			delete(result, csaManager)
+		} else {
+			result[csaManager] = fieldpath.NewVersionedSet(owned, version, set.Applied())
+		}
+	}
+	if existing, ok := result[manager]; ok {
+		if existing.APIVersion() != version {
+			return nil, fmt.Errorf("manager %q is at version %v, not %v", manager, existing.APIVersion(), version)
+		}
+		entry = fieldpath.NewVersionedSet(existing.Set().Union(entry.Set()), version, true)
+	}
+	if !entry.Set().Empty() {
+		result[manager] = entry
+	}
+	return result, nil
+}
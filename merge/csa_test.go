@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+)
+
+func TestUpgradeClientSideApply(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: &specificVersionConverter{
+			AcceptedVersions: []fieldpath.APIVersion{"v1"},
+		}},
+		Parser: DeducedParser,
+	}
+	// Client-side apply shows up as updates.
+	if err := state.Update(`{"a": 1, "b": 1, "c": 1}`, "v1", "kubectl-client-side-apply"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Update(`{"a": 1, "b": 1, "c": 1, "d": 1}`, "v1", "controller"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	lastApplied, err := DeducedParser.Type("v1").FromYAML(`{"a": 1, "b": 1, "gone": 1}`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	managers, err := merge.UpgradeClientSideApply(state.Live, lastApplied, "v1", state.Managers, []string{"kubectl-client-side-apply"}, "kubectl")
+	if err != nil {
+		t.Fatalf("Failed to upgrade: %v", err)
+	}
+	expected := fieldpath.ManagedFields{
+		"kubectl-client-side-apply": fieldpath.NewVersionedSet(_NS(_P("c")), "v1", false),
+		"controller":                fieldpath.NewVersionedSet(_NS(_P("d")), "v1", false),
+		"kubectl":                   fieldpath.NewVersionedSet(_NS(_P("a"), _P("b")), "v1", true),
+	}
+	if !managers.Equals(expected) {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, managers)
+	}
+
+	// The next server-side apply prunes what client-side apply would
+	// have removed.
+	state.Managers = managers
+	if err := state.Apply(`{"a": 2}`, "v1", "kubectl", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	comparison, err := state.CompareLive(`{"a": 2, "c": 1, "d": 1}`, "v1")
+	if err != nil {
+		t.Fatalf("Failed to compare live object: %v", err)
+	}
+	if comparison != "" {
+		t.Errorf("unexpected live object:\n%v", comparison)
+	}
+}
@@ -20,8 +20,8 @@ import (
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
-	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
 	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 )
 
 func TestDeduced(t *testing.T) {
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestApplyDryRun(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: &specificVersionConverter{
+			AcceptedVersions: []fieldpath.APIVersion{"v1"},
+		}},
+		Parser: DeducedParser,
+	}
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "b": 1}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	live, managers := state.Live, state.Managers.Copy()
+
+	parse := func(obj typed.YAMLObject) *typed.TypedValue {
+		tv, err := DeducedParser.Type("v1").FromYAML(obj)
+		if err != nil {
+			t.Fatalf("Failed to parse %v: %v", obj, err)
+		}
+		return tv
+	}
+
+	result, err := state.Updater.ApplyDryRun(live, parse(`{"a": 2, "c": 2}`), "v1", state.Managers, "two", false)
+	if err != nil {
+		t.Fatalf("Failed to dry-run: %v", err)
+	}
+	expectedConflicts := merge.Conflicts{merge.Conflict{Manager: "one", Path: _P("a")}}
+	if !result.Conflicts.Equals(expectedConflicts) || result.Object != nil || result.Managers != nil {
+		t.Errorf("expected conflicts %v, got %+v", expectedConflicts, result)
+	}
+
+	result, err = state.Updater.ApplyDryRun(live, parse(`{"a": 2, "c": 2}`), "v1", state.Managers, "two", true)
+	if err != nil {
+		t.Fatalf("Failed to dry-run: %v", err)
+	}
+	if !value.Equals(result.Object.AsValue(), parse(`{"a": 2, "b": 1, "c": 2}`).AsValue()) {
+		t.Errorf("unexpected object: %v", value.ToString(result.Object.AsValue()))
+	}
+	expectedManagers := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSet(_NS(_P("b")), "v1", true),
+		"two": fieldpath.NewVersionedSet(_NS(_P("a"), _P("c")), "v1", true),
+	}
+	if !result.Managers.Equals(expectedManagers) {
+		t.Errorf("expected managers:\n%v\ngot:\n%v", expectedManagers, result.Managers)
+	}
+
+	result, err = state.Updater.ApplyDryRun(live, parse(`{"a": 1, "b": 1}`), "v1", state.Managers, "one", false)
+	if err != nil {
+		t.Fatalf("Failed to dry-run: %v", err)
+	}
+	if result.Object != live || len(result.Conflicts) != 0 {
+		t.Errorf("expected no-op to return the live object, got %+v", result)
+	}
+
+	if state.Live != live || !state.Managers.Equals(managers) {
+		t.Errorf("dry-run modified its inputs: %v", state.Managers)
+	}
+}
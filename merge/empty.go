@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// pruneEmptyContainers removes the maps and lists that the apply left
+// empty, as well as their parents if that leaves them empty in turn. A
+// container is only removed if it wasn't empty in the live object and no
+// applier at the apply's version owns it: an applier may ask for an empty
+// container on purpose. The pruned containers are no longer owned by
+// anyone. It returns the object and the pruned containers.
+func pruneEmptyContainers(liveObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields) (*typed.TypedValue, *fieldpath.Set, error) {
+	wasEmpty, err := liveObject.EmptyContainers()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find empty containers in live object: %w", err)
+	}
+	owned := fieldpath.NewSet()
+	for _, set := range managers {
+		if set.Applied() && set.APIVersion() == version {
+			owned = owned.Union(set.Set())
+		}
+	}
+	keep := wasEmpty.Union(owned)
+
+	pruned := fieldpath.NewSet()
+	for {
+		empty, err := newObject.EmptyContainers()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find empty containers: %w", err)
+		}
+		empty = empty.Difference(keep)
+		if empty.Empty() {
+			break
+		}
+		newObject = newObject.RemoveItems(empty)
+		pruned = pruned.Union(empty)
+	}
+	for manager, set := range managers {
+		if set.APIVersion() != version {
+			continue
+		}
+		if owned := set.Set().Difference(pruned); owned.Empty() {
+			delete(managers, manager)
+		} else {
+			managers[manager] = fieldpath.NewVersionedSet(owned, set.APIVersion(), set.Applied())
+		}
+	}
+	return newObject, pruned, nil
+}
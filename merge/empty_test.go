@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+var labelsParser = func() Parser {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: spec
+      type:
+        map:
+          fields:
+          - name: labels
+            type:
+              map:
+                elementType:
+                  scalar: string
+          - name: selector
+            type:
+              map:
+                elementType:
+                  scalar: string
+                elementRelationship: atomic
+          - name: replicas
+            type:
+              scalar: numeric
+`)
+	if err != nil {
+		panic(err)
+	}
+	return SameVersionParser{T: parser.Type("type")}
+}()
+
+func TestPruneEmptyContainers(t *testing.T) {
+	var pruned *fieldpath.Set
+	updater := (&merge.UpdaterBuilder{
+		Converter:            &specificVersionConverter{AcceptedVersions: []fieldpath.APIVersion{"v1"}},
+		PruneEmptyContainers: true,
+		EmptyContainersPruned: func(manager string, set *fieldpath.Set) {
+			if manager != "applier" {
+				t.Errorf("unexpected manager %q", manager)
+			}
+			pruned = set
+		},
+	}).BuildUpdater()
+	state := State{Updater: updater, Parser: labelsParser}
+
+	if err := state.Update(`{"spec": {"labels": {"a": "0"}, "selector": {}}}`, "v1", "updater"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(`{"spec": {"labels": {"a": "1"}, "replicas": 1}}`, "v1", "applier", true); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if pruned != nil {
+		t.Errorf("expected nothing to be pruned, got %v", pruned)
+	}
+
+	// Dropping the last label leaves the labels empty, while the atomic
+	// selector was already empty.
+	if err := state.Apply(`{"spec": {"replicas": 1}}`, "v1", "applier", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if expected := _NS(_P("spec", "labels")); pruned == nil || !pruned.Equals(expected) {
+		t.Errorf("expected pruned %v, got %v", expected, pruned)
+	}
+	comparison, err := state.CompareLive(`{"spec": {"selector": {}, "replicas": 1}}`, "v1")
+	if err != nil {
+		t.Fatalf("Failed to compare live object: %v", err)
+	}
+	if comparison != "" {
+		t.Errorf("unexpected live object:\n%v", comparison)
+	}
+	for manager, set := range state.Managers {
+		if set.Set().Has(_P("spec", "labels")) {
+			t.Errorf("expected %q not to own the pruned labels", manager)
+		}
+	}
+
+	// An applier can ask for an empty container.
+	pruned = nil
+	if err := state.Apply(`{"spec": {"labels": {}}}`, "v1", "other", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if pruned != nil {
+		t.Errorf("expected nothing to be pruned, got %v", pruned)
+	}
+}
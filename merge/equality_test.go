@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestEqualities(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: cpu
+      type:
+        namedType: quantity
+- name: quantity
+  scalar: string
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	millis := func(v value.Value) string {
+		if s := v.AsString(); strings.HasSuffix(s, "m") {
+			return strings.TrimSuffix(s, "000m")
+		}
+		return v.AsString()
+	}
+	for _, tc := range []struct {
+		equalities *typed.Equalities
+		owners     fieldpath.ManagedFields
+	}{{
+		owners: fieldpath.ManagedFields{
+			"controller": fieldpath.NewVersionedSet(_NS(_P("cpu")), "v1", false),
+		},
+	}, {
+		equalities: &typed.Equalities{NamedTypes: map[string]typed.EqualityFunc{
+			"quantity": func(lhs, rhs value.Value) bool { return millis(lhs) == millis(rhs) },
+		}},
+		owners: fieldpath.ManagedFields{
+			"apply": fieldpath.NewVersionedSet(_NS(_P("cpu")), "v1", true),
+		},
+	}} {
+		state := State{
+			Updater: (&merge.UpdaterBuilder{
+				Converter: &specificVersionConverter{
+					AcceptedVersions: []fieldpath.APIVersion{"v1"},
+				},
+				Equalities: tc.equalities,
+			}).BuildUpdater(),
+			Parser: SameVersionParser{T: parser.Type("type")},
+		}
+		if err := state.Apply(typed.YAMLObject(`{"cpu": "1"}`), "v1", "apply", false); err != nil {
+			t.Fatal(err)
+		}
+		if err := state.Update(typed.YAMLObject(`{"cpu": "1000m"}`), "v1", "controller"); err != nil {
+			t.Fatal(err)
+		}
+		if !state.Managers.Equals(tc.owners) {
+			t.Errorf("expected managers:\n%v\ngot:\n%v", tc.owners, state.Managers)
+		}
+	}
+}
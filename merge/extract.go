@@ -0,0 +1,45 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// ExtractApplyConfiguration returns the fields of obj owned by manager, as
+// a configuration that the manager can modify and apply again without
+// dropping any of its fields. The keys of the list items holding its
+// fields are extracted too, even when the manager doesn't own them, so
+// that the items can be matched when applied. A manager that owns nothing
+// gets an empty object, or null if obj isn't a map.
+//
+// obj must be at the version of the fields of the manager.
+func ExtractApplyConfiguration(obj *typed.TypedValue, managers fieldpath.ManagedFields, manager string) *typed.TypedValue {
+	vs, ok := managers[manager]
+	if !ok || vs.Set().Empty() {
+		if a, ok := obj.Schema().Resolve(obj.TypeRef()); ok && a.Map != nil {
+			return typed.AsTypedUnvalidated(value.NewValueInterface(map[string]interface{}{}), obj.Schema(), obj.TypeRef())
+		}
+		return obj.Empty()
+	}
+	owned := vs.Set().WithListKeys()
+	// Extracting a field extracts everything below it, so only the
+	// leaves must be extracted.
+	return obj.ExtractItems(owned.Leaves())
+}
@@ -20,8 +20,10 @@ import (
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
-	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
 var extractParser = func() Parser {
@@ -753,3 +755,70 @@ func TestExtractApply(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractApplyConfiguration(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: items
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: name
+                type:
+                  scalar: string
+              - name: value
+                type:
+                  scalar: string
+              - name: other
+                type:
+                  scalar: string
+          elementRelationship: associative
+          keys: [name]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := parser.Type("type").FromYAML(`{"replicas": 3, "items": [{"name": "a", "value": "1", "other": "x"}, {"name": "b", "value": "2"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	managers := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSet(_NS(
+			_P("items", _KBF("name", "a"), "value"),
+		), "v1", true),
+		"two": fieldpath.NewVersionedSet(_NS(
+			_P("replicas"),
+			_P("items", _KBF("name", "a")),
+			_P("items", _KBF("name", "a"), "name"),
+			_P("items", _KBF("name", "a"), "other"),
+			_P("items", _KBF("name", "b")),
+			_P("items", _KBF("name", "b"), "name"),
+			_P("items", _KBF("name", "b"), "value"),
+		), "v1", true),
+	}
+
+	for manager, want := range map[string]string{
+		"one":     `{"items":[{"name":"a","value":"1"}]}`,
+		"two":     `{"items":[{"name":"a","other":"x"},{"name":"b","value":"2"}],"replicas":3}`,
+		"missing": `{}`,
+	} {
+		extracted := merge.ExtractApplyConfiguration(obj, managers, manager)
+		got, err := value.ToJSON(extracted.AsValue())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%v: expected %v, got %s", manager, want, got)
+		}
+		if err := extracted.Validate(); err != nil {
+			t.Errorf("%v: expected an applyable configuration, got %v", manager, err)
+		}
+	}
+}
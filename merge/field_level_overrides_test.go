@@ -4,13 +4,13 @@ import (
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
-	"sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
 	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
 
 func TestFieldLevelOverrides(t *testing.T) {
-	var overrideStructTypeParser = func() fixture.Parser {
+	var overrideStructTypeParser = func() smdtest.Parser {
 		parser, err := typed.NewParser(`
         types:
         - name: type
@@ -67,15 +67,15 @@ func TestFieldLevelOverrides(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
-		return fixture.SameVersionParser{T: parser.Type("type")}
+		return smdtest.SameVersionParser{T: parser.Type("type")}
 	}()
 
-	tests := map[string]fixture.TestCase{
+	tests := map[string]smdtest.TestCase{
 		"test_override_atomic_map_with_separable": {
 			// Test that a reference with an separable override to an atomic type
 			// is treated as separable
-			Ops: []fixture.Operation{
-				fixture.Apply{
+			Ops: []smdtest.Operation{
+				smdtest.Apply{
 					Manager: "apply_one",
 					Object: `
                         separableMapReference:
@@ -83,7 +83,7 @@ func TestFieldLevelOverrides(t *testing.T) {
                     `,
 					APIVersion: "v1",
 				},
-				fixture.Apply{
+				smdtest.Apply{
 					Manager: "apply_two",
 					Object: `
                         separableMapReference:
@@ -118,8 +118,8 @@ func TestFieldLevelOverrides(t *testing.T) {
 		"test_override_unspecified_map_with_atomic": {
 			// Test that a map which has its element relaetionship left as defualt
 			// (granular) can be overriden to be atomic
-			Ops: []fixture.Operation{
-				fixture.Apply{
+			Ops: []smdtest.Operation{
+				smdtest.Apply{
 					Manager: "apply_one",
 					Object: `
                         atomicMapReference:
@@ -127,7 +127,7 @@ func TestFieldLevelOverrides(t *testing.T) {
                     `,
 					APIVersion: "v1",
 				},
-				fixture.Apply{
+				smdtest.Apply{
 					Manager: "apply_two",
 					Object: `
                         atomicMapReference:
@@ -138,7 +138,7 @@ func TestFieldLevelOverrides(t *testing.T) {
 						merge.Conflict{Manager: "apply_one", Path: _P("atomicMapReference")},
 					},
 				},
-				fixture.Apply{
+				smdtest.Apply{
 					Manager: "apply_one",
 					Object: `
                         atomicMapReference:
@@ -167,8 +167,8 @@ func TestFieldLevelOverrides(t *testing.T) {
 		"test_override_associative_list_with_atomic": {
 			// Test that if a list type is listed associative but referred to as atomic
 			// that attempting to add to the list fauks
-			Ops: []fixture.Operation{
-				fixture.Apply{
+			Ops: []smdtest.Operation{
+				smdtest.Apply{
 					Manager: "apply_one",
 					Object: `
                         associativeListReference:
@@ -177,7 +177,7 @@ func TestFieldLevelOverrides(t *testing.T) {
                     `,
 					APIVersion: "v1",
 				},
-				fixture.Apply{
+				smdtest.Apply{
 					Manager: "apply_two",
 					Object: `
                         associativeListReference:
@@ -209,8 +209,8 @@ func TestFieldLevelOverrides(t *testing.T) {
 		"test_override_inline_atomic_list_with_associative": {
 			// Tests that an inline atomic list can have its type overridden to be
 			// associative
-			Ops: []fixture.Operation{
-				fixture.Apply{
+			Ops: []smdtest.Operation{
+				smdtest.Apply{
 					Manager: "apply_one",
 					Object: `
                         separableInlineList:
@@ -218,7 +218,7 @@ func TestFieldLevelOverrides(t *testing.T) {
                     `,
 					APIVersion: "v1",
 				},
-				fixture.Apply{
+				smdtest.Apply{
 					Manager: "apply_two",
 					Object: `
                         separableInlineList:
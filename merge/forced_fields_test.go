@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+)
+
+func TestForcedFields(t *testing.T) {
+	tests := map[string]TestCase{
+		"forced_conflicts_only": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 1
+						  b: 1
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 2
+						  c: 2
+					`,
+					ForcedFields: _NS(_P("obj", "a")),
+				},
+			},
+			Object: `
+				obj:
+				  a: 2
+				  b: 1
+				  c: 2
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("obj"),
+						_P("obj", "b"),
+					),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(
+						_P("obj"),
+						_P("obj", "a"),
+						_P("obj", "c"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+		"unforced_conflicts": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 1
+						  b: 1
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 2
+						  b: 2
+					`,
+					ForcedFields: _NS(_P("obj", "a")),
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("obj", "b")},
+					},
+				},
+			},
+			Object: `
+				obj:
+				  a: 1
+				  b: 1
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("obj"),
+						_P("obj", "a"),
+						_P("obj", "b"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+		"forced_parent": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 1
+						  b: 1
+						other: 1
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 2
+						  b: 2
+						other: 2
+					`,
+					ForcedFields: _NS(_P("obj")),
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("other")},
+					},
+				},
+			},
+			Object: `
+				obj:
+				  a: 1
+				  b: 1
+				other: 1
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("obj"),
+						_P("obj", "a"),
+						_P("obj", "b"),
+						_P("other"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+		"forced_parent_covers_conflicts": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 1
+						  b: 1
+						other: 1
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						obj:
+						  a: 2
+						  b: 2
+					`,
+					ForcedFields: _NS(_P("obj")),
+				},
+			},
+			Object: `
+				obj:
+				  a: 2
+				  b: 2
+				other: 1
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("obj"),
+						_P("other"),
+					),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(
+						_P("obj"),
+						_P("obj", "a"),
+						_P("obj", "b"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(DeducedParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+// isAncestor returns whether the manager applying is a parent, or a
+// further ancestor, of manager in the hierarchy of the child managers.
+// Managers are matched by their name, whatever their operation or
+// subresource.
+func (s *Updater) isAncestor(applier, manager string) bool {
+	if len(s.childManagers) == 0 {
+		return false
+	}
+	parent, child := ParseManagerIdentity(applier).Manager, ParseManagerIdentity(manager).Manager
+	visited := map[string]bool{parent: true}
+	pending := []string{parent}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, c := range s.childManagers[current] {
+			if c == child {
+				return true
+			}
+			if !visited[c] {
+				visited[c] = true
+				pending = append(pending, c)
+			}
+		}
+	}
+	return false
+}
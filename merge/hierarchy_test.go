@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+)
+
+func TestChildManagers(t *testing.T) {
+	children := map[string][]string{
+		"operator":     {"sub-operator"},
+		"sub-operator": {"controller"},
+	}
+	controller := merge.ManagerIdentity{Manager: "controller", Subresource: "status"}.String()
+	tests := map[string]TestCase{
+		"parent_takes_fields_of_children": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "sub-operator",
+					APIVersion: "v1",
+					Object: `
+						a: 1
+						b: 1
+					`,
+				},
+				Apply{
+					Manager:    controller,
+					APIVersion: "v1",
+					Object: `
+						c: 1
+					`,
+				},
+				Apply{
+					Manager:    "operator",
+					APIVersion: "v1",
+					Object: `
+						a: 2
+						c: 2
+					`,
+				},
+			},
+			Object: `
+				a: 2
+				b: 1
+				c: 2
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"sub-operator": fieldpath.NewVersionedSet(_NS(_P("b")), "v1", true),
+				"operator":     fieldpath.NewVersionedSet(_NS(_P("a"), _P("c")), "v1", true),
+			},
+		},
+		"children_conflict_with_parents": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "operator",
+					APIVersion: "v1",
+					Object: `
+						a: 1
+					`,
+				},
+				Apply{
+					Manager:    "sub-operator",
+					APIVersion: "v1",
+					Object: `
+						a: 2
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "operator", Path: _P("a")},
+					},
+				},
+			},
+			Object: `
+				a: 1
+			`,
+			APIVersion: "v1",
+		},
+		"parents_conflict_with_other_managers": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "sub-operator",
+					APIVersion: "v1",
+					Object: `
+						a: 1
+					`,
+				},
+				Apply{
+					Manager:    "other",
+					APIVersion: "v1",
+					Object: `
+						b: 1
+					`,
+				},
+				Apply{
+					Manager:    "operator",
+					APIVersion: "v1",
+					Object: `
+						a: 2
+						b: 2
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "other", Path: _P("b")},
+					},
+				},
+			},
+			Object: `
+				a: 1
+				b: 1
+			`,
+			APIVersion: "v1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.ChildManagers = children
+			if err := test.Test(DeducedParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
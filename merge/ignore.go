@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// IgnoreFilter returns true if the field at the given path and version
+// must be ignored, like the fields in IgnoredFields. Ignoring a field
+// ignores everything below it.
+type IgnoreFilter func(version fieldpath.APIVersion, path fieldpath.Path) bool
+
+// IgnorePatterns returns an IgnoreFilter that ignores the fields matching
+// any of the patterns, at every version. A pattern matches a path of the
+// same length whose elements are equal to the pattern's, except that an
+// empty PathElement in the pattern matches any element. For example,
+// MakePathOrDie("metadata", "annotations", PathElement{}) ignores every
+// annotation, but not the annotations field itself.
+func IgnorePatterns(patterns ...fieldpath.Path) IgnoreFilter {
+	return func(_ fieldpath.APIVersion, path fieldpath.Path) bool {
+	patterns:
+		for _, pattern := range patterns {
+			if len(pattern) != len(path) {
+				continue
+			}
+			for i, pe := range pattern {
+				if pe != (fieldpath.PathElement{}) && !pe.Equals(path[i]) {
+					continue patterns
+				}
+			}
+			return true
+		}
+		return false
+	}
+}
+
+// WithIgnoreFilters returns a copy of the Updater that also ignores the
+// fields selected by the filters, on top of its IgnoredFields. This is
+// cheap, so that filters can be set for a single operation.
+func (s *Updater) WithIgnoreFilters(filters ...IgnoreFilter) *Updater {
+	u := *s
+	u.ignoreFilters = append(append([]IgnoreFilter(nil), s.ignoreFilters...), filters...)
+	return &u
+}
+
+// removeIgnored returns the set of fields of the manager without the
+// ignored fields at the given version.
+func (s *Updater) removeIgnored(set *fieldpath.Set, version fieldpath.APIVersion, manager string) *fieldpath.Set {
+	kept := set
+	if ignored := s.IgnoredFields[version]; ignored != nil {
+		kept = kept.RecursiveDifference(ignored)
+	}
+	kept = s.filterIgnored(kept, version)
+	if s.logger != nil {
+		if ignored := set.Difference(kept); !ignored.Empty() {
+			s.logger.Info("Ignoring fields", "manager", manager, "version", version, "fields", ignored.String())
+		}
+	}
+	return kept
+}
+
+// filterIgnored returns the set without the fields selected by the
+// ignore filters.
+func (s *Updater) filterIgnored(set *fieldpath.Set, version fieldpath.APIVersion) *fieldpath.Set {
+	if len(s.ignoreFilters) == 0 {
+		return set
+	}
+	filtered := fieldpath.NewSet()
+	set.Iterate(func(p fieldpath.Path) {
+		for i := range p {
+			for _, filter := range s.ignoreFilters {
+				if filter(version, p[:i+1]) {
+					return
+				}
+			}
+		}
+		filtered.Insert(p.Copy())
+	})
+	return filtered
+}
+
+// excludeIgnored removes the ignored fields from the comparison.
+func (s *Updater) excludeIgnored(c *typed.Comparison, version fieldpath.APIVersion) *typed.Comparison {
+	c = c.ExcludeFields(s.IgnoredFields[version])
+	if len(s.ignoreFilters) == 0 {
+		return c
+	}
+	return &typed.Comparison{
+		Removed:  s.filterIgnored(c.Removed, version),
+		Modified: s.filterIgnored(c.Modified, version),
+		Added:    s.filterIgnored(c.Added, version),
+	}
+}
@@ -20,7 +20,8 @@ import (
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
-	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 )
 
 func TestIgnoredFields(t *testing.T) {
@@ -348,3 +349,59 @@ func TestIgnoredFieldsUsesVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestIgnoreFilters(t *testing.T) {
+	base := &merge.Updater{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}
+	state := State{Updater: base, Parser: DeducedParser}
+
+	if err := state.Apply(`{"a": 1, "volatile": {"x": 1}, "annotations": {"one": "1", "two": "2"}}`, "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+
+	// Ignoring the fields for a couple of operations neither conflicts
+	// with nor steals them from their current owner, and the manager
+	// that only changed ignored fields doesn't own anything.
+	state.Updater = base.WithIgnoreFilters(
+		func(_ fieldpath.APIVersion, p fieldpath.Path) bool {
+			return p.String() == ".volatile"
+		},
+		merge.IgnorePatterns(_P("annotations", fieldpath.PathElement{})),
+	)
+	if err := state.Update(`{"a": 1, "volatile": {"x": 2}, "annotations": {"one": "3", "two": "2", "three": "3"}}`, "v1", "two"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(`{"b": 2, "volatile": {"x": 3}, "annotations": {"one": "4"}}`, "v1", "three", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+
+	expected := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSet(
+			_NS(
+				_P("a"),
+				_P("volatile"),
+				_P("volatile", "x"),
+				_P("annotations"),
+				_P("annotations", "one"),
+				_P("annotations", "two"),
+			),
+			"v1",
+			true,
+		),
+		"three": fieldpath.NewVersionedSet(
+			_NS(
+				_P("annotations"),
+				_P("b"),
+			),
+			"v1",
+			true,
+		),
+	}
+	if !state.Managers.Equals(expected) {
+		t.Errorf("expected managers:\n%v\ngot:\n%v", expected, state.Managers)
+	}
+	if len(base.WithIgnoreFilters().IgnoredFields) != 0 {
+		t.Errorf("filters leaked into the base updater")
+	}
+}
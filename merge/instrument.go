@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// Phase is a step of Update and Apply that is timed by Instrumentation.
+type Phase string
+
+const (
+	// PhaseConvert is the conversion of an object to another version.
+	// Conversions served from the cache of an operation aren't observed.
+	PhaseConvert Phase = "Convert"
+	// PhaseCompare is the comparison of two objects.
+	PhaseCompare Phase = "Compare"
+	// PhaseMerge is the merge of an applied configuration into the live
+	// object.
+	PhaseMerge Phase = "Merge"
+	// PhasePrune is the removal of the fields that an applier stopped
+	// applying. It includes the conversions it needs.
+	PhasePrune Phase = "Prune"
+)
+
+// Instrumentation is notified of what the Updater spends its time on. It
+// must be safe for concurrent use if the Updater is, or if it converts in
+// parallel.
+type Instrumentation interface {
+	// ObservePhase is called every time a phase completes, whether it
+	// succeeded or not. Each operation goes through several phases, some
+	// of them repeatedly.
+	ObservePhase(phase Phase, duration time.Duration)
+	// ObserveConflicts is called with the number of conflicts of each
+	// operation failing because of them.
+	ObserveConflicts(operation string, conflicts int)
+	// ObserveOperation is called at the end of every successful Update
+	// and Apply, with its total duration and the number of fields owned
+	// by all the managers afterwards.
+	ObserveOperation(operation string, duration time.Duration, managedFields int)
+}
+
+// observe reports an operation that started at the given time to the
+// Tracer, the Instrumentation and the OwnershipRecorder, if any.
+func (s *Updater) observe(operation, manager string, start time.Time, before, after fieldpath.ManagedFields, err error) {
+	s.endOperation(err)
+	if err == nil {
+		s.recordOwnership(operation, manager, before, after)
+	}
+	if s.instrumentation == nil {
+		return
+	}
+	if err != nil {
+		if conflicts, ok := err.(Conflicts); ok {
+			s.instrumentation.ObserveConflicts(operation, len(conflicts))
+		}
+		return
+	}
+	size := 0
+	for _, set := range after {
+		size += set.Set().Size()
+	}
+	s.instrumentation.ObserveOperation(operation, time.Since(start), size)
+}
+
+// startPhase starts a phase, and returns the function reporting it once
+// it completes.
+func (s *Updater) startPhase(phase Phase, attributes ...Attribute) func(error) {
+	start, endSpan := time.Now(), s.startSpan(string(phase), attributes...)
+	return func(err error) {
+		endSpan(err)
+		if s.instrumentation != nil {
+			s.instrumentation.ObservePhase(phase, time.Since(start))
+		}
+	}
+}
+
+func (s *Updater) compare(lhs, rhs *typed.TypedValue) (*typed.Comparison, error) {
+	end := s.startPhase(PhaseCompare)
+	c, err := lhs.CompareWithOptions(rhs, s.typedOptions())
+	end(err)
+	return c, err
+}
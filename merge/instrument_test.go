@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+type countingInstrumentation struct {
+	phases     map[merge.Phase]int
+	conflicts  map[string]int
+	operations map[string]int
+	fields     int
+}
+
+func (c *countingInstrumentation) ObservePhase(phase merge.Phase, _ time.Duration) {
+	c.phases[phase]++
+}
+
+func (c *countingInstrumentation) ObserveConflicts(operation string, conflicts int) {
+	c.conflicts[operation] += conflicts
+}
+
+func (c *countingInstrumentation) ObserveOperation(operation string, _ time.Duration, managedFields int) {
+	c.operations[operation]++
+	c.fields = managedFields
+}
+
+func TestInstrumentation(t *testing.T) {
+	instrumentation := &countingInstrumentation{
+		phases:     map[merge.Phase]int{},
+		conflicts:  map[string]int{},
+		operations: map[string]int{},
+	}
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: &specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1", "v2"},
+			},
+			Instrumentation: instrumentation,
+		}).BuildUpdater(),
+		Parser: DeducedParser,
+	}
+
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "b": 1}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 1, "b": 1, "c": 1}`), "v2", "two"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{"a": 2, "b": 2}`), "v2", "three", false); err == nil {
+		t.Fatal("Expected the apply to conflict")
+	}
+
+	if expected := map[string]int{merge.OperationApply: 1, merge.OperationUpdate: 1}; !reflect.DeepEqual(instrumentation.operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, instrumentation.operations)
+	}
+	if expected := map[string]int{merge.OperationApply: 2}; !reflect.DeepEqual(instrumentation.conflicts, expected) {
+		t.Errorf("expected conflicts %v, got %v", expected, instrumentation.conflicts)
+	}
+	if instrumentation.fields != 3 {
+		t.Errorf("expected 3 managed fields, got %v", instrumentation.fields)
+	}
+	for _, phase := range []merge.Phase{merge.PhaseConvert, merge.PhaseCompare, merge.PhaseMerge, merge.PhasePrune} {
+		if instrumentation.phases[phase] == 0 {
+			t.Errorf("expected phase %v to be observed", phase)
+		}
+	}
+}
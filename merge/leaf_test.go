@@ -20,8 +20,8 @@ import (
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
-	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
 	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
 
@@ -119,6 +119,48 @@ func TestUpdateLeaf(t *testing.T) {
 				),
 			},
 		},
+		"update_takes_conflicting_and_removed_fields": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "default",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "string"
+						bool: true
+					`,
+				},
+				Update{
+					Manager:    "controller",
+					APIVersion: "v1",
+					Object: `
+						numeric: 2
+						bool: true
+					`,
+				},
+			},
+			Object: `
+				numeric: 2
+				bool: true
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"default": fieldpath.NewVersionedSet(
+					_NS(
+						_P("bool"),
+					),
+					"v1",
+					true,
+				),
+				"controller": fieldpath.NewVersionedSet(
+					_NS(
+						_P("numeric"),
+					),
+					"v1",
+					false,
+				),
+			},
+		},
 		"apply_update_apply_no_conflict": {
 			Ops: []Operation{
 				Apply{
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// ApplyUnioningLists is like Apply, except that the lists at the given
+// paths, expressed at the given version, are treated as sets for this
+// apply only: the values of the configuration are added to the ones of
+// the live object rather than replacing them. It is meant for the atomic
+// lists of scalars that a controller must add an entry to, e.g. a list of
+// finalizers. The lists are still owned as a whole, so adding to a list
+// owned by another manager conflicts unless the apply is forced, and
+// lists that the configuration doesn't specify are left alone.
+func (s *Updater) ApplyUnioningLists(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool, lists *fieldpath.Set) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	configObject, err := unionLists(liveObject, configObject, lists)
+	if err != nil {
+		return nil, fieldpath.ManagedFields{}, err
+	}
+	return s.Apply(liveObject, configObject, version, managers, manager, force)
+}
+
+// unionLists returns the configuration whose lists at the given paths are
+// the unions of their values in the live object and in the configuration.
+func unionLists(liveObject, configObject *typed.TypedValue, lists *fieldpath.Set) (*typed.TypedValue, error) {
+	if lists == nil || lists.Empty() {
+		return configObject, nil
+	}
+	config := configObject.AsValue()
+	var err error
+	lists.Iterate(func(p fieldpath.Path) {
+		if err != nil {
+			return
+		}
+		desired := valueAtPath(config, p)
+		current := valueAtPath(liveObject.AsValue(), p)
+		if desired == nil || desired.IsNull() || current == nil || current.IsNull() {
+			return
+		}
+		if !desired.IsList() || !current.IsList() {
+			err = fmt.Errorf("failed to union %v: expected a list", p)
+			return
+		}
+		var u interface{}
+		if u, err = setAtPath(config, p, unionListValues(current.AsList(), desired.AsList())); err != nil {
+			err = fmt.Errorf("failed to union %v: %v", p, err)
+			return
+		}
+		config = value.NewValueInterface(u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return typed.AsTypedUnvalidated(config, configObject.Schema(), configObject.TypeRef()), nil
+}
+
+// unionListValues returns the values of current followed by the values of
+// desired that current doesn't have, each only once.
+func unionListValues(current, desired value.List) []interface{} {
+	var out []interface{}
+	seen := []value.Value{}
+	for _, l := range []value.List{current, desired} {
+		for i := 0; i < l.Length(); i++ {
+			item := l.At(i)
+			found := false
+			for _, v := range seen {
+				if value.Equals(v, item) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				seen = append(seen, item)
+				out = append(out, item.Unstructured())
+			}
+		}
+	}
+	return out
+}
+
+// setAtPath returns the unstructured copy of node where the value at the
+// given path, which must exist, is replaced with v. Only the maps and lists
+// along the path are copied.
+func setAtPath(node value.Value, p fieldpath.Path, v interface{}) (interface{}, error) {
+	if len(p) == 0 {
+		return v, nil
+	}
+	pe := p[0]
+	if pe.FieldName != nil {
+		if !node.IsMap() {
+			return nil, fmt.Errorf("expected a map at %v", pe)
+		}
+		out := map[string]interface{}{}
+		var err error
+		node.AsMap().Iterate(func(k string, child value.Value) bool {
+			if k != *pe.FieldName {
+				out[k] = child.Unstructured()
+				return true
+			}
+			out[k], err = setAtPath(child, p[1:], v)
+			return err == nil
+		})
+		return out, err
+	}
+	if !node.IsList() {
+		return nil, fmt.Errorf("expected a list at %v", pe)
+	}
+	l := node.AsList()
+	out := make([]interface{}, l.Length())
+	found := false
+	for i := range out {
+		child := l.At(i)
+		if found || (pe.Index != nil && *pe.Index != i) || (pe.Index == nil && !listItemMatches(child, pe)) {
+			out[i] = child.Unstructured()
+			continue
+		}
+		var err error
+		if out[i], err = setAtPath(child, p[1:], v); err != nil {
+			return nil, err
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no item matching %v", pe)
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestApplyUnioningLists(t *testing.T) {
+	parse := func(y typed.YAMLObject) *typed.TypedValue {
+		tv, err := planParser.FromYAML(y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tv
+	}
+	updater := &merge.Updater{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}
+	live := parse(`{"spec": {"replicas": 1, "args": ["a", "b"]}}`)
+	managers := fieldpath.ManagedFields{
+		"other": fieldpath.NewVersionedSet(_NS(_P("spec", "replicas"), _P("spec", "args")), "v1", true),
+	}
+	args := _NS(_P("spec", "args"))
+	config := parse(`{"spec": {"args": ["c", "a"]}}`)
+
+	_, _, err := updater.ApplyUnioningLists(live, config, "v1", managers.Copy(), "controller", false, args)
+	expectedConflicts := merge.Conflicts{merge.Conflict{Manager: "other", Path: _P("spec", "args")}}
+	if conflicts, ok := err.(merge.Conflicts); !ok || !conflicts.Equals(expectedConflicts) {
+		t.Errorf("expected conflicts %v, got %v", expectedConflicts, err)
+	}
+
+	object, newManagers, err := updater.ApplyUnioningLists(live, config, "v1", managers.Copy(), "controller", true, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parse(`{"spec": {"replicas": 1, "args": ["a", "b", "c"]}}`); !value.Equals(object.AsValue(), want.AsValue()) {
+		t.Errorf("expected\n%v\nbut got\n%v", value.ToString(want.AsValue()), value.ToString(object.AsValue()))
+	}
+	expectedManagers := fieldpath.ManagedFields{
+		"other":      fieldpath.NewVersionedSet(_NS(_P("spec", "replicas")), "v1", true),
+		"controller": fieldpath.NewVersionedSet(_NS(_P("spec", "args")), "v1", true),
+	}
+	if !newManagers.Equals(expectedManagers) {
+		t.Errorf("expected managers\n%v\nbut got\n%v", expectedManagers, newManagers)
+	}
+	if want := parse(`{"spec": {"args": ["c", "a"]}}`); !value.Equals(config.AsValue(), want.AsValue()) {
+		t.Errorf("the configuration was modified: %v", value.ToString(config.AsValue()))
+	}
+
+	// Lists that aren't set on both sides are applied as they are.
+	object, _, err = updater.ApplyUnioningLists(parse(`{"spec": {"replicas": 1}}`), config, "v1", fieldpath.ManagedFields{}, "controller", false, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parse(`{"spec": {"replicas": 1, "args": ["c", "a"]}}`); !value.Equals(object.AsValue(), want.AsValue()) {
+		t.Errorf("expected\n%v\nbut got\n%v", value.ToString(want.AsValue()), value.ToString(object.AsValue()))
+	}
+
+	if _, _, err := updater.ApplyUnioningLists(live, parse(`{"spec": {"replicas": 2}}`), "v1", managers.Copy(), "controller", true, _NS(_P("spec", "replicas"))); err == nil {
+		t.Errorf("expected an error unioning a scalar")
+	}
+}
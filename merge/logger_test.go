@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// recordingLogger records its events as "msg key=value ...".
+type recordingLogger []string
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	event := msg
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		event += fmt.Sprintf(" %v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	*l = append(*l, event)
+}
+
+func (l recordingLogger) has(prefix string) bool {
+	for _, event := range l {
+		if strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	converter := &specificVersionConverter{AcceptedVersions: []fieldpath.APIVersion{"v1", "v2"}}
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: converter,
+			IgnoredFields: map[fieldpath.APIVersion]*fieldpath.Set{
+				"v2": fieldpath.NewSet(fieldpath.MakePathOrDie("ignored")),
+			},
+		}).BuildUpdater().WithLogger(logger),
+		Parser: DeducedParser,
+	}
+
+	if err := state.Update(typed.YAMLObject(`{"a": 1}`), "v1", "old"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{"b": 1}`), "v2", "applier", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{"b": 2, "ignored": 1}`), "v2", "other", true); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	converter.AcceptedVersions = []fieldpath.APIVersion{"v2"}
+	if err := state.Apply(typed.YAMLObject(`{"b": 2}`), "v2", "other", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+
+	for _, expected := range []string{
+		`Forcing conflicts manager=other conflicts=conflict with "applier": .b`,
+		`Ignoring fields manager=other version=v2 fields=.ignored`,
+		`Skipping missing version version=v1 error=Unknown version: v1`,
+	} {
+		if !logger.has(expected) {
+			t.Errorf("expected event %q, got:\n%v", expected, strings.Join(*logger, "\n"))
+		}
+	}
+}
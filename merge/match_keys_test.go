@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// routeListParser keys its routes by "host" and "path", but matches them
+// on "host" only, as if "path" was optional.
+var routeListParser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: v1
+  map:
+    fields:
+      - name: routes
+        type:
+          list:
+            elementType:
+              map:
+                fields:
+                - name: host
+                  type:
+                    scalar: string
+                - name: path
+                  type:
+                    scalar: string
+                - name: backend
+                  type:
+                    scalar: string
+            elementRelationship: associative
+            keys:
+            - host
+            - path
+            matchKeys:
+            - host
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+func TestMatchKeys(t *testing.T) {
+	tests := map[string]TestCase{
+		"apply_partial_keys_merges_elements": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						routes:
+						- host: example.com
+						  path: /
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						routes:
+						- host: example.com
+						  backend: web
+					`,
+				},
+			},
+			APIVersion: "v1",
+			Object: `
+				routes:
+				- host: example.com
+				  path: /
+				  backend: web
+			`,
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("routes", _KBF("host", "example.com")),
+						_P("routes", _KBF("host", "example.com"), "host"),
+						_P("routes", _KBF("host", "example.com"), "path"),
+					),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(
+						_P("routes", _KBF("host", "example.com")),
+						_P("routes", _KBF("host", "example.com"), "host"),
+						_P("routes", _KBF("host", "example.com"), "backend"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+		"apply_conflicting_remaining_key": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						routes:
+						- host: example.com
+						  path: /
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						routes:
+						- host: example.com
+						  path: /api
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("routes", _KBF("host", "example.com"), "path")},
+					},
+				},
+			},
+			APIVersion: "v1",
+			Object: `
+				routes:
+				- host: example.com
+				  path: /
+			`,
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("routes", _KBF("host", "example.com")),
+						_P("routes", _KBF("host", "example.com"), "host"),
+						_P("routes", _KBF("host", "example.com"), "path"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(routeListParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMatchKeysErrors(t *testing.T) {
+	tests := map[string]TestCase{
+		"apply_duplicate_match_keys": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "default",
+					APIVersion: "v1",
+					Object: `
+						routes:
+						- host: example.com
+						  path: /
+						- host: example.com
+						  path: /api
+					`,
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.Test(routeListParser) == nil {
+				t.Fatal("Should fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+type overriddenTypeKey struct {
+	schema    *schema.Schema
+	namedType string
+	inlined   schema.Atom
+	version   fieldpath.APIVersion
+}
+
+// overriddenTypes memoizes the types with the field overrides of an
+// Updater, so that all the objects of a type at a version get the same
+// schema, and so can be merged and compared with each other.
+type overriddenTypes struct {
+	lock  sync.Mutex
+	types map[overriddenTypeKey]typed.ParseableType
+	// overridden holds the schemas that already have overrides.
+	overridden map[*schema.Schema]bool
+}
+
+// withFieldOverrides returns object typed with the field overrides of the
+// version, if any.
+func (s *Updater) withFieldOverrides(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	overrides := s.fieldOverrides[version]
+	if object == nil || len(overrides) == 0 {
+		return object, nil
+	}
+	tr := object.TypeRef()
+	key := overriddenTypeKey{schema: object.Schema(), inlined: tr.Inlined, version: version}
+	if tr.NamedType != nil {
+		key.namedType = *tr.NamedType
+	}
+
+	s.overriddenTypes.lock.Lock()
+	defer s.overriddenTypes.lock.Unlock()
+	if s.overriddenTypes.overridden[key.schema] {
+		return object, nil
+	}
+	pt, ok := s.overriddenTypes.types[key]
+	if !ok {
+		var err error
+		pt, err = typed.ParseableType{Schema: key.schema, TypeRef: tr}.WithFieldOverrides(overrides...)
+		if err != nil {
+			return nil, err
+		}
+		s.overriddenTypes.types[key] = pt
+		s.overriddenTypes.overridden[pt.Schema] = true
+	}
+	return typed.AsTypedUnvalidated(object.AsValue(), pt.Schema, pt.TypeRef), nil
+}
+
+// overrideFields types the objects, which are at the given version, with
+// the field overrides of the version, and returns a function typing the
+// result of the operation back like the original objects.
+func (s *Updater) overrideFields(version fieldpath.APIVersion, objects ...**typed.TypedValue) (restore func(*typed.TypedValue) *typed.TypedValue, err error) {
+	if len(s.fieldOverrides[version]) == 0 {
+		return func(object *typed.TypedValue) *typed.TypedValue { return object }, nil
+	}
+	originals := map[*typed.TypedValue]*typed.TypedValue{}
+	var like *typed.TypedValue
+	for _, object := range objects {
+		original := *object
+		if original == nil {
+			continue
+		}
+		if *object, err = s.withFieldOverrides(original, version); err != nil {
+			return nil, err
+		}
+		originals[*object] = original
+		like = original
+	}
+	return func(object *typed.TypedValue) *typed.TypedValue {
+		if object == nil || like == nil {
+			return object
+		}
+		if original, ok := originals[object]; ok {
+			return original
+		}
+		return typed.AsTypedUnvalidated(object.AsValue(), like.Schema(), like.TypeRef())
+	}, nil
+}
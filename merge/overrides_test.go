@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestFieldOverrides(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: list
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: name
+                type:
+                  scalar: string
+          elementRelationship: associative
+          keys:
+          - name
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := parser.Type("type")
+	parse := func(y typed.YAMLObject) *typed.TypedValue {
+		tv, err := pt.FromYAML(y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tv
+	}
+
+	for _, tc := range []struct {
+		name      string
+		overrides map[fieldpath.APIVersion][]typed.FieldOverride
+		conflicts bool
+	}{
+		{name: "granular"},
+		{
+			name: "atomic",
+			overrides: map[fieldpath.APIVersion][]typed.FieldOverride{
+				"v1": {{Path: _P("list"), Granularity: typed.ForceAtomic}},
+			},
+			conflicts: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			updater := (&merge.UpdaterBuilder{
+				Converter:      &specificVersionConverter{AcceptedVersions: []fieldpath.APIVersion{"v1"}},
+				FieldOverrides: tc.overrides,
+			}).BuildUpdater()
+			live, managers, err := updater.Apply(parse(`{}`), parse(`{"list": [{"name": "a"}]}`), "v1", fieldpath.ManagedFields{}, "one", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if live.Schema() != pt.Schema {
+				t.Errorf("expected the object to keep its schema")
+			}
+			_, _, err = updater.Apply(live, parse(`{"list": [{"name": "b"}]}`), "v1", managers, "two", false)
+			if _, ok := err.(merge.Conflicts); ok != tc.conflicts {
+				t.Errorf("expected conflicts: %v, got %v", tc.conflicts, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// panickingConverter panics on every conversion.
+type panickingConverter struct{}
+
+func (panickingConverter) Convert(*typed.TypedValue, fieldpath.APIVersion) (*typed.TypedValue, error) {
+	panic("conversion failed")
+}
+
+func (panickingConverter) IsMissingVersionError(error) bool {
+	return false
+}
+
+func TestRecoverPanics(t *testing.T) {
+	obj := &struct {
+		Spec struct {
+			C chan int `json:"c"`
+		} `json:"spec"`
+	}{}
+	obj.Spec.C = make(chan int)
+	v, err := value.NewValueReflect(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := typed.AsTypedUnvalidated(v, typed.DeducedParseableType.Schema, typed.DeducedParseableType.TypeRef)
+	config, err := typed.DeducedParseableType.FromYAML(`{"spec": {"c": 1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updater := (&merge.UpdaterBuilder{
+		Converter:     &specificVersionConverter{AcceptedVersions: []fieldpath.APIVersion{"v1"}},
+		RecoverPanics: true,
+	}).BuildUpdater()
+	_, _, err = updater.Apply(live, config, "v1", fieldpath.ManagedFields{}, "one", false)
+	var pe *typed.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a *typed.PanicError, got %v", err)
+	}
+	if want := fieldpath.MakePathOrDie("spec"); !pe.Path.Equals(want) {
+		t.Errorf("expected the panic at %v, got %v", want, pe.Path)
+	}
+
+	updater = (&merge.UpdaterBuilder{
+		Converter:     panickingConverter{},
+		RecoverPanics: true,
+	}).BuildUpdater()
+	managers := fieldpath.ManagedFields{
+		"other": fieldpath.NewVersionedSet(_NS(_P("spec", "c")), "v2", false),
+	}
+	_, _, err = updater.Update(config, config, "v1", managers, "one")
+	if !errors.As(err, &pe) || pe.Value != "conversion failed" {
+		t.Fatalf("expected the panic of the conversion, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// PlanAction is what a PlanChange does to a field. Its value is the
+// character marking it in plans.
+type PlanAction byte
+
+const (
+	PlanAdd    PlanAction = '+'
+	PlanModify PlanAction = '~'
+	PlanRemove PlanAction = '-'
+)
+
+// PlanChange is the change of a single field.
+type PlanChange struct {
+	Action PlanAction
+	Path   fieldpath.Path
+	// Old and New are the values of the field before and after the
+	// change, nil when it doesn't exist.
+	Old value.Value
+	New value.Value
+	// Owners are the names of the managers owning the field, directly or
+	// through one of its parents, before the change.
+	Owners []string
+}
+
+// String renders the change as a line of a plan, e.g.
+//
+//	~ spec.replicas: 3 → 5 (owned by hpa)
+func (c PlanChange) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%c %v: ", c.Action, strings.TrimPrefix(c.Path.String(), "."))
+	switch c.Action {
+	case PlanAdd:
+		b.WriteString(planValue(c.New))
+	case PlanModify:
+		fmt.Fprintf(&b, "%v → %v", planValue(c.Old), planValue(c.New))
+	case PlanRemove:
+		b.WriteString(planValue(c.Old))
+	}
+	if len(c.Owners) != 0 {
+		fmt.Fprintf(&b, " (owned by %v)", strings.Join(c.Owners, ", "))
+	}
+	return b.String()
+}
+
+func planValue(v value.Value) string {
+	if v == nil {
+		return "null"
+	}
+	return value.ToString(v)
+}
+
+// Plan is a human-readable preview of the changes between two objects,
+// like those of kubectl diff or terraform plan.
+type Plan []PlanChange
+
+// NewPlan returns the plan of the comparison of lhs to rhs, with the
+// owners of the changed fields according to managers, which may be nil.
+// Only the leaf fields of the comparison are part of the plan, so that an
+// added map shows as its added fields, sorted by path.
+func NewPlan(c *typed.Comparison, lhs, rhs *typed.TypedValue, managers fieldpath.ManagedFields) Plan {
+	var plan Plan
+	for _, changes := range []struct {
+		action PlanAction
+		set    *fieldpath.Set
+	}{
+		{PlanAdd, c.Added},
+		{PlanModify, c.Modified},
+		{PlanRemove, c.Removed},
+	} {
+		changes.set.Leaves().Iterate(func(p fieldpath.Path) {
+			change := PlanChange{Action: changes.action, Path: p.Copy()}
+			if changes.action != PlanAdd {
+				change.Old = valueAtPath(lhs.AsValue(), p)
+			}
+			if changes.action != PlanRemove {
+				change.New = valueAtPath(rhs.AsValue(), p)
+			}
+			change.Owners = owners(managers, p)
+			plan = append(plan, change)
+		})
+	}
+	sort.SliceStable(plan, func(i, j int) bool { return plan[i].Path.Compare(plan[j].Path) < 0 })
+	return plan
+}
+
+// FocusedObjects returns lhs and rhs restricted to the fields that the
+// comparison of lhs to rhs found changed, and to the key fields of the
+// list items containing them, so that UIs can show a focused before and
+// after view of exactly what changed. The fields that only one side has,
+// e.g. because they were added, are only part of that side; atomic maps
+// and lists that changed are part of both as a whole.
+func FocusedObjects(c *typed.Comparison, lhs, rhs *typed.TypedValue) (before, after *typed.TypedValue) {
+	changed := c.Added.Union(c.Modified).Union(c.Removed).Leaves().WithListKeys()
+	return lhs.ExtractItems(changed), rhs.ExtractItems(changed)
+}
+
+// Focused returns the live object and the object the apply would result
+// in restricted to the fields the apply would change, as FocusedObjects
+// does. It fails if the apply conflicts.
+func (r ApplyResult) Focused(liveObject *typed.TypedValue) (before, after *typed.TypedValue, err error) {
+	if r.Object == nil {
+		return nil, nil, fmt.Errorf("the apply conflicts: %w", r.Conflicts)
+	}
+	c, err := liveObject.Compare(r.Object)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compare objects: %w", err)
+	}
+	before, after = FocusedObjects(c, liveObject, r.Object)
+	return before, after, nil
+}
+
+// owners returns the sorted names of the managers owning p, without their
+// operation and subresource.
+func owners(managers fieldpath.ManagedFields, p fieldpath.Path) []string {
+	seen := map[string]bool{}
+	var names []string
+	for manager, vs := range managers {
+		name := ParseManagerIdentity(manager).Manager
+		if !seen[name] && vs.Set().CoversPath(p) {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String renders the plan one change per line, followed by a summary.
+func (p Plan) String() string {
+	if len(p) == 0 {
+		return "No changes.\n"
+	}
+	var b strings.Builder
+	counts := map[PlanAction]int{}
+	for _, c := range p {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+		counts[c.Action]++
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to add, %d to change, %d to remove.\n", counts[PlanAdd], counts[PlanModify], counts[PlanRemove])
+	return b.String()
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var planParser = func() typed.ParseableType {
+	parser, err := typed.NewParser(`types:
+- name: deployment
+  map:
+    fields:
+    - name: spec
+      type:
+        map:
+          fields:
+          - name: replicas
+            type:
+              scalar: numeric
+          - name: paused
+            type:
+              scalar: boolean
+          - name: args
+            type:
+              list:
+                elementType:
+                  scalar: string
+                elementRelationship: atomic
+          - name: containers
+            type:
+              list:
+                elementType:
+                  map:
+                    fields:
+                    - name: name
+                      type:
+                        scalar: string
+                    - name: image
+                      type:
+                        scalar: string
+                elementRelationship: associative
+                keys:
+                - name
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser.Type("deployment")
+}()
+
+func TestPlan(t *testing.T) {
+	lhs, err := planParser.FromYAML(`{"spec": {"replicas": 3, "paused": true, "args": ["a"], "containers": [{"name": "a", "image": "a:1"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := planParser.FromYAML(`{"spec": {"replicas": 5, "args": ["a", "b"], "containers": [{"name": "a", "image": "a:2"}, {"name": "b", "image": "b:1"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := lhs.Compare(rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	managers := fieldpath.ManagedFields{
+		merge.ManagerIdentity{Manager: "hpa", Operation: "Update", Subresource: "scale"}.String(): fieldpath.NewVersionedSet(
+			fieldpath.NewSet(fieldpath.MakePathOrDie("spec", "replicas")), "v1", false),
+		"kubectl": fieldpath.NewVersionedSet(
+			fieldpath.NewSet(
+				fieldpath.MakePathOrDie("spec", "replicas"),
+				fieldpath.MakePathOrDie("spec", "args"),
+				fieldpath.MakePathOrDie("spec", "containers", _KBF("name", "a")),
+			), "v1", true),
+	}
+
+	expected := `~ spec.args: ["a"] → ["a","b"] (owned by kubectl)
+~ spec.containers[name="a"].image: "a:1" → "a:2" (owned by kubectl)
++ spec.containers[name="b"].image: "b:1"
++ spec.containers[name="b"].name: "b"
+- spec.paused: true
+~ spec.replicas: 3 → 5 (owned by hpa, kubectl)
+
+Plan: 2 to add, 3 to change, 1 to remove.
+`
+	if got := merge.NewPlan(c, lhs, rhs, managers).String(); got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+
+	if got := merge.NewPlan(&typed.Comparison{Added: fieldpath.NewSet(), Modified: fieldpath.NewSet(), Removed: fieldpath.NewSet()}, lhs, lhs, nil).String(); got != "No changes.\n" {
+		t.Errorf("expected no changes, got:\n%v", got)
+	}
+}
+
+func TestFocusedObjects(t *testing.T) {
+	parse := func(y typed.YAMLObject) *typed.TypedValue {
+		tv, err := planParser.FromYAML(y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tv
+	}
+	expectEqual := func(name string, got, want *typed.TypedValue) {
+		t.Helper()
+		if c, err := got.Compare(want); err != nil {
+			t.Fatal(err)
+		} else if !c.IsSame() {
+			t.Errorf("unexpected %v:\n%v\ndifferences:\n%v", name, value.ToString(got.AsValue()), c)
+		}
+	}
+
+	lhs := parse(`{"spec": {"replicas": 3, "paused": true, "args": ["a"], "containers": [{"name": "a", "image": "a:1"}, {"name": "c", "image": "c:1"}]}}`)
+	rhs := parse(`{"spec": {"replicas": 5, "args": ["a", "b"], "containers": [{"name": "a", "image": "a:2"}, {"name": "b", "image": "b:1"}, {"name": "c", "image": "c:1"}]}}`)
+	c, err := lhs.Compare(rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, after := merge.FocusedObjects(c, lhs, rhs)
+	expectEqual("before", before, parse(`{"spec": {"replicas": 3, "paused": true, "args": ["a"], "containers": [{"name": "a", "image": "a:1"}]}}`))
+	expectEqual("after", after, parse(`{"spec": {"replicas": 5, "args": ["a", "b"], "containers": [{"name": "a", "image": "a:2"}, {"name": "b", "image": "b:1"}]}}`))
+
+	updater := &merge.Updater{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}
+	result, err := updater.ApplyDryRun(lhs, parse(`{"spec": {"replicas": 5}}`), "v1", fieldpath.ManagedFields{}, "applier", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, after, err = result.Focused(lhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectEqual("before", before, parse(`{"spec": {"replicas": 3}}`))
+	expectEqual("after", after, parse(`{"spec": {"replicas": 5}}`))
+
+	conflicting := merge.ApplyResult{Conflicts: merge.Conflicts{{Manager: "other", Path: _P("spec", "replicas")}}}
+	if _, _, err := conflicting.Focused(lhs); !errors.Is(err, merge.ErrConflict) {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}
@@ -21,7 +21,7 @@ import (
 	"path/filepath"
 	"testing"
 
-	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
 
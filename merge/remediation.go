@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// RemediationAction is a way of resolving the conflicts of an apply.
+type RemediationAction string
+
+const (
+	// ForceOwnership is to apply again with force, taking the fields from
+	// their current manager.
+	ForceOwnership RemediationAction = "ForceOwnership"
+	// StopManaging is to remove the fields from the applied
+	// configuration, leaving them to their current manager.
+	StopManaging RemediationAction = "StopManaging"
+	// ShareOwnership is to set the fields to their current values in the
+	// applied configuration, so that the applier manages them along with
+	// their current manager.
+	ShareOwnership RemediationAction = "ShareOwnership"
+)
+
+// Remediation suggests how to resolve conflicts.
+type Remediation struct {
+	Action RemediationAction
+	// Fields are the conflicting fields the remediation applies to.
+	Fields *fieldpath.Set
+	// Message describes the remediation for humans.
+	Message string
+}
+
+// ConflictGroup is the conflicts with a single manager, and the ways to
+// resolve them.
+type ConflictGroup struct {
+	// Manager is the manager of the conflicting fields, as in Conflict,
+	// and Identity is its parsed identity.
+	Manager  string
+	Identity ManagerIdentity
+	// Conflicts are the conflicts with the manager, in their order in the
+	// grouped Conflicts.
+	Conflicts Conflicts
+	// Remediations are the ways to resolve the conflicts, the most
+	// direct first.
+	Remediations []Remediation
+}
+
+// Groups returns the conflicts grouped by sorted managers, like Error
+// prints them, with suggestions of how to resolve them, so that clients
+// can render their own guidance. Sharing the ownership of fields is only
+// suggested for the conflicts whose current value is known.
+func (conflicts Conflicts) Groups() []ConflictGroup {
+	byManager := map[string]Conflicts{}
+	managers := []string{}
+	for _, c := range conflicts {
+		if _, ok := byManager[c.Manager]; !ok {
+			managers = append(managers, c.Manager)
+		}
+		byManager[c.Manager] = append(byManager[c.Manager], c)
+	}
+	sort.Strings(managers)
+
+	groups := make([]ConflictGroup, 0, len(managers))
+	for _, manager := range managers {
+		g := ConflictGroup{
+			Manager:   manager,
+			Identity:  ParseManagerIdentity(manager),
+			Conflicts: byManager[manager],
+		}
+		g.Remediations = g.remediations()
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func (g ConflictGroup) remediations() []Remediation {
+	all := g.Conflicts.ToSet()
+	known := fieldpath.NewSet()
+	for _, c := range g.Conflicts {
+		if c.Current != nil {
+			known.Insert(c.Path)
+		}
+	}
+	name := g.Identity.Manager
+	remediations := []Remediation{{
+		Action:  ForceOwnership,
+		Fields:  all,
+		Message: fmt.Sprintf("If you intend to manage these fields, apply again with force to take them from %q.", name),
+	}, {
+		Action:  StopManaging,
+		Fields:  all,
+		Message: fmt.Sprintf("If you don't intend to manage these fields, remove them from your configuration to leave them to %q.", name),
+	}}
+	if !known.Empty() {
+		remediations = append(remediations, Remediation{
+			Action:  ShareOwnership,
+			Fields:  known,
+			Message: fmt.Sprintf("To manage these fields along with %q, set them to their current values in your configuration.", name),
+		})
+	}
+	return remediations
+}
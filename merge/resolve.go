@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// ConflictResolution is the decision taken by a ConflictResolver for a
+// single conflicting field.
+type ConflictResolution int
+
+const (
+	// ConflictAbort fails the apply with a conflict error for this field,
+	// which is what happens without a resolver.
+	ConflictAbort ConflictResolution = iota
+	// ConflictTakeNew gives the field and its new value to the applier,
+	// as if the apply was forced for this field.
+	ConflictTakeNew
+	// ConflictKeepExisting keeps the current owner and value of the
+	// field, as if the applier hadn't specified it.
+	ConflictKeepExisting
+)
+
+// ConflictDetails describes a conflict for a ConflictResolver.
+type ConflictDetails struct {
+	// Path is the conflicting field, at the version Manager owns it.
+	Path fieldpath.Path
+	// Manager is the current owner of the field.
+	Manager string
+	// APIVersion is the version at which Manager owns the field, and
+	// at which Path and the values are expressed.
+	APIVersion fieldpath.APIVersion
+	// Applier is the manager that is applying.
+	Applier string
+	// Current is the value of the field in the live object, or nil if
+	// it isn't set.
+	Current value.Value
+	// Desired is the value of the field after the apply, or nil if it
+	// would be removed.
+	Desired value.Value
+}
+
+// ConflictResolver is called for each conflicting field of a non-forced
+// Apply, and decides what to do with it. A single ConflictAbort fails the
+// apply with the conflicts for all the fields that were aborted.
+type ConflictResolver func(ConflictDetails) ConflictResolution
+
+// resolveConflicts asks the resolver about each conflict. It returns the
+// fields that the applier must not take, per version, or the conflicts the
+// resolver decided to abort on.
+func (s *Updater) resolveConflicts(liveObject, configObject *typed.TypedValue, conflicts Conflicts, managers fieldpath.ManagedFields, applier string) (map[fieldpath.APIVersion]*fieldpath.Set, error) {
+	merged, err := liveObject.MergeWithOptions(configObject, s.typedOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config: %w", err)
+	}
+	type versionedObjects struct {
+		live, merged value.Value
+	}
+	objects := map[fieldpath.APIVersion]versionedObjects{}
+	keep := map[fieldpath.APIVersion]*fieldpath.Set{}
+	aborted := Conflicts{}
+	for _, conflict := range conflicts {
+		version := managers[conflict.Manager].APIVersion()
+		objs, ok := objects[version]
+		if !ok {
+			live, err := s.convert(liveObject, version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert live object to %v: %w", version, err)
+			}
+			m, err := s.convert(merged, version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert merged object to %v: %w", version, err)
+			}
+			objs = versionedObjects{live: live.AsValue(), merged: m.AsValue()}
+			objects[version] = objs
+		}
+		switch s.conflictResolver(ConflictDetails{
+			Path:       conflict.Path,
+			Manager:    conflict.Manager,
+			APIVersion: version,
+			Applier:    applier,
+			Current:    valueAtPath(objs.live, conflict.Path),
+			Desired:    valueAtPath(objs.merged, conflict.Path),
+		}) {
+		case ConflictTakeNew:
+		case ConflictKeepExisting:
+			if keep[version] == nil {
+				keep[version] = fieldpath.NewSet()
+			}
+			keep[version].Insert(conflict.Path)
+		default:
+			aborted = append(aborted, conflict)
+		}
+	}
+	if len(aborted) != 0 {
+		return nil, aborted
+	}
+	return keep, nil
+}
+
+// removeFromConfig removes the given fields, expressed at each of their
+// versions, from the applied configuration.
+func (s *Updater) removeFromConfig(configObject *typed.TypedValue, version fieldpath.APIVersion, remove map[fieldpath.APIVersion]*fieldpath.Set) (*typed.TypedValue, error) {
+	for v, set := range remove {
+		converted, err := s.convert(configObject, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert config to %v: %w", v, err)
+		}
+		converted = converted.RemoveItemsUsing(s.allocator, set)
+		configObject, err = s.convert(converted, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert config back to %v: %w", version, err)
+		}
+	}
+	return configObject, nil
+}
+
+// valueAtPath returns the value found at the given path, or nil if there is
+// none. Keyed list items are matched on the key fields they specify.
+func valueAtPath(v value.Value, p fieldpath.Path) value.Value {
+	for _, pe := range p {
+		if v == nil || v.IsNull() {
+			return nil
+		}
+		switch {
+		case pe.FieldName != nil:
+			if !v.IsMap() {
+				return nil
+			}
+			child, ok := v.AsMap().Get(*pe.FieldName)
+			if !ok {
+				return nil
+			}
+			v = child
+		case pe.Index != nil:
+			if !v.IsList() || *pe.Index >= v.AsList().Length() {
+				return nil
+			}
+			v = v.AsList().At(*pe.Index)
+		case pe.Key != nil, pe.Value != nil:
+			if !v.IsList() {
+				return nil
+			}
+			l := v.AsList()
+			var found value.Value
+			for i := 0; i < l.Length() && found == nil; i++ {
+				if item := l.At(i); listItemMatches(item, pe) {
+					found = item
+				}
+			}
+			v = found
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func listItemMatches(item value.Value, pe fieldpath.PathElement) bool {
+	if pe.Value != nil {
+		return value.Equals(item, *pe.Value)
+	}
+	if !item.IsMap() {
+		return false
+	}
+	m := item.AsMap()
+	for _, f := range *pe.Key {
+		v, ok := m.Get(f.Name)
+		if !ok || !value.Equals(v, f.Value) {
+			return false
+		}
+	}
+	return true
+}
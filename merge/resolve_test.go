@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// resolveByField takes or keeps the conflicting fields named "take" and
+// "keep", and aborts on anything else.
+func resolveByField(t *testing.T) merge.ConflictResolver {
+	return func(c merge.ConflictDetails) merge.ConflictResolution {
+		if c.Manager != "apply-one" || c.Applier != "apply-two" || c.APIVersion != "v1" {
+			t.Errorf("unexpected conflict details: %+v", c)
+		}
+		if c.Current == nil || c.Desired == nil || value.Equals(c.Current, c.Desired) {
+			t.Errorf("expected different current and desired values: %+v", c)
+		}
+		switch c.Path.String() {
+		case ".take", ".obj.take":
+			return merge.ConflictTakeNew
+		case ".keep", ".obj.keep":
+			return merge.ConflictKeepExisting
+		}
+		return merge.ConflictAbort
+	}
+}
+
+func TestConflictResolver(t *testing.T) {
+	tests := map[string]TestCase{
+		"take_and_keep": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						take: a
+						keep: a
+						obj:
+						  take: a
+						  keep: a
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						take: b
+						keep: b
+						other: b
+						obj:
+						  take: b
+						  keep: b
+					`,
+				},
+			},
+			Object: `
+				take: b
+				keep: a
+				other: b
+				obj:
+				  take: b
+				  keep: a
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("keep"),
+						_P("obj"),
+						_P("obj", "keep"),
+					),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(
+						_P("take"),
+						_P("other"),
+						_P("obj"),
+						_P("obj", "take"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+		"abort": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						take: a
+						abort: a
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						take: b
+						abort: b
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("abort")},
+					},
+				},
+			},
+			Object: `
+				take: a
+				abort: a
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("take"),
+						_P("abort"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.ConflictResolver = resolveByField(t)
+			if err := test.Test(DeducedParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
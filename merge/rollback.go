@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// WithoutManager computes what the live object, at the given version,
+// would look like without the given manager, e.g. if its controller were
+// deleted: the fields it owns are removed, except those that other
+// managers own too, just like if it applied an empty configuration. It
+// returns the object, or the live object if the manager doesn't own any
+// field that would be removed, and the managed fields without the
+// manager. The managers that are passed in aren't modified.
+func (s *Updater) WithoutManager(liveObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	s = s.forOperation()
+	manager, managers = s.normalizeManagers(manager, managers.Copy())
+	return s.guard(func() (*typed.TypedValue, fieldpath.ManagedFields, error) {
+		s.prefetchConversions(managers, liveObject)
+		managers, err := s.reconcileManagedFieldsWithSchemaChanges(liveObject, managers)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, err
+		}
+		lastSet, ok := managers[manager]
+		if !ok {
+			return liveObject, managers, nil
+		}
+		// Pruning keeps what the pruning manager owns, so it owns
+		// nothing while its fields are pruned.
+		managers[manager] = fieldpath.NewVersionedSet(fieldpath.NewSet(), version, true)
+		object, err := s.prune(liveObject, managers, manager, lastSet)
+		delete(managers, manager)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to prune fields: %w", err)
+		}
+		return object, managers, nil
+	})
+}
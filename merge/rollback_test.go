@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestWithoutManager(t *testing.T) {
+	live, err := planParser.FromYAML(`{"spec": {"replicas": 3, "paused": true, "args": ["a"], "containers": [{"name": "a", "image": "a:1"}, {"name": "b", "image": "b:1"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerA := fieldpath.KeyByFields("name", "a")
+	containerB := fieldpath.KeyByFields("name", "b")
+	managers := fieldpath.ManagedFields{
+		"controller": fieldpath.NewVersionedSet(_NS(
+			_P("spec", "replicas"),
+			_P("spec", "paused"),
+			_P("spec", "containers", containerA),
+			_P("spec", "containers", containerA, "name"),
+			_P("spec", "containers", containerA, "image"),
+		), "v1", false),
+		"user": fieldpath.NewVersionedSet(_NS(
+			_P("spec", "paused"),
+			_P("spec", "args"),
+			_P("spec", "containers", containerB),
+			_P("spec", "containers", containerB, "name"),
+			_P("spec", "containers", containerB, "image"),
+		), "v1", true),
+	}
+	updater := &merge.Updater{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}
+
+	object, newManagers, err := updater.WithoutManager(live, "v1", managers, "controller")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := planParser.FromYAML(`{"spec": {"paused": true, "args": ["a"], "containers": [{"name": "b", "image": "b:1"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !value.Equals(object.AsValue(), want.AsValue()) {
+		t.Errorf("expected\n%v\nbut got\n%v", value.ToString(want.AsValue()), value.ToString(object.AsValue()))
+	}
+	if want := (fieldpath.ManagedFields{"user": managers["user"]}); !newManagers.Equals(want) {
+		t.Errorf("expected managers\n%v\nbut got\n%v", want, newManagers)
+	}
+	if _, ok := managers["controller"]; !ok {
+		t.Errorf("the managers that were passed in were modified")
+	}
+
+	object, newManagers, err = updater.WithoutManager(live, "v1", managers, "unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object != live || !newManagers.Equals(managers) {
+		t.Errorf("expected the live object and managers, got\n%v\n%v", value.ToString(object.AsValue()), newManagers)
+	}
+}
@@ -17,11 +17,12 @@ limitations under the License.
 package merge_test
 
 import (
+	"reflect"
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
-	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
 	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
 
@@ -252,3 +253,86 @@ func TestAtomicToGranularSchemaChanges(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileManagedFields(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: v1
+  map:
+    fields:
+      - name: items
+        type:
+          list:
+            elementType:
+              namedType: item
+            elementRelationship: associative
+            keys: [name]
+      - name: struct
+        type:
+          namedType: struct
+- name: item
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: port
+      type:
+        scalar: numeric
+    - name: value
+      type:
+        scalar: string
+- name: struct
+  map:
+    fields:
+    - name: numeric
+      type:
+        scalar: numeric
+    elementRelationship: atomic`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := parser.Type("v1").FromYAML(`
+items:
+- name: a
+  port: 80
+  value: x
+struct:
+  numeric: 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updater := (&merge.UpdaterBuilder{Converter: &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1"},
+	}}).BuildUpdater()
+
+	// The items used to be keyed by name and port, and the struct used to
+	// be granular.
+	managers := fieldpath.ManagedFields{
+		"lister": fieldpath.NewVersionedSet(_NS(
+			_P("items", _KBF("name", "a", "port", 80)),
+			_P("items", _KBF("name", "a", "port", 80), "value"),
+		), "v1", true),
+		"struct": fieldpath.NewVersionedSet(_NS(_P("struct", "numeric")), "v1", false),
+		"other":  fieldpath.NewVersionedSet(_NS(_P("items", _KBF("name", "a"), "port")), "v1", false),
+		"old":    fieldpath.NewVersionedSet(_NS(_P("items")), "v0", false),
+	}
+	reconciled, changed, err := updater.ReconcileManagedFields(live, "v1", managers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fieldpath.ManagedFields{
+		"lister": fieldpath.NewVersionedSet(_NS(
+			_P("items", _KBF("name", "a")),
+			_P("items", _KBF("name", "a"), "value"),
+		), "v1", true),
+		"struct": fieldpath.NewVersionedSet(_NS(_P("struct")), "v1", false),
+		"other":  fieldpath.NewVersionedSet(_NS(_P("items", _KBF("name", "a"), "port")), "v1", false),
+	}
+	if !reconciled.Equals(expected) {
+		t.Errorf("expected managers:\n%v\ngot:\n%v", expected, reconciled)
+	}
+	if want := []string{"lister", "old", "struct"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("expected %v to change, got %v", want, changed)
+	}
+}
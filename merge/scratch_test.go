@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// freeCountingAllocator counts the values given back to it.
+type freeCountingAllocator struct {
+	value.Allocator
+	frees int
+}
+
+func (a *freeCountingAllocator) Free(v interface{}) {
+	a.frees++
+	a.Allocator.Free(v)
+}
+
+func TestWithAllocator(t *testing.T) {
+	a := &freeCountingAllocator{Allocator: value.NewFreelistAllocator()}
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: &specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1"},
+			},
+		}).BuildUpdater().WithAllocator(a),
+		Parser: DeducedParser,
+	}
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "b": {"c": 1}, "l": [1, 2]}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 2, "b": {"c": 1}, "l": [1, 2]}`), "v1", "two"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{"b": {"d": 1}}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	comparison, err := state.CompareLive(`{"a": 2, "b": {"d": 1}}`, "v1")
+	if err != nil {
+		t.Fatalf("Failed to compare live object: %v", err)
+	}
+	if comparison != "" {
+		t.Errorf("unexpected live object:\n%v", comparison)
+	}
+	if a.frees == 0 {
+		t.Errorf("Expected the operations to use the given allocator")
+	}
+}
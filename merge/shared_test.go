@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+)
+
+func TestSharedFields(t *testing.T) {
+	test := TestCase{
+		Ops: []Operation{
+			Apply{
+				Manager:    "kubectl",
+				APIVersion: "v1",
+				Object: `
+					labels:
+					  team: x
+					spec: 1
+				`,
+			},
+			Update{
+				Manager:    "controller",
+				APIVersion: "v1",
+				Object: `
+					labels:
+					  team: x
+					spec: 2
+				`,
+			},
+			Apply{
+				Manager:    "kubectl",
+				APIVersion: "v1",
+				Object: `
+					spec: 2
+				`,
+			},
+			Apply{
+				Manager:    "other",
+				APIVersion: "v1",
+				Object: `
+					labels:
+					  team: y
+				`,
+				Conflicts: merge.Conflicts{
+					merge.Conflict{Manager: "controller", Path: _P("labels", "team")},
+				},
+			},
+		},
+		Object: `
+			labels:
+			  team: x
+			spec: 2
+		`,
+		APIVersion: "v1",
+		Managed: fieldpath.ManagedFields{
+			"kubectl": fieldpath.NewVersionedSet(
+				_NS(
+					_P("spec"),
+				),
+				"v1",
+				true,
+			),
+			"controller": fieldpath.NewVersionedSet(
+				_NS(
+					_P("labels"),
+					_P("labels", "team"),
+					_P("spec"),
+				),
+				"v1",
+				false,
+			),
+		},
+		SharedFields: map[fieldpath.APIVersion]*fieldpath.Set{
+			"v1": _NS(_P("labels")),
+		},
+	}
+
+	if err := test.Test(DeducedParser); err != nil {
+		t.Fatal(err)
+	}
+}
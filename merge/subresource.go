@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// ManagerIdentity identifies a manager along with the operation it runs
+// and the subresource it operates on. The same manager operating on
+// different subresources is tracked as different managers.
+type ManagerIdentity struct {
+	Manager     string `json:"manager"`
+	Operation   string `json:"operation,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+}
+
+// String encodes the identity as the name of a manager in ManagedFields.
+// Identities with neither an operation nor a subresource are encoded as
+// the bare manager name.
+func (m ManagerIdentity) String() string {
+	if m.Operation == "" && m.Subresource == "" {
+		return m.Manager
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		// Marshaling two strings can't fail.
+		panic(err)
+	}
+	return string(b)
+}
+
+// ParseManagerIdentity decodes the name of a manager produced by
+// ManagerIdentity.String. Any other name is the identity of a manager
+// without a subresource.
+func ParseManagerIdentity(manager string) ManagerIdentity {
+	if strings.HasPrefix(manager, "{") {
+		var id ManagerIdentity
+		if err := json.Unmarshal([]byte(manager), &id); err == nil && (id.Operation != "" || id.Subresource != "") {
+			return id
+		}
+	}
+	return ManagerIdentity{Manager: manager}
+}
+
+// subresourceScope returns the fields that the given manager can modify,
+// or nil if it isn't restricted.
+func (s *Updater) subresourceScope(manager string) *fieldpath.Set {
+	if len(s.subresourceScopes) == 0 {
+		return nil
+	}
+	return s.subresourceScopes[ParseManagerIdentity(manager).Subresource]
+}
+
+// restrictConfigToScope drops the fields of an applied configuration that
+// are outside of the scope.
+func restrictConfigToScope(configObject *typed.TypedValue, scope *fieldpath.Set) (*typed.TypedValue, error) {
+	set, err := configObject.ToFieldSet()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get field set: %w", err)
+	}
+	return configObject.ExtractItems(set.Leaves().RecursiveIntersection(scope)), nil
+}
+
+// restrictComparisonToScope drops the changes outside of the scope.
+func restrictComparisonToScope(c *typed.Comparison, scope *fieldpath.Set) *typed.Comparison {
+	if scope == nil {
+		return c
+	}
+	return &typed.Comparison{
+		Removed:  c.Removed.RecursiveIntersection(scope),
+		Modified: c.Modified.RecursiveIntersection(scope),
+		Added:    c.Added.RecursiveIntersection(scope),
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+)
+
+func TestManagerIdentity(t *testing.T) {
+	for _, id := range []merge.ManagerIdentity{
+		{Manager: "kubectl"},
+		{Manager: "controller", Subresource: "status"},
+		{Manager: "controller", Operation: "Update"},
+		{Manager: `{"manager":"odd"}`},
+	} {
+		if got := merge.ParseManagerIdentity(id.String()); got != id {
+			t.Errorf("expected %v to round-trip, got %v", id, got)
+		}
+	}
+	if got := merge.ParseManagerIdentity("kubectl").String(); got != "kubectl" {
+		t.Errorf("expected bare manager to be kept as is, got %v", got)
+	}
+}
+
+func TestSubresourceScopes(t *testing.T) {
+	statusManager := merge.ManagerIdentity{Manager: "controller", Subresource: "status"}.String()
+	tests := map[string]TestCase{
+		"apply_outside_of_scope_is_dropped": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "controller",
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 1
+					`,
+				},
+				Apply{
+					Manager:    statusManager,
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 2
+						status:
+						  ready: true
+					`,
+				},
+			},
+			Object: `
+				spec:
+				  replicas: 1
+				status:
+				  ready: true
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"controller": fieldpath.NewVersionedSet(
+					_NS(
+						_P("spec"),
+						_P("spec", "replicas"),
+					),
+					"v1",
+					true,
+				),
+				statusManager: fieldpath.NewVersionedSet(
+					_NS(
+						_P("status"),
+						_P("status", "ready"),
+					),
+					"v1",
+					true,
+				),
+			},
+		},
+		"ownership_is_independent": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "controller",
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 1
+						status:
+						  ready: false
+					`,
+				},
+				Update{
+					Manager:    statusManager,
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 2
+						status:
+						  ready: true
+					`,
+				},
+			},
+			Object: `
+				spec:
+				  replicas: 2
+				status:
+				  ready: true
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"controller": fieldpath.NewVersionedSet(
+					_NS(
+						_P("spec"),
+						_P("spec", "replicas"),
+						_P("status"),
+					),
+					"v1",
+					true,
+				),
+				statusManager: fieldpath.NewVersionedSet(
+					_NS(
+						_P("status", "ready"),
+					),
+					"v1",
+					false,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.SubresourceScopes = map[string]*fieldpath.Set{
+				"status": _NS(_P("status")),
+			}
+			if err := test.Test(DeducedParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Names of the spans that are not phases.
+const (
+	SpanParse    = "Parse"
+	SpanValidate = "Validate"
+)
+
+// Keys of the attributes of spans.
+const (
+	// AttributeManager is the manager running the operation.
+	AttributeManager = "smd.manager"
+	// AttributeManagers is the number of managers of the object before
+	// the operation.
+	AttributeManagers = "smd.managers"
+	// AttributeObjectSize is the number of nodes of the object that is
+	// updated, applied or parsed.
+	AttributeObjectSize = "smd.object.size"
+	// AttributeConflicts is the number of conflicts failing the
+	// operation.
+	AttributeConflicts = "smd.conflicts"
+	// AttributeVersion is the version an object is converted to.
+	AttributeVersion = "smd.version"
+)
+
+// Attribute is a key and its value, which is either an int64 or a string.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts the spans of the Updater operations, their phases, and of
+// parsing and validating objects with ParseTraced. It is meant to wrap a
+// tracer such as OpenTelemetry's, which this module doesn't depend on:
+// the spans of an Update or Apply and of its phases are named after the
+// operation and the Phase, and nest under the span given to WithTracer.
+//
+// A Tracer must be safe for concurrent use if the Updater is, or if it
+// converts in parallel.
+type Tracer interface {
+	// StartSpan starts a span as a child of parent. A nil parent is the
+	// span of the caller, e.g. the one in the context that the tracer
+	// was created with.
+	StartSpan(parent Span, name string, attributes ...Attribute) Span
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	// End ends the span, with the error of what it traced, if any.
+	End(err error)
+}
+
+// WithTracer returns a copy of the Updater tracing its operations with t,
+// as children of parent, which may be nil. The copy is cheap enough to be
+// made per request, with a tracer wrapping the context of the request.
+func (s *Updater) WithTracer(t Tracer, parent Span) *Updater {
+	u := *s
+	u.tracer = t
+	u.span = parent
+	return &u
+}
+
+// startOperation starts the span of an operation, which its phases are
+// children of. It must be called on the Updater of the operation.
+func (s *Updater) startOperation(operation, manager string, object *typed.TypedValue, managers fieldpath.ManagedFields) {
+	if s.tracer == nil {
+		return
+	}
+	s.span = s.tracer.StartSpan(s.span, operation,
+		Attribute{Key: AttributeManager, Value: manager},
+		Attribute{Key: AttributeManagers, Value: int64(len(managers))},
+		Attribute{Key: AttributeObjectSize, Value: valueSize(object.AsValue())},
+	)
+}
+
+// endOperation ends the span of an operation.
+func (s *Updater) endOperation(err error) {
+	if s.tracer == nil {
+		return
+	}
+	if conflicts, ok := err.(Conflicts); ok {
+		s.span.SetAttributes(Attribute{Key: AttributeConflicts, Value: int64(len(conflicts))})
+	}
+	s.span.End(err)
+}
+
+// startSpan starts a child of the span of the current operation, and
+// returns the function ending it, which does nothing without a tracer.
+func (s *Updater) startSpan(name string, attributes ...Attribute) func(error) {
+	if s.tracer == nil {
+		return func(error) {}
+	}
+	return s.tracer.StartSpan(s.span, name, attributes...).End
+}
+
+// ParseTraced parses and validates obj as the given type, with a span for
+// each step, as children of parent. The validation span has the size of
+// the object.
+func ParseTraced(t Tracer, parent Span, pt typed.ParseableType, obj interface{}, opts ...typed.ValidationOptions) (*typed.TypedValue, error) {
+	span := t.StartSpan(parent, SpanParse)
+	tv := typed.AsTypedUnvalidated(value.NewValueInterface(obj), pt.Schema, pt.TypeRef)
+	span.End(nil)
+
+	span = t.StartSpan(parent, SpanValidate, Attribute{Key: AttributeObjectSize, Value: valueSize(tv.AsValue())})
+	err := tv.Validate(opts...)
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	return tv, nil
+}
+
+// valueSize returns the number of nodes of v.
+func valueSize(v value.Value) int64 {
+	if v == nil || v.IsNull() {
+		return 0
+	}
+	size := int64(1)
+	switch {
+	case v.IsList():
+		l := v.AsList()
+		for i := 0; i < l.Length(); i++ {
+			size += valueSize(l.At(i))
+		}
+	case v.IsMap():
+		v.AsMap().Iterate(func(_ string, child value.Value) bool {
+			size += valueSize(child)
+			return true
+		})
+	}
+	return size
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"sync"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	. "sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+type recordingSpan struct {
+	tracer     *recordingTracer
+	parent     *recordingSpan
+	name       string
+	attributes map[string]interface{}
+	ended      bool
+	err        error
+}
+
+func (s *recordingSpan) SetAttributes(attributes ...merge.Attribute) {
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+	for _, a := range attributes {
+		s.attributes[a.Key] = a.Value
+	}
+}
+
+func (s *recordingSpan) End(err error) {
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+	s.ended, s.err = true, err
+}
+
+type recordingTracer struct {
+	lock  sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) StartSpan(parent merge.Span, name string, attributes ...merge.Attribute) merge.Span {
+	span := &recordingSpan{tracer: t, name: name, attributes: map[string]interface{}{}}
+	if parent != nil {
+		span.parent = parent.(*recordingSpan)
+	}
+	span.SetAttributes(attributes...)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func (t *recordingTracer) named(name string) []*recordingSpan {
+	var spans []*recordingSpan
+	for _, span := range t.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	request := &recordingSpan{tracer: tracer, name: "request"}
+	state := State{
+		Updater: (&merge.UpdaterBuilder{
+			Converter: &specificVersionConverter{
+				AcceptedVersions: []fieldpath.APIVersion{"v1", "v2"},
+			},
+		}).BuildUpdater().WithTracer(tracer, request),
+		Parser: DeducedParser,
+	}
+
+	if err := state.Apply(typed.YAMLObject(`{"a": 1, "b": {"c": 1}}`), "v1", "one", false); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+	if err := state.Update(typed.YAMLObject(`{"a": 1, "b": {"c": 2}}`), "v2", "two"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := state.Apply(typed.YAMLObject(`{"b": {"c": 3}}`), "v1", "one", false); err == nil {
+		t.Fatal("Expected the apply to conflict")
+	}
+
+	applies, updates := tracer.named(merge.OperationApply), tracer.named(merge.OperationUpdate)
+	if len(applies) != 2 || len(updates) != 1 {
+		t.Fatalf("expected 2 apply spans and 1 update span, got %v and %v", len(applies), len(updates))
+	}
+	first, conflicting := applies[0], applies[1]
+	if first.parent != request || first.err != nil {
+		t.Errorf("unexpected first apply span %+v", first)
+	}
+	if first.attributes[merge.AttributeManager] != "one" || first.attributes[merge.AttributeManagers] != int64(0) || first.attributes[merge.AttributeObjectSize] != int64(4) {
+		t.Errorf("unexpected attributes of the first apply %v", first.attributes)
+	}
+	if conflicting.err == nil || conflicting.attributes[merge.AttributeConflicts] != int64(1) {
+		t.Errorf("expected the last apply to end with 1 conflict, got %v with %v", conflicting.err, conflicting.attributes)
+	}
+
+	for _, phase := range []merge.Phase{merge.PhaseConvert, merge.PhaseCompare, merge.PhaseMerge, merge.PhasePrune} {
+		spans := tracer.named(string(phase))
+		if len(spans) == 0 {
+			t.Errorf("expected spans for phase %v", phase)
+		}
+		for _, span := range spans {
+			if span.parent == nil || (span.parent.name != merge.OperationApply && span.parent.name != merge.OperationUpdate) {
+				t.Errorf("expected phase %v to be a child of an operation, got %+v", phase, span.parent)
+			}
+		}
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span %v wasn't ended", span.name)
+		}
+	}
+}
+
+func TestParseTraced(t *testing.T) {
+	tracer := &recordingTracer{}
+	tv, err := merge.ParseTraced(tracer, nil, DeducedParser.Type("v1"), map[string]interface{}{"a": int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tv == nil || len(tracer.spans) != 2 || tracer.spans[0].name != merge.SpanParse || tracer.spans[1].name != merge.SpanValidate {
+		t.Fatalf("unexpected spans %v", tracer.spans)
+	}
+	if size := tracer.spans[1].attributes[merge.AttributeObjectSize]; size != int64(2) {
+		t.Errorf("expected size 2, got %v", size)
+	}
+}
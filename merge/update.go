@@ -15,8 +15,12 @@ package merge
 
 import (
 	"fmt"
+	"runtime/debug"
+	"sort"
+	"time"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
@@ -41,6 +45,118 @@ type UpdaterBuilder struct {
 	// Comparing has become more expensive too now that we're not using
 	// `Compare` but `value.Equals` so this gives an option to avoid it.
 	ReturnInputOnNoop bool
+
+	// ConflictResolver, if set, is called for each conflicting field of
+	// a non-forced Apply instead of failing right away.
+	ConflictResolver ConflictResolver
+
+	// ManagerExpiration, if set, drops the stale managers from the
+	// managed fields on every Update and Apply. See also
+	// CompactManagedFields.
+	ManagerExpiration ManagerExpiration
+
+	// SubresourceScopes restricts the managers operating on a
+	// subresource, as encoded by ManagerIdentity, to the given fields
+	// and everything below them. Changes they make outside of their
+	// subresource are neither owned nor checked for conflicts, and
+	// their applied configurations are stripped of such fields. The
+	// scopes must be valid at every version. Managers operating on a
+	// subresource without a scope are not restricted.
+	SubresourceScopes map[string]*fieldpath.Set
+
+	// IncludeConflictValues makes conflicts carry the current and
+	// desired values of the conflicting fields, which then show in
+	// their error messages.
+	IncludeConflictValues bool
+
+	// PruneEmptyContainers removes the maps and lists that an apply
+	// leaves empty or null, unless they were already empty or an
+	// applier owns them. Atomic maps and lists are never pruned.
+	PruneEmptyContainers bool
+
+	// EmptyContainersPruned, if set, is called with the paths of the
+	// containers removed by PruneEmptyContainers, if any.
+	EmptyContainersPruned func(manager string, pruned *fieldpath.Set)
+
+	// EmptyManagersDropped, if set, is called with the sorted names of
+	// the managers that Update and Apply drop from the managed fields
+	// because they no longer own any field, if any, so that their
+	// removal can be reported. Managers dropped by ManagerExpiration, or
+	// because their version is missing, aren't included.
+	EmptyManagersDropped func(manager string, dropped []string)
+
+	// SharedFields are the fields, and everything below them, that are
+	// co-owned by every manager asserting their current value. Appliers
+	// already co-own the fields they apply with the same value; with
+	// this, updaters also co-own the shared fields that their objects
+	// leave unchanged, which keeps appliers from pruning them. Conflicts
+	// still happen when the values disagree.
+	SharedFields map[fieldpath.APIVersion]*fieldpath.Set
+
+	// OwnershipRecorder, if set, receives an event for every field
+	// whose owners change on Update and Apply.
+	OwnershipRecorder OwnershipRecorder
+
+	// Instrumentation, if set, is notified of the duration of the
+	// phases of Update and Apply, of their conflicts and of the size of
+	// the managed fields they return.
+	Instrumentation Instrumentation
+
+	// ManagerNormalizer, if set, canonicalizes the names of the managers
+	// on every Update and Apply, before their ownership is compared. The
+	// managers that end up with the same name are merged, as long as
+	// they are at the same version and have the same applied flag; the
+	// others keep their original name.
+	ManagerNormalizer func(manager string) string
+
+	// ParallelConversions converts the objects to the versions of the
+	// managers concurrently at the start of every Update and Apply. The
+	// Converter must then be safe for concurrent use. Conversions are
+	// memoized within an operation either way.
+	ParallelConversions bool
+
+	// Logger, if set, is given debug events: the managers whose versions
+	// are missing and are skipped, the fields that are ignored, and the
+	// conflicts that are forced. Use Updater.WithLogger to log them with
+	// the logger of a request instead.
+	Logger typed.Logger
+
+	// Tracer, if set, traces every Update and Apply and their phases.
+	// Use Updater.WithTracer to trace them as children of the span of a
+	// request instead.
+	Tracer Tracer
+
+	// NodeBudget, if positive, bounds the number of nodes that the
+	// merges and comparisons of every Update and Apply can visit. The
+	// operations exceeding it fail with an error wrapping
+	// typed.ErrBudgetExceeded.
+	NodeBudget int64
+
+	// FieldOverrides force the granularity of the fields of the objects
+	// at each version, overriding their schema, e.g. to work around an
+	// incorrect `x-kubernetes-list-type` until the API is fixed. The
+	// objects returned by Update and Apply keep the type they were given.
+	FieldOverrides map[fieldpath.APIVersion][]typed.FieldOverride
+
+	// RecoverPanics makes Update and Apply fail with a *typed.PanicError
+	// instead of panicking, so that a single malformed object can't
+	// crash the process. The error holds the path of the field being
+	// walked, if the panic happened in a merge or comparison.
+	RecoverPanics bool
+
+	// ChildManagers declares the children of parent managers, by name:
+	// an apply by a parent takes the fields owned by its children, and
+	// by their own children, without conflicting with them, as if it
+	// were forced for those fields. Children still conflict with their
+	// parents. Managers are matched by their name in ManagerIdentity,
+	// whatever their operation or subresource.
+	ChildManagers map[string][]string
+
+	// Equalities are the semantic equalities of the scalars compared
+	// to find the fields that an Update or Apply changed, so that e.g.
+	// a quantity rewritten from "1" to "1000m" doesn't take ownership
+	// of the field. They don't change whether an operation is a no-op.
+	Equalities *typed.Equalities
 }
 
 func (u *UpdaterBuilder) BuildUpdater() *Updater {
@@ -48,6 +164,31 @@ func (u *UpdaterBuilder) BuildUpdater() *Updater {
 		Converter:         u.Converter,
 		IgnoredFields:     u.IgnoredFields,
 		returnInputOnNoop: u.ReturnInputOnNoop,
+		conflictResolver:  u.ConflictResolver,
+		managerExpiration: u.ManagerExpiration,
+		subresourceScopes: u.SubresourceScopes,
+
+		includeConflictValues: u.IncludeConflictValues,
+		pruneEmptyContainers:  u.PruneEmptyContainers,
+		emptyContainersPruned: u.EmptyContainersPruned,
+		emptyManagersDropped:  u.EmptyManagersDropped,
+		sharedFields:          u.SharedFields,
+		recorder:              u.OwnershipRecorder,
+		instrumentation:       u.Instrumentation,
+		managerNormalizer:     u.ManagerNormalizer,
+		parallelConversions:   u.ParallelConversions,
+		nodeBudget:            u.NodeBudget,
+		recoverPanics:         u.RecoverPanics,
+		equalities:            u.Equalities,
+		childManagers:         u.ChildManagers,
+		fieldOverrides:        u.FieldOverrides,
+		logger:                u.Logger,
+		tracer:                u.Tracer,
+
+		overriddenTypes: &overriddenTypes{
+			types:      map[overriddenTypeKey]typed.ParseableType{},
+			overridden: map[*schema.Schema]bool{},
+		},
 	}
 }
 
@@ -61,18 +202,71 @@ type Updater struct {
 	IgnoredFields map[fieldpath.APIVersion]*fieldpath.Set
 
 	returnInputOnNoop bool
+
+	conflictResolver ConflictResolver
+
+	managerExpiration ManagerExpiration
+
+	subresourceScopes map[string]*fieldpath.Set
+
+	includeConflictValues bool
+
+	ignoreFilters []IgnoreFilter
+
+	pruneEmptyContainers  bool
+	emptyContainersPruned func(manager string, pruned *fieldpath.Set)
+	emptyManagersDropped  func(manager string, dropped []string)
+
+	sharedFields map[fieldpath.APIVersion]*fieldpath.Set
+
+	recorder OwnershipRecorder
+
+	instrumentation Instrumentation
+
+	managerNormalizer func(manager string) string
+
+	parallelConversions bool
+	conversions         *conversionCache
+
+	nodeBudget int64
+	budget     *typed.Budget
+
+	recoverPanics bool
+
+	equalities *typed.Equalities
+
+	childManagers map[string][]string
+
+	fieldOverrides  map[fieldpath.APIVersion][]typed.FieldOverride
+	overriddenTypes *overriddenTypes
+
+	allocator value.Allocator
+
+	logger typed.Logger
+
+	tracer Tracer
+	span   Span
 }
 
-func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, workflow string, force bool) (fieldpath.ManagedFields, *typed.Comparison, error) {
+// update computes the managed fields after the change from oldObject to
+// newObject, and returns them with the comparison of the objects and the
+// names of the managers it dropped because they no longer own anything.
+func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, workflow string, force bool) (fieldpath.ManagedFields, *typed.Comparison, []string, error) {
 	conflicts := fieldpath.ManagedFields{}
 	removed := fieldpath.ManagedFields{}
-	compare, err := oldObject.Compare(newObject)
+	compare, err := s.compare(oldObject, newObject)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to compare objects: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to compare objects: %w", err)
 	}
+	scope := s.subresourceScope(workflow)
+	compare = restrictComparisonToScope(compare, scope)
 
 	versions := map[fieldpath.APIVersion]*typed.Comparison{
-		version: compare.ExcludeFields(s.IgnoredFields[version]),
+		version: s.excludeIgnored(compare, version),
+	}
+	// Only needed to report the values of conflicts.
+	objects := map[fieldpath.APIVersion][2]*typed.TypedValue{
+		version: {oldObject, newObject},
 	}
 
 	for manager, managerSet := range managers {
@@ -82,27 +276,29 @@ func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpa
 		compare, ok := versions[managerSet.APIVersion()]
 		if !ok {
 			var err error
-			versionedOldObject, err := s.Converter.Convert(oldObject, managerSet.APIVersion())
+			versionedOldObject, err := s.convert(oldObject, managerSet.APIVersion())
 			if err != nil {
-				if s.Converter.IsMissingVersionError(err) {
+				if s.isMissingVersion(err, managerSet.APIVersion()) {
 					delete(managers, manager)
 					continue
 				}
-				return nil, nil, fmt.Errorf("failed to convert old object: %v", err)
+				return nil, nil, nil, fmt.Errorf("failed to convert old object: %w", err)
 			}
-			versionedNewObject, err := s.Converter.Convert(newObject, managerSet.APIVersion())
+			versionedNewObject, err := s.convert(newObject, managerSet.APIVersion())
 			if err != nil {
-				if s.Converter.IsMissingVersionError(err) {
+				if s.isMissingVersion(err, managerSet.APIVersion()) {
 					delete(managers, manager)
 					continue
 				}
-				return nil, nil, fmt.Errorf("failed to convert new object: %v", err)
+				return nil, nil, nil, fmt.Errorf("failed to convert new object: %w", err)
 			}
-			compare, err = versionedOldObject.Compare(versionedNewObject)
+			compare, err = s.compare(versionedOldObject, versionedNewObject)
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to compare objects: %v", err)
+				return nil, nil, nil, fmt.Errorf("failed to compare objects: %w", err)
 			}
-			versions[managerSet.APIVersion()] = compare.ExcludeFields(s.IgnoredFields[managerSet.APIVersion()])
+			compare = restrictComparisonToScope(compare, scope)
+			versions[managerSet.APIVersion()] = s.excludeIgnored(compare, managerSet.APIVersion())
+			objects[managerSet.APIVersion()] = [2]*typed.TypedValue{versionedOldObject, versionedNewObject}
 		}
 
 		conflictSet := managerSet.Set().Intersection(compare.Modified.Union(compare.Added))
@@ -115,25 +311,61 @@ func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpa
 		}
 	}
 
-	if !force && len(conflicts) != 0 {
-		return nil, nil, ConflictsFromManagers(conflicts)
+	// Parents take the fields of their children.
+	blocking := conflicts
+	if !force && len(s.childManagers) != 0 {
+		blocking = fieldpath.ManagedFields{}
+		for manager, conflictSet := range conflicts {
+			if !s.isAncestor(workflow, manager) {
+				blocking[manager] = conflictSet
+			}
+		}
 	}
-
-	for manager, conflictSet := range conflicts {
-		managers[manager] = fieldpath.NewVersionedSet(managers[manager].Set().Difference(conflictSet.Set()), managers[manager].APIVersion(), managers[manager].Applied())
+	if !force && len(blocking) != 0 {
+		c := ConflictsFromManagers(blocking)
+		if s.includeConflictValues {
+			for i := range c {
+				objs := objects[blocking[c[i].Manager].APIVersion()]
+				c[i].Current = valueAtPath(objs[0].AsValue(), c[i].Path)
+				c[i].Desired = valueAtPath(objs[1].AsValue(), c[i].Path)
+			}
+		}
+		return nil, nil, nil, c
 	}
 
-	for manager, removedSet := range removed {
-		managers[manager] = fieldpath.NewVersionedSet(managers[manager].Set().Difference(removedSet.Set()), managers[manager].APIVersion(), managers[manager].Applied())
+	if s.logger != nil && len(conflicts) != 0 {
+		s.logger.Info("Forcing conflicts", "manager", workflow, "conflicts", ConflictsFromManagers(conflicts).Error())
+	}
+	// Managers lose their conflicting and removed fields in a single
+	// difference. The fields are gathered into fresh sets, which
+	// UnionInPlace fills without sharing nodes with the sets they come
+	// from.
+	lost := map[string]*fieldpath.Set{}
+	for _, fields := range []fieldpath.ManagedFields{conflicts, removed} {
+		for manager, set := range fields {
+			if lost[manager] == nil {
+				lost[manager] = fieldpath.NewSet()
+			}
+			lost[manager].UnionInPlace(set.Set())
+		}
+	}
+	for manager, lostSet := range lost {
+		remaining := fieldpath.NewSet()
+		if !managers[manager].Set().IsSubsetOf(lostSet) {
+			remaining = managers[manager].Set().Difference(lostSet)
+		}
+		managers[manager] = fieldpath.NewVersionedSet(remaining, managers[manager].APIVersion(), managers[manager].Applied())
 	}
 
+	var dropped []string
 	for manager := range managers {
 		if managers[manager].Set().Empty() {
 			delete(managers, manager)
+			dropped = append(dropped, manager)
 		}
 	}
 
-	return managers, compare, nil
+	return managers, compare, dropped, nil
 }
 
 // Update is the method you should call once you've merged your final
@@ -142,76 +374,344 @@ func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpa
 // PATCH call), and liveObject must be the original object (empty if
 // this is a CREATE call).
 func (s *Updater) Update(liveObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	s = s.forOperation()
+	start, before := time.Now(), s.snapshotOwnership(managers)
+	s.startOperation(OperationUpdate, manager, newObject, managers)
+	manager, managers = s.normalizeManagers(manager, managers)
+	newObject, newManagers, err := s.guard(func() (*typed.TypedValue, fieldpath.ManagedFields, error) {
+		restore, err := s.overrideFields(version, &liveObject, &newObject)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, err
+		}
+		object, owners, err := s.updateObject(liveObject, newObject, version, managers, manager)
+		return restore(object), owners, err
+	})
+	s.observe(OperationUpdate, manager, start, before, newManagers, err)
+	return newObject, newManagers, err
+}
+
+func (s *Updater) updateObject(liveObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string) (*typed.TypedValue, fieldpath.ManagedFields, error) {
 	var err error
+	s.prefetchConversions(managers, liveObject, newObject)
 	managers, err = s.reconcileManagedFieldsWithSchemaChanges(liveObject, managers)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
-	managers, compare, err := s.update(liveObject, newObject, version, managers, manager, true)
+	managers = s.expireManagers(managers, manager)
+	managers, compare, dropped, err := s.update(liveObject, newObject, version, managers, manager, true)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
+	// The manager is only dropped if it doesn't own anything once it
+	// owns what it changed.
+	_, existed := managers[manager]
+	dropped, wasDropped := withoutManager(dropped, manager)
+	existed = existed || wasDropped
 	if _, ok := managers[manager]; !ok {
 		managers[manager] = fieldpath.NewVersionedSet(fieldpath.NewSet(), version, false)
 	}
 
-	ignored := s.IgnoredFields[version]
-	if ignored == nil {
-		ignored = fieldpath.NewSet()
+	owned := managers[manager].Set().Difference(compare.Removed).Union(compare.Modified).Union(compare.Added)
+	if shared := s.sharedFields[version]; shared != nil {
+		set, err := newObject.ToFieldSetUsing(s.allocator)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to get field set: %w", err)
+		}
+		owned = owned.Union(set.RecursiveIntersection(shared))
 	}
 	managers[manager] = fieldpath.NewVersionedSet(
-		managers[manager].Set().Difference(compare.Removed).Union(compare.Modified).Union(compare.Added).RecursiveDifference(ignored),
+		s.removeIgnored(owned, version, manager),
 		version,
 		false,
 	)
 	if managers[manager].Set().Empty() {
 		delete(managers, manager)
+		if existed {
+			dropped = append(dropped, manager)
+		}
 	}
+	s.reportDroppedManagers(manager, dropped)
 	return newObject, managers, nil
 }
 
 // Apply should be called when Apply is run, given the current object as
 // well as the configuration that is applied. This will merge the object
 // and return it.
+//
+// If the Updater has a ConflictResolver and the apply isn't forced, any
+// conflicts are handed to the resolver rather than returned directly.
 func (s *Updater) Apply(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	s = s.forOperation()
+	start, before := time.Now(), s.snapshotOwnership(managers)
+	s.startOperation(OperationApply, manager, configObject, managers)
+	manager, managers = s.normalizeManagers(manager, managers)
+	newObject, newManagers, err := s.guard(func() (*typed.TypedValue, fieldpath.ManagedFields, error) {
+		restore, err := s.overrideFields(version, &liveObject, &configObject)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, err
+		}
+		var object *typed.TypedValue
+		var owners fieldpath.ManagedFields
+		if force {
+			object, owners, err = s.apply(liveObject, configObject, version, managers, manager, true)
+		} else {
+			object, owners, err = s.applyForcingFields(liveObject, configObject, version, managers, manager, nil)
+		}
+		return restore(object), owners, err
+	})
+	s.observe(OperationApply, manager, start, before, newManagers, err)
+	return newObject, newManagers, err
+}
+
+// ApplyResult is the projected outcome of an apply.
+type ApplyResult struct {
+	// Object is the object as it would be after the apply. It is the
+	// live object if the apply doesn't change anything, or nil if the
+	// apply conflicts.
+	Object *typed.TypedValue
+	// Managers are the managed fields as they would be after the apply,
+	// or nil if the apply conflicts.
+	Managers fieldpath.ManagedFields
+	// Conflicts are the conflicts that would fail the apply, if any.
+	Conflicts Conflicts
+}
+
+// ApplyDryRun computes what Apply would do, without modifying the
+// managers that are passed in. Conflicts are reported in the result rather
+// than as an error.
+func (s *Updater) ApplyDryRun(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool) (ApplyResult, error) {
+	newObject, newManagers, err := s.withoutReports().Apply(liveObject, configObject, version, managers.Copy(), manager, force)
+	if conflicts, ok := err.(Conflicts); ok {
+		return ApplyResult{Conflicts: conflicts}, nil
+	}
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	if newObject == nil {
+		newObject = liveObject
+	}
+	return ApplyResult{Object: newObject, Managers: newManagers}, nil
+}
+
+// ApplyForcingFields is like a non-forced Apply, except that conflicts on
+// the forced fields, expressed at the given version, are forced. Conflicts
+// caused by any other field still fail the apply.
+func (s *Updater) ApplyForcingFields(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, forced *fieldpath.Set) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	s = s.forOperation()
+	start, before := time.Now(), s.snapshotOwnership(managers)
+	s.startOperation(OperationApply, manager, configObject, managers)
+	manager, managers = s.normalizeManagers(manager, managers)
+	newObject, newManagers, err := s.guard(func() (*typed.TypedValue, fieldpath.ManagedFields, error) {
+		restore, err := s.overrideFields(version, &liveObject, &configObject)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, err
+		}
+		object, owners, err := s.applyForcingFields(liveObject, configObject, version, managers, manager, forced)
+		return restore(object), owners, err
+	})
+	s.observe(OperationApply, manager, start, before, newManagers, err)
+	return newObject, newManagers, err
+}
+
+func (s *Updater) applyForcingFields(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, forced *fieldpath.Set) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	// apply modifies the managers even when it fails, so only hand it a
+	// copy until we know whether it conflicts.
+	newObject, newManagers, err := s.apply(liveObject, configObject, version, managers.Copy(), manager, false)
+	conflicts, ok := err.(Conflicts)
+	if !ok {
+		return newObject, newManagers, err
+	}
+	if forced != nil && !forced.Empty() {
+		if coversConflicts(forced, version, conflicts, managers) {
+			return s.apply(liveObject, configObject, version, managers, manager, true)
+		}
+		// Conflicts that remain without the forced fields in the
+		// configuration are the only ones that can't be forced.
+		probe := s.withoutReports()
+		_, _, err = probe.apply(liveObject, configObject.RemoveItemsUsing(s.allocator, forced), version, managers.Copy(), manager, false)
+		if conflicts, ok = err.(Conflicts); !ok {
+			if err != nil {
+				return nil, fieldpath.ManagedFields{}, err
+			}
+			return s.apply(liveObject, configObject, version, managers, manager, true)
+		}
+	}
+	if s.conflictResolver == nil {
+		return nil, fieldpath.ManagedFields{}, conflicts
+	}
+	keep, err := s.resolveConflicts(liveObject, configObject, conflicts, managers, manager)
+	if err != nil {
+		return nil, fieldpath.ManagedFields{}, err
+	}
+	configObject, err = s.removeFromConfig(configObject, version, keep)
+	if err != nil {
+		return nil, fieldpath.ManagedFields{}, err
+	}
+	// Every remaining conflict has been forced or resolved in favor of
+	// the applier.
+	return s.apply(liveObject, configObject, version, managers, manager, true)
+}
+
+// coversConflicts returns true if every conflict is on a field owned at
+// the given version that is forced, directly or through one of its
+// parents. The paths of conflicts at other versions can't be compared to
+// the forced fields without converting the configuration.
+func coversConflicts(forced *fieldpath.Set, version fieldpath.APIVersion, conflicts Conflicts, managers fieldpath.ManagedFields) bool {
+	for _, conflict := range conflicts {
+		owned, ok := managers[conflict.Manager]
+		if !ok || owned.APIVersion() != version || !forced.CoversPath(conflict.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Updater) apply(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool) (*typed.TypedValue, fieldpath.ManagedFields, error) {
 	var err error
+	s.prefetchConversions(managers, liveObject)
 	managers, err = s.reconcileManagedFieldsWithSchemaChanges(liveObject, managers)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
-	newObject, err := liveObject.Merge(configObject)
+	managers = s.expireManagers(managers, manager)
+	if scope := s.subresourceScope(manager); scope != nil {
+		configObject, err = restrictConfigToScope(configObject, scope)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, err
+		}
+	}
+	end := s.startPhase(PhaseMerge)
+	newObject, err := liveObject.MergeWithOptions(configObject, s.typedOptions())
+	end(err)
 	if err != nil {
-		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to merge config: %v", err)
+		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to merge config: %w", err)
 	}
 	lastSet := managers[manager]
-	set, err := configObject.ToFieldSet()
+	set, err := configObject.ToFieldSetUsing(s.allocator)
 	if err != nil {
-		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to get field set: %v", err)
+		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to get field set: %w", err)
 	}
 
-	ignored := s.IgnoredFields[version]
-	if ignored != nil {
-		set = set.RecursiveDifference(ignored)
+	set = s.removeIgnored(set, version, manager)
+	if ignored := s.IgnoredFields[version]; ignored != nil {
 		// TODO: is this correct. If we don't remove from lastSet pruning might remove the fields?
 		if lastSet != nil {
 			lastSet.Set().RecursiveDifference(ignored)
 		}
 	}
 	managers[manager] = fieldpath.NewVersionedSet(set, version, true)
+	end = s.startPhase(PhasePrune)
 	newObject, err = s.prune(newObject, managers, manager, lastSet)
+	end(err)
 	if err != nil {
-		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to prune fields: %v", err)
+		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to prune fields: %w", err)
 	}
-	managers, _, err = s.update(liveObject, newObject, version, managers, manager, force)
+	s.prefetchConversions(managers, newObject)
+	managers, _, dropped, err := s.update(liveObject, newObject, version, managers, manager, force)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
+	if s.pruneEmptyContainers {
+		var pruned *fieldpath.Set
+		newObject, pruned, err = pruneEmptyContainers(liveObject, newObject, version, managers)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to prune empty containers: %w", err)
+		}
+		if s.emptyContainersPruned != nil && !pruned.Empty() {
+			s.emptyContainersPruned(manager, pruned)
+		}
+	}
+	if lastSet == nil {
+		// The applier didn't own anything before, so it isn't dropped.
+		dropped, _ = withoutManager(dropped, manager)
+	}
+	s.reportDroppedManagers(manager, dropped)
 	if !s.returnInputOnNoop && value.EqualsUsing(value.NewFreelistAllocator(), liveObject.AsValue(), newObject.AsValue()) {
 		newObject = nil
 	}
 	return newObject, managers, nil
 }
 
+// forOperation returns a copy of the Updater holding the state of a
+// single operation: its conversion cache, since objects at the same
+// address in different operations may differ, and its budget. Updaters
+// that already hold such state are returned as is.
+func (s *Updater) forOperation() *Updater {
+	if s.conversions != nil {
+		return s
+	}
+	u := *s
+	u.conversions = &conversionCache{results: map[conversionKey]conversionResult{}}
+	if s.nodeBudget > 0 {
+		u.budget = typed.NewBudget(s.nodeBudget)
+	}
+	return &u
+}
+
+// WithAllocator returns a copy of the Updater whose operations allocate
+// their scratch values with the given allocator, so that the several
+// merges, comparisons and walks of an Update or Apply reuse each other's
+// buffers. Allocators aren't safe for concurrent use, so the copy must not
+// be used concurrently either; it is cheap enough to be made per request.
+func (s *Updater) WithAllocator(a value.Allocator) *Updater {
+	u := *s
+	u.allocator = a
+	return &u
+}
+
+// typedOptions returns the options of the merges and comparisons of the
+// current operation.
+func (s *Updater) typedOptions() typed.Options {
+	return typed.Options{Budget: s.budget, Allocator: s.allocator, RecoverPanics: s.recoverPanics, Equalities: s.equalities}
+}
+
+// guard calls op, and returns its panic, if any, as an error if the
+// Updater recovers panics.
+func (s *Updater) guard(op func() (*typed.TypedValue, fieldpath.ManagedFields, error)) (_ *typed.TypedValue, _ fieldpath.ManagedFields, err error) {
+	if s.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				pe, ok := r.(*typed.PanicError)
+				if !ok {
+					pe = &typed.PanicError{Value: r, Stack: debug.Stack()}
+				}
+				err = pe
+			}
+		}()
+	}
+	return op()
+}
+
+// WithLogger returns a copy of the Updater logging its debug events with
+// the given logger, e.g. one carrying the values of a request.
+func (s *Updater) WithLogger(l typed.Logger) *Updater {
+	u := *s
+	u.logger = l
+	return &u
+}
+
+// isMissingVersion returns whether err is a conversion to a missing
+// version, which the callers tolerate.
+func (s *Updater) isMissingVersion(err error, version fieldpath.APIVersion) bool {
+	if !s.Converter.IsMissingVersionError(err) {
+		return false
+	}
+	if s.logger != nil {
+		s.logger.Info("Skipping missing version", "version", version, "error", err)
+	}
+	return true
+}
+
+// withoutReports returns a copy of the Updater that doesn't report what it
+// does, for operations that are not actually persisted.
+func (s *Updater) withoutReports() *Updater {
+	u := *s
+	u.emptyContainersPruned = nil
+	u.emptyManagersDropped = nil
+	u.recorder = nil
+	return &u
+}
+
 // prune will remove a field, list or map item, iff:
 // * applyingManager applied it last time
 // * applyingManager didn't apply it this time
@@ -221,25 +721,25 @@ func (s *Updater) prune(merged *typed.TypedValue, managers fieldpath.ManagedFiel
 		return merged, nil
 	}
 	version := lastSet.APIVersion()
-	convertedMerged, err := s.Converter.Convert(merged, version)
+	convertedMerged, err := s.convert(merged, version)
 	if err != nil {
-		if s.Converter.IsMissingVersionError(err) {
+		if s.isMissingVersion(err, version) {
 			return merged, nil
 		}
-		return nil, fmt.Errorf("failed to convert merged object to last applied version: %v", err)
+		return nil, fmt.Errorf("failed to convert merged object to last applied version: %w", err)
 	}
 
 	sc, tr := convertedMerged.Schema(), convertedMerged.TypeRef()
-	pruned := convertedMerged.RemoveItems(lastSet.Set().EnsureNamedFieldsAreMembers(sc, tr))
+	pruned := convertedMerged.RemoveItemsUsing(s.allocator, lastSet.Set().EnsureNamedFieldsAreMembers(sc, tr))
 	pruned, err = s.addBackOwnedItems(convertedMerged, pruned, version, managers, applyingManager)
 	if err != nil {
-		return nil, fmt.Errorf("failed add back owned items: %v", err)
+		return nil, fmt.Errorf("failed add back owned items: %w", err)
 	}
 	pruned, err = s.addBackDanglingItems(convertedMerged, pruned, lastSet)
 	if err != nil {
-		return nil, fmt.Errorf("failed add back dangling items: %v", err)
+		return nil, fmt.Errorf("failed add back dangling items: %w", err)
 	}
-	return s.Converter.Convert(pruned, managers[applyingManager].APIVersion())
+	return s.convert(pruned, managers[applyingManager].APIVersion())
 }
 
 // addBackOwnedItems adds back any fields, list and map items that were removed by prune,
@@ -275,30 +775,30 @@ func (s *Updater) addBackOwnedItems(merged, pruned *typed.TypedValue, prunedVers
 // It is an extracted sub-function from addBackOwnedItems for code reuse.
 func (s *Updater) addBackOwnedItemsForVersion(merged, pruned *typed.TypedValue, version fieldpath.APIVersion, managed *fieldpath.Set) (*typed.TypedValue, *typed.TypedValue, error) {
 	var err error
-	merged, err = s.Converter.Convert(merged, version)
+	merged, err = s.convert(merged, version)
 	if err != nil {
-		if s.Converter.IsMissingVersionError(err) {
+		if s.isMissingVersion(err, version) {
 			return merged, pruned, nil
 		}
-		return nil, nil, fmt.Errorf("failed to convert merged object at version %v: %v", version, err)
+		return nil, nil, fmt.Errorf("failed to convert merged object at version %v: %w", version, err)
 	}
-	pruned, err = s.Converter.Convert(pruned, version)
+	pruned, err = s.convert(pruned, version)
 	if err != nil {
-		if s.Converter.IsMissingVersionError(err) {
+		if s.isMissingVersion(err, version) {
 			return merged, pruned, nil
 		}
-		return nil, nil, fmt.Errorf("failed to convert pruned object at version %v: %v", version, err)
+		return nil, nil, fmt.Errorf("failed to convert pruned object at version %v: %w", version, err)
 	}
-	mergedSet, err := merged.ToFieldSet()
+	mergedSet, err := merged.ToFieldSetUsing(s.allocator)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create field set from merged object at version %v: %v", version, err)
+		return nil, nil, fmt.Errorf("failed to create field set from merged object at version %v: %w", version, err)
 	}
-	prunedSet, err := pruned.ToFieldSet()
+	prunedSet, err := pruned.ToFieldSetUsing(s.allocator)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create field set from pruned object at version %v: %v", version, err)
+		return nil, nil, fmt.Errorf("failed to create field set from pruned object at version %v: %w", version, err)
 	}
 	sc, tr := merged.Schema(), merged.TypeRef()
-	pruned = merged.RemoveItems(mergedSet.EnsureNamedFieldsAreMembers(sc, tr).Difference(prunedSet.EnsureNamedFieldsAreMembers(sc, tr).Union(managed.EnsureNamedFieldsAreMembers(sc, tr))))
+	pruned = merged.RemoveItemsUsing(s.allocator, mergedSet.EnsureNamedFieldsAreMembers(sc, tr).Difference(prunedSet.EnsureNamedFieldsAreMembers(sc, tr).Union(managed.EnsureNamedFieldsAreMembers(sc, tr))))
 	return merged, pruned, nil
 }
 
@@ -306,26 +806,54 @@ func (s *Updater) addBackOwnedItemsForVersion(merged, pruned *typed.TypedValue,
 // previously owned by the currently applying manager. This will add back fields list and map items
 // that are unowned or that are owned by Updaters and shouldn't be removed.
 func (s *Updater) addBackDanglingItems(merged, pruned *typed.TypedValue, lastSet fieldpath.VersionedSet) (*typed.TypedValue, error) {
-	convertedPruned, err := s.Converter.Convert(pruned, lastSet.APIVersion())
+	convertedPruned, err := s.convert(pruned, lastSet.APIVersion())
 	if err != nil {
-		if s.Converter.IsMissingVersionError(err) {
+		if s.isMissingVersion(err, lastSet.APIVersion()) {
 			return merged, nil
 		}
-		return nil, fmt.Errorf("failed to convert pruned object to last applied version: %v", err)
+		return nil, fmt.Errorf("failed to convert pruned object to last applied version: %w", err)
 	}
-	prunedSet, err := convertedPruned.ToFieldSet()
+	prunedSet, err := convertedPruned.ToFieldSetUsing(s.allocator)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create field set from pruned object in last applied version: %v", err)
+		return nil, fmt.Errorf("failed to create field set from pruned object in last applied version: %w", err)
 	}
-	mergedSet, err := merged.ToFieldSet()
+	mergedSet, err := merged.ToFieldSetUsing(s.allocator)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create field set from merged object in last applied version: %v", err)
+		return nil, fmt.Errorf("failed to create field set from merged object in last applied version: %w", err)
 	}
 	sc, tr := merged.Schema(), merged.TypeRef()
 	prunedSet = prunedSet.EnsureNamedFieldsAreMembers(sc, tr)
 	mergedSet = mergedSet.EnsureNamedFieldsAreMembers(sc, tr)
 	last := lastSet.Set().EnsureNamedFieldsAreMembers(sc, tr)
-	return merged.RemoveItems(mergedSet.Difference(prunedSet).Intersection(last)), nil
+	return merged.RemoveItemsUsing(s.allocator, mergedSet.Difference(prunedSet).Intersection(last)), nil
+}
+
+// ReconcileManagedFields reconciles the managed fields stored with the
+// live object, at the given version, with the changes to the schema of the
+// object since they were written, as Update and Apply do, e.g. to rewrite
+// the managed fields of all the objects of a type after its schema
+// changed: the managers of fields that became atomic own them as a whole,
+// and the items of associative lists whose keys changed are identified by
+// their new keys. It returns the reconciled managed fields, and the sorted
+// names of the managers whose fields were rewritten or dropped. Managers
+// at versions that no longer exist are dropped.
+func (s *Updater) ReconcileManagedFields(liveObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields) (fieldpath.ManagedFields, []string, error) {
+	s = s.forOperation()
+	if _, err := s.overrideFields(version, &liveObject); err != nil {
+		return nil, nil, err
+	}
+	reconciled, err := s.reconcileManagedFieldsWithSchemaChanges(liveObject, managers)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed := []string{}
+	for manager, versionedSet := range managers {
+		if r, ok := reconciled[manager]; !ok || !r.Set().Equals(versionedSet.Set()) {
+			changed = append(changed, manager)
+		}
+	}
+	sort.Strings(changed)
+	return reconciled, changed, nil
 }
 
 // reconcileManagedFieldsWithSchemaChanges reconciles the managed fields with any changes to the
@@ -334,11 +862,12 @@ func (s *Updater) addBackDanglingItems(merged, pruned *typed.TypedValue, lastSet
 // Supports:
 // - changing types from atomic to granular
 // - changing types from granular to atomic
+// - changing the keys of associative lists
 func (s *Updater) reconcileManagedFieldsWithSchemaChanges(liveObject *typed.TypedValue, managers fieldpath.ManagedFields) (fieldpath.ManagedFields, error) {
 	result := fieldpath.ManagedFields{}
 	for manager, versionedSet := range managers {
-		tv, err := s.Converter.Convert(liveObject, versionedSet.APIVersion())
-		if s.Converter.IsMissingVersionError(err) { // okay to skip, obsolete versions will be deleted automatically anyway
+		tv, err := s.convert(liveObject, versionedSet.APIVersion())
+		if s.isMissingVersion(err, versionedSet.APIVersion()) { // okay to skip, obsolete versions will be deleted automatically anyway
 			continue
 		}
 		if err != nil {
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics measures structured-merge-diff: the durations of
+// parsing objects and of the phases and operations of Updaters, their
+// conflicts, the size of managed fields and the hits of the reflection
+// cache of structured objects.
+//
+// The metrics are created in a Registry provided by the caller, so that
+// this module doesn't depend on a metrics library. With Prometheus, the
+// methods of a Registry create and register a CounterVec, HistogramVec or
+// CounterFunc, and Counter and Histogram are implemented by calling
+// WithLabelValues on the vectors.
+package metrics
+
+import (
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Registry creates and registers metrics.
+type Registry interface {
+	// Counter returns a counter with the given label names.
+	Counter(name, help string, labels ...string) Counter
+	// CounterFunc registers a counter whose value is returned by f.
+	CounterFunc(name, help string, f func() float64)
+	// Histogram returns a histogram with the given buckets and label
+	// names.
+	Histogram(name, help string, buckets []float64, labels ...string) Histogram
+}
+
+// Counter is a counter with labels.
+type Counter interface {
+	// Add adds v, which is positive, to the counter with the given
+	// label values.
+	Add(v float64, labelValues ...string)
+}
+
+// Histogram is a histogram with labels.
+type Histogram interface {
+	// Observe adds v to the histogram with the given label values.
+	Observe(v float64, labelValues ...string)
+}
+
+// Names of the metrics.
+const (
+	ParseDuration      = "smd_parse_duration_seconds"
+	PhaseDuration      = "smd_phase_duration_seconds"
+	OperationDuration  = "smd_operation_duration_seconds"
+	Conflicts          = "smd_conflicts_total"
+	ManagedFieldsSize  = "smd_managed_fields_size"
+	ReflectCacheHits   = "smd_reflect_cache_hits_total"
+	ReflectCacheMisses = "smd_reflect_cache_misses_total"
+)
+
+var (
+	// durationBuckets go from 100µs to about 6.5s.
+	durationBuckets = exponentialBuckets(0.0001, 2, 17)
+	// sizeBuckets go from 1 to about 65k fields.
+	sizeBuckets = exponentialBuckets(1, 4, 9)
+)
+
+func exponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// Metrics records the metrics of structured-merge-diff. It is a
+// merge.Instrumentation: set it in UpdaterBuilder.Instrumentation to
+// measure Updaters. It is safe for concurrent use if the metrics of its
+// Registry are.
+type Metrics struct {
+	parseDuration     Histogram
+	phaseDuration     Histogram
+	operationDuration Histogram
+	conflicts         Counter
+	managedFieldsSize Histogram
+}
+
+var _ merge.Instrumentation = &Metrics{}
+
+// New creates the metrics in r. The reflection cache is process-wide, so
+// its metrics are only worth registering once.
+func New(r Registry) *Metrics {
+	r.CounterFunc(ReflectCacheHits, "Number of lookups of Go types in the reflection cache that hit it.", func() float64 {
+		hits, _ := value.ReflectCacheStats()
+		return float64(hits)
+	})
+	r.CounterFunc(ReflectCacheMisses, "Number of lookups of Go types in the reflection cache that missed it.", func() float64 {
+		_, misses := value.ReflectCacheStats()
+		return float64(misses)
+	})
+	return &Metrics{
+		parseDuration:     r.Histogram(ParseDuration, "Duration of parsing and validating objects, by type.", durationBuckets, "type", "result"),
+		phaseDuration:     r.Histogram(PhaseDuration, "Duration of the phases of updates and applies.", durationBuckets, "phase"),
+		operationDuration: r.Histogram(OperationDuration, "Duration of successful updates and applies.", durationBuckets, "operation"),
+		conflicts:         r.Counter(Conflicts, "Number of conflicts failing operations.", "operation"),
+		managedFieldsSize: r.Histogram(ManagedFieldsSize, "Number of fields owned by all the managers after successful operations.", sizeBuckets, "operation"),
+	}
+}
+
+// ObservePhase implements merge.Instrumentation.
+func (m *Metrics) ObservePhase(phase merge.Phase, duration time.Duration) {
+	m.phaseDuration.Observe(duration.Seconds(), string(phase))
+}
+
+// ObserveConflicts implements merge.Instrumentation.
+func (m *Metrics) ObserveConflicts(operation string, conflicts int) {
+	m.conflicts.Add(float64(conflicts), operation)
+}
+
+// ObserveOperation implements merge.Instrumentation.
+func (m *Metrics) ObserveOperation(operation string, duration time.Duration, managedFields int) {
+	m.operationDuration.Observe(duration.Seconds(), operation)
+	m.managedFieldsSize.Observe(float64(managedFields), operation)
+}
+
+// FromUnstructured is like pt.FromUnstructured, and measures its duration.
+func (m *Metrics) FromUnstructured(pt typed.ParseableType, in interface{}, opts ...typed.ValidationOptions) (*typed.TypedValue, error) {
+	start := time.Now()
+	tv, err := pt.FromUnstructured(in, opts...)
+	m.observeParse(pt, start, err)
+	return tv, err
+}
+
+// FromStructured is like pt.FromStructured, and measures its duration.
+func (m *Metrics) FromStructured(pt typed.ParseableType, in interface{}, opts ...typed.ValidationOptions) (*typed.TypedValue, error) {
+	start := time.Now()
+	tv, err := pt.FromStructured(in, opts...)
+	m.observeParse(pt, start, err)
+	return tv, err
+}
+
+func (m *Metrics) observeParse(pt typed.ParseableType, start time.Time, err error) {
+	name, result := "", "success"
+	if pt.TypeRef.NamedType != nil {
+		name = *pt.TypeRef.NamedType
+	}
+	if err != nil {
+		result = "error"
+	}
+	m.parseDuration.Observe(time.Since(start).Seconds(), name, result)
+}
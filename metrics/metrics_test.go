@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/metrics"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// fakeRegistry records the sums and counts of its metrics by name and
+// label values.
+type fakeRegistry struct {
+	lock   sync.Mutex
+	sums   map[string]float64
+	counts map[string]int
+	funcs  map[string]func() float64
+}
+
+type fakeMetric struct {
+	r    *fakeRegistry
+	name string
+}
+
+func (m fakeMetric) Add(v float64, labelValues ...string) {
+	m.Observe(v, labelValues...)
+}
+
+func (m fakeMetric) Observe(v float64, labelValues ...string) {
+	m.r.lock.Lock()
+	defer m.r.lock.Unlock()
+	key := m.name + "{" + strings.Join(labelValues, ",") + "}"
+	m.r.sums[key] += v
+	m.r.counts[key]++
+}
+
+func (r *fakeRegistry) Counter(name, _ string, _ ...string) metrics.Counter {
+	return fakeMetric{r: r, name: name}
+}
+
+func (r *fakeRegistry) CounterFunc(name, _ string, f func() float64) {
+	r.funcs[name] = f
+}
+
+func (r *fakeRegistry) Histogram(name, _ string, _ []float64, _ ...string) metrics.Histogram {
+	return fakeMetric{r: r, name: name}
+}
+
+type converter struct{}
+
+func (converter) Convert(v *typed.TypedValue, _ fieldpath.APIVersion) (*typed.TypedValue, error) {
+	return v, nil
+}
+
+func (converter) IsMissingVersionError(error) bool {
+	return false
+}
+
+func TestMetrics(t *testing.T) {
+	r := &fakeRegistry{sums: map[string]float64{}, counts: map[string]int{}, funcs: map[string]func() float64{}}
+	m := metrics.New(r)
+	updater := (&merge.UpdaterBuilder{Converter: converter{}, Instrumentation: m}).BuildUpdater()
+	pt := typed.DeducedParseableType
+
+	live, err := m.FromUnstructured(pt, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := m.FromUnstructured(pt, map[string]interface{}{"a": int64(1), "b": int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, managers, err := updater.Apply(live, config, "v1", fieldpath.ManagedFields{}, "one", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err = m.FromStructured(pt, &struct {
+		A int64 `json:"a"`
+	}{A: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := updater.Apply(live, config, "v1", managers, "two", false); err == nil {
+		t.Fatal("expected a conflict")
+	}
+
+	for key, expected := range map[string]int{
+		"smd_parse_duration_seconds{__untyped_deduced_,success}": 3,
+		"smd_operation_duration_seconds{Apply}":                  1,
+		"smd_phase_duration_seconds{Merge}":                      2,
+	} {
+		if r.counts[key] != expected {
+			t.Errorf("expected %v observations of %v, got %v", expected, key, r.counts[key])
+		}
+	}
+	for key, expected := range map[string]float64{
+		"smd_conflicts_total{Apply}":     1,
+		"smd_managed_fields_size{Apply}": 2,
+	} {
+		if r.sums[key] != expected {
+			t.Errorf("expected %v to sum to %v, got %v", key, expected, r.sums[key])
+		}
+	}
+	if hits := r.funcs[metrics.ReflectCacheHits]() + r.funcs[metrics.ReflectCacheMisses](); hits == 0 {
+		t.Error("expected FromStructured to look up the reflection cache")
+	}
+}
@@ -61,6 +61,13 @@ type TypeRef struct {
 	// If this field is nil, then it has no effect.
 	// See `Map` and `List` for more information about `ElementRelationship`
 	ElementRelationship *ElementRelationship `yaml:"elementRelationship,omitempty"`
+
+	// MergePolicy, if set, names the merge policy which merges the values
+	// of this reference instead of the merge rules of its type, e.g. "sum"
+	// to add up numbers. Policies are registered by the programs which
+	// use them, see typed.RegisterMergePolicy; merging values with an
+	// unregistered policy fails.
+	MergePolicy string `yaml:"mergePolicy,omitempty"`
 }
 
 // Atom represents the smallest possible pieces of the type system.
@@ -143,6 +150,12 @@ type Map struct {
 	// leave this unset to get the default behavior.
 	ElementRelationship ElementRelationship `yaml:"elementRelationship,omitempty"`
 
+	// MapType and StructType are the `x-kubernetes-map-type` and
+	// `+structType` markers, `atomic` or `granular`, which can be used
+	// instead of ElementRelationship. See ApplyMarkers.
+	MapType    string `yaml:"mapType,omitempty"`
+	StructType string `yaml:"structType,omitempty"`
+
 	once sync.Once
 	m    map[string]StructField
 }
@@ -174,6 +187,8 @@ func (m *Map) CopyInto(dst *Map) {
 	dst.ElementType = m.ElementType
 	dst.Unions = m.Unions
 	dst.ElementRelationship = m.ElementRelationship
+	dst.MapType = m.MapType
+	dst.StructType = m.StructType
 
 	if m.m != nil {
 		// If cache is non-nil then the once token had been consumed.
@@ -268,6 +283,29 @@ type List struct {
 	//
 	// Each key must refer to a single field name (no nesting, not JSONPath).
 	Keys []string `yaml:"keys,omitempty"`
+
+	// MatchKeys optionally lists the subset of Keys that identifies the
+	// elements, for lists where the other keys are effectively optional:
+	// elements with the same values for these keys are then the same
+	// element, and the other keys are merged like regular fields. By
+	// default, elements are identified by all their Keys.
+	MatchKeys []string `yaml:"matchKeys,omitempty"`
+
+	// ListType and ListMapKeys are the `x-kubernetes-list-type` and
+	// `x-kubernetes-list-map-keys` markers, which can be used instead of
+	// ElementRelationship and Keys. ListType is one of `atomic`, `set`
+	// and `map`, which requires ListMapKeys. See ApplyMarkers.
+	ListType    string   `yaml:"listType,omitempty"`
+	ListMapKeys []string `yaml:"listMapKeys,omitempty"`
+}
+
+// IdentityKeys returns the keys identifying the elements of the list: its
+// MatchKeys if set, and its Keys otherwise.
+func (l *List) IdentityKeys() []string {
+	if len(l.MatchKeys) > 0 {
+		return l.MatchKeys
+	}
+	return l.Keys
 }
 
 // FindNamedType is a convenience function that returns the referenced TypeDef,
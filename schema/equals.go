@@ -55,6 +55,9 @@ func (a *TypeRef) Equals(b *TypeRef) bool {
 	if a.ElementRelationship != b.ElementRelationship {
 		return false
 	}
+	if a.MergePolicy != b.MergePolicy {
+		return false
+	}
 	return a.Inlined.Equals(&b.Inlined)
 }
 
@@ -105,6 +108,9 @@ func (a *Map) Equals(b *Map) bool {
 	if a.ElementRelationship != b.ElementRelationship {
 		return false
 	}
+	if a.MapType != b.MapType || a.StructType != b.StructType {
+		return false
+	}
 	if len(a.Fields) != len(b.Fields) {
 		return false
 	}
@@ -198,5 +204,24 @@ func (a *List) Equals(b *List) bool {
 			return false
 		}
 	}
+	if len(a.MatchKeys) != len(b.MatchKeys) {
+		return false
+	}
+	for i := range a.MatchKeys {
+		if a.MatchKeys[i] != b.MatchKeys[i] {
+			return false
+		}
+	}
+	if a.ListType != b.ListType {
+		return false
+	}
+	if len(a.ListMapKeys) != len(b.ListMapKeys) {
+		return false
+	}
+	for i := range a.ListMapKeys {
+		if a.ListMapKeys[i] != b.ListMapKeys[i] {
+			return false
+		}
+	}
 	return true
 }
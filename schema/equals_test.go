@@ -97,6 +97,7 @@ func TestEquals(t *testing.T) {
 			var y TypeRef
 			y.NamedType = x.NamedType
 			y.Inlined = x.Inlined
+			y.MergePolicy = x.MergePolicy
 			return x.Equals(&y) == reflect.DeepEqual(x, y)
 		},
 		func(x Atom) bool {
@@ -118,6 +119,8 @@ func TestEquals(t *testing.T) {
 			y.ElementRelationship = x.ElementRelationship
 			y.Fields = x.Fields
 			y.Unions = x.Unions
+			y.MapType = x.MapType
+			y.StructType = x.StructType
 			return x.Equals(&y) == reflect.DeepEqual(x, &y)
 		},
 		func(x Union) bool {
@@ -157,6 +160,9 @@ func TestEquals(t *testing.T) {
 			y.ElementType = x.ElementType
 			y.ElementRelationship = x.ElementRelationship
 			y.Keys = x.Keys
+			y.MatchKeys = x.MatchKeys
+			y.ListType = x.ListType
+			y.ListMapKeys = x.ListMapKeys
 			return x.Equals(&y) == reflect.DeepEqual(x, y)
 		},
 	}
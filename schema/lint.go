@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import "fmt"
+
+// Severity is the severity of a LintProblem.
+type Severity string
+
+const (
+	// SeverityError marks problems that make the schema behave
+	// differently than it says, or fail on valid objects.
+	SeverityError = Severity("error")
+	// SeverityWarning marks suspicious but usable constructs.
+	SeverityWarning = Severity("warning")
+)
+
+// LintProblem is a problem found in a schema by Lint.
+type LintProblem struct {
+	Severity Severity
+	// Path locates the problem in the schema, as in
+	// types[name=foo].map.fields[name=bar].type.
+	Path    string
+	Message string
+}
+
+// String returns a human readable description of the problem.
+func (p LintProblem) String() string {
+	return fmt.Sprintf("%v: %v: %v", p.Severity, p.Path, p.Message)
+}
+
+// Lint returns the problems of s that parsing it doesn't catch: references
+// to missing types, invalid element relationships, list keys that aren't
+// scalar fields of their elements or whose defaults don't match their type,
+// match keys that aren't keys,
+// inconsistent unions and incoherent markers, among others.
+func Lint(s *Schema) []LintProblem {
+	l := linter{schema: s}
+	names := map[string]bool{}
+	for _, t := range s.Types {
+		path := fmt.Sprintf("types[name=%v]", t.Name)
+		if t.Name == "" {
+			l.errorf(path, "type has no name")
+		} else if names[t.Name] {
+			l.errorf(path, "duplicate type name %q", t.Name)
+		}
+		names[t.Name] = true
+		l.atom(path, t.Atom)
+	}
+	return l.problems
+}
+
+type linter struct {
+	schema   *Schema
+	problems []LintProblem
+}
+
+func (l *linter) errorf(path, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{SeverityError, path, fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warningf(path, format string, args ...interface{}) {
+	l.problems = append(l.problems, LintProblem{SeverityWarning, path, fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) typeRef(path string, tr TypeRef) {
+	empty := tr.Inlined == Atom{}
+	if tr.NamedType != nil {
+		if !empty {
+			l.warningf(path, "both namedType and an inlined type are set, the inlined type is ignored")
+		}
+		if _, ok := l.schema.FindNamedType(*tr.NamedType); !ok {
+			l.errorf(path, "unknown type %q", *tr.NamedType)
+			return
+		}
+	} else {
+		l.atom(path, tr.Inlined)
+	}
+	if tr.ElementRelationship == nil {
+		return
+	}
+	a, ok := l.schema.resolveNoOverrides(tr)
+	switch er := *tr.ElementRelationship; {
+	case !ok:
+	case a.Map != nil:
+		if er != Separable && er != Atomic {
+			l.errorf(path, "invalid elementRelationship %q for a map", er)
+		}
+	case a.List != nil:
+		if er != Associative && er != Atomic {
+			l.errorf(path, "invalid elementRelationship %q for a list", er)
+		}
+	default:
+		l.errorf(path, "elementRelationship %q set on a type that is neither a map nor a list", er)
+	}
+}
+
+func (l *linter) atom(path string, a Atom) {
+	if a.Scalar == nil && a.List == nil && a.Map == nil {
+		l.warningf(path, "type is neither a scalar, a list nor a map, so no value is valid")
+	}
+	if a.Scalar != nil {
+		switch *a.Scalar {
+		case Numeric, String, Boolean, Untyped:
+		default:
+			l.errorf(path+".scalar", "unknown scalar type %q", *a.Scalar)
+		}
+	}
+	if a.List != nil {
+		l.list(path+".list", a.List)
+	}
+	if a.Map != nil {
+		l.mapType(path+".map", a.Map)
+	}
+}
+
+func (l *linter) list(path string, list *List) {
+	l.typeRef(path+".elementType", list.ElementType)
+	er, keys, err := list.resolveMarkers()
+	if err != nil {
+		l.errorf(path, "%v", err)
+		return
+	}
+	for _, key := range list.MatchKeys {
+		if !containsString(keys, key) {
+			l.errorf(path, "match key %q is not a key", key)
+		}
+	}
+	switch er {
+	case Atomic:
+		if len(keys) > 0 {
+			l.warningf(path, "keys are ignored on atomic lists")
+		}
+		return
+	case Associative:
+	case "":
+		l.warningf(path, "elementRelationship is not set, the list is atomic")
+		return
+	default:
+		l.errorf(path, "invalid elementRelationship %q for a list", er)
+		return
+	}
+
+	element, ok := l.schema.Resolve(list.ElementType)
+	if !ok {
+		return
+	}
+	switch {
+	case element.Map != nil:
+		if len(keys) == 0 {
+			l.errorf(path, "associative list of maps has no keys")
+		}
+		for _, key := range keys {
+			f, ok := element.Map.FindField(key)
+			if !ok {
+				l.errorf(path, "key %q is not a field of the elements", key)
+				continue
+			}
+			a, ok := l.schema.Resolve(f.Type)
+			if ok && (a.Scalar == nil || a.List != nil || a.Map != nil) {
+				l.errorf(path, "key %q is not a scalar", key)
+			} else if ok && f.Default != nil && !scalarAllows(*a.Scalar, f.Default) {
+				l.errorf(path, "default %v of key %q is not a valid %v", f.Default, key, *a.Scalar)
+			}
+		}
+	case element.List != nil:
+		l.errorf(path, "associative lists of lists aren't supported")
+	case len(keys) > 0:
+		l.errorf(path, "keys are set on a list of scalars")
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarAllows returns whether v, as decoded from YAML or JSON, is a value
+// of the scalar type s. Unknown scalar types are reported separately.
+func scalarAllows(s Scalar, v interface{}) bool {
+	switch v.(type) {
+	case string:
+		return s == String || s == Untyped
+	case bool:
+		return s == Boolean || s == Untyped
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return s == Numeric || s == Untyped
+	}
+	return false
+}
+
+func (l *linter) mapType(path string, m *Map) {
+	if er, err := m.resolveMarkers(); err != nil {
+		l.errorf(path, "%v", err)
+	} else if er != "" && er != Separable && er != Atomic {
+		l.errorf(path, "invalid elementRelationship %q for a map", er)
+	}
+	fields := map[string]bool{}
+	for _, f := range m.Fields {
+		fieldPath := fmt.Sprintf("%v.fields[name=%v]", path, f.Name)
+		if fields[f.Name] {
+			l.errorf(fieldPath, "duplicate field %q", f.Name)
+		}
+		fields[f.Name] = true
+		l.typeRef(fieldPath+".type", f.Type)
+	}
+	if (m.ElementType != TypeRef{}) {
+		l.typeRef(path+".elementType", m.ElementType)
+	}
+
+	inUnion := map[string]bool{}
+	for i, u := range m.Unions {
+		unionPath := fmt.Sprintf("%v.unions[%v]", path, i)
+		if u.Discriminator != nil && !fields[*u.Discriminator] {
+			l.errorf(unionPath, "discriminator %q is not a field", *u.Discriminator)
+		}
+		values := map[string]bool{}
+		for _, f := range u.Fields {
+			if !fields[f.FieldName] {
+				l.errorf(unionPath, "field %q is not a field", f.FieldName)
+			}
+			if inUnion[f.FieldName] {
+				l.errorf(unionPath, "field %q is in several unions", f.FieldName)
+			}
+			inUnion[f.FieldName] = true
+			if u.Discriminator != nil {
+				if values[f.DiscriminatorValue] {
+					l.errorf(unionPath, "duplicate discriminator value %q", f.DiscriminatorValue)
+				}
+				values[f.DiscriminatorValue] = true
+			}
+		}
+	}
+}
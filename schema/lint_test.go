@@ -0,0 +1,291 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		expected []string
+	}{{
+		name: "valid",
+		schema: `types:
+- name: object
+  map:
+    fields:
+    - name: items
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+          keys: [name]
+    - name: tags
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: ref
+      type:
+        namedType: item
+        elementRelationship: atomic
+- name: item
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+`,
+	}, {
+		name: "types",
+		schema: `types:
+- name: a
+  scalar: text
+- name: a
+- name: ""
+  map:
+    fields:
+    - name: x
+      type:
+        namedType: missing
+    - name: x
+      type:
+        namedType: a
+        elementRelationship: atomic
+`,
+		expected: []string{
+			`error: types[name=a].scalar: unknown scalar type "text"`,
+			`error: types[name=a]: duplicate type name "a"`,
+			`warning: types[name=a]: type is neither a scalar, a list nor a map, so no value is valid`,
+			`error: types[name=]: type has no name`,
+			`error: types[name=].map.fields[name=x].type: unknown type "missing"`,
+			`error: types[name=].map.fields[name=x]: duplicate field "x"`,
+			`error: types[name=].map.fields[name=x].type: elementRelationship "atomic" set on a type that is neither a map nor a list`,
+		},
+	}, {
+		name: "lists",
+		schema: `types:
+- name: item
+  map:
+    elementRelationship: associative
+    fields:
+    - name: name
+      type:
+        map:
+          elementType:
+            scalar: string
+- name: lists
+  map:
+    fields:
+    - name: nokeys
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+    - name: badkeys
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+          keys: [name, id]
+    - name: scalars
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+          keys: [name]
+    - name: unset
+      type:
+        list:
+          elementType:
+            scalar: string
+    - name: granular
+      type:
+        namedType: item
+        elementRelationship: granular
+`,
+		expected: []string{
+			`error: types[name=item].map: invalid elementRelationship "associative" for a map`,
+			`error: types[name=lists].map.fields[name=nokeys].type.list: associative list of maps has no keys`,
+			`error: types[name=lists].map.fields[name=badkeys].type.list: key "name" is not a scalar`,
+			`error: types[name=lists].map.fields[name=badkeys].type.list: key "id" is not a field of the elements`,
+			`error: types[name=lists].map.fields[name=scalars].type.list: keys are set on a list of scalars`,
+			`warning: types[name=lists].map.fields[name=unset].type.list: elementRelationship is not set, the list is atomic`,
+			`error: types[name=lists].map.fields[name=granular].type: invalid elementRelationship "granular" for a map`,
+		},
+	}, {
+		name: "unions",
+		schema: `types:
+- name: union
+  map:
+    fields:
+    - name: kind
+      type:
+        scalar: string
+    - name: a
+      type:
+        scalar: string
+    - name: b
+      type:
+        scalar: string
+    unions:
+    - discriminator: type
+      fields:
+      - fieldName: a
+        discriminatorValue: A
+      - fieldName: b
+        discriminatorValue: A
+    - fields:
+      - fieldName: a
+      - fieldName: c
+`,
+		expected: []string{
+			`error: types[name=union].map.unions[0]: discriminator "type" is not a field`,
+			`error: types[name=union].map.unions[0]: duplicate discriminator value "A"`,
+			`error: types[name=union].map.unions[1]: field "a" is in several unions`,
+			`error: types[name=union].map.unions[1]: field "c" is not a field`,
+		},
+	}, {
+		name: "markers",
+		schema: `types:
+- name: object
+  map:
+    mapType: granular
+    fields:
+    - name: items
+      type:
+        list:
+          elementType:
+            namedType: item
+          listType: map
+          listMapKeys: [name]
+    - name: unkeyed
+      type:
+        list:
+          elementType:
+            namedType: item
+          listType: set
+    - name: contradiction
+      type:
+        list:
+          elementType:
+            scalar: string
+          listType: atomic
+          elementRelationship: associative
+- name: item
+  map:
+    mapType: atomic
+    structType: granular
+    fields:
+    - name: name
+      type:
+        scalar: string
+`,
+		expected: []string{
+			`error: types[name=object].map.fields[name=unkeyed].type.list: associative list of maps has no keys`,
+			`error: types[name=object].map.fields[name=contradiction].type.list: listType "atomic" contradicts elementRelationship "associative"`,
+			`error: types[name=item].map: mapType "atomic" contradicts structType "granular"`,
+		},
+	}, {
+		name: "key defaults",
+		schema: `types:
+- name: item
+  map:
+    fields:
+    - name: port
+      default: 80
+      type:
+        scalar: numeric
+    - name: protocol
+      default: 6
+      type:
+        scalar: string
+    - name: name
+      default: [a]
+      type:
+        scalar: untyped
+- name: ports
+  list:
+    elementType:
+      namedType: item
+    elementRelationship: associative
+    keys: [port, protocol, name]
+`,
+		expected: []string{
+			`error: types[name=ports].list: default 6 of key "protocol" is not a valid string`,
+			`error: types[name=ports].list: default [a] of key "name" is not a valid untyped`,
+		},
+	}, {
+		name: "match keys",
+		schema: `types:
+- name: item
+  map:
+    fields:
+    - name: host
+      type:
+        scalar: string
+    - name: path
+      type:
+        scalar: string
+- name: routes
+  list:
+    elementType:
+      namedType: item
+    elementRelationship: associative
+    keys: [host, path]
+    matchKeys: [host, port]
+- name: unkeyed
+  list:
+    elementType:
+      scalar: string
+    elementRelationship: associative
+    matchKeys: [host]
+`,
+		expected: []string{
+			`error: types[name=routes].list: match key "port" is not a key`,
+			`error: types[name=unkeyed].list: match key "host" is not a key`,
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var s Schema
+			if err := yaml.Unmarshal([]byte(tt.schema), &s); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range Lint(&s) {
+				got = append(got, p.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", tt.expected, got)
+			}
+		})
+	}
+}
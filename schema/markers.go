@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import "fmt"
+
+// The values of the ListType, MapType and StructType markers.
+const (
+	ListTypeAtomic = "atomic"
+	ListTypeSet    = "set"
+	ListTypeMap    = "map"
+
+	MapTypeAtomic   = "atomic"
+	MapTypeGranular = "granular"
+)
+
+// ApplyMarkers sets the element relationships and keys of the lists and
+// maps of s that have markers, e.g. `listType: map`, to the ones they stand
+// for, so that schemas can be written with markers instead. It fails if
+// the markers of a type are incoherent with each other, or with its
+// element relationship or keys if they are set too.
+//
+// It modifies s, so it must be called before s is used.
+func ApplyMarkers(s *Schema) error {
+	for _, t := range s.Types {
+		if err := applyAtomMarkers(fmt.Sprintf("types[name=%v]", t.Name), t.Atom); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyAtomMarkers(path string, a Atom) error {
+	if a.List != nil {
+		er, keys, err := a.List.resolveMarkers()
+		if err != nil {
+			return fmt.Errorf("%v.list: %v", path, err)
+		}
+		a.List.ElementRelationship, a.List.Keys = er, keys
+		if err := applyTypeRefMarkers(path+".list.elementType", a.List.ElementType); err != nil {
+			return err
+		}
+	}
+	if a.Map != nil {
+		er, err := a.Map.resolveMarkers()
+		if err != nil {
+			return fmt.Errorf("%v.map: %v", path, err)
+		}
+		a.Map.ElementRelationship = er
+		for _, f := range a.Map.Fields {
+			if err := applyTypeRefMarkers(fmt.Sprintf("%v.map.fields[name=%v].type", path, f.Name), f.Type); err != nil {
+				return err
+			}
+		}
+		if err := applyTypeRefMarkers(path+".map.elementType", a.Map.ElementType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyTypeRefMarkers(path string, tr TypeRef) error {
+	if tr.NamedType != nil {
+		return nil
+	}
+	return applyAtomMarkers(path, tr.Inlined)
+}
+
+// resolveMarkers returns the element relationship and keys of l, as set
+// directly or by its markers.
+func (l *List) resolveMarkers() (ElementRelationship, []string, error) {
+	if l.ListType == "" {
+		if len(l.ListMapKeys) > 0 {
+			return "", nil, fmt.Errorf("listMapKeys requires listType %q", ListTypeMap)
+		}
+		return l.ElementRelationship, l.Keys, nil
+	}
+	var er ElementRelationship
+	switch l.ListType {
+	case ListTypeAtomic:
+		er = Atomic
+	case ListTypeSet, ListTypeMap:
+		er = Associative
+	default:
+		return "", nil, fmt.Errorf("unknown listType %q", l.ListType)
+	}
+	if l.ListType == ListTypeMap && len(l.ListMapKeys) == 0 {
+		return "", nil, fmt.Errorf("listType %q requires listMapKeys", ListTypeMap)
+	}
+	if l.ListType != ListTypeMap && len(l.ListMapKeys) > 0 {
+		return "", nil, fmt.Errorf("listMapKeys requires listType %q, not %q", ListTypeMap, l.ListType)
+	}
+	if l.ElementRelationship != "" && l.ElementRelationship != er {
+		return "", nil, fmt.Errorf("listType %q contradicts elementRelationship %q", l.ListType, l.ElementRelationship)
+	}
+	if len(l.Keys) > 0 && !sameStrings(l.Keys, l.ListMapKeys) {
+		return "", nil, fmt.Errorf("listMapKeys %q contradict keys %q", l.ListMapKeys, l.Keys)
+	}
+	return er, l.ListMapKeys, nil
+}
+
+// resolveMarkers returns the element relationship of m, as set directly or
+// by its markers.
+func (m *Map) resolveMarkers() (ElementRelationship, error) {
+	mapER, err := mapMarker("mapType", m.MapType)
+	if err != nil {
+		return "", err
+	}
+	structER, err := mapMarker("structType", m.StructType)
+	if err != nil {
+		return "", err
+	}
+	name, value, er := "mapType", m.MapType, mapER
+	switch {
+	case mapER != "" && structER != "" && mapER != structER:
+		return "", fmt.Errorf("mapType %q contradicts structType %q", m.MapType, m.StructType)
+	case mapER == "" && structER == "":
+		return m.ElementRelationship, nil
+	case mapER == "":
+		name, value, er = "structType", m.StructType, structER
+	}
+	if m.ElementRelationship != "" && m.ElementRelationship != er {
+		return "", fmt.Errorf("%v %q contradicts elementRelationship %q", name, value, m.ElementRelationship)
+	}
+	return er, nil
+}
+
+// mapMarker returns the element relationship a mapType or structType
+// marker stands for, if set.
+func mapMarker(name, value string) (ElementRelationship, error) {
+	switch value {
+	case "":
+		return "", nil
+	case MapTypeAtomic:
+		return Atomic, nil
+	case MapTypeGranular:
+		return Separable, nil
+	}
+	return "", fmt.Errorf("unknown %v %q", name, value)
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
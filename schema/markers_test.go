@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestApplyMarkers(t *testing.T) {
+	var s Schema
+	if err := yaml.Unmarshal([]byte(`types:
+- name: object
+  map:
+    structType: atomic
+    fields:
+    - name: items
+      type:
+        list:
+          elementType:
+            map:
+              mapType: granular
+              fields:
+              - name: name
+                type:
+                  scalar: string
+          listType: map
+          listMapKeys: [name]
+    - name: tags
+      type:
+        list:
+          elementType:
+            scalar: string
+          listType: set
+          elementRelationship: associative
+    - name: atomic
+      type:
+        list:
+          elementType:
+            scalar: string
+          listType: atomic
+`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyMarkers(&s); err != nil {
+		t.Fatal(err)
+	}
+	object, _ := s.FindNamedType("object")
+	if object.Map.ElementRelationship != Atomic {
+		t.Errorf("expected structType atomic to make the map atomic, got %q", object.Map.ElementRelationship)
+	}
+	items, _ := object.Map.FindField("items")
+	if l := items.Type.Inlined.List; l.ElementRelationship != Associative || !reflect.DeepEqual(l.Keys, []string{"name"}) {
+		t.Errorf("expected an associative list keyed by name, got %q %q", l.ElementRelationship, l.Keys)
+	}
+	if m := items.Type.Inlined.List.ElementType.Inlined.Map; m.ElementRelationship != Separable {
+		t.Errorf("expected mapType granular to make the map separable, got %q", m.ElementRelationship)
+	}
+	tags, _ := object.Map.FindField("tags")
+	if l := tags.Type.Inlined.List; l.ElementRelationship != Associative || len(l.Keys) != 0 {
+		t.Errorf("expected a set, got %q %q", l.ElementRelationship, l.Keys)
+	}
+	atomic, _ := object.Map.FindField("atomic")
+	if l := atomic.Type.Inlined.List; l.ElementRelationship != Atomic {
+		t.Errorf("expected an atomic list, got %q", l.ElementRelationship)
+	}
+}
+
+func TestApplyMarkersErrors(t *testing.T) {
+	for _, tc := range []struct {
+		atom string
+		want string
+	}{
+		{`list: {listType: map}`, `types[name=t].list: listType "map" requires listMapKeys`},
+		{`list: {listType: set, listMapKeys: [a]}`, `types[name=t].list: listMapKeys requires listType "map", not "set"`},
+		{`list: {listMapKeys: [a]}`, `types[name=t].list: listMapKeys requires listType "map"`},
+		{`list: {listType: bag}`, `types[name=t].list: unknown listType "bag"`},
+		{`list: {listType: map, listMapKeys: [a], keys: [b]}`, `types[name=t].list: listMapKeys ["a"] contradict keys ["b"]`},
+		{`map: {mapType: atomic, elementRelationship: separable}`, `types[name=t].map: mapType "atomic" contradicts elementRelationship "separable"`},
+		{`map: {structType: partial}`, `types[name=t].map: unknown structType "partial"`},
+	} {
+		var s Schema
+		if err := yaml.Unmarshal([]byte("types:\n- name: t\n  "+tc.atom+"\n"), &s); err != nil {
+			t.Fatal(err)
+		}
+		if err := ApplyMarkers(&s); err == nil || err.Error() != tc.want {
+			t.Errorf("%v: expected %q, got %v", tc.atom, tc.want, err)
+		}
+	}
+}
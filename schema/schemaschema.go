@@ -69,6 +69,9 @@ var SchemaSchemaYAML = `types:
     - name: elementRelationship
       type:
         scalar: string
+    - name: mergePolicy
+      type:
+        scalar: string
 - name: scalar
   scalar: string
 - name: map
@@ -93,6 +96,12 @@ var SchemaSchemaYAML = `types:
     - name: elementRelationship
       type:
         scalar: string
+    - name: mapType
+      type:
+        scalar: string
+    - name: structType
+      type:
+        scalar: string
 - name: unionField
   map:
     fields:
@@ -146,6 +155,21 @@ var SchemaSchemaYAML = `types:
           elementType:
             scalar: string
           elementRelationship: atomic
+    - name: matchKeys
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: listType
+      type:
+        scalar: string
+    - name: listMapKeys
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
 - name: untyped
   map:
     fields:
@@ -0,0 +1,391 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaconv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+)
+
+// The names of the types of values with no schema, as in
+// typed.DeducedParseableType: maps are separable, and everything else
+// atomic.
+const (
+	untypedAtomic  = "__untyped_atomic_"
+	untypedDeduced = "__untyped_deduced_"
+)
+
+// FromOpenAPI converts the schemas of an OpenAPI v3 document, or the
+// definitions of a v2 one, to types named after them.
+func FromOpenAPI(doc *OpenAPI) (*schema.Schema, error) {
+	c := newConverter("")
+	for name, js := range doc.Definitions {
+		c.define(name, js)
+	}
+	for name, js := range doc.Components.Schemas {
+		c.define(name, js)
+	}
+	return c.result()
+}
+
+// GnosticDocument is an OpenAPI v2 document of gnostic, i.e. the
+// *openapi_v2.Document decoded from the protobuf form of the document that
+// Kubernetes apiservers serve at /openapi/v2.
+type GnosticDocument interface {
+	// YAMLValue returns the document serialized as YAML.
+	YAMLValue(comment string) ([]byte, error)
+}
+
+// FromGnosticV2 converts the definitions of an OpenAPI v2 document of
+// gnostic to types named after them, like FromOpenAPI.
+func FromGnosticV2(doc GnosticDocument) (*schema.Schema, error) {
+	b, err := doc.YAMLValue("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the document: %v", err)
+	}
+	var openAPI OpenAPI
+	if err := yaml.Unmarshal(b, &openAPI); err != nil {
+		return nil, fmt.Errorf("failed to parse the document: %v", err)
+	}
+	if openAPI.OpenAPI != "" {
+		return nil, fmt.Errorf("expected an OpenAPI v2 document, got version %v", openAPI.OpenAPI)
+	}
+	return FromOpenAPI(&openAPI)
+}
+
+// FromJSONSchema converts a JSON Schema to a type named root, and its
+// definitions to types named after them.
+func FromJSONSchema(doc *JSONSchemaDocument, root string) (*schema.Schema, error) {
+	c := newConverter(root)
+	c.define(root, &doc.JSONSchema)
+	for name, js := range doc.Defs {
+		c.define(name, js)
+	}
+	for name, js := range doc.Definitions {
+		c.define(name, js)
+	}
+	return c.result()
+}
+
+// FromCRD converts the schema of each version of a CustomResource
+// Definition to a type named after its group, version and kind in the
+// style of the Kubernetes types, as in com.example.v1.Widget.
+func FromCRD(crd *CustomResourceDefinition) (*schema.Schema, error) {
+	if crd.Kind != "" && crd.Kind != "CustomResourceDefinition" {
+		return nil, fmt.Errorf("expected a CustomResourceDefinition, got a %v", crd.Kind)
+	}
+	c := newConverter("")
+	for _, v := range crd.Spec.Versions {
+		js := (*JSONSchema)(nil)
+		if v.Schema != nil {
+			js = v.Schema.OpenAPIV3Schema
+		} else if crd.Spec.Validation != nil {
+			js = crd.Spec.Validation.OpenAPIV3Schema
+		}
+		c.define(CRDTypeName(crd.Spec.Group, v.Name, crd.Spec.Names.Kind), js)
+	}
+	if len(crd.Spec.Versions) == 0 && crd.Spec.Validation != nil {
+		c.define(CRDTypeName(crd.Spec.Group, crd.Spec.Version, crd.Spec.Names.Kind), crd.Spec.Validation.OpenAPIV3Schema)
+	}
+	return c.result()
+}
+
+// CRDTypeName returns the name FromCRD gives to the type of a version of a
+// custom resource.
+func CRDTypeName(group, version, kind string) string {
+	parts := strings.Split(group, ".")
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(append(parts, version, kind), ".")
+}
+
+type converter struct {
+	root  string
+	types []schema.TypeDef
+	// untypedUsed holds the untyped types referred to.
+	untypedUsed map[string]bool
+	problems    []string
+}
+
+func newConverter(root string) *converter {
+	return &converter{root: root, untypedUsed: map[string]bool{}}
+}
+
+func (c *converter) errorf(path, format string, args ...interface{}) {
+	c.problems = append(c.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// define adds the type of js named name.
+func (c *converter) define(name string, js *JSONSchema) {
+	atom, ok := c.atom(name, js)
+	if !ok {
+		// Types can't be aliases, so copy the untyped one, which
+		// refers to the atomic one.
+		untyped := untypedTypes()
+		atom = untyped[1].Atom
+		if *c.untyped(js).NamedType == untypedAtomic {
+			atom = untyped[0].Atom
+		}
+		c.untypedUsed[untypedAtomic] = true
+	}
+	c.types = append(c.types, schema.TypeDef{Name: name, Atom: atom})
+}
+
+func (c *converter) result() (*schema.Schema, error) {
+	if len(c.problems) > 0 {
+		return nil, errors.New(strings.Join(c.problems, "\n"))
+	}
+	sort.Slice(c.types, func(i, j int) bool { return c.types[i].Name < c.types[j].Name })
+	// The deduced type refers to the atomic one, but not the other way
+	// around.
+	switch untyped := untypedTypes(); {
+	case c.untypedUsed[untypedDeduced]:
+		c.types = append(c.types, untyped...)
+	case c.untypedUsed[untypedAtomic]:
+		c.types = append(c.types, untyped[0])
+	}
+	return &schema.Schema{Types: c.types}, nil
+}
+
+// untypedTypes returns the definitions of the untyped types.
+func untypedTypes() []schema.TypeDef {
+	untyped := schema.Untyped
+	atomic, deduced := untypedAtomic, untypedDeduced
+	return []schema.TypeDef{{
+		Name: untypedAtomic,
+		Atom: schema.Atom{
+			Scalar: &untyped,
+			List:   &schema.List{ElementType: schema.TypeRef{NamedType: &atomic}, ElementRelationship: schema.Atomic},
+			Map:    &schema.Map{ElementType: schema.TypeRef{NamedType: &atomic}, ElementRelationship: schema.Atomic},
+		},
+	}, {
+		Name: untypedDeduced,
+		Atom: schema.Atom{
+			Scalar: &untyped,
+			List:   &schema.List{ElementType: schema.TypeRef{NamedType: &atomic}, ElementRelationship: schema.Atomic},
+			Map:    &schema.Map{ElementType: schema.TypeRef{NamedType: &deduced}, ElementRelationship: schema.Separable},
+		},
+	}}
+}
+
+func (c *converter) deduced() schema.TypeRef {
+	return c.untypedRef(untypedDeduced)
+}
+
+// untyped returns the untyped type for js, which is atomic if js is.
+func (c *converter) untyped(js *JSONSchema) schema.TypeRef {
+	if js != nil && js.MapType == "atomic" {
+		return c.untypedRef(untypedAtomic)
+	}
+	return c.deduced()
+}
+
+func (c *converter) untypedRef(name string) schema.TypeRef {
+	c.untypedUsed[name] = true
+	return schema.TypeRef{NamedType: &name}
+}
+
+// refName returns the name of the type a reference refers to.
+func (c *converter) refName(path, ref string) string {
+	if ref == "#" && c.root != "" {
+		return c.root
+	}
+	for _, prefix := range []string{"#/components/schemas/", "#/definitions/", "#/$defs/"} {
+		if strings.HasPrefix(ref, prefix) {
+			return strings.TrimPrefix(ref, prefix)
+		}
+	}
+	c.errorf(path, "unsupported reference %q", ref)
+	return ref
+}
+
+func (c *converter) typeRef(path string, js *JSONSchema) schema.TypeRef {
+	if js == nil {
+		return c.deduced()
+	}
+	if js.Ref != "" {
+		name := c.refName(path, js.Ref)
+		return schema.TypeRef{NamedType: &name}
+	}
+	if len(js.AllOf) == 1 && js.AllOf[0].Ref != "" && js.Type == "" && len(js.Properties) == 0 && js.Items == nil {
+		// The allOf of a single reference is how OpenAPI v3 adds
+		// properties, such as the element relationship, to a
+		// reference.
+		tr := c.typeRef(path+".allOf[0]", js.AllOf[0])
+		var er schema.ElementRelationship
+		switch {
+		case js.MapType != "":
+			er = c.mapRelationship(path, js.MapType)
+		case js.ListType != "":
+			er, _ = c.listRelationship(path, js)
+		}
+		if er != "" {
+			tr.ElementRelationship = &er
+		}
+		return tr
+	}
+	atom, ok := c.atom(path, js)
+	if !ok {
+		return c.untyped(js)
+	}
+	return schema.TypeRef{Inlined: atom}
+}
+
+// atom returns the atom of js, or false if its values are untyped.
+func (c *converter) atom(path string, js *JSONSchema) (schema.Atom, bool) {
+	if js == nil {
+		return schema.Atom{}, false
+	}
+	if js.Ref != "" || len(js.AllOf) > 0 {
+		// Named types can't be aliases of others, and allOf can't be
+		// expressed, so they are untyped.
+		return schema.Atom{}, false
+	}
+	if js.IntOrString || js.Format == "int-or-string" {
+		// The int-or-string format is how OpenAPI v2 documents of
+		// Kubernetes declare them.
+		return scalarAtom(schema.Untyped), true
+	}
+	if alternatives := append(append([]*JSONSchema{}, js.AnyOf...), js.OneOf...); len(alternatives) > 0 && js.Type == "" {
+		for _, alt := range alternatives {
+			if _, ok := scalarType(alt.Type); !ok || len(alt.Properties) > 0 || alt.Items != nil {
+				return schema.Atom{}, false
+			}
+		}
+		return scalarAtom(schema.Untyped), true
+	}
+
+	switch {
+	case js.Type == "array" || (js.Type == "" && js.Items != nil):
+		er, keys := c.listRelationship(path, js)
+		return schema.Atom{List: &schema.List{
+			ElementType:         c.typeRef(path+".items", js.Items),
+			ElementRelationship: er,
+			Keys:                keys,
+		}}, true
+	case js.Type == "object" || (js.Type == "" && (len(js.Properties) > 0 || js.AdditionalProperties != nil)):
+		return schema.Atom{Map: c.mapType(path, js)}, true
+	case js.Type == "" || js.Type == "null":
+		return schema.Atom{}, false
+	}
+	scalar, ok := scalarType(js.Type)
+	if !ok {
+		c.errorf(path, "unknown type %q", js.Type)
+		return schema.Atom{}, false
+	}
+	return scalarAtom(scalar), true
+}
+
+func scalarAtom(s schema.Scalar) schema.Atom {
+	return schema.Atom{Scalar: &s}
+}
+
+func scalarType(t string) (schema.Scalar, bool) {
+	switch t {
+	case "string":
+		return schema.String, true
+	case "integer", "number":
+		return schema.Numeric, true
+	case "boolean":
+		return schema.Boolean, true
+	}
+	return "", false
+}
+
+func (c *converter) mapType(path string, js *JSONSchema) *schema.Map {
+	m := &schema.Map{ElementRelationship: c.mapRelationship(path, js.MapType)}
+	names := make([]string, 0, len(js.Properties))
+	for name := range js.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.Fields = append(m.Fields, schema.StructField{
+			Name:    name,
+			Type:    c.typeRef(path+".properties."+name, js.Properties[name]),
+			Default: js.Properties[name].Default,
+		})
+	}
+	if js.EmbeddedResource {
+		// Embedded resources can always have the fields of objects.
+		for _, f := range []string{"apiVersion", "kind", "metadata"} {
+			if _, ok := js.Properties[f]; ok {
+				continue
+			}
+			tr := schema.TypeRef{Inlined: scalarAtom(schema.String)}
+			if f == "metadata" {
+				tr = c.deduced()
+			}
+			m.Fields = append(m.Fields, schema.StructField{Name: f, Type: tr})
+		}
+	}
+	switch ap := js.AdditionalProperties; {
+	case ap != nil && ap.Schema != nil:
+		m.ElementType = c.typeRef(path+".additionalProperties", ap.Schema)
+	case ap != nil && ap.Allows, js.PreserveUnknownFields, ap == nil && len(js.Properties) == 0:
+		// Objects without properties allow any, as in JSON Schema, but
+		// those with properties only allow them, as in structural
+		// schemas, unless they preserve unknown fields.
+		m.ElementType = c.deduced()
+	}
+	return m
+}
+
+func (c *converter) mapRelationship(path, mapType string) schema.ElementRelationship {
+	switch mapType {
+	case "", "granular":
+		return ""
+	case "atomic":
+		return schema.Atomic
+	}
+	c.errorf(path, "unknown x-kubernetes-map-type %q", mapType)
+	return ""
+}
+
+// listRelationship returns the element relationship and keys of a list,
+// following the patch strategy of the Kubernetes types without a list
+// type, as Kubernetes does.
+func (c *converter) listRelationship(path string, js *JSONSchema) (schema.ElementRelationship, []string) {
+	switch js.ListType {
+	case "":
+		if strings.Contains(js.PatchStrategy, "merge") {
+			if js.PatchMergeKey != "" {
+				return schema.Associative, []string{js.PatchMergeKey}
+			}
+			return schema.Associative, nil
+		}
+		return schema.Atomic, nil
+	case "atomic":
+		return schema.Atomic, nil
+	case "set":
+		return schema.Associative, nil
+	case "map":
+		if len(js.ListMapKeys) == 0 {
+			c.errorf(path, "x-kubernetes-list-type map requires x-kubernetes-list-map-keys")
+		}
+		return schema.Associative, js.ListMapKeys
+	}
+	c.errorf(path, "unknown x-kubernetes-list-type %q", js.ListType)
+	return schema.Atomic, nil
+}
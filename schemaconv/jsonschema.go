@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schemaconv converts between the schemas of this module and the
+// JSON Schema based ones of OpenAPI v2 and v3 documents, CustomResource
+// Definitions and JSON Schema documents, including the x-kubernetes
+// extensions which determine how their values are merged.
+//
+// Not everything converts: oneOf and anyOf alternatives that aren't all
+// scalars become untyped values, validations such as patterns and bounds
+// are dropped, and unions of the schemas of this module aren't exported.
+package schemaconv
+
+import "fmt"
+
+// JSONSchema is the subset of a JSON Schema, as used by OpenAPI and
+// CustomResourceDefinitions, that determines the structure of values.
+type JSONSchema struct {
+	Ref         string      `yaml:"$ref,omitempty"`
+	Description string      `yaml:"description,omitempty"`
+	Type        string      `yaml:"type,omitempty"`
+	Format      string      `yaml:"format,omitempty"`
+	Default     interface{} `yaml:"default,omitempty"`
+
+	Properties           map[string]*JSONSchema `yaml:"properties,omitempty"`
+	Required             []string               `yaml:"required,omitempty"`
+	AdditionalProperties *SchemaOrBool          `yaml:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `yaml:"items,omitempty"`
+
+	AllOf []*JSONSchema `yaml:"allOf,omitempty"`
+	AnyOf []*JSONSchema `yaml:"anyOf,omitempty"`
+	OneOf []*JSONSchema `yaml:"oneOf,omitempty"`
+
+	ListType              string   `yaml:"x-kubernetes-list-type,omitempty"`
+	ListMapKeys           []string `yaml:"x-kubernetes-list-map-keys,omitempty"`
+	MapType               string   `yaml:"x-kubernetes-map-type,omitempty"`
+	PreserveUnknownFields bool     `yaml:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	IntOrString           bool     `yaml:"x-kubernetes-int-or-string,omitempty"`
+	EmbeddedResource      bool     `yaml:"x-kubernetes-embedded-resource,omitempty"`
+	PatchStrategy         string   `yaml:"x-kubernetes-patch-strategy,omitempty"`
+	PatchMergeKey         string   `yaml:"x-kubernetes-patch-merge-key,omitempty"`
+}
+
+// SchemaOrBool is the value of additionalProperties, either a boolean or a
+// schema.
+type SchemaOrBool struct {
+	Allows bool
+	Schema *JSONSchema
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *SchemaOrBool) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&s.Allows); err == nil {
+		s.Schema = nil
+		return nil
+	}
+	s.Schema = &JSONSchema{}
+	if err := unmarshal(s.Schema); err != nil {
+		return fmt.Errorf("additionalProperties must be a boolean or a schema: %v", err)
+	}
+	s.Allows = true
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s SchemaOrBool) MarshalYAML() (interface{}, error) {
+	if s.Schema != nil {
+		return s.Schema, nil
+	}
+	return s.Allows, nil
+}
+
+// OpenAPI is the part of OpenAPI v2 and v3 documents holding schemas.
+type OpenAPI struct {
+	OpenAPI string `yaml:"openapi,omitempty"`
+	Swagger string `yaml:"swagger,omitempty"`
+	// Definitions holds the schemas of v2 documents.
+	Definitions map[string]*JSONSchema `yaml:"definitions,omitempty"`
+	Components  struct {
+		Schemas map[string]*JSONSchema `yaml:"schemas,omitempty"`
+	} `yaml:"components,omitempty"`
+}
+
+// JSONSchemaDocument is a JSON Schema with the definitions it refers to.
+type JSONSchemaDocument struct {
+	Schema      string `yaml:"$schema,omitempty"`
+	JSONSchema  `yaml:",inline"`
+	Defs        map[string]*JSONSchema `yaml:"$defs,omitempty"`
+	Definitions map[string]*JSONSchema `yaml:"definitions,omitempty"`
+}
+
+// CustomResourceDefinition is the part of a CustomResourceDefinition
+// holding its schemas.
+type CustomResourceDefinition struct {
+	Kind string `yaml:"kind"`
+	Spec struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind string `yaml:"kind"`
+		} `yaml:"names"`
+		Versions []struct {
+			Name   string `yaml:"name"`
+			Schema *struct {
+				OpenAPIV3Schema *JSONSchema `yaml:"openAPIV3Schema"`
+			} `yaml:"schema,omitempty"`
+		} `yaml:"versions"`
+		// Version and Validation are the deprecated fields of the
+		// apiextensions.k8s.io/v1beta1 version, for all versions.
+		Version    string `yaml:"version,omitempty"`
+		Validation *struct {
+			OpenAPIV3Schema *JSONSchema `yaml:"openAPIV3Schema"`
+		} `yaml:"validation,omitempty"`
+	} `yaml:"spec"`
+}
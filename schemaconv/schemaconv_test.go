@@ -0,0 +1,389 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaconv_test
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/schemaconv"
+)
+
+func toYAML(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFromCRD(t *testing.T) {
+	var crd schemaconv.CustomResourceDefinition
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: widgets.example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                x-kubernetes-int-or-string: true
+              ports:
+                type: array
+                x-kubernetes-list-type: map
+                x-kubernetes-list-map-keys: [port, protocol]
+                items:
+                  type: object
+                  properties:
+                    port:
+                      type: integer
+                    protocol:
+                      type: string
+                      default: TCP
+              selector:
+                type: object
+                x-kubernetes-map-type: atomic
+                additionalProperties:
+                  type: string
+              tags:
+                type: array
+                x-kubernetes-list-type: set
+                items:
+                  type: string
+              config:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+`), &crd); err != nil {
+		t.Fatal(err)
+	}
+	s, err := schemaconv.FromCRD(&crd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `types:
+- name: com.example.widgets.v1.Widget
+  map:
+    fields:
+    - name: spec
+      type:
+        map:
+          fields:
+          - name: config
+            type:
+              map:
+                elementType:
+                  namedType: __untyped_deduced_
+          - name: ports
+            type:
+              list:
+                elementType:
+                  map:
+                    fields:
+                    - name: port
+                      type:
+                        scalar: numeric
+                    - name: protocol
+                      type:
+                        scalar: string
+                      default: TCP
+                elementRelationship: associative
+                keys:
+                - port
+                - protocol
+          - name: selector
+            type:
+              map:
+                elementType:
+                  scalar: string
+                elementRelationship: atomic
+          - name: size
+            type:
+              scalar: untyped
+          - name: tags
+            type:
+              list:
+                elementType:
+                  scalar: string
+                elementRelationship: associative
+`
+	got := toYAML(t, s)
+	// The untyped types are appended when used.
+	got = got[:strings.Index(got, "- name: __untyped_atomic_")]
+	if got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+	if problems := schema.Lint(s); len(problems) != 0 {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+func TestFromOpenAPI(t *testing.T) {
+	var doc schemaconv.OpenAPI
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+definitions:
+  Pod:
+    type: object
+    properties:
+      containers:
+        type: array
+        x-kubernetes-patch-strategy: merge
+        x-kubernetes-patch-merge-key: name
+        items:
+          $ref: '#/definitions/Container'
+      finalizers:
+        type: array
+        x-kubernetes-patch-strategy: merge
+        items:
+          type: string
+      owner:
+        allOf:
+        - $ref: '#/definitions/Container'
+        x-kubernetes-map-type: atomic
+      value:
+        anyOf:
+        - type: integer
+        - type: string
+  Container:
+    type: object
+    properties:
+      name:
+        type: string
+  Any: {}
+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	s, err := schemaconv.FromOpenAPI(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `types:
+- name: Any
+  scalar: untyped
+  list:
+    elementType:
+      namedType: __untyped_atomic_
+    elementRelationship: atomic
+  map:
+    elementType:
+      namedType: __untyped_deduced_
+    elementRelationship: separable
+- name: Container
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+- name: Pod
+  map:
+    fields:
+    - name: containers
+      type:
+        list:
+          elementType:
+            namedType: Container
+          elementRelationship: associative
+          keys:
+          - name
+    - name: finalizers
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: owner
+      type:
+        namedType: Container
+        elementRelationship: atomic
+    - name: value
+      type:
+        scalar: untyped
+`
+	got := toYAML(t, s)
+	got = got[:strings.Index(got, "- name: __untyped_atomic_")]
+	if got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+}
+
+// gnosticDocument is a stand-in for the documents of gnostic.
+type gnosticDocument string
+
+func (d gnosticDocument) YAMLValue(string) ([]byte, error) {
+	return []byte(d), nil
+}
+
+func TestFromGnosticV2(t *testing.T) {
+	s, err := schemaconv.FromGnosticV2(gnosticDocument(`
+swagger: "2.0"
+definitions:
+  io.k8s.apimachinery.pkg.util.intstr.IntOrString:
+    type: string
+    format: int-or-string
+  Port:
+    type: object
+    properties:
+      port:
+        $ref: '#/definitions/io.k8s.apimachinery.pkg.util.intstr.IntOrString'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `types:
+- name: Port
+  map:
+    fields:
+    - name: port
+      type:
+        namedType: io.k8s.apimachinery.pkg.util.intstr.IntOrString
+- name: io.k8s.apimachinery.pkg.util.intstr.IntOrString
+  scalar: untyped
+`
+	if got := toYAML(t, s); got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+
+	if _, err := schemaconv.FromGnosticV2(gnosticDocument(`openapi: 3.0.0`)); err == nil {
+		t.Errorf("expected an error converting an OpenAPI v3 document")
+	}
+}
+
+func TestToStructural(t *testing.T) {
+	var s schema.Schema
+	if err := yaml.Unmarshal([]byte(`types:
+- name: root
+  map:
+    fields:
+    - name: items
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+          keys: [name]
+    - name: data
+      type:
+        namedType: __untyped_deduced_
+- name: item
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: children
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: atomic
+`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schemaconv.ToStructural(&s, "root"); err == nil || !strings.Contains(err.Error(), `type "item" is recursive`) {
+		t.Errorf("expected a recursion error, got %v", err)
+	}
+
+	doc, err := schemaconv.ToJSONSchema(&s, "item")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `$schema: https://json-schema.org/draft/2020-12/schema
+type: object
+properties:
+  children:
+    type: array
+    items:
+      $ref: '#'
+    x-kubernetes-list-type: atomic
+  name:
+    type: string
+`
+	if got := toYAML(t, doc); got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+}
+
+// TestRoundTrip checks that the Kubernetes schema doesn't change when
+// converted to OpenAPI and back, but for the order of fields and unions,
+// which aren't exported.
+func TestRoundTrip(t *testing.T) {
+	b, err := ioutil.ReadFile("../internal/testdata/k8s-schema.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s schema.Schema
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := schemaconv.ToOpenAPI(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed schemaconv.OpenAPI
+	if err := yaml.Unmarshal([]byte(toYAML(t, doc)), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	got, err := schemaconv.FromOpenAPI(&parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	normalize(&s)
+	normalize(got)
+	if a, b := toYAML(t, &s), toYAML(t, got); a != b {
+		t.Errorf("schema changed in round trip")
+	}
+}
+
+func normalize(s *schema.Schema) {
+	sort.Slice(s.Types, func(i, j int) bool { return s.Types[i].Name < s.Types[j].Name })
+	var atom func(a *schema.Atom)
+	atom = func(a *schema.Atom) {
+		if a.Map != nil {
+			fields := a.Map.Fields
+			sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+			for i := range fields {
+				atom(&fields[i].Type.Inlined)
+			}
+			atom(&a.Map.ElementType.Inlined)
+			a.Map.Unions = nil
+			if a.Map.ElementRelationship == schema.Separable {
+				a.Map.ElementRelationship = ""
+			}
+		}
+		if a.List != nil {
+			atom(&a.List.ElementType.Inlined)
+		}
+	}
+	for i := range s.Types {
+		atom(&s.Types[i].Atom)
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemaconv
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+)
+
+// ToOpenAPI returns an OpenAPI v3 document with the schemas of the types
+// of s, but the untyped ones.
+func ToOpenAPI(s *schema.Schema) (*OpenAPI, error) {
+	e := exporter{schema: s, refPrefix: "#/components/schemas/"}
+	doc := &OpenAPI{OpenAPI: "3.0.0"}
+	doc.Components.Schemas = map[string]*JSONSchema{}
+	for _, t := range s.Types {
+		if isUntyped(t.Name) {
+			continue
+		}
+		js, err := e.atom(t.Name, t.Atom)
+		if err != nil {
+			return nil, err
+		}
+		doc.Components.Schemas[t.Name] = js
+	}
+	return doc, nil
+}
+
+// ToJSONSchema returns a JSON Schema of the type named root, with the
+// definitions of the types it refers to.
+func ToJSONSchema(s *schema.Schema, root string) (*JSONSchemaDocument, error) {
+	t, ok := s.FindNamedType(root)
+	if !ok {
+		return nil, fmt.Errorf("no type named %q", root)
+	}
+	e := exporter{schema: s, refPrefix: "#/$defs/", root: root, referenced: map[string]bool{}}
+	js, err := e.atom(root, t.Atom)
+	if err != nil {
+		return nil, err
+	}
+	doc := &JSONSchemaDocument{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		JSONSchema: *js,
+	}
+	// Export the referenced types, and the ones they refer to in turn.
+	done := map[string]bool{root: true}
+	for len(e.referenced) > len(done)-1 {
+		var names []string
+		for name := range e.referenced {
+			if !done[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			done[name] = true
+			t, ok := s.FindNamedType(name)
+			if !ok {
+				return nil, fmt.Errorf("no type named %q", name)
+			}
+			js, err := e.atom(name, t.Atom)
+			if err != nil {
+				return nil, err
+			}
+			if doc.Defs == nil {
+				doc.Defs = map[string]*JSONSchema{}
+			}
+			doc.Defs[name] = js
+		}
+	}
+	return doc, nil
+}
+
+// ToStructural returns the schema of the type named root with all the
+// types it refers to inlined, as required by the openAPIV3Schema of
+// CustomResourceDefinitions. It fails on recursive types.
+func ToStructural(s *schema.Schema, root string) (*JSONSchema, error) {
+	t, ok := s.FindNamedType(root)
+	if !ok {
+		return nil, fmt.Errorf("no type named %q", root)
+	}
+	e := exporter{schema: s, inline: true, inlining: map[string]bool{root: true}}
+	return e.atom(root, t.Atom)
+}
+
+func isUntyped(name string) bool {
+	return name == untypedAtomic || name == untypedDeduced
+}
+
+type exporter struct {
+	schema *schema.Schema
+	// refPrefix prefixes the names of types in references, unless they
+	// are inlined.
+	refPrefix string
+	inline    bool
+	// inlining holds the types being inlined, to detect recursion.
+	inlining map[string]bool
+	// root is referred to as "#", and referenced holds the other types
+	// referred to, if set.
+	root       string
+	referenced map[string]bool
+}
+
+func (e *exporter) typeRef(path string, tr schema.TypeRef) (*JSONSchema, error) {
+	if tr.NamedType == nil {
+		return e.atom(path, tr.Inlined)
+	}
+	name := *tr.NamedType
+	if name == untypedAtomic {
+		return &JSONSchema{PreserveUnknownFields: true, MapType: "atomic"}, nil
+	} else if name == untypedDeduced {
+		return &JSONSchema{PreserveUnknownFields: true}, nil
+	}
+
+	var js *JSONSchema
+	if e.inline {
+		if e.inlining[name] {
+			return nil, fmt.Errorf("%v: type %q is recursive and can't be inlined", path, name)
+		}
+		t, ok := e.schema.FindNamedType(name)
+		if !ok {
+			return nil, fmt.Errorf("%v: no type named %q", path, name)
+		}
+		e.inlining[name] = true
+		var err error
+		js, err = e.atom(path, t.Atom)
+		delete(e.inlining, name)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ref := "#"
+		if name != e.root || e.root == "" {
+			ref = e.refPrefix + name
+			if e.referenced != nil {
+				e.referenced[name] = true
+			}
+		}
+		js = &JSONSchema{Ref: ref}
+	}
+
+	if tr.ElementRelationship != nil {
+		// Properties can't be added to references, but to an allOf of
+		// them.
+		if js.Ref != "" {
+			js = &JSONSchema{AllOf: []*JSONSchema{js}}
+		}
+		atom, _ := e.schema.Resolve(tr)
+		switch {
+		case atom.Map != nil:
+			js.MapType = mapType(*tr.ElementRelationship)
+		case atom.List != nil:
+			js.ListType, js.ListMapKeys = listType(atom.List)
+		}
+	}
+	return js, nil
+}
+
+func (e *exporter) atom(path string, a schema.Atom) (*JSONSchema, error) {
+	kinds := 0
+	for _, set := range []bool{a.Scalar != nil, a.List != nil, a.Map != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		// There are no types that are either of them, so they are
+		// untyped.
+		js := &JSONSchema{PreserveUnknownFields: true}
+		if a.Map != nil {
+			js.MapType = mapType(a.Map.ElementRelationship)
+		}
+		return js, nil
+	}
+
+	switch {
+	case a.Scalar != nil:
+		switch *a.Scalar {
+		case schema.String:
+			return &JSONSchema{Type: "string"}, nil
+		case schema.Numeric:
+			return &JSONSchema{Type: "number"}, nil
+		case schema.Boolean:
+			return &JSONSchema{Type: "boolean"}, nil
+		case schema.Untyped:
+			return &JSONSchema{IntOrString: true}, nil
+		}
+		return nil, fmt.Errorf("%v: unknown scalar type %q", path, *a.Scalar)
+	case a.List != nil:
+		items, err := e.typeRef(path+".items", a.List.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		js := &JSONSchema{Type: "array", Items: items}
+		js.ListType, js.ListMapKeys = listType(a.List)
+		return js, nil
+	}
+
+	js := &JSONSchema{Type: "object", MapType: mapType(a.Map.ElementRelationship)}
+	for _, f := range a.Map.Fields {
+		property, err := e.typeRef(path+".properties."+f.Name, f.Type)
+		if err != nil {
+			return nil, err
+		}
+		property.Default = f.Default
+		if js.Properties == nil {
+			js.Properties = map[string]*JSONSchema{}
+		}
+		js.Properties[f.Name] = property
+	}
+	if (a.Map.ElementType != schema.TypeRef{}) {
+		if len(a.Map.Fields) > 0 && isUntypedRef(a.Map.ElementType) {
+			// Structural schemas can't have both properties and
+			// additionalProperties.
+			js.PreserveUnknownFields = true
+		} else {
+			element, err := e.typeRef(path+".additionalProperties", a.Map.ElementType)
+			if err != nil {
+				return nil, err
+			}
+			js.AdditionalProperties = &SchemaOrBool{Allows: true, Schema: element}
+		}
+	}
+	return js, nil
+}
+
+func isUntypedRef(tr schema.TypeRef) bool {
+	return tr.NamedType != nil && isUntyped(*tr.NamedType)
+}
+
+func mapType(er schema.ElementRelationship) string {
+	if er == schema.Atomic {
+		return "atomic"
+	}
+	return ""
+}
+
+func listType(l *schema.List) (string, []string) {
+	switch {
+	case l.ElementRelationship != schema.Associative:
+		return "atomic", nil
+	case len(l.Keys) > 0:
+		return "map", l.Keys
+	}
+	return "set", nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main implements smd-gen, a tool generating code converting Go
+// types to and from their unstructured representation without reflection.
+//
+// For example, in the directory of a package:
+//
+//	smd-gen -types Deployment,Service
+//
+// writes their ToUnstructured and FromUnstructured methods, and those of
+// the struct types they reference, to zz_generated.unstructured.go.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/smdgen"
+)
+
+func main() {
+	var (
+		o          smdgen.Options
+		typeNames  string
+		headerPath string
+		output     string
+	)
+	flag.StringVar(&o.Dir, "dir", ".", "Directory of the package declaring the types.")
+	flag.StringVar(&typeNames, "types", "", "Comma-separated names of the struct types to generate code for. Required.")
+	flag.StringVar(&headerPath, "header", "", "Path to a file to write at the top of the generated file, typically a license.")
+	flag.StringVar(&output, "output", smdgen.DefaultOutputFile, "Name of the generated file in the package directory. '-' means stdout.")
+	flag.Parse()
+
+	if typeNames == "" {
+		log.Fatalf("Couldn't understand command line flags: --types is required")
+	}
+	o.Types = strings.Split(typeNames, ",")
+	if headerPath != "" {
+		header, err := ioutil.ReadFile(headerPath)
+		if err != nil {
+			log.Fatalf("Couldn't read header: %v", err)
+		}
+		o.Header = header
+	}
+
+	src, err := smdgen.Generate(o)
+	if err != nil {
+		log.Fatalf("Couldn't generate code: %v", err)
+	}
+
+	if output == "-" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(filepath.Join(o.Dir, output), src, 0644)
+	}
+	if err != nil {
+		log.Fatalf("Couldn't write output: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+//go:build js && wasm
+// +build js,wasm
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main implements smd-wasm, which exposes the operations of
+// structured-merge-diff to JavaScript, so that web pages can compute the
+// merges and diffs of server-side apply in the browser. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o smd.wasm ./smd-wasm
+//
+// and load smd.wasm with the wasm_exec.js of the Go distribution. Once
+// started, it defines the global structuredMergeDiff object, whose
+// functions take and return strings of JSON:
+//
+//	registerSchema(name, schema)
+//	parse(schema, type, object)
+//	merge(schema, type, lhs, rhs)
+//	compare(schema, type, lhs, rhs)
+//
+// where schema is the name a schema was registered with. They throw an
+// Error when they fail.
+package main
+
+import (
+	"syscall/js"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/jsapi"
+)
+
+func main() {
+	api := jsapi.New()
+	js.Global().Set("structuredMergeDiff", map[string]interface{}{
+		"registerSchema": function(2, func(args []string) (string, error) {
+			return "", api.RegisterSchema(args[0], args[1])
+		}),
+		"parse": function(3, func(args []string) (string, error) {
+			return api.Parse(args[0], args[1], args[2])
+		}),
+		"merge": function(4, func(args []string) (string, error) {
+			return api.Merge(args[0], args[1], args[2], args[3])
+		}),
+		"compare": function(4, func(args []string) (string, error) {
+			return api.Compare(args[0], args[1], args[2], args[3])
+		}),
+	})
+	// The functions can only be called while the program runs.
+	select {}
+}
+
+// function returns a JavaScript function calling fn with its n string
+// arguments, and throwing an Error if it fails.
+func function(n int, fn func(args []string) (string, error)) js.Func {
+	return js.FuncOf(func(_ js.Value, jsArgs []js.Value) interface{} {
+		args := make([]string, n)
+		for i := range args {
+			if i < len(jsArgs) && jsArgs[i].Type() == js.TypeString {
+				args[i] = jsArgs[i].String()
+			}
+		}
+		out, err := fn(args)
+		if err != nil {
+			panic(js.Global().Get("Error").New(err.Error()))
+		}
+		return out
+	})
+}
@@ -16,16 +16,37 @@ limitations under the License.
 
 // Package main implements a command line tool for performing structured
 // operations on yaml files.
+//
+// Operations are either selected with flags, as in
+//
+//	smd --schema schema.yaml --merge --lhs a.yaml --rhs b.yaml
+//
+// or as subcommands, as in
+//
+//	smd merge --schema schema.yaml --type Foo a.yaml b.yaml
+//
+// Subcommands comparing or validating objects, like diff and validate, exit
+// with 1 if they differ or are invalid, and 2 on other errors.
 package main
 
 import (
 	"flag"
 	"log"
+	"os"
 
 	"sigs.k8s.io/structured-merge-diff/v4/internal/cli"
 )
 
 func main() {
+	if c, args := cli.FindCommand(os.Args[1:]); c != nil {
+		err := c.Run(args, os.Stdout, os.Stderr)
+		code := cli.ExitCode(err)
+		if code > 1 {
+			log.Printf("Couldn't execute %v: %v", c.Name, err)
+		}
+		os.Exit(code)
+	}
+
 	var o cli.Options
 	o.AddFlags(flag.CommandLine)
 	flag.Parse()
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdserver_test
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver"
+)
+
+var (
+	protoMessage = regexp.MustCompile(`(?s)message (\w+) \{(.*?)\}`)
+	protoField   = regexp.MustCompile(`^\s*(repeated )?(\w+) (\w+) = \d+;$`)
+	protoRPC     = regexp.MustCompile(`rpc (\w+)\((\w+)\) returns \((\w+)\);`)
+)
+
+// protoTypes maps the messages of smdserver.proto to their Go message.
+var protoTypes = map[string]reflect.Type{
+	"ObjectType":         reflect.TypeOf(smdserver.ObjectType{}),
+	"ManagedFieldsEntry": reflect.TypeOf(smdserver.ManagedFieldsEntry{}),
+	"Conflict":           reflect.TypeOf(smdserver.Conflict{}),
+	"MergeRequest":       reflect.TypeOf(smdserver.MergeRequest{}),
+	"MergeResponse":      reflect.TypeOf(smdserver.MergeResponse{}),
+	"ApplyRequest":       reflect.TypeOf(smdserver.ApplyRequest{}),
+	"ApplyResponse":      reflect.TypeOf(smdserver.ApplyResponse{}),
+	"CompareRequest":     reflect.TypeOf(smdserver.CompareRequest{}),
+	"CompareResponse":    reflect.TypeOf(smdserver.CompareResponse{}),
+	"ExtractRequest":     reflect.TypeOf(smdserver.ExtractRequest{}),
+	"ExtractResponse":    reflect.TypeOf(smdserver.ExtractResponse{}),
+}
+
+// protoScalars maps the scalar types used by smdserver.proto to Go.
+var protoScalars = map[string]reflect.Type{
+	"string": reflect.TypeOf(""),
+	"bytes":  reflect.TypeOf([]byte(nil)),
+	"bool":   reflect.TypeOf(false),
+}
+
+func readProto(t *testing.T) string {
+	data, err := ioutil.ReadFile("smdserver.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// jsonName is the name of a proto field in the JSON mapping of proto3,
+// which the Go messages use as their JSON tags.
+func jsonName(field string) string {
+	parts := strings.Split(field, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// goFields returns the JSON names and types of the fields of a Go
+// message, in order. Embedded messages are named after their type, like
+// the proto fields holding them.
+func goFields(rt reflect.Type) (names []string, types []reflect.Type) {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous {
+			name = strings.ToLower(f.Name[:1]) + f.Name[1:]
+		}
+		names = append(names, name)
+		types = append(types, f.Type)
+	}
+	return names, types
+}
+
+func TestProtoMessagesInSync(t *testing.T) {
+	messages := protoMessage.FindAllStringSubmatch(readProto(t), -1)
+	if len(messages) != len(protoTypes) {
+		t.Errorf("expected %v messages in smdserver.proto, got %v", len(protoTypes), len(messages))
+	}
+	for _, message := range messages {
+		name := message[1]
+		rt, ok := protoTypes[name]
+		if !ok {
+			t.Errorf("message %v has no Go message", name)
+			continue
+		}
+		var names []string
+		var types []reflect.Type
+		for _, line := range strings.Split(strings.TrimSpace(message[2]), "\n") {
+			field := protoField.FindStringSubmatch(line)
+			if field == nil {
+				t.Fatalf("message %v: failed to parse field %q", name, line)
+			}
+			ft, ok := protoScalars[field[2]]
+			if !ok {
+				if ft, ok = protoTypes[field[2]]; !ok {
+					t.Fatalf("message %v: unknown type %v", name, field[2])
+				}
+			}
+			if field[1] != "" {
+				ft = reflect.SliceOf(ft)
+			}
+			names = append(names, jsonName(field[3]))
+			types = append(types, ft)
+		}
+		goNames, goTypes := goFields(rt)
+		if !reflect.DeepEqual(names, goNames) {
+			t.Errorf("message %v: expected fields %v, Go message has %v", name, names, goNames)
+		} else if !reflect.DeepEqual(types, goTypes) {
+			t.Errorf("message %v: expected types %v, Go message has %v", name, types, goTypes)
+		}
+	}
+}
+
+func TestProtoServiceInSync(t *testing.T) {
+	rpcs := protoRPC.FindAllStringSubmatch(readProto(t), -1)
+	server := reflect.TypeOf(&smdserver.Server{})
+	ctx := reflect.TypeOf((*context.Context)(nil)).Elem()
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	if len(rpcs) == 0 {
+		t.Fatal("no rpc found in smdserver.proto")
+	}
+	for _, rpc := range rpcs {
+		method, ok := server.MethodByName(rpc[1])
+		if !ok {
+			t.Errorf("rpc %v has no method on Server", rpc[1])
+			continue
+		}
+		expected := reflect.FuncOf(
+			[]reflect.Type{server, ctx, reflect.PtrTo(protoTypes[rpc[2]])},
+			[]reflect.Type{reflect.PtrTo(protoTypes[rpc[3]]), errType},
+			false,
+		)
+		if method.Type != expected {
+			t.Errorf("rpc %v: expected method %v, got %v", rpc[1], expected, method.Type)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smdserver exposes the merge operations of structured-merge-diff
+// as a service, so that control planes not written in Go can merge,
+// apply, compare and extract objects with exactly the semantics of the
+// Kubernetes API server.
+//
+// The service is defined by smdserver.proto. Server implements it over
+// plain Go messages that mirror the protocol buffer messages field by
+// field, with objects and field sets encoded as JSON, so that the
+// generated gRPC server only has to forward its calls to it. This module
+// doesn't depend on gRPC; the generated code and its registration belong
+// to the binary serving it, and smdserver.proto shows how to generate it.
+//
+// Schemas are registered by name, and every request names the schema and
+// the type of its objects. The service doesn't convert between versions:
+// the API version of a request only labels the managed fields, and all
+// the versions of a type share its registered schema.
+package smdserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var (
+	// ErrUnknownSchema is wrapped by the errors of the requests naming a
+	// schema that isn't registered, or a type it doesn't define.
+	ErrUnknownSchema = errors.New("unknown schema")
+	// ErrInvalidRequest is wrapped by the errors of the requests that
+	// can't be decoded or whose objects don't match their schema.
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// Server serves the operations of the service against its registered
+// schemas. It is safe for concurrent use.
+type Server struct {
+	lock    sync.RWMutex
+	schemas map[string]*typed.Parser
+	updater *merge.Updater
+}
+
+// NewServer returns a server without any schema.
+func NewServer() *Server {
+	return &Server{
+		schemas: map[string]*typed.Parser{},
+		updater: (&merge.UpdaterBuilder{
+			Converter:             sameVersionConverter{},
+			IncludeConflictValues: true,
+		}).BuildUpdater(),
+	}
+}
+
+// RegisterSchema parses schema and registers it under name, replacing the
+// schema previously registered with that name.
+func (s *Server) RegisterSchema(name string, schema typed.YAMLObject) error {
+	parser, err := typed.NewParser(schema)
+	if err != nil {
+		return fmt.Errorf("failed to parse schema %q: %v", name, err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.schemas[name] = parser
+	return nil
+}
+
+// Schemas returns the names of the registered schemas.
+func (s *Server) Schemas() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	names := make([]string, 0, len(s.schemas))
+	for name := range s.schemas {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ObjectType identifies the type of the objects of a request.
+type ObjectType struct {
+	// Schema is the name of a registered schema.
+	Schema string `json:"schema"`
+	// Type is the name of a type of the schema.
+	Type string `json:"type"`
+}
+
+// ManagedFieldsEntry is the set of fields owned by a manager, as in the
+// managed fields of Kubernetes objects.
+type ManagedFieldsEntry struct {
+	Manager    string `json:"manager"`
+	APIVersion string `json:"apiVersion"`
+	// Applied is whether the fields were set by an apply, rather than
+	// an update.
+	Applied bool `json:"applied,omitempty"`
+	// FieldsV1 is the JSON serialization of the fields.
+	FieldsV1 []byte `json:"fieldsV1,omitempty"`
+}
+
+// Conflict is a field that a manager applies with a different value than
+// another manager owning it.
+type Conflict struct {
+	// Manager is the manager owning the field.
+	Manager string `json:"manager"`
+	// Path is the human readable path to the field.
+	Path string `json:"path"`
+	// PathElements are the elements of the path, serialized as in
+	// managed fields.
+	PathElements []string `json:"pathElements"`
+	// Current and Desired are the JSON encodings of the values of the
+	// field in the object and in the configuration.
+	Current []byte `json:"current,omitempty"`
+	Desired []byte `json:"desired,omitempty"`
+}
+
+// MergeRequest merges RHS into LHS. Both objects are JSON.
+type MergeRequest struct {
+	ObjectType
+	LHS []byte `json:"lhs"`
+	RHS []byte `json:"rhs"`
+}
+
+// MergeResponse is the result of a merge.
+type MergeResponse struct {
+	Object []byte `json:"object"`
+}
+
+// ApplyRequest applies Config, as Manager, to Live, an object whose
+// fields are owned by ManagedFields. Live may be empty for objects that
+// don't exist yet.
+type ApplyRequest struct {
+	ObjectType
+	APIVersion    string               `json:"apiVersion"`
+	Live          []byte               `json:"live,omitempty"`
+	Config        []byte               `json:"config"`
+	ManagedFields []ManagedFieldsEntry `json:"managedFields,omitempty"`
+	Manager       string               `json:"manager"`
+	Force         bool                 `json:"force,omitempty"`
+}
+
+// ApplyResponse is the result of an apply. Conflicts are part of the
+// response rather than an error: when there are any, Object and
+// ManagedFields are empty.
+type ApplyResponse struct {
+	Object        []byte               `json:"object,omitempty"`
+	ManagedFields []ManagedFieldsEntry `json:"managedFields,omitempty"`
+	Conflicts     []Conflict           `json:"conflicts,omitempty"`
+}
+
+// CompareRequest compares LHS to RHS.
+type CompareRequest struct {
+	ObjectType
+	LHS []byte `json:"lhs"`
+	RHS []byte `json:"rhs"`
+}
+
+// CompareResponse holds the fields added, modified and removed from LHS
+// to RHS, serialized as in managed fields.
+type CompareResponse struct {
+	Added    []byte `json:"added"`
+	Modified []byte `json:"modified"`
+	Removed  []byte `json:"removed"`
+}
+
+// ExtractRequest extracts from Object the fields owned by Manager in
+// ManagedFields, as a client would to build its next apply configuration.
+type ExtractRequest struct {
+	ObjectType
+	Object        []byte               `json:"object"`
+	ManagedFields []ManagedFieldsEntry `json:"managedFields,omitempty"`
+	Manager       string               `json:"manager"`
+}
+
+// ExtractResponse is the extracted object.
+type ExtractResponse struct {
+	Object []byte `json:"object"`
+}
+
+// Merge merges the objects of the request.
+func (s *Server) Merge(_ context.Context, req *MergeRequest) (*MergeResponse, error) {
+	pt, err := s.parseableType(req.ObjectType)
+	if err != nil {
+		return nil, err
+	}
+	lhs, err := parseObject(pt, "lhs", req.LHS)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := parseObject(pt, "rhs", req.RHS)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := lhs.Merge(rhs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to merge: %v", ErrInvalidRequest, err)
+	}
+	out, err := value.ToJSON(merged.AsValue())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode object: %v", err)
+	}
+	return &MergeResponse{Object: out}, nil
+}
+
+// Apply applies the configuration of the request.
+func (s *Server) Apply(_ context.Context, req *ApplyRequest) (*ApplyResponse, error) {
+	pt, err := s.parseableType(req.ObjectType)
+	if err != nil {
+		return nil, err
+	}
+	if req.Manager == "" {
+		return nil, fmt.Errorf("%w: manager is required", ErrInvalidRequest)
+	}
+	if req.APIVersion == "" {
+		return nil, fmt.Errorf("%w: apiVersion is required", ErrInvalidRequest)
+	}
+	live, err := pt.FromUnstructured(nil)
+	if len(req.Live) != 0 {
+		live, err = parseObject(pt, "live", req.Live)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseObject(pt, "config", req.Config)
+	if err != nil {
+		return nil, err
+	}
+	managers, err := decodeManagedFields(req.ManagedFields)
+	if err != nil {
+		return nil, err
+	}
+	object, managers, err := s.updater.Apply(live, config, fieldpath.APIVersion(req.APIVersion), managers, req.Manager, req.Force)
+	if err != nil {
+		var conflicts merge.Conflicts
+		if errors.As(err, &conflicts) {
+			out, err := encodeConflicts(conflicts)
+			if err != nil {
+				return nil, err
+			}
+			return &ApplyResponse{Conflicts: out}, nil
+		}
+		return nil, fmt.Errorf("%w: failed to apply: %v", ErrInvalidRequest, err)
+	}
+	resp := &ApplyResponse{}
+	if resp.Object, err = value.ToJSON(object.AsValue()); err != nil {
+		return nil, fmt.Errorf("failed to encode object: %v", err)
+	}
+	if resp.ManagedFields, err = encodeManagedFields(managers); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Compare compares the objects of the request.
+func (s *Server) Compare(_ context.Context, req *CompareRequest) (*CompareResponse, error) {
+	pt, err := s.parseableType(req.ObjectType)
+	if err != nil {
+		return nil, err
+	}
+	lhs, err := parseObject(pt, "lhs", req.LHS)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := parseObject(pt, "rhs", req.RHS)
+	if err != nil {
+		return nil, err
+	}
+	comparison, err := lhs.Compare(rhs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to compare: %v", ErrInvalidRequest, err)
+	}
+	resp := &CompareResponse{}
+	for _, f := range []struct {
+		out *[]byte
+		set *fieldpath.Set
+	}{
+		{&resp.Added, comparison.Added},
+		{&resp.Modified, comparison.Modified},
+		{&resp.Removed, comparison.Removed},
+	} {
+		if *f.out, err = f.set.ToJSON(); err != nil {
+			return nil, fmt.Errorf("failed to encode fields: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// Extract extracts the fields of the manager of the request.
+func (s *Server) Extract(_ context.Context, req *ExtractRequest) (*ExtractResponse, error) {
+	pt, err := s.parseableType(req.ObjectType)
+	if err != nil {
+		return nil, err
+	}
+	object, err := parseObject(pt, "object", req.Object)
+	if err != nil {
+		return nil, err
+	}
+	managers, err := decodeManagedFields(req.ManagedFields)
+	if err != nil {
+		return nil, err
+	}
+	out, err := value.ToJSON(merge.ExtractApplyConfiguration(object, managers, req.Manager).AsValue())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode object: %v", err)
+	}
+	return &ExtractResponse{Object: out}, nil
+}
+
+func (s *Server) parseableType(t ObjectType) (typed.ParseableType, error) {
+	s.lock.RLock()
+	parser, ok := s.schemas[t.Schema]
+	s.lock.RUnlock()
+	if !ok {
+		return typed.ParseableType{}, fmt.Errorf("%w %q", ErrUnknownSchema, t.Schema)
+	}
+	pt := parser.Type(t.Type)
+	if !pt.IsValid() {
+		return typed.ParseableType{}, fmt.Errorf("%w: schema %q has no type %q", ErrUnknownSchema, t.Schema, t.Type)
+	}
+	return pt, nil
+}
+
+func parseObject(pt typed.ParseableType, name string, data []byte) (*typed.TypedValue, error) {
+	v, err := value.FromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode %v: %v", ErrInvalidRequest, name, err)
+	}
+	tv, err := typed.AsTyped(v, pt.Schema, pt.TypeRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %v: %v", ErrInvalidRequest, name, err)
+	}
+	return tv, nil
+}
+
+func decodeManagedFields(entries []ManagedFieldsEntry) (fieldpath.ManagedFields, error) {
+	managers := fieldpath.ManagedFields{}
+	for _, entry := range entries {
+		if _, ok := managers[entry.Manager]; ok {
+			return nil, fmt.Errorf("%w: duplicate managed fields of manager %q", ErrInvalidRequest, entry.Manager)
+		}
+		set, err := fieldpath.SetFromFieldsV1(fieldpath.FieldsV1{Raw: entry.FieldsV1})
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid managed fields of manager %q: %v", ErrInvalidRequest, entry.Manager, err)
+		}
+		managers[entry.Manager] = fieldpath.NewVersionedSet(set, fieldpath.APIVersion(entry.APIVersion), entry.Applied)
+	}
+	return managers, nil
+}
+
+// encodeManagedFields encodes managers sorted by name, so that responses
+// are deterministic.
+func encodeManagedFields(managers fieldpath.ManagedFields) ([]ManagedFieldsEntry, error) {
+	entries := make([]ManagedFieldsEntry, 0, len(managers))
+	for manager, vs := range managers {
+		fields, err := vs.Set().ToFieldsV1()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode managed fields of manager %q: %v", manager, err)
+		}
+		entries = append(entries, ManagedFieldsEntry{
+			Manager:    manager,
+			APIVersion: string(vs.APIVersion()),
+			Applied:    vs.Applied(),
+			FieldsV1:   fields.Raw,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Manager < entries[j].Manager })
+	return entries, nil
+}
+
+func encodeConflicts(conflicts merge.Conflicts) ([]Conflict, error) {
+	out := make([]Conflict, 0, len(conflicts))
+	for _, c := range conflicts {
+		conflict := Conflict{
+			Manager:      c.Manager,
+			Path:         c.Path.String(),
+			PathElements: make([]string, 0, len(c.Path)),
+		}
+		for _, pe := range c.Path {
+			s, err := fieldpath.SerializePathElement(pe)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize path %v: %v", c.Path, err)
+			}
+			conflict.PathElements = append(conflict.PathElements, s)
+		}
+		var err error
+		if c.Current != nil {
+			if conflict.Current, err = value.ToJSON(c.Current); err != nil {
+				return nil, fmt.Errorf("failed to encode current value: %v", err)
+			}
+		}
+		if c.Desired != nil {
+			if conflict.Desired, err = value.ToJSON(c.Desired); err != nil {
+				return nil, fmt.Errorf("failed to encode desired value: %v", err)
+			}
+		}
+		out = append(out, conflict)
+	}
+	return out, nil
+}
+
+// sameVersionConverter doesn't convert, since all the versions of a type
+// share its schema.
+type sameVersionConverter struct{}
+
+func (sameVersionConverter) Convert(v *typed.TypedValue, _ fieldpath.APIVersion) (*typed.TypedValue, error) {
+	return v, nil
+}
+
+func (sameVersionConverter) IsMissingVersionError(error) bool {
+	return false
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdserver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+const deploymentSchema typed.YAMLObject = `types:
+- name: deployment
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: containers
+      type:
+        list:
+          elementType:
+            namedType: container
+          elementRelationship: associative
+          keys:
+          - name
+- name: container
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: image
+      type:
+        scalar: string
+`
+
+func newServer(t *testing.T) *smdserver.Server {
+	s := smdserver.NewServer()
+	if err := s.RegisterSchema("apps", deploymentSchema); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+var deployment = smdserver.ObjectType{Schema: "apps", Type: "deployment"}
+
+func TestMerge(t *testing.T) {
+	resp, err := newServer(t).Merge(context.Background(), &smdserver.MergeRequest{
+		ObjectType: deployment,
+		LHS:        []byte(`{"replicas": 1, "containers": [{"name": "a", "image": "a:1"}, {"name": "b", "image": "b:1"}]}`),
+		RHS:        []byte(`{"containers": [{"name": "b", "image": "b:2"}]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"containers":[{"image":"a:1","name":"a"},{"image":"b:2","name":"b"}],"replicas":1}`
+	if string(resp.Object) != expected {
+		t.Errorf("expected %s, got %s", expected, resp.Object)
+	}
+}
+
+func TestApply(t *testing.T) {
+	s := newServer(t)
+	first, err := s.Apply(context.Background(), &smdserver.ApplyRequest{
+		ObjectType: deployment,
+		APIVersion: "v1",
+		Config:     []byte(`{"replicas": 1, "containers": [{"name": "a", "image": "a:1"}]}`),
+		Manager:    "one",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Conflicts) != 0 || len(first.ManagedFields) != 1 {
+		t.Fatalf("unexpected response: %+v", first)
+	}
+
+	req := &smdserver.ApplyRequest{
+		ObjectType:    deployment,
+		APIVersion:    "v1",
+		Live:          first.Object,
+		Config:        []byte(`{"replicas": 2}`),
+		ManagedFields: first.ManagedFields,
+		Manager:       "two",
+	}
+	conflicting, err := s.Apply(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicting.Conflicts) != 1 || conflicting.Object != nil {
+		t.Fatalf("expected a conflict, got %+v", conflicting)
+	}
+	if c := conflicting.Conflicts[0]; c.Manager != "one" || c.Path != ".replicas" || string(c.Desired) != "2" {
+		t.Errorf("unexpected conflict: %+v", c)
+	}
+
+	req.Force = true
+	forced, err := s.Apply(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"containers":[{"image":"a:1","name":"a"}],"replicas":2}`
+	if string(forced.Object) != expected {
+		t.Errorf("expected %s, got %s", expected, forced.Object)
+	}
+	if len(forced.ManagedFields) != 2 || forced.ManagedFields[0].Manager != "one" || forced.ManagedFields[1].Manager != "two" {
+		t.Errorf("unexpected managed fields: %+v", forced.ManagedFields)
+	}
+
+	extracted, err := s.Extract(context.Background(), &smdserver.ExtractRequest{
+		ObjectType:    deployment,
+		Object:        forced.Object,
+		ManagedFields: forced.ManagedFields,
+		Manager:       "one",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"containers":[{"image":"a:1","name":"a"}]}`
+	if string(extracted.Object) != expected {
+		t.Errorf("expected %s, got %s", expected, extracted.Object)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	resp, err := newServer(t).Compare(context.Background(), &smdserver.CompareRequest{
+		ObjectType: deployment,
+		LHS:        []byte(`{"replicas": 1, "containers": [{"name": "a", "image": "a:1"}]}`),
+		RHS:        []byte(`{"replicas": 2, "containers": [{"name": "b", "image": "b:1"}]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []struct {
+		name, got, expected string
+	}{
+		{"added", string(resp.Added), `{"f:containers":{"k:{\"name\":\"b\"}":{".":{},"f:image":{},"f:name":{}}}}`},
+		{"modified", string(resp.Modified), `{"f:replicas":{}}`},
+		{"removed", string(resp.Removed), `{"f:containers":{"k:{\"name\":\"a\"}":{".":{},"f:image":{},"f:name":{}}}}`},
+	} {
+		if f.got != f.expected {
+			t.Errorf("expected %v fields %s, got %s", f.name, f.expected, f.got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := newServer(t)
+	for _, tc := range []struct {
+		name     string
+		req      *smdserver.MergeRequest
+		expected error
+	}{
+		{
+			name:     "unknown schema",
+			req:      &smdserver.MergeRequest{ObjectType: smdserver.ObjectType{Schema: "batch", Type: "job"}},
+			expected: smdserver.ErrUnknownSchema,
+		},
+		{
+			name:     "unknown type",
+			req:      &smdserver.MergeRequest{ObjectType: smdserver.ObjectType{Schema: "apps", Type: "job"}},
+			expected: smdserver.ErrUnknownSchema,
+		},
+		{
+			name:     "malformed object",
+			req:      &smdserver.MergeRequest{ObjectType: deployment, LHS: []byte(`{`), RHS: []byte(`{}`)},
+			expected: smdserver.ErrInvalidRequest,
+		},
+		{
+			name:     "invalid object",
+			req:      &smdserver.MergeRequest{ObjectType: deployment, LHS: []byte(`{"replicas": "one"}`), RHS: []byte(`{}`)},
+			expected: smdserver.ErrInvalidRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Merge(context.Background(), tc.req)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
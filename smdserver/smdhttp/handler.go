@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smdhttp serves the apply operation of structured-merge-diff
+// over HTTP with JSON bodies, so that CI systems can preview the result
+// of a server-side apply without an API server.
+//
+// The handler serves two endpoints, both taking a POST of an
+// ApplyRequest:
+//
+//	/apply returns the ApplyResponse of the apply.
+//	/diff returns the DiffResponse of the apply: its result and the
+//	      fields it adds, modifies and removes from the live object.
+//
+// Conflicts are returned with the status 409, along with the response.
+// Errors are returned as {"error": "..."} with the status 400 for invalid
+// requests, 404 for unknown schemas and 500 otherwise.
+package smdhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// DefaultMaxRequestBytes is the size limit of request bodies of the
+// handlers that don't set one.
+const DefaultMaxRequestBytes = 8 << 20
+
+// Handler serves the endpoints of the package.
+type Handler struct {
+	// Server, if set, serves the requests naming a registered schema.
+	// Requests can always carry their own schema instead.
+	Server *smdserver.Server
+	// MaxRequestBytes, if positive, is the size limit of request bodies
+	// instead of DefaultMaxRequestBytes.
+	MaxRequestBytes int64
+}
+
+// ApplyRequest applies Config, as Manager, to Live, an object whose
+// fields are owned by ManagedFields. Live may be omitted for objects that
+// don't exist yet.
+type ApplyRequest struct {
+	// Schema, if set, is the YAML or JSON schema of the objects.
+	// Otherwise, SchemaName names a schema registered with the server
+	// of the handler.
+	Schema     string `json:"schema,omitempty"`
+	SchemaName string `json:"schemaName,omitempty"`
+	// Type is the name of the type of the objects in the schema.
+	Type string `json:"type"`
+
+	APIVersion    string               `json:"apiVersion"`
+	Live          json.RawMessage      `json:"live,omitempty"`
+	Config        json.RawMessage      `json:"config"`
+	ManagedFields []ManagedFieldsEntry `json:"managedFields,omitempty"`
+	Manager       string               `json:"manager"`
+	Force         bool                 `json:"force,omitempty"`
+}
+
+// ManagedFieldsEntry is the set of fields owned by a manager, as in the
+// managed fields of Kubernetes objects.
+type ManagedFieldsEntry struct {
+	Manager    string          `json:"manager"`
+	APIVersion string          `json:"apiVersion"`
+	Applied    bool            `json:"applied,omitempty"`
+	FieldsV1   json.RawMessage `json:"fieldsV1,omitempty"`
+}
+
+// Conflict is a field that the manager applies with a different value
+// than another manager owning it.
+type Conflict struct {
+	Manager      string          `json:"manager"`
+	Path         string          `json:"path"`
+	PathElements []string        `json:"pathElements"`
+	Current      json.RawMessage `json:"current,omitempty"`
+	Desired      json.RawMessage `json:"desired,omitempty"`
+}
+
+// ApplyResponse is the result of an apply, unless it conflicts.
+type ApplyResponse struct {
+	Object        json.RawMessage      `json:"object,omitempty"`
+	ManagedFields []ManagedFieldsEntry `json:"managedFields,omitempty"`
+	Conflicts     []Conflict           `json:"conflicts,omitempty"`
+}
+
+// DiffResponse is an ApplyResponse along with the fields added, modified
+// and removed from the live object, serialized as in managed fields.
+type DiffResponse struct {
+	ApplyResponse
+	Added    json.RawMessage `json:"added,omitempty"`
+	Modified json.RawMessage `json:"modified,omitempty"`
+	Removed  json.RawMessage `json:"removed,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP serves the endpoints of the package.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var diff bool
+	switch r.URL.Path {
+	case "/apply":
+	case "/diff":
+		diff = true
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only POST is allowed"})
+		return
+	}
+
+	limit := h.MaxRequestBytes
+	if limit <= 0 {
+		limit = DefaultMaxRequestBytes
+	}
+	var req ApplyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("failed to decode request: %v", err)})
+		return
+	}
+
+	resp, err := h.serve(r.Context(), &req, diff)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, smdserver.ErrInvalidRequest):
+			status = http.StatusBadRequest
+		case errors.Is(err, smdserver.ErrUnknownSchema):
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, errorResponse{Error: err.Error()})
+		return
+	}
+	status := http.StatusOK
+	if len(resp.Conflicts) != 0 {
+		status = http.StatusConflict
+	}
+	if diff {
+		writeJSON(w, status, resp)
+	} else {
+		writeJSON(w, status, resp.ApplyResponse)
+	}
+}
+
+// serve applies the request, and compares its result to the live object
+// if diff is set.
+func (h *Handler) serve(ctx context.Context, req *ApplyRequest, diff bool) (*DiffResponse, error) {
+	server := h.Server
+	objectType := smdserver.ObjectType{Schema: req.SchemaName, Type: req.Type}
+	if req.Schema != "" {
+		server = smdserver.NewServer()
+		objectType.Schema = "inline"
+		if err := server.RegisterSchema(objectType.Schema, typed.YAMLObject(req.Schema)); err != nil {
+			return nil, fmt.Errorf("%w: %v", smdserver.ErrInvalidRequest, err)
+		}
+	} else if server == nil {
+		return nil, fmt.Errorf("%w: schema is required", smdserver.ErrInvalidRequest)
+	}
+
+	applyReq := &smdserver.ApplyRequest{
+		ObjectType: objectType,
+		APIVersion: req.APIVersion,
+		Live:       req.Live,
+		Config:     req.Config,
+		Manager:    req.Manager,
+		Force:      req.Force,
+	}
+	for _, entry := range req.ManagedFields {
+		applyReq.ManagedFields = append(applyReq.ManagedFields, smdserver.ManagedFieldsEntry{
+			Manager:    entry.Manager,
+			APIVersion: entry.APIVersion,
+			Applied:    entry.Applied,
+			FieldsV1:   entry.FieldsV1,
+		})
+	}
+	applied, err := server.Apply(ctx, applyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &DiffResponse{ApplyResponse: ApplyResponse{Object: applied.Object}}
+	for _, entry := range applied.ManagedFields {
+		resp.ManagedFields = append(resp.ManagedFields, ManagedFieldsEntry{
+			Manager:    entry.Manager,
+			APIVersion: entry.APIVersion,
+			Applied:    entry.Applied,
+			FieldsV1:   entry.FieldsV1,
+		})
+	}
+	for _, c := range applied.Conflicts {
+		resp.Conflicts = append(resp.Conflicts, Conflict{
+			Manager:      c.Manager,
+			Path:         c.Path,
+			PathElements: c.PathElements,
+			Current:      c.Current,
+			Desired:      c.Desired,
+		})
+	}
+	if !diff || len(resp.Conflicts) != 0 {
+		return resp, nil
+	}
+
+	live := req.Live
+	if len(live) == 0 {
+		live = json.RawMessage("null")
+	}
+	compared, err := server.Compare(ctx, &smdserver.CompareRequest{
+		ObjectType: objectType,
+		LHS:        live,
+		RHS:        applied.Object,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Added, resp.Modified, resp.Removed = compared.Added, compared.Modified, compared.Removed
+	return resp, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The response can't be changed once its status is written.
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdhttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver"
+	"sigs.k8s.io/structured-merge-diff/v4/smdserver/smdhttp"
+)
+
+const schema = `types:
+- name: deployment
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: image
+      type:
+        scalar: string
+`
+
+func post(t *testing.T, h http.Handler, path string, req interface{}) (int, string) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(body))))
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+func TestApply(t *testing.T) {
+	h := &smdhttp.Handler{}
+	code, body := post(t, h, "/apply", smdhttp.ApplyRequest{
+		Schema:     schema,
+		Type:       "deployment",
+		APIVersion: "v1",
+		Config:     json.RawMessage(`{"replicas": 1, "image": "a:1"}`),
+		Manager:    "one",
+	})
+	expected := `{"object":{"image":"a:1","replicas":1},"managedFields":[{"manager":"one","apiVersion":"v1","applied":true,"fieldsV1":{"f:image":{},"f:replicas":{}}}]}`
+	if code != http.StatusOK || body != expected {
+		t.Errorf("expected 200 %s, got %d %s", expected, code, body)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	server := smdserver.NewServer()
+	if err := server.RegisterSchema("apps", schema); err != nil {
+		t.Fatal(err)
+	}
+	h := &smdhttp.Handler{Server: server}
+	req := smdhttp.ApplyRequest{
+		SchemaName: "apps",
+		Type:       "deployment",
+		APIVersion: "v1",
+		Live:       json.RawMessage(`{"replicas": 1, "image": "a:1"}`),
+		Config:     json.RawMessage(`{"replicas": 2}`),
+		ManagedFields: []smdhttp.ManagedFieldsEntry{{
+			Manager:    "one",
+			APIVersion: "v1",
+			Applied:    true,
+			FieldsV1:   json.RawMessage(`{"f:image":{},"f:replicas":{}}`),
+		}},
+		Manager: "two",
+	}
+
+	code, body := post(t, h, "/diff", req)
+	expected := `{"conflicts":[{"manager":"one","path":".replicas","pathElements":["f:replicas"],"current":1,"desired":2}]}`
+	if code != http.StatusConflict || body != expected {
+		t.Errorf("expected 409 %s, got %d %s", expected, code, body)
+	}
+
+	req.Force = true
+	code, body = post(t, h, "/diff", req)
+	expected = `{"object":{"image":"a:1","replicas":2},` +
+		`"managedFields":[{"manager":"one","apiVersion":"v1","applied":true,"fieldsV1":{"f:image":{}}},{"manager":"two","apiVersion":"v1","applied":true,"fieldsV1":{"f:replicas":{}}}],` +
+		`"added":{},"modified":{"f:replicas":{}},"removed":{}}`
+	if code != http.StatusOK || body != expected {
+		t.Errorf("expected 200 %s, got %d %s", expected, code, body)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := &smdhttp.Handler{MaxRequestBytes: 1024}
+	for _, tc := range []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"unknown path", http.MethodPost, "/merge", `{}`, http.StatusNotFound},
+		{"wrong method", http.MethodGet, "/apply", ``, http.StatusMethodNotAllowed},
+		{"malformed request", http.MethodPost, "/apply", `{`, http.StatusBadRequest},
+		{"too large", http.MethodPost, "/apply", `{"schema": "` + strings.Repeat("a", 2048) + `"}`, http.StatusBadRequest},
+		{"no schema", http.MethodPost, "/apply", `{"type": "deployment"}`, http.StatusBadRequest},
+		{"invalid schema", http.MethodPost, "/apply", `{"schema": "types: 1"}`, http.StatusBadRequest},
+		{"unknown type", http.MethodPost, "/diff", `{"schema": "types: []", "type": "deployment"}`, http.StatusNotFound},
+		{"invalid config", http.MethodPost, "/diff", `{"schema": ` + mustJSON(t, schema) + `, "type": "deployment", "apiVersion": "v1", "manager": "one", "config": {"replicas": "one"}}`, http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			if w.Code != tc.expected {
+				t.Errorf("expected %d, got %d: %s", tc.expected, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
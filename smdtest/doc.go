@@ -0,0 +1,26 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smdtest simulates sequences of Apply and Update operations
+// against a schema, so that the resulting objects, managed fields and
+// conflicts can be asserted on. It is what structured-merge-diff uses to
+// test itself, and is meant for anyone designing the merge behavior of a
+// schema, such as CRD authors.
+//
+// A TestCase lists the operations to run along with the expected final
+// object and managed fields, and is run by TestCase.Test. The State that
+// a TestCase runs against can also be driven directly.
+package smdtest
@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// GeneratorOptions are the knobs of Generate. Zero values pick the
+// defaults.
+type GeneratorOptions struct {
+	// MaxDepth bounds the nesting of maps and lists. Containers at
+	// that depth are empty. It defaults to 5.
+	MaxDepth int
+	// MaxListLength bounds the number of items of lists. It defaults
+	// to 4.
+	MaxListLength int
+	// MaxMapSize bounds the number of keys of maps, beyond the fields
+	// of their struct. It defaults to 4.
+	MaxMapSize int
+	// FieldProbability is the probability of setting each field of a
+	// struct. It defaults to 0.5.
+	FieldProbability float64
+	// MaxStringLength bounds the length of strings. It defaults to 12.
+	MaxStringLength int
+}
+
+func (o GeneratorOptions) withDefaults() GeneratorOptions {
+	if o.MaxDepth == 0 {
+		o.MaxDepth = 5
+	}
+	if o.MaxListLength == 0 {
+		o.MaxListLength = 4
+	}
+	if o.MaxMapSize == 0 {
+		o.MaxMapSize = 4
+	}
+	if o.FieldProbability == 0 {
+		o.FieldProbability = 0.5
+	}
+	if o.MaxStringLength == 0 {
+		o.MaxStringLength = 12
+	}
+	return o
+}
+
+// Generate returns a random object of the type, which is valid against
+// it: the items of associative lists have all their keys, and are unique.
+// The same source of randomness generates the same objects, so that they
+// can be reproduced from a seed.
+func Generate(r *rand.Rand, t typed.ParseableType, opts GeneratorOptions) (*typed.TypedValue, error) {
+	g := generator{rand: r, schema: t.Schema, opts: opts.withDefaults()}
+	obj := g.value(t.TypeRef, 0)
+	tv, err := t.FromUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("generated an invalid object: %v", err)
+	}
+	return tv, nil
+}
+
+type generator struct {
+	rand   *rand.Rand
+	schema *schema.Schema
+	opts   GeneratorOptions
+}
+
+func (g *generator) value(tr schema.TypeRef, depth int) interface{} {
+	atom, ok := g.schema.Resolve(tr)
+	if !ok {
+		return nil
+	}
+	// Untyped atoms can be any of their kinds, but containers stop at
+	// the maximum depth.
+	var kinds []func() interface{}
+	if atom.Scalar != nil {
+		kinds = append(kinds, func() interface{} { return g.scalar(*atom.Scalar) })
+	}
+	if atom.List != nil && (depth < g.opts.MaxDepth || len(kinds) == 0) {
+		kinds = append(kinds, func() interface{} { return g.list(atom.List, depth) })
+	}
+	if atom.Map != nil && (depth < g.opts.MaxDepth || len(kinds) == 0) {
+		kinds = append(kinds, func() interface{} { return g.mapValue(atom.Map, depth) })
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	return kinds[g.rand.Intn(len(kinds))]()
+}
+
+func (g *generator) scalar(s schema.Scalar) interface{} {
+	if s == schema.Untyped {
+		s = []schema.Scalar{schema.String, schema.Numeric, schema.Boolean}[g.rand.Intn(3)]
+	}
+	switch s {
+	case schema.String:
+		return g.string()
+	case schema.Numeric:
+		if g.rand.Intn(4) == 0 {
+			return g.rand.Float64() * 1000
+		}
+		return int64(g.rand.Intn(100000))
+	case schema.Boolean:
+		return g.rand.Intn(2) == 0
+	}
+	return nil
+}
+
+const generatorAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789-"
+
+func (g *generator) string() string {
+	n := 1 + g.rand.Intn(g.opts.MaxStringLength)
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(generatorAlphabet[g.rand.Intn(len(generatorAlphabet))])
+	}
+	return b.String()
+}
+
+func (g *generator) length(max, depth int) int {
+	if depth >= g.opts.MaxDepth {
+		return 0
+	}
+	return g.rand.Intn(max + 1)
+}
+
+func (g *generator) list(l *schema.List, depth int) interface{} {
+	n := g.length(g.opts.MaxListLength, depth)
+	items := make([]interface{}, 0, n)
+	seen := map[string]bool{}
+	for i := 0; i < n; i++ {
+		item := g.value(l.ElementType, depth+1)
+		if l.ElementRelationship == schema.Associative {
+			if len(l.Keys) > 0 {
+				item = g.withKeys(l, item, depth+1)
+				if item == nil {
+					continue
+				}
+			}
+			// Items of associative lists must be unique, either by
+			// their keys or their values.
+			id := g.identity(l, item)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// withKeys returns the item, a map, with all the keys of the list set.
+func (g *generator) withKeys(l *schema.List, item interface{}, depth int) interface{} {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	element, _ := g.schema.Resolve(l.ElementType)
+	for _, key := range l.Keys {
+		if _, ok := m[key]; ok {
+			continue
+		}
+		if element.Map == nil {
+			return nil
+		}
+		f, ok := element.Map.FindField(key)
+		if !ok {
+			return nil
+		}
+		m[key] = g.value(f.Type, depth+1)
+	}
+	return m
+}
+
+func (g *generator) identity(l *schema.List, item interface{}) string {
+	keys := l.IdentityKeys()
+	if len(keys) == 0 {
+		return fmt.Sprintf("%#v", item)
+	}
+	m := item.(map[string]interface{})
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%#v", m[key])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (g *generator) mapValue(m *schema.Map, depth int) interface{} {
+	out := map[string]interface{}{}
+	if depth >= g.opts.MaxDepth {
+		return out
+	}
+	for _, f := range m.Fields {
+		if g.rand.Float64() < g.opts.FieldProbability {
+			out[f.Name] = g.value(f.Type, depth+1)
+		}
+	}
+	if (m.ElementType != schema.TypeRef{}) {
+		n := g.length(g.opts.MaxMapSize, depth)
+		for i := 0; i < n; i++ {
+			key := g.string()
+			if _, ok := m.FindField(key); ok {
+				continue
+			}
+			out[key] = g.value(m.ElementType, depth+1)
+		}
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtest
+
+import (
+	"math/rand"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var generateParser = func() *typed.Parser {
+	p, err := typed.NewParser(`types:
+- name: root
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: items
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+          keys: [key, port]
+    - name: tags
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+    - name: child
+      type:
+        namedType: root
+    - name: extra
+      type:
+        namedType: __untyped_atomic_
+- name: item
+  map:
+    fields:
+    - name: key
+      type:
+        scalar: string
+    - name: port
+      type:
+        scalar: numeric
+    - name: value
+      type:
+        scalar: untyped
+- name: __untyped_atomic_
+  scalar: untyped
+  list:
+    elementType:
+      namedType: __untyped_atomic_
+    elementRelationship: atomic
+  map:
+    elementType:
+      namedType: __untyped_atomic_
+    elementRelationship: atomic
+`)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+func TestGenerate(t *testing.T) {
+	pt := generateParser.Type("root")
+	opts := GeneratorOptions{MaxDepth: 4, FieldProbability: 0.8}
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 200; i++ {
+		tv, err := Generate(r, pt, opts)
+		if err != nil {
+			t.Fatalf("object %v: %v", i, err)
+		}
+		// Generated objects can be merged with each other, which also
+		// requires their lists to be valid.
+		if _, err := tv.Merge(tv); err != nil {
+			t.Fatalf("object %v: unable to merge: %v", i, err)
+		}
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	pt := generateParser.Type("root")
+	generate := func(seed int64) []string {
+		r := rand.New(rand.NewSource(seed))
+		var out []string
+		for i := 0; i < 10; i++ {
+			tv, err := Generate(r, pt, GeneratorOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			y, err := value.ToJSON(tv.AsValue())
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, string(y))
+		}
+		return out
+	}
+	a, b := generate(7), generate(7)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("object %v differs with the same seed:\n%v\n%v", i, a[i], b[i])
+		}
+	}
+}
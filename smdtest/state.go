@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package fixture
+package smdtest
 
 import (
 	"bytes"
@@ -158,6 +158,28 @@ func (s *State) ApplyObject(tv *typed.TypedValue, version fieldpath.APIVersion,
 	return nil
 }
 
+// ApplyObjectForcingFields is like ApplyObject, except that only the
+// conflicts on the given fields are forced.
+func (s *State) ApplyObjectForcingFields(tv *typed.TypedValue, version fieldpath.APIVersion, manager string, forced *fieldpath.Set) error {
+	err := s.checkInit(version)
+	if err != nil {
+		return err
+	}
+	s.Live, err = s.Updater.Converter.Convert(s.Live, version)
+	if err != nil {
+		return err
+	}
+	new, managers, err := s.Updater.ApplyForcingFields(s.Live, tv, version, s.Managers, manager, forced)
+	if err != nil {
+		return err
+	}
+	s.Managers = managers
+	if new != nil {
+		s.Live = new
+	}
+	return nil
+}
+
 // Apply the passed in object to the current state
 func (s *State) Apply(obj typed.YAMLObject, version fieldpath.APIVersion, manager string, force bool) error {
 	tv, err := s.Parser.Type(string(version)).FromYAML(FixTabsOrDie(obj))
@@ -257,12 +279,14 @@ func addedConflicts(one, other merge.Conflicts) merge.Conflicts {
 // Apply is a type of operation. It is a non-forced apply run by a
 // manager with a given object. Since non-forced apply operation can
 // conflict, the user can specify the expected conflicts. If conflicts
-// don't match, an error will occur.
+// don't match, an error will occur. Conflicts on ForcedFields, if
+// specified, are forced.
 type Apply struct {
-	Manager    string
-	APIVersion fieldpath.APIVersion
-	Object     typed.YAMLObject
-	Conflicts  merge.Conflicts
+	Manager      string
+	APIVersion   fieldpath.APIVersion
+	Object       typed.YAMLObject
+	Conflicts    merge.Conflicts
+	ForcedFields *fieldpath.Set
 }
 
 var _ Operation = &Apply{}
@@ -281,24 +305,31 @@ func (a Apply) preprocess(parser Parser) (Operation, error) {
 		return nil, err
 	}
 	return ApplyObject{
-		Manager:    a.Manager,
-		APIVersion: a.APIVersion,
-		Object:     tv,
-		Conflicts:  a.Conflicts,
+		Manager:      a.Manager,
+		APIVersion:   a.APIVersion,
+		Object:       tv,
+		Conflicts:    a.Conflicts,
+		ForcedFields: a.ForcedFields,
 	}, nil
 }
 
 type ApplyObject struct {
-	Manager    string
-	APIVersion fieldpath.APIVersion
-	Object     *typed.TypedValue
-	Conflicts  merge.Conflicts
+	Manager      string
+	APIVersion   fieldpath.APIVersion
+	Object       *typed.TypedValue
+	Conflicts    merge.Conflicts
+	ForcedFields *fieldpath.Set
 }
 
 var _ Operation = &ApplyObject{}
 
 func (a ApplyObject) run(state *State) error {
-	err := state.ApplyObject(a.Object, a.APIVersion, a.Manager, false)
+	var err error
+	if a.ForcedFields != nil {
+		err = state.ApplyObjectForcingFields(a.Object, a.APIVersion, a.Manager, a.ForcedFields)
+	} else {
+		err = state.ApplyObject(a.Object, a.APIVersion, a.Manager, false)
+	}
 	if err != nil {
 		if _, ok := err.(merge.Conflicts); !ok || a.Conflicts == nil {
 			return err
@@ -540,6 +571,16 @@ type TestCase struct {
 	ReturnInputOnNoop bool
 	// IgnoredFields containing the set to ignore for every version
 	IgnoredFields map[fieldpath.APIVersion]*fieldpath.Set
+	// ConflictResolver, if set, is passed to the updater.
+	ConflictResolver merge.ConflictResolver
+	// ManagerExpiration, if set, is passed to the updater.
+	ManagerExpiration merge.ManagerExpiration
+	// SubresourceScopes, if set, is passed to the updater.
+	SubresourceScopes map[string]*fieldpath.Set
+	// SharedFields, if set, is passed to the updater.
+	SharedFields map[fieldpath.APIVersion]*fieldpath.Set
+	// ChildManagers, if set, is passed to the updater.
+	ChildManagers map[string][]string
 }
 
 // Test runs the test-case using the given parser and a dummy converter.
@@ -574,6 +615,11 @@ func (tc TestCase) BenchWithConverter(parser Parser, converter merge.Converter)
 		Converter:         converter,
 		IgnoredFields:     tc.IgnoredFields,
 		ReturnInputOnNoop: tc.ReturnInputOnNoop,
+		ConflictResolver:  tc.ConflictResolver,
+		ManagerExpiration: tc.ManagerExpiration,
+		SubresourceScopes: tc.SubresourceScopes,
+		SharedFields:      tc.SharedFields,
+		ChildManagers:     tc.ChildManagers,
 	}
 	state := State{
 		Updater: updaterBuilder.BuildUpdater(),
@@ -596,6 +642,11 @@ func (tc TestCase) TestWithConverter(parser Parser, converter merge.Converter) e
 		Converter:         converter,
 		IgnoredFields:     tc.IgnoredFields,
 		ReturnInputOnNoop: tc.ReturnInputOnNoop,
+		ConflictResolver:  tc.ConflictResolver,
+		ManagerExpiration: tc.ManagerExpiration,
+		SubresourceScopes: tc.SubresourceScopes,
+		SharedFields:      tc.SharedFields,
+		ChildManagers:     tc.ChildManagers,
 	}
 	state := State{
 		Updater: updaterBuilder.BuildUpdater(),
@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtesting
+
+import "fmt"
+
+// CheckDeterministic calls f the given number of times, and returns an
+// error showing the first output that differs from the first one. It's
+// meant to check that the outputs that golden tests compare, such as
+// serialized sets, errors and comparisons, don't depend on the order in
+// which Go iterates maps, which changes from one call to the next.
+func CheckDeterministic(runs int, f func() string) error {
+	if runs < 2 {
+		return fmt.Errorf("at least 2 runs are needed to compare outputs, got %v", runs)
+	}
+	first := f()
+	for i := 1; i < runs; i++ {
+		if out := f(); out != first {
+			return fmt.Errorf("run %v differs from the first run:\n%v\nfirst run:\n%v", i+1, out, first)
+		}
+	}
+	return nil
+}
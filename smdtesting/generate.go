@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtesting
+
+import (
+	"fmt"
+	"math/rand"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// RandomValue returns a random value conforming to the type, as generated
+// by smdtest.Generate.
+func RandomValue(r *rand.Rand, t typed.ParseableType, opts smdtest.GeneratorOptions) (*typed.TypedValue, error) {
+	return smdtest.Generate(r, t, opts)
+}
+
+// SetOptions are the knobs of RandomSet. Zero values pick the defaults.
+type SetOptions struct {
+	// MaxDepth bounds the length of the paths. It defaults to 4.
+	MaxDepth int
+	// MaxChildren bounds the number of children of each path. It
+	// defaults to 3.
+	MaxChildren int
+}
+
+func (o SetOptions) withDefaults() SetOptions {
+	if o.MaxDepth == 0 {
+		o.MaxDepth = 4
+	}
+	if o.MaxChildren == 0 {
+		o.MaxChildren = 3
+	}
+	return o
+}
+
+// RandomSet returns a random set, regardless of any schema, with path
+// elements of every kind. Its elements are drawn from a small alphabet, so
+// that sets generated with the same options share many paths.
+func RandomSet(r *rand.Rand, opts SetOptions) *fieldpath.Set {
+	opts = opts.withDefaults()
+	s := fieldpath.NewSet()
+	var add func(prefix fieldpath.Path)
+	add = func(prefix fieldpath.Path) {
+		if len(prefix) >= opts.MaxDepth {
+			return
+		}
+		n := r.Intn(opts.MaxChildren + 1)
+		for i := 0; i < n; i++ {
+			p := append(prefix.Copy(), randomPathElement(r))
+			if r.Intn(2) == 0 {
+				s.Insert(p)
+			}
+			add(p)
+		}
+	}
+	add(nil)
+	return s
+}
+
+func randomPathElement(r *rand.Rand) fieldpath.PathElement {
+	switch r.Intn(4) {
+	case 0:
+		v := value.NewValueInterface(fmt.Sprintf("v%d", r.Intn(3)))
+		return fieldpath.PathElement{Value: &v}
+	case 1:
+		i := r.Intn(3)
+		return fieldpath.PathElement{Index: &i}
+	case 2:
+		return fieldpath.PathElement{Key: &value.FieldList{{
+			Name:  "name",
+			Value: value.NewValueInterface(fmt.Sprintf("k%d", r.Intn(3))),
+		}}}
+	}
+	name := fmt.Sprintf("f%d", r.Intn(3))
+	return fieldpath.PathElement{FieldName: &name}
+}
+
+// RandomSubset returns a subset of s, keeping each of its paths with the
+// given probability. Applied to the set of fields of a value, it gives the
+// kind of sets that managers own.
+func RandomSubset(r *rand.Rand, s *fieldpath.Set, probability float64) *fieldpath.Set {
+	out := fieldpath.NewSet()
+	s.Iterate(func(p fieldpath.Path) {
+		if r.Float64() < probability {
+			out.Insert(p)
+		}
+	})
+	return out
+}
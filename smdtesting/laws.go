@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtesting
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// CheckSetLaws returns an error describing the first law of set algebra
+// that a, b and c don't obey, if any.
+func CheckSetLaws(a, b, c *fieldpath.Set) error {
+	empty := fieldpath.NewSet()
+	laws := []struct {
+		name string
+		lhs  *fieldpath.Set
+		rhs  *fieldpath.Set
+	}{
+		{"a ∪ b = b ∪ a", a.Union(b), b.Union(a)},
+		{"a ∩ b = b ∩ a", a.Intersection(b), b.Intersection(a)},
+		{"(a ∪ b) ∪ c = a ∪ (b ∪ c)", a.Union(b).Union(c), a.Union(b.Union(c))},
+		{"(a ∩ b) ∩ c = a ∩ (b ∩ c)", a.Intersection(b).Intersection(c), a.Intersection(b.Intersection(c))},
+		{"a ∩ (b ∪ c) = (a ∩ b) ∪ (a ∩ c)", a.Intersection(b.Union(c)), a.Intersection(b).Union(a.Intersection(c))},
+		{"a ∪ (b ∩ c) = (a ∪ b) ∩ (a ∪ c)", a.Union(b.Intersection(c)), a.Union(b).Intersection(a.Union(c))},
+		{"a ∪ ∅ = a", a.Union(empty), a},
+		{"a ∪ a = a", a.Union(a), a},
+		{"a ∩ a = a", a.Intersection(a), a},
+		{"a ∩ ∅ = ∅", a.Intersection(empty), empty},
+		{"a − a = ∅", a.Difference(a), empty},
+		{"(a − b) ∩ b = ∅", a.Difference(b).Intersection(b), empty},
+		{"(a − b) ∪ (a ∩ b) = a", a.Difference(b).Union(a.Intersection(b)), a},
+		{"a − (b ∪ c) = (a − b) ∩ (a − c)", a.Difference(b.Union(c)), a.Difference(b).Intersection(a.Difference(c))},
+	}
+	for _, law := range laws {
+		if !law.lhs.Equals(law.rhs) {
+			return fmt.Errorf("%v doesn't hold for a = %v, b = %v, c = %v: got\n%v\nand\n%v", law.name, a, b, c, law.lhs, law.rhs)
+		}
+	}
+	if !a.Intersection(b).IsSubsetOf(a) || !a.IsSubsetOf(a.Union(b)) {
+		return fmt.Errorf("a ∩ b ⊆ a ⊆ a ∪ b doesn't hold for a = %v, b = %v", a, b)
+	}
+	var err error
+	a.Iterate(func(p fieldpath.Path) {
+		if err == nil && (!a.Has(p) || !a.Union(b).Has(p) || b.Difference(a).Has(p)) {
+			err = fmt.Errorf("membership of %v is inconsistent for a = %v, b = %v", p, a, b)
+		}
+	})
+	return err
+}
+
+// CheckMergeLaws returns an error describing the first law of merges and
+// extractions that lhs and rhs, values of the same type, don't obey, if
+// any:
+//   - merging is idempotent: merging rhs into the merge of lhs and rhs,
+//     or a value into itself, changes nothing;
+//   - the fields of rhs, except its empty lists and maps, are fields of its
+//     merge into lhs;
+//   - extracting the leaf fields of a value gives it back, and extracting
+//     the leaf fields of rhs from its merge into lhs gives back rhs, up to
+//     empty lists and maps, which extractions drop or turn into nulls.
+//
+// Values are equal if Compare finds no differences between them, so that
+// the order of the items of associative lists doesn't matter.
+func CheckMergeLaws(lhs, rhs *typed.TypedValue) error {
+	merged, err := lhs.Merge(rhs)
+	if err != nil {
+		return fmt.Errorf("unable to merge: %v", err)
+	}
+	again, err := merged.Merge(rhs)
+	if err != nil {
+		return fmt.Errorf("unable to merge again: %v", err)
+	}
+	if err := same("merge(merge(lhs, rhs), rhs) = merge(lhs, rhs)", merged, again); err != nil {
+		return err
+	}
+	self, err := lhs.Merge(lhs)
+	if err != nil {
+		return fmt.Errorf("unable to merge lhs into itself: %v", err)
+	}
+	if err := same("merge(lhs, lhs) = lhs", lhs, self); err != nil {
+		return err
+	}
+
+	// Empty lists and maps of rhs are leaves, which are no longer fields
+	// once merged with items.
+	prunedRHS, err := withoutEmpty(rhs)
+	if err != nil {
+		return err
+	}
+	rhsSet, err := prunedRHS.ToFieldSet()
+	if err != nil {
+		return fmt.Errorf("unable to get the fields of rhs: %v", err)
+	}
+	mergedSet, err := merged.ToFieldSet()
+	if err != nil {
+		return fmt.Errorf("unable to get the fields of the merge: %v", err)
+	}
+	if !rhsSet.IsSubsetOf(mergedSet) {
+		return fmt.Errorf("fields(rhs) ⊆ fields(merge(lhs, rhs)) doesn't hold: %v is not a subset of %v", rhsSet, mergedSet)
+	}
+	// Extractions take the leaves of sets, as managed fields are used.
+	leaves := rhsSet.Leaves()
+	if err := sameWithoutEmpty("extract(rhs, fields(rhs)) = rhs", rhs, rhs.ExtractItems(leaves)); err != nil {
+		return err
+	}
+	return sameWithoutEmpty("extract(merge(lhs, rhs), fields(rhs)) = rhs", rhs, merged.ExtractItems(leaves))
+}
+
+func same(law string, want, got *typed.TypedValue) error {
+	c, err := want.Compare(got)
+	if err != nil {
+		return fmt.Errorf("%v: unable to compare: %v", law, err)
+	}
+	if !c.IsSame() {
+		return fmt.Errorf("%v doesn't hold: %v", law, c)
+	}
+	return nil
+}
+
+// sameWithoutEmpty is like same, ignoring the null, empty and thus
+// pruned lists and maps of the values.
+func sameWithoutEmpty(law string, want, got *typed.TypedValue) error {
+	prunedWant, err := withoutEmpty(want)
+	if err != nil {
+		return fmt.Errorf("%v: %v", law, err)
+	}
+	prunedGot, err := withoutEmpty(got)
+	if err != nil {
+		return fmt.Errorf("%v: %v", law, err)
+	}
+	return same(law, prunedWant, prunedGot)
+}
+
+func withoutEmpty(tv *typed.TypedValue) (*typed.TypedValue, error) {
+	pruned, _ := prune(tv.AsValue().Unstructured())
+	return typed.AsTyped(value.NewValueInterface(pruned), tv.Schema(), tv.TypeRef())
+}
+
+// prune removes the null and empty fields of maps, recursively, and returns
+// whether the value is empty or null.
+func prune(u interface{}) (interface{}, bool) {
+	switch u := u.(type) {
+	case nil:
+		return nil, true
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, v := range u {
+			if v, empty := prune(v); !empty {
+				out[k] = v
+			}
+		}
+		return out, len(out) == 0
+	case []interface{}:
+		out := make([]interface{}, len(u))
+		for i, v := range u {
+			out[i], _ = prune(v)
+		}
+		return out, len(out) == 0
+	}
+	return u, false
+}
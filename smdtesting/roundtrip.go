@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtesting
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Divergence is the first difference found by CheckRoundTrip.
+type Divergence struct {
+	// Step is the check that found it, like "JSON round trip".
+	Step string
+	// Path is the path of the first differing value, like .spec.replicas,
+	// if the step compares values.
+	Path string
+	// Want and Got are the expected and actual values at Path, or a
+	// description of the failure.
+	Want, Got string
+}
+
+func (d *Divergence) Error() string {
+	if d.Path == "" && d.Want == "" {
+		return fmt.Sprintf("%v: %v", d.Step, d.Got)
+	}
+	return fmt.Sprintf("%v: %v: expected %v, got %v", d.Step, d.Path, d.Want, d.Got)
+}
+
+// CheckRoundTrip checks that the object, a value of the type, survives
+// the operations that persist and apply it unchanged, and returns a
+// *Divergence describing the first one it doesn't, if any:
+//   - its Unstructured form is equal to it, as custom Value
+//     implementations must ensure;
+//   - it reads back the same after being written to JSON or YAML, and
+//     writing it back to JSON gives the same document;
+//   - it is valid, and merging it into itself doesn't change it;
+//   - extracting its leaf fields gives it back, up to empty lists and
+//     maps, and extracting them again changes nothing.
+//
+// It's meant for the CI of schema changes and for debugging custom Value
+// implementations.
+func CheckRoundTrip(t typed.ParseableType, obj value.Value) error {
+	if d := diverge("Unstructured", obj, value.NewValueInterface(obj.Unstructured())); d != nil {
+		return d
+	}
+
+	j, err := value.ToJSON(obj)
+	if err != nil {
+		return &Divergence{Step: "JSON serialization", Got: err.Error()}
+	}
+	fromJSON, err := value.FromJSON(j)
+	if err != nil {
+		return &Divergence{Step: "JSON parsing", Got: err.Error()}
+	}
+	if d := diverge("JSON round trip", obj, fromJSON); d != nil {
+		return d
+	}
+	j2, err := value.ToJSON(fromJSON)
+	if err != nil {
+		return &Divergence{Step: "JSON serialization", Got: err.Error()}
+	}
+	if string(j) != string(j2) {
+		return &Divergence{Step: "JSON serialization stability", Want: string(j), Got: string(j2)}
+	}
+
+	y, err := value.ToYAML(obj)
+	if err != nil {
+		return &Divergence{Step: "YAML serialization", Got: err.Error()}
+	}
+	fromYAML, err := t.FromYAML(typed.YAMLObject(y))
+	if err != nil {
+		return &Divergence{Step: "YAML parsing", Got: err.Error()}
+	}
+	if d := diverge("YAML round trip", obj, fromYAML.AsValue()); d != nil {
+		return d
+	}
+
+	tv, err := typed.AsTyped(obj, t.Schema, t.TypeRef)
+	if err != nil {
+		return &Divergence{Step: "validation", Got: err.Error()}
+	}
+	merged, err := tv.Merge(tv)
+	if err != nil {
+		return &Divergence{Step: "merge", Got: err.Error()}
+	}
+	if err := same("merge(obj, obj) = obj", tv, merged); err != nil {
+		return &Divergence{Step: "merge idempotency", Got: err.Error()}
+	}
+
+	set, err := tv.ToFieldSet()
+	if err != nil {
+		return &Divergence{Step: "field set", Got: err.Error()}
+	}
+	leaves := set.Leaves()
+	extracted := tv.ExtractItems(leaves)
+	if err := sameWithoutEmpty("extract(obj, fields(obj)) = obj", tv, extracted); err != nil {
+		return &Divergence{Step: "extraction", Got: err.Error()}
+	}
+	if d := diverge("extraction idempotency", extracted.AsValue(), extracted.ExtractItems(leaves).AsValue()); d != nil {
+		return d
+	}
+	return nil
+}
+
+// diverge returns the first difference between want and got, or nil if
+// they are equal.
+func diverge(step string, want, got value.Value) *Divergence {
+	if value.Equals(want, got) {
+		return nil
+	}
+	path, w, g := firstDifference(want, got, "")
+	return &Divergence{Step: step, Path: path, Want: w, Got: g}
+}
+
+// firstDifference returns the path of the first difference between lhs
+// and rhs, in the order of the sorted keys of maps, and their values
+// there.
+func firstDifference(lhs, rhs value.Value, path string) (string, string, string) {
+	switch {
+	case lhs != nil && rhs != nil && lhs.IsMap() && rhs.IsMap():
+		lm, rm := lhs.AsMap(), rhs.AsMap()
+		keys := map[string]bool{}
+		for _, m := range []value.Map{lm, rm} {
+			m.Iterate(func(k string, _ value.Value) bool {
+				keys[k] = true
+				return true
+			})
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			l, lok := lm.Get(k)
+			r, rok := rm.Get(k)
+			if !lok || !rok || !value.Equals(l, r) {
+				if !lok {
+					l = nil
+				}
+				if !rok {
+					r = nil
+				}
+				return firstDifference(l, r, path+"."+k)
+			}
+		}
+	case lhs != nil && rhs != nil && lhs.IsList() && rhs.IsList():
+		ll, rl := lhs.AsList(), rhs.AsList()
+		for i := 0; i < ll.Length() && i < rl.Length(); i++ {
+			if !value.Equals(ll.At(i), rl.At(i)) {
+				return firstDifference(ll.At(i), rl.At(i), path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	}
+	return path, describe(lhs), describe(rhs)
+}
+
+func describe(v value.Value) string {
+	if v == nil {
+		return "nothing"
+	}
+	return value.ToString(v)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smdtesting helps property testing code integrating with
+// structured-merge-diff: it generates random schemas, values conforming
+// to them and sets of fields, and checks the laws that merges,
+// extractions and sets must obey on them.
+//
+// All the generators take their randomness from a *rand.Rand, so that
+// failures can be reproduced from its seed.
+package smdtesting
+
+import (
+	"fmt"
+	"math/rand"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// RootType is the name of the type of the objects of the random schemas.
+const RootType = "root"
+
+// SchemaOptions are the knobs of RandomSchema. Zero values pick the
+// defaults.
+type SchemaOptions struct {
+	// MaxFields bounds the number of fields of each struct. It defaults
+	// to 6.
+	MaxFields int
+	// MaxDepth bounds the nesting of structs. It defaults to 3.
+	MaxDepth int
+}
+
+func (o SchemaOptions) withDefaults() SchemaOptions {
+	if o.MaxFields == 0 {
+		o.MaxFields = 6
+	}
+	if o.MaxDepth == 0 {
+		o.MaxDepth = 3
+	}
+	return o
+}
+
+// RandomSchema returns a random schema, whose objects are of RootType. Its
+// structs have scalar fields, atomic and granular maps, atomic lists, sets,
+// lists of structs keyed by one or two of their fields, and nested structs.
+func RandomSchema(r *rand.Rand, opts SchemaOptions) *schema.Schema {
+	g := schemaGenerator{rand: r, opts: opts.withDefaults()}
+	g.structType(RootType, nil, 0)
+	return &schema.Schema{Types: g.types}
+}
+
+// RandomParseableType returns the root type of a random schema.
+func RandomParseableType(r *rand.Rand, opts SchemaOptions) typed.ParseableType {
+	root := RootType
+	return typed.ParseableType{
+		Schema:  RandomSchema(r, opts),
+		TypeRef: schema.TypeRef{NamedType: &root},
+	}
+}
+
+type schemaGenerator struct {
+	rand  *rand.Rand
+	opts  SchemaOptions
+	types []schema.TypeDef
+}
+
+var scalars = []schema.Scalar{schema.String, schema.Numeric, schema.Boolean}
+
+func (g *schemaGenerator) scalar() schema.TypeRef {
+	s := scalars[g.rand.Intn(len(scalars))]
+	return schema.TypeRef{Inlined: schema.Atom{Scalar: &s}}
+}
+
+func namedType(name string) schema.TypeRef {
+	return schema.TypeRef{NamedType: &name}
+}
+
+// structType adds a struct named name with the given fields, and random
+// ones, and returns a reference to it.
+func (g *schemaGenerator) structType(name string, fields []schema.StructField, depth int) schema.TypeRef {
+	// Add the type first, so that nested types come after it.
+	i := len(g.types)
+	g.types = append(g.types, schema.TypeDef{Name: name})
+	n := 1 + g.rand.Intn(g.opts.MaxFields)
+	for j := 0; j < n; j++ {
+		fields = append(fields, schema.StructField{
+			Name: fmt.Sprintf("f%d", j),
+			Type: g.fieldType(fmt.Sprintf("%v.f%d", name, j), depth),
+		})
+	}
+	g.types[i].Atom = schema.Atom{Map: &schema.Map{Fields: fields}}
+	return namedType(name)
+}
+
+func (g *schemaGenerator) fieldType(name string, depth int) schema.TypeRef {
+	kinds := 5
+	if depth < g.opts.MaxDepth {
+		kinds = 7
+	}
+	switch g.rand.Intn(kinds) {
+	case 1:
+		return schema.TypeRef{Inlined: schema.Atom{List: &schema.List{
+			ElementType:         g.scalar(),
+			ElementRelationship: schema.Atomic,
+		}}}
+	case 2:
+		return schema.TypeRef{Inlined: schema.Atom{List: &schema.List{
+			ElementType:         g.scalar(),
+			ElementRelationship: schema.Associative,
+		}}}
+	case 3:
+		return schema.TypeRef{Inlined: schema.Atom{Map: &schema.Map{
+			ElementType:         g.scalar(),
+			ElementRelationship: schema.Atomic,
+		}}}
+	case 4:
+		return schema.TypeRef{Inlined: schema.Atom{Map: &schema.Map{
+			ElementType: g.scalar(),
+		}}}
+	case 5:
+		return g.structType(name, nil, depth+1)
+	case 6:
+		keys := []schema.StructField{{Name: "name", Type: g.scalar()}}
+		if g.rand.Intn(2) == 0 {
+			keys = append(keys, schema.StructField{Name: "port", Type: g.scalar()})
+		}
+		l := &schema.List{
+			ElementType:         g.structType(name, keys, depth+1),
+			ElementRelationship: schema.Associative,
+		}
+		for _, k := range keys {
+			l.Keys = append(l.Keys, k.Name)
+		}
+		return schema.TypeRef{Inlined: schema.Atom{List: l}}
+	}
+	return g.scalar()
+}
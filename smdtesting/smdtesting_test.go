@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smdtesting
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestRandomSchemaIsValid(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		s := RandomSchema(r, SchemaOptions{})
+		for _, p := range schema.Lint(s) {
+			if p.Severity == schema.SeverityError {
+				t.Fatalf("schema %v: %v", i, p)
+			}
+		}
+	}
+}
+
+func TestSetLaws(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a, b, c := RandomSet(r, SetOptions{}), RandomSet(r, SetOptions{}), RandomSet(r, SetOptions{})
+		if err := CheckSetLaws(a, b, c); err != nil {
+			t.Fatalf("sets %v: %v", i, err)
+		}
+	}
+}
+
+func TestMergeLaws(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		pt := RandomParseableType(r, SchemaOptions{})
+		for j := 0; j < 10; j++ {
+			lhs, err := RandomValue(r, pt, smdtest.GeneratorOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rhs, err := RandomValue(r, pt, smdtest.GeneratorOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckMergeLaws(lhs, rhs); err != nil {
+				t.Fatalf("schema %v, values %v: %v", i, j, err)
+			}
+		}
+	}
+}
+
+func TestRandomSubset(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := RandomSet(r, SetOptions{MaxDepth: 5})
+	if sub := RandomSubset(r, s, 0.5); !sub.IsSubsetOf(s) {
+		t.Errorf("%v is not a subset of %v", sub, s)
+	}
+	if all := RandomSubset(r, s, 1); !all.Equals(s) {
+		t.Errorf("expected %v, got %v", s, all)
+	}
+}
+
+func TestCheckDeterministic(t *testing.T) {
+	m := map[string]bool{"a": true, "b": true, "c": true, "d": true, "e": true}
+	err := CheckDeterministic(100, func() string {
+		out := ""
+		for k := range m {
+			out += k
+		}
+		return out
+	})
+	if err == nil {
+		t.Error("expected the iteration of a map not to be deterministic")
+	}
+}
+
+func TestOutputsAreDeterministic(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: root
+  map:
+    elementType:
+      scalar: numeric
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := map[string]interface{}{}
+	managers := fieldpath.ManagedFields{}
+	for i := 0; i < 20; i++ {
+		invalid[fmt.Sprintf("k%d", i)] = "not a number"
+		managers[fmt.Sprintf("m%d", i)] = fieldpath.NewVersionedSet(
+			fieldpath.NewSet(fieldpath.MakePathOrDie(fmt.Sprintf("k%d", i))), "v1", false)
+	}
+
+	outputs := map[string]func() string{
+		"validation errors": func() string {
+			_, err := parser.Type("root").FromUnstructured(invalid)
+			return err.Error()
+		},
+		"conflicts": func() string {
+			conflicts := merge.ConflictsFromManagers(managers)
+			out := ""
+			for _, c := range conflicts {
+				out += c.Error() + "\n"
+			}
+			return out
+		},
+		"managed fields": managers.String,
+	}
+	for name, f := range outputs {
+		if err := CheckDeterministic(20, f); err != nil {
+			t.Errorf("%v: %v", name, err)
+		}
+	}
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		pt := RandomParseableType(r, SchemaOptions{})
+		for j := 0; j < 10; j++ {
+			tv, err := RandomValue(r, pt, smdtest.GeneratorOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := CheckRoundTrip(pt, tv.AsValue()); err != nil {
+				t.Fatalf("schema %v, value %v: %v", i, j, err)
+			}
+		}
+	}
+}
+
+// inconsistentValue is a custom Value whose Unstructured form differs from
+// what its other methods return.
+type inconsistentValue struct {
+	value.Value
+}
+
+func (v inconsistentValue) Unstructured() interface{} {
+	return map[string]interface{}{"a": map[string]interface{}{"b": "wrong"}}
+}
+
+func TestCheckRoundTripDivergence(t *testing.T) {
+	v := inconsistentValue{value.NewValueInterface(map[string]interface{}{"a": map[string]interface{}{"b": "right"}})}
+	err := CheckRoundTrip(typed.DeducedParseableType, v)
+	d, ok := err.(*Divergence)
+	if !ok {
+		t.Fatalf("expected a divergence, got %v", err)
+	}
+	if d.Step != "Unstructured" || d.Path != ".a.b" || d.Want != `"right"` || d.Got != `"wrong"` {
+		t.Errorf("unexpected divergence: %v", d)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// ErrBudgetExceeded is returned by the operations that ran out of their
+// Budget.
+var ErrBudgetExceeded = errors.New("operation exceeded its budget")
+
+// budgetExceeded returns the errors unwinding the walkers once the budget
+// is exceeded. They are replaced with ErrBudgetExceeded before being
+// returned.
+func budgetExceeded() ValidationErrors {
+	return errorf("budget exceeded")
+}
+
+// Budget bounds the work, and so approximately the memory, that operations
+// can spend on their inputs, by counting the nodes of the values they
+// visit. The same Budget can be shared by all the operations serving a
+// request, including concurrently.
+type Budget struct {
+	maxNodes int64
+	used     int64
+}
+
+// NewBudget returns a Budget allowing operations to visit up to maxNodes
+// nodes in total.
+func NewBudget(maxNodes int64) *Budget {
+	return &Budget{maxNodes: maxNodes}
+}
+
+// Used returns the number of nodes visited so far, which may exceed the
+// budget by the nodes that were being visited when it ran out.
+func (b *Budget) Used() int64 {
+	return atomic.LoadInt64(&b.used)
+}
+
+// spend spends a node of the budget, and returns false if it's exceeded.
+// A nil budget is unlimited.
+func (b *Budget) spend() bool {
+	if b == nil {
+		return true
+	}
+	return atomic.AddInt64(&b.used, 1) <= b.maxNodes
+}
+
+func (b *Budget) exceeded() bool {
+	return b != nil && atomic.LoadInt64(&b.used) > b.maxNodes
+}
+
+// walkOptions are the options of the merging and comparing walkers.
+type walkOptions struct {
+	// If set, large lists are processed concurrently.
+	parallelism *ListParallelism
+	// If set, the walk aborts once it visited more nodes than allowed.
+	budget *Budget
+	// If set, used instead of the walker's own allocator.
+	scratch value.Allocator
+	// If set, merges reuse the subtrees that only one side sets instead
+	// of rebuilding them.
+	shareUnchanged bool
+	// If set, the inputs are checked against the limits before being
+	// walked.
+	limits value.Limits
+	// If set, panics are returned as a *PanicError.
+	recoverPanics bool
+	// If set, semantically equal scalars compare as unchanged.
+	equalities *Equalities
+}
+
+// MergeWithBudget is like Merge, except that it fails with
+// ErrBudgetExceeded once it visited more nodes than the budget allows.
+func (tv TypedValue) MergeWithBudget(pso *TypedValue, b *Budget) (*TypedValue, error) {
+	return mergeKeepRHS(&tv, pso, walkOptions{budget: b})
+}
+
+// CompareWithBudget is like Compare, except that it fails with
+// ErrBudgetExceeded once it visited more nodes than the budget allows.
+func (tv TypedValue) CompareWithBudget(rhs *TypedValue, b *Budget) (*Comparison, error) {
+	return compare(&tv, rhs, walkOptions{budget: b})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestBudget(t *testing.T) {
+	lhs, err := typed.DeducedParseableType.FromYAML(`{"a": 1, "b": {"c": 2, "d": 3}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := typed.DeducedParseableType.FromYAML(`{"a": 2, "b": {"c": 2, "e": 4}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget := typed.NewBudget(100)
+	if _, err := lhs.MergeWithBudget(rhs, budget); err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if _, err := lhs.CompareWithBudget(rhs, budget); err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	// Merge visits the root, a, b and c, d and e in b.
+	if used := budget.Used(); used != 12 {
+		t.Errorf("expected 12 nodes to be used, got %v", used)
+	}
+
+	if _, err := lhs.MergeWithBudget(rhs, typed.NewBudget(5)); err != typed.ErrBudgetExceeded {
+		t.Errorf("expected the merge to exceed its budget, got %v", err)
+	}
+	if _, err := lhs.CompareWithBudget(rhs, typed.NewBudget(5)); err != typed.ErrBudgetExceeded {
+		t.Errorf("expected the comparison to exceed its budget, got %v", err)
+	}
+	if _, err := lhs.CompareWithBudget(rhs, typed.NewBudget(6)); err != nil {
+		t.Errorf("expected the comparison to fit its budget, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	limits := value.Limits{MaxDepth: 2, MaxElements: 2}
+	shallow, err := typed.DeducedParseableType.FromYAML(`{"a": 1, "b": {"c": 2}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deep, err := typed.DeducedParseableType.FromYAML(`{"a": 1, "b": {"c": {"d": 3}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wide, err := typed.DeducedParseableType.FromYAML(`{"a": 1, "b": {"c": 2, "d": 3, "e": 4}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := typed.Options{Limits: limits}
+	if _, err := shallow.MergeWithOptions(shallow, opts); err != nil {
+		t.Errorf("failed to merge: %v", err)
+	}
+	if _, err := shallow.CompareWithOptions(shallow, opts); err != nil {
+		t.Errorf("failed to compare: %v", err)
+	}
+	for _, tt := range []struct {
+		tv    *typed.TypedValue
+		limit string
+		path  string
+	}{{deep, "depth", ".b.c"}, {wide, "elements", ".b"}} {
+		_, err := shallow.MergeWithOptions(tt.tv, opts)
+		if le, ok := err.(*value.LimitError); !ok || le.Limit != tt.limit || le.Path != tt.path {
+			t.Errorf("expected the merge to exceed the %v limit at %v, got %v", tt.limit, tt.path, err)
+		}
+		_, err = tt.tv.CompareWithOptions(shallow, opts)
+		if le, ok := err.(*value.LimitError); !ok || le.Limit != tt.limit {
+			t.Errorf("expected the comparison to exceed the %v limit, got %v", tt.limit, err)
+		}
+	}
+
+	if _, err := typed.DeducedParseableType.FromYAMLWithLimits(`{"a": {"b": {"c": 1}}}`, limits); err == nil {
+		t.Error("expected parsing to exceed the depth limit")
+	}
+	if _, err := typed.DeducedParseableType.FromYAMLWithLimits(`{"a": {"b": 1}}`, limits); err != nil {
+		t.Errorf("failed to parse: %v", err)
+	}
+}
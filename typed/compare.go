@@ -17,6 +17,7 @@ limitations under the License.
 package typed
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -37,6 +38,9 @@ type Comparison struct {
 	Modified *fieldpath.Set
 	// Added contains any fields added by rhs.
 	Added *fieldpath.Set
+
+	// rhs holds the values of the added and modified fields, if known.
+	rhs *TypedValue
 }
 
 // IsSame returns true if the comparison returned no changes (the two
@@ -60,6 +64,29 @@ func (c *Comparison) String() string {
 	return bld.String()
 }
 
+// Apply applies the comparison to tv as a patch, e.g. to rebase changes
+// onto a newer version of the object they were computed from: the removed
+// fields are removed from tv, and the added and modified ones are set to
+// their value in rhs, along with the keys of their list items. tv must be
+// of the same type as the compared objects.
+//
+// Only the comparisons returned by Compare know the values of their
+// fields; Apply fails on the others.
+func (c *Comparison) Apply(tv *TypedValue) (*TypedValue, error) {
+	if c.rhs == nil {
+		return nil, errors.New("comparison doesn't hold the values of its fields")
+	}
+	if err := checkSameType(tv, c.rhs); err != nil {
+		return nil, err
+	}
+	patched := tv.RemoveItems(c.Removed)
+	changed := c.Added.Union(c.Modified)
+	if changed.Empty() {
+		return patched, nil
+	}
+	return patched.Merge(c.rhs.ExtractItems(changed.WithListKeys().Leaves()))
+}
+
 // ExcludeFields fields from the compare recursively removes the fields
 // from the entire comparison
 func (c *Comparison) ExcludeFields(fields *fieldpath.Set) *Comparison {
@@ -91,17 +118,25 @@ type compareWalker struct {
 	spareWalkers *[]*compareWalker
 
 	allocator value.Allocator
+
+	walkOptions
 }
 
 // compare compares stuff.
 func (w *compareWalker) compare(prefixFn func() string) (errs ValidationErrors) {
+	if w.recoverPanics {
+		defer annotatePanic(w.path)
+	}
 	if w.lhs == nil && w.rhs == nil {
 		// check this condidition here instead of everywhere below.
 		return errorf("at least one of lhs and rhs must be provided")
 	}
+	if !w.budget.spend() {
+		return budgetExceeded()
+	}
 	a, ok := w.schema.Resolve(w.typeRef)
 	if !ok {
-		return errorf("schema error: no type found matching: %v", *w.typeRef.NamedType)
+		return kindErrorf(schemaNotFound, "schema error: no type found matching: %v", *w.typeRef.NamedType)
 	}
 
 	alhs := deduceAtom(a, w.lhs)
@@ -159,6 +194,14 @@ func (w *compareWalker) doScalar(t *schema.Scalar) ValidationErrors {
 		return append(lerrs, rerrs...)
 	}
 
+	// Semantically equal scalars are unchanged.
+	if w.lhs != nil && w.rhs != nil && !w.inLeaf {
+		if eq, ok := w.equalities.of(w.typeRef, w.lhs, w.rhs); ok && eq(w.lhs, w.rhs) {
+			w.inLeaf = true
+			return nil
+		}
+	}
+
 	// All scalars are leaf fields.
 	w.doLeaf()
 
@@ -198,7 +241,7 @@ func (w *compareWalker) derefMap(prefix string, v value.Value) (value.Map, Valid
 	}
 	m, err := mapValue(w.allocator, v)
 	if err != nil {
-		return nil, errorf("%v: %v", prefix, err)
+		return nil, kindErrorf(kindOf(err), "%v: %v", prefix, err)
 	}
 	return m, nil
 }
@@ -227,7 +270,7 @@ func (w *compareWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		child := lhs.At(i)
 		pe, err := listItemToPathElement(w.allocator, w.schema, t, child)
 		if err != nil {
-			errs = append(errs, errorf("element %v: %v", i, err.Error())...)
+			errs = append(errs, kindErrorf(kindOf(err), "element %v: %v", i, err.Error())...)
 			// If we can't construct the path element, we can't
 			// even report errors deeper in the schema, so bail on
 			// this element.
@@ -249,7 +292,7 @@ func (w *compareWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		rValue := rhs.At(i)
 		pe, err := listItemToPathElement(w.allocator, w.schema, t, rValue)
 		if err != nil {
-			errs = append(errs, errorf("element %v: %v", i, err.Error())...)
+			errs = append(errs, kindErrorf(kindOf(err), "element %v: %v", i, err.Error())...)
 			// If we can't construct the path element, we can't
 			// even report errors deeper in the schema, so bail on
 			// this element.
@@ -266,6 +309,7 @@ func (w *compareWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		}
 	}
 
+	items := make([]listItem, 0, len(allPEs))
 	for _, pe := range allPEs {
 		lList := []value.Value(nil)
 		if l, ok := lValues.Get(pe); ok {
@@ -279,7 +323,7 @@ func (w *compareWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		switch {
 		case len(lList) == 0 && len(rList) == 0:
 			// We shouldn't be here anyway.
-			return
+			continue
 		// Normal use-case:
 		// We have no duplicates for this PE, compare items one-to-one.
 		case len(lList) <= 1 && len(rList) <= 1:
@@ -291,7 +335,7 @@ func (w *compareWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 			if len(rList) != 0 {
 				rValue = rList[0]
 			}
-			errs = append(errs, w.compareListItem(t, pe, lValue, rValue)...)
+			items = append(items, listItem{pe: pe, lhs: lValue, rhs: rValue})
 		// Duplicates before & after use-case:
 		// Compare the duplicates lists as if they were atomic, mark modified if they changed.
 		case len(lList) >= 2 && len(rList) >= 2:
@@ -313,20 +357,20 @@ func (w *compareWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		// Rcursively add new non-duplicate items, Remove duplicate marker,
 		case len(lList) >= 2:
 			if len(rList) != 0 {
-				errs = append(errs, w.compareListItem(t, pe, nil, rList[0])...)
+				items = append(items, listItem{pe: pe, rhs: rList[0]})
 			}
 			w.comparison.Removed.Insert(append(w.path, pe))
 		// New duplicates use-case:
 		// Recursively remove old non-duplicate items, add duplicate marker.
 		case len(rList) >= 2:
 			if len(lList) != 0 {
-				errs = append(errs, w.compareListItem(t, pe, lList[0], nil)...)
+				items = append(items, listItem{pe: pe, lhs: lList[0]})
 			}
 			w.comparison.Added.Insert(append(w.path, pe))
 		}
 	}
 
-	return
+	return append(errs, w.compareListItems(t, items)...)
 }
 
 func (w *compareWalker) indexListPathElements(t *schema.List, list value.List) ([]fieldpath.PathElement, fieldpath.PathElementValueMap, ValidationErrors) {
@@ -341,7 +385,7 @@ func (w *compareWalker) indexListPathElements(t *schema.List, list value.List) (
 		child := list.At(i)
 		pe, err := listItemToPathElement(w.allocator, w.schema, t, child)
 		if err != nil {
-			errs = append(errs, errorf("element %v: %v", i, err.Error())...)
+			errs = append(errs, kindErrorf(kindOf(err), "element %v: %v", i, err.Error())...)
 			// If we can't construct the path element, we can't
 			// even report errors deeper in the schema, so bail on
 			// this element.
@@ -372,7 +416,7 @@ func (w *compareWalker) derefList(prefix string, v value.Value) (value.List, Val
 	}
 	l, err := listValue(w.allocator, v)
 	if err != nil {
-		return nil, errorf("%v: %v", prefix, err)
+		return nil, kindErrorf(kindOf(err), "%v: %v", prefix, err)
 	}
 	return l, nil
 }
@@ -1,10 +1,14 @@
 package typed_test
 
 import (
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
 func TestComparisonExcludeFields(t *testing.T) {
@@ -74,3 +78,229 @@ func TestComparisonExcludeFields(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareMany(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: object
+  map:
+    elementType:
+      namedType: __untyped_deduced_
+- name: other
+  map:
+    elementType:
+      scalar: numeric
+- name: __untyped_deduced_
+  scalar: untyped
+  map:
+    elementType:
+      namedType: __untyped_deduced_
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parse := func(typeName string, obj typed.YAMLObject) *typed.TypedValue {
+		tv, err := parser.Type(typeName).FromYAML(obj)
+		if err != nil {
+			t.Fatalf("failed to parse %v: %v", obj, err)
+		}
+		return tv
+	}
+	base := parse("object", `{"a": 1, "b": {"c": 2}}`)
+	candidates := []*typed.TypedValue{
+		parse("object", `{"a": 1, "b": {"c": 2}}`),
+		parse("object", `{"a": 2, "b": {"d": 3}}`),
+		parse("object", `{"e": 4}`),
+	}
+	comparisons, err := typed.CompareMany(base, candidates)
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	if len(comparisons) != len(candidates) {
+		t.Fatalf("expected %v comparisons, got %v", len(candidates), len(comparisons))
+	}
+	for i, candidate := range candidates {
+		expected, err := base.Compare(candidate)
+		if err != nil {
+			t.Fatalf("failed to compare: %v", err)
+		}
+		if !expected.Added.Equals(comparisons[i].Added) ||
+			!expected.Modified.Equals(comparisons[i].Modified) ||
+			!expected.Removed.Equals(comparisons[i].Removed) {
+			t.Errorf("candidate %v: expected comparison:\n%v\ngot:\n%v", i, expected, comparisons[i])
+		}
+	}
+	if !comparisons[0].IsSame() || comparisons[1].IsSame() {
+		t.Errorf("unexpected comparisons: %v", comparisons)
+	}
+
+	_, err = typed.CompareMany(base, append(candidates, parse("other", `{"a": 1}`)))
+	if err == nil || !strings.Contains(err.Error(), "candidates[3]") {
+		t.Errorf("expected an error for candidate 3, got %v", err)
+	}
+}
+
+func TestComparisonApply(t *testing.T) {
+	pt := overridesParser.Type("type")
+	parse := func(y typed.YAMLObject) *typed.TypedValue {
+		tv, err := pt.FromYAML(y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tv
+	}
+
+	lhs := parse(`{"a": [{"name": "x", "value": 1}, {"name": "z", "value": 0}], "name": "n"}`)
+	rhs := parse(`{"a": [{"name": "x", "value": 2}, {"name": "y", "value": 3}], "b": [{"name": "x"}]}`)
+	c, err := lhs.Compare(rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The target has diverged from lhs: the patch must keep its other
+	// fields and items.
+	target := parse(`{"a": [{"name": "w", "value": 4}, {"name": "x", "value": 1}, {"name": "z", "value": 0}], "name": "n", "labels": {"k": "v"}}`)
+	got, err := c.Apply(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := parse(`{"a": [{"name": "w", "value": 4}, {"name": "x", "value": 2}, {"name": "y", "value": 3}], "b": [{"name": "x"}], "labels": {"k": "v"}}`)
+	if cmp, err := got.Compare(want); err != nil {
+		t.Fatal(err)
+	} else if !cmp.IsSame() {
+		t.Errorf("unexpected result:\n%v\ndifferences:\n%v", value.ToString(got.AsValue()), cmp)
+	}
+
+	// Applying the comparison to lhs gives rhs.
+	got, err = c.Apply(lhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp, err := got.Compare(rhs); err != nil {
+		t.Fatal(err)
+	} else if !cmp.IsSame() {
+		t.Errorf("expected rhs, got:\n%v", value.ToString(got.AsValue()))
+	}
+
+	if _, err := c.Apply(parseDeduced(t, `{"a": 1}`)); err == nil {
+		t.Error("expected an error applying the comparison to another type")
+	}
+	manual := &typed.Comparison{Added: fieldpath.NewSet(), Modified: fieldpath.NewSet(), Removed: fieldpath.NewSet()}
+	if _, err := manual.Apply(lhs); err == nil {
+		t.Error("expected an error applying a comparison without values")
+	}
+}
+
+func parseDeduced(t *testing.T, y typed.YAMLObject) *typed.TypedValue {
+	tv, err := typed.DeducedParseableType.FromYAML(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tv
+}
+
+// quantityMillis returns the number of thousandths of a quantity like "1"
+// or "1000m".
+func quantityMillis(v value.Value) (int64, bool) {
+	if !v.IsString() {
+		return 0, false
+	}
+	s, scale := v.AsString(), int64(1000)
+	if strings.HasSuffix(s, "m") {
+		s, scale = strings.TrimSuffix(s, "m"), 1
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n * scale, err == nil
+}
+
+func quantitiesEqual(lhs, rhs value.Value) bool {
+	l, lok := quantityMillis(lhs)
+	r, rok := quantityMillis(rhs)
+	return lok && rok && l == r
+}
+
+func TestNamedTypeEquality(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: cpu
+      type:
+        namedType: quantity
+    - name: name
+      type:
+        scalar: string
+    - name: limits
+      type:
+        list:
+          elementType:
+            namedType: quantity
+          elementRelationship: atomic
+- name: quantity
+  scalar: string
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := parser.Type("type")
+	lhs, err := pt.FromYAML(`{"cpu": "1", "name": "1", "limits": ["2"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := pt.FromYAML(`{"cpu": "1000m", "name": "1000m", "limits": ["2000m"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := typed.Options{Equalities: &typed.Equalities{
+		NamedTypes: map[string]typed.EqualityFunc{"quantity": quantitiesEqual},
+	}}
+	c, err := lhs.CompareWithOptions(rhs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the quantity itself is compared semantically: the string isn't
+	// a quantity, and atomic lists are compared as a whole.
+	want := fieldpath.NewSet(fieldpath.MakePathOrDie("name"), fieldpath.MakePathOrDie("limits"))
+	if !c.Modified.Equals(want) || !c.Added.Empty() || !c.Removed.Empty() {
+		t.Errorf("unexpected comparison:\n%v", c)
+	}
+
+	// Equalities only apply to the comparisons they are passed to.
+	if c, err = lhs.Compare(rhs); err != nil {
+		t.Fatal(err)
+	} else if !c.Modified.Has(fieldpath.MakePathOrDie("cpu")) {
+		t.Errorf("expected the quantities to differ without the equality:\n%v", c)
+	}
+}
+
+type quantity string
+
+func TestGoTypeEquality(t *testing.T) {
+	type resources struct {
+		CPU    quantity  `json:"cpu"`
+		Memory *quantity `json:"memory"`
+		Name   string    `json:"name"`
+	}
+	mem := quantity("1000m")
+	lhs, err := typed.DeducedParseableType.FromStructured(&resources{CPU: "1", Memory: &mem, Name: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := typed.DeducedParseableType.FromUnstructured(map[string]interface{}{"cpu": "1000m", "memory": "1", "name": "1000m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := typed.Options{Equalities: &typed.Equalities{
+		GoTypes: map[reflect.Type]typed.EqualityFunc{reflect.TypeOf(quantity("")): quantitiesEqual},
+	}}
+	for _, c := range []struct{ lhs, rhs *typed.TypedValue }{{lhs, rhs}, {rhs, lhs}} {
+		got, err := c.lhs.CompareWithOptions(c.rhs, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := fieldpath.NewSet(fieldpath.MakePathOrDie("name"))
+		if !got.Modified.Equals(want) {
+			t.Errorf("expected only the field that isn't a quantity to be modified, got:\n%v", got)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// The merges and comparisons of values of DeducedParseableType don't need
+// the schema: maps are separable, lists are atomic and everything else is
+// a scalar. The functions in this file implement them directly, following
+// the walkers step by step, including for mismatched types, so that they
+// give the same results without resolving types or allocating walkers.
+// They don't count nodes or track paths, so operations with a budget,
+// parallelism, recovering panics or equalities still use the walkers.
+
+// isDeduced returns whether the fast path applies to tv with the options.
+func isDeduced(tv *TypedValue, opts walkOptions) bool {
+	if opts.budget != nil || opts.parallelism != nil || opts.recoverPanics || opts.equalities != nil {
+		return false
+	}
+	return tv.schema == DeducedParseableType.Schema &&
+		tv.typeRef.NamedType != nil && *tv.typeRef.NamedType == *DeducedParseableType.TypeRef.NamedType
+}
+
+// deducedAtom is the part of the deduced type handling a value.
+type deducedAtom int
+
+const (
+	deducedScalar deducedAtom = iota
+	deducedList
+	// deducedMap also handles null and missing values, for which the
+	// walkers use the whole type, and so its map.
+	deducedMap
+)
+
+// deduce mirrors deduceAtom.
+func deduce(v value.Value) deducedAtom {
+	switch {
+	case v == nil:
+	case v.IsFloat(), v.IsInt(), v.IsString(), v.IsBool():
+		return deducedScalar
+	case v.IsList():
+		return deducedList
+	}
+	return deducedMap
+}
+
+// deducedMapValue mirrors derefMap, which ignores the values that are not
+// maps.
+func deducedMapValue(a value.Allocator, v value.Value) value.Map {
+	if v == nil || v.IsNull() || !v.IsMap() {
+		return nil
+	}
+	return v.AsMapUsing(a)
+}
+
+func freeMaps(a value.Allocator, maps ...value.Map) {
+	for _, m := range maps {
+		if m != nil {
+			a.Free(m)
+		}
+	}
+}
+
+// mergeDeduced merges lhs and rhs, at least one of which is set, and
+// keeps rhs on leaves.
+func mergeDeduced(a value.Allocator, lhs, rhs value.Value, shareUnchanged bool) interface{} {
+	if shareUnchanged && (lhs == nil || rhs == nil) {
+		return keepRHS(lhs, rhs)
+	}
+	// The walkers may handle lhs with its own atom first, but without
+	// side effects on the result.
+	atom := deduce(rhs)
+	if rhs == nil {
+		atom = deduce(lhs)
+	}
+	if atom != deducedMap {
+		return keepRHS(lhs, rhs)
+	}
+	lm, rm := deducedMapValue(a, lhs), deducedMapValue(a, rhs)
+	defer freeMaps(a, lm, rm)
+	if (lm == nil || lm.Empty()) && (rm == nil || rm.Empty()) {
+		return keepRHS(lhs, rhs)
+	}
+	out := map[string]interface{}{}
+	value.MapZipUsing(a, lm, rm, value.Unordered, func(key string, lhsValue, rhsValue value.Value) bool {
+		out[key] = mergeDeduced(a, lhsValue, rhsValue, shareUnchanged)
+		return true
+	})
+	return out
+}
+
+func keepRHS(lhs, rhs value.Value) interface{} {
+	if rhs != nil {
+		return rhs.Unstructured()
+	}
+	return lhs.Unstructured()
+}
+
+// compareDeduced adds the differences between lhs and rhs, at least one of
+// which is set, at the path to the comparison.
+func compareDeduced(a value.Allocator, lhs, rhs value.Value, path fieldpath.Path, c *Comparison) {
+	var leaf bool
+	if rhs == nil {
+		leaf = compareDeducedAtom(a, deduce(lhs), lhs, rhs, path, c)
+	} else if lhs == nil || deduce(lhs) == deduce(rhs) {
+		leaf = compareDeducedAtom(a, deduce(rhs), lhs, rhs, path, c)
+	} else {
+		// The walkers handle each side with its own atom, and keep the
+		// differences found with both.
+		compareDeducedAtom(a, deduce(lhs), lhs, rhs, path, c)
+		leaf = compareDeducedAtom(a, deduce(rhs), lhs, rhs, path, c)
+	}
+	if !leaf {
+		if lhs == nil {
+			c.Added.Insert(path)
+		} else if rhs == nil {
+			c.Removed.Insert(path)
+		}
+	}
+}
+
+// compareDeducedAtom compares lhs and rhs as the given atom, and returns
+// whether they were compared as a leaf.
+func compareDeducedAtom(a value.Allocator, atom deducedAtom, lhs, rhs value.Value, path fieldpath.Path, c *Comparison) bool {
+	if atom == deducedMap {
+		lm, rm := deducedMapValue(a, lhs), deducedMapValue(a, rhs)
+		defer freeMaps(a, lm, rm)
+		if (lm != nil && !lm.Empty()) || (rm != nil && !rm.Empty()) {
+			value.MapZipUsing(a, lm, rm, value.Unordered, func(key string, lhsValue, rhsValue value.Value) bool {
+				compareDeduced(a, lhsValue, rhsValue, append(path, fieldpath.PathElement{FieldName: &key}), c)
+				return true
+			})
+			return false
+		}
+	}
+	if lhs == nil {
+		c.Added.Insert(path)
+	} else if rhs == nil {
+		c.Removed.Insert(path)
+	} else if !value.EqualsUsing(a, rhs, lhs) {
+		c.Modified.Insert(path)
+	}
+	return true
+}
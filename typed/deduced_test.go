@@ -18,6 +18,7 @@ package typed_test
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
@@ -494,3 +495,150 @@ func TestSymdiffDeduced(t *testing.T) {
 		})
 	}
 }
+
+// randomDeduced returns a random value of limited depth, with few distinct
+// keys and scalars so that pairs of them overlap.
+func randomDeduced(r *rand.Rand, depth int) interface{} {
+	n := r.Intn(7)
+	if depth == 0 && n >= 5 {
+		n = r.Intn(5)
+	}
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return int64(r.Intn(2))
+	case 2:
+		return []string{"a", "b"}[r.Intn(2)]
+	case 3:
+		return true
+	case 4:
+		return []interface{}{int64(r.Intn(2))}
+	case 5:
+		return map[string]interface{}{}
+	}
+	m := map[string]interface{}{}
+	for _, k := range []string{"a", "b", "c"} {
+		if r.Intn(2) == 0 {
+			m[k] = randomDeduced(r, depth-1)
+		}
+	}
+	return m
+}
+
+// TestDeducedFastPath checks that the merges and comparisons of deduced
+// values, which don't use the walkers, give the same results as the walkers,
+// which are used when there's a budget.
+func TestDeducedFastPath(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 5000; i++ {
+		lhs, err := typed.DeducedParseableType.FromUnstructured(randomDeduced(r, 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rhs, err := typed.DeducedParseableType.FromUnstructured(randomDeduced(r, 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		budget := typed.NewBudget(1 << 30)
+
+		merged, err := lhs.Merge(rhs)
+		if err != nil {
+			t.Fatalf("failed to merge: %v", err)
+		}
+		expected, err := lhs.MergeWithBudget(rhs, budget)
+		if err != nil {
+			t.Fatalf("failed to merge: %v", err)
+		}
+		if !value.Equals(merged.AsValue(), expected.AsValue()) {
+			t.Errorf("merging %v and %v: expected %v, got %v",
+				value.ToString(lhs.AsValue()), value.ToString(rhs.AsValue()),
+				value.ToString(expected.AsValue()), value.ToString(merged.AsValue()))
+		}
+
+		comparison, err := lhs.Compare(rhs)
+		if err != nil {
+			t.Fatalf("failed to compare: %v", err)
+		}
+		expectedComparison, err := lhs.CompareWithBudget(rhs, budget)
+		if err != nil {
+			t.Fatalf("failed to compare: %v", err)
+		}
+		if comparison.String() != expectedComparison.String() {
+			t.Errorf("comparing %v and %v: expected %v, got %v",
+				value.ToString(lhs.AsValue()), value.ToString(rhs.AsValue()),
+				expectedComparison, comparison)
+		}
+	}
+}
+
+// crdPayload returns an unstructured custom resource with n entries in its
+// spec, as a typical CRD without a schema would be handled.
+func crdPayload(n int, generation int64) map[string]interface{} {
+	entries := map[string]interface{}{}
+	for i := 0; i < n; i++ {
+		entries[fmt.Sprintf("entry-%d", i)] = map[string]interface{}{
+			"image":    "registry.example.com/app:v1",
+			"replicas": int64(3),
+			"ports":    []interface{}{int64(80), int64(443)},
+			"labels": map[string]interface{}{
+				"app":  "example",
+				"tier": "backend",
+			},
+			"generation": generation,
+		}
+	}
+	return map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":      "widget",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"entries": entries,
+		},
+	}
+}
+
+func benchmarkDeduced(b *testing.B, op func(lhs, rhs *typed.TypedValue, budget *typed.Budget) error) {
+	lhs, err := typed.DeducedParseableType.FromUnstructured(crdPayload(100, 1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	rhs, err := typed.DeducedParseableType.FromUnstructured(crdPayload(100, 2))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bc := range []struct {
+		name   string
+		budget func() *typed.Budget
+	}{
+		{"fast", func() *typed.Budget { return nil }},
+		// A budget disables the fast path.
+		{"walker", func() *typed.Budget { return typed.NewBudget(1 << 30) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := op(lhs, rhs, bc.budget()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMergeDeduced(b *testing.B) {
+	benchmarkDeduced(b, func(lhs, rhs *typed.TypedValue, budget *typed.Budget) error {
+		_, err := lhs.MergeWithOptions(rhs, typed.Options{Budget: budget})
+		return err
+	})
+}
+
+func BenchmarkCompareDeduced(b *testing.B) {
+	benchmarkDeduced(b, func(lhs, rhs *typed.TypedValue, budget *typed.Budget) error {
+		_, err := lhs.CompareWithOptions(rhs, typed.Options{Budget: budget})
+		return err
+	})
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// deduplicated returns v without the duplicate items of its sets of
+// scalars if opts ask for it. If they also ask for warnings, it returns one
+// warning per removed item and logs them to the logger.
+func deduplicated(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts []ValidationOptions, logger Logger) (value.Value, []string) {
+	dedupe, warn := false, false
+	for _, opt := range opts {
+		switch opt {
+		case DeduplicateSets:
+			dedupe = true
+		case DeduplicateSetsWithWarnings:
+			dedupe, warn = true, true
+		}
+	}
+	if !dedupe {
+		return v, nil
+	}
+	var warnings []string
+	var report func(fieldpath.Path, value.Value)
+	if warn {
+		report = func(path fieldpath.Path, item value.Value) {
+			warnings = append(warnings, fmt.Sprintf("removed duplicate set item %v at %v", value.ToString(item), path.String()))
+			if logger != nil {
+				logger.Info("Removed duplicate set item", "path", path.String(), "item", value.ToString(item))
+			}
+		}
+	}
+	v, _ = dedupeSets(v, s, typeRef, nil, report)
+	return v, warnings
+}
+
+// dedupingWalker removes the duplicate items of the sets of scalars of a
+// value. It leaves the values it can't walk as they are, for the
+// validation to report them.
+type dedupingWalker struct {
+	value  value.Value
+	schema *schema.Schema
+	path   fieldpath.Path
+	report func(fieldpath.Path, value.Value)
+
+	// out is the deduplicated value, if it differs from value.
+	out     interface{}
+	changed bool
+}
+
+// dedupeSets returns v without the duplicate items of its sets, and
+// whether it had any.
+func dedupeSets(v value.Value, s *schema.Schema, typeRef schema.TypeRef, path fieldpath.Path, report func(fieldpath.Path, value.Value)) (value.Value, bool) {
+	w := &dedupingWalker{value: v, schema: s, path: path, report: report}
+	resolveSchema(s, typeRef, v, w)
+	if !w.changed {
+		return v, false
+	}
+	return value.NewValueInterface(w.out), true
+}
+
+func (w *dedupingWalker) doScalar(t *schema.Scalar) ValidationErrors {
+	return nil
+}
+
+func (w *dedupingWalker) doList(t *schema.List) ValidationErrors {
+	if w.value == nil || !w.value.IsList() {
+		return nil
+	}
+	l := w.value.AsList()
+	isSet := t.ElementRelationship == schema.Associative && len(t.Keys) == 0
+	observed := fieldpath.MakePathElementSet(l.Length())
+	// items holds the deduplicated list once it differs from the input.
+	var items []interface{}
+	for i := 0; i < l.Length(); i++ {
+		item := l.At(i)
+		var pe fieldpath.PathElement
+		if t.ElementRelationship != schema.Associative {
+			pe.Index = &i
+		} else {
+			var err error
+			if pe, err = listItemToPathElement(value.HeapAllocator, w.schema, t, item); err != nil {
+				return nil
+			}
+		}
+		path := append(w.path[:len(w.path):len(w.path)], pe)
+		if isSet && observed.Has(pe) {
+			if w.report != nil {
+				w.report(path, item)
+			}
+			if items == nil {
+				items = unstructuredItems(l, i)
+			}
+			continue
+		}
+		observed.Insert(pe)
+		deduped, changed := dedupeSets(item, w.schema, t.ElementType, path, w.report)
+		if changed && items == nil {
+			items = unstructuredItems(l, i)
+		}
+		if items != nil {
+			items = append(items, deduped.Unstructured())
+		}
+	}
+	if items != nil {
+		w.out, w.changed = items, true
+	}
+	return nil
+}
+
+// unstructuredItems returns the first n items of l.
+func unstructuredItems(l value.List, n int) []interface{} {
+	items := make([]interface{}, 0, l.Length())
+	for i := 0; i < n; i++ {
+		items = append(items, l.At(i).Unstructured())
+	}
+	return items
+}
+
+func (w *dedupingWalker) doMap(t *schema.Map) ValidationErrors {
+	if w.value == nil || !w.value.IsMap() {
+		return nil
+	}
+	m := w.value.AsMap()
+	changed := map[string]interface{}{}
+	m.Iterate(func(k string, val value.Value) bool {
+		tr := t.ElementType
+		if sf, ok := t.FindField(k); ok {
+			tr = sf.Type
+		} else if (t.ElementType == schema.TypeRef{}) {
+			return true
+		}
+		path := append(w.path[:len(w.path):len(w.path)], fieldpath.PathElement{FieldName: &k})
+		if deduped, ok := dedupeSets(val, w.schema, tr, path, w.report); ok {
+			changed[k] = deduped.Unstructured()
+		}
+		return true
+	})
+	if len(changed) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, m.Length())
+	m.Iterate(func(k string, val value.Value) bool {
+		if v, ok := changed[k]; ok {
+			out[k] = v
+		} else {
+			out[k] = val.Unstructured()
+		}
+		return true
+	})
+	w.out, w.changed = out, true
+	return nil
+}
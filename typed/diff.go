@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDiffContext is the number of unchanged lines shown around the
+// changes of a diff by default.
+const DefaultDiffContext = 3
+
+// DiffOptions are the options of UnifiedDiff.
+type DiffOptions struct {
+	// LHSName and RHSName name the objects in the header of the diff.
+	// They default to "lhs" and "rhs".
+	LHSName string
+	RHSName string
+	// Context is the number of unchanged lines shown around changes. It
+	// defaults to DefaultDiffContext; negative values show none.
+	Context int
+	// Color colors the diff with ANSI escape sequences, for terminals.
+	Color bool
+}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+	ansiBold  = "\x1b[1m"
+)
+
+// UnifiedDiff returns the differences between lhs and rhs as a unified
+// diff of their YAML representations, or an empty string if they are
+// equal. The fields of structs are written in the order of the schema,
+// and the keys of other maps are sorted, so that the diff doesn't depend
+// on how the objects were serialized.
+func UnifiedDiff(lhs, rhs *TypedValue, opts DiffOptions) (string, error) {
+	if errs := checkSameType(lhs, rhs); len(errs) != 0 {
+		return "", errs
+	}
+	a := lineTexts(lhs.RenderYAML())
+	b := lineTexts(rhs.RenderYAML())
+	edits := diffLines(a, b)
+	context := opts.Context
+	if context == 0 {
+		context = DefaultDiffContext
+	} else if context < 0 {
+		context = 0
+	}
+	hunks := diffHunks(edits, context)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+
+	colors := map[byte]string{}
+	if opts.Color {
+		colors = map[byte]string{'-': ansiRed, '+': ansiGreen, '@': ansiCyan, 'h': ansiBold}
+	}
+	line := func(b *strings.Builder, kind byte, text string) {
+		if color := colors[kind]; color != "" {
+			b.WriteString(color + text + ansiReset + "\n")
+			return
+		}
+		b.WriteString(text + "\n")
+	}
+	lhsName, rhsName := opts.LHSName, opts.RHSName
+	if lhsName == "" {
+		lhsName = "lhs"
+	}
+	if rhsName == "" {
+		rhsName = "rhs"
+	}
+
+	var out strings.Builder
+	line(&out, 'h', "--- "+lhsName)
+	line(&out, 'h', "+++ "+rhsName)
+	for _, h := range hunks {
+		line(&out, '@', fmt.Sprintf("@@ -%v +%v @@", hunkRange(h.aStart, h.aLines), hunkRange(h.bStart, h.bLines)))
+		for _, e := range h.edits {
+			switch e.kind {
+			case ' ':
+				line(&out, ' ', " "+a[e.a])
+			case '-':
+				line(&out, '-', "-"+a[e.a])
+			case '+':
+				line(&out, '+', "+"+b[e.b])
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	if lines == 0 {
+		// An empty range is given by the line before it.
+		return fmt.Sprintf("%v,0", start)
+	}
+	return fmt.Sprintf("%v,%v", start+1, lines)
+}
+
+// diffEdit keeps line a, removes line a or adds line b, depending on its
+// kind: ' ', '-' or '+'.
+type diffEdit struct {
+	kind byte
+	a, b int
+}
+
+// diffLines returns the shortest edit script from a to b, with Myers'
+// algorithm.
+func diffLines(a, b []string) []diffEdit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace holds the frontier before each step d, for the diagonals
+	// -d-1 to d+1 that backtrack reads.
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m, d)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack rebuilds the edit script from the frontiers of each step of
+// diffLines.
+func backtrack(trace [][]int, x, y, d int) []diffEdit {
+	var edits []diffEdit
+	for ; d > 0; d-- {
+		v, offset := trace[d], d+1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			edits = append(edits, diffEdit{kind: ' ', a: x, b: y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, diffEdit{kind: '+', a: x, b: y})
+		} else {
+			x--
+			edits = append(edits, diffEdit{kind: '-', a: x, b: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		edits = append(edits, diffEdit{kind: ' ', a: x, b: y})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// diffHunk is a group of changes with their context.
+type diffHunk struct {
+	aStart, aLines int
+	bStart, bLines int
+	edits          []diffEdit
+}
+
+// diffHunks groups the changes of edits that are less than twice the
+// context apart.
+func diffHunks(edits []diffEdit, context int) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// Extend the hunk while the next change is close enough.
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+		h := diffHunk{aStart: edits[start].a, bStart: edits[start].b, edits: edits[start:stop]}
+		for _, e := range h.edits {
+			if e.kind != '+' {
+				h.aLines++
+			}
+			if e.kind != '-' {
+				h.bLines++
+			}
+		}
+		hunks = append(hunks, h)
+		i = stop
+	}
+	return hunks
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+var diffParser = func() *typed.Parser {
+	p, err := typed.NewParser(`types:
+- name: deployment
+  map:
+    fields:
+    - name: spec
+      type:
+        namedType: spec
+    - name: metadata
+      type:
+        map:
+          elementType:
+            scalar: string
+- name: spec
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: containers
+      type:
+        list:
+          elementType:
+            namedType: container
+          elementRelationship: associative
+          keys:
+          - name
+    - name: args
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+- name: container
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: image
+      type:
+        scalar: string
+    - name: ports
+      type:
+        list:
+          elementType:
+            scalar: numeric
+          elementRelationship: atomic
+`)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+func TestUnifiedDiff(t *testing.T) {
+	lhs, err := diffParser.Type("deployment").FromYAML(`
+metadata: {name: a, namespace: b}
+spec:
+  args: []
+  containers:
+  - {image: "a:1", name: a, ports: [80]}
+  - {image: "b:1", name: b}
+  replicas: 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := diffParser.Type("deployment").FromYAML(`
+metadata: {name: a, namespace: b}
+spec:
+  args: [x]
+  containers:
+  - {image: "a:2", name: a, ports: [80]}
+  - {image: "b:1", name: b}
+  replicas: 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     typed.DiffOptions
+		expected string
+	}{
+		{
+			name: "default",
+			expected: `--- lhs
++++ rhs
+@@ -2,12 +2,13 @@
+   replicas: 1
+   containers:
+   - name: "a"
+-    image: "a:1"
++    image: "a:2"
+     ports:
+     - 80
+   - name: "b"
+     image: "b:1"
+-  args: []
++  args:
++  - "x"
+ metadata:
+   name: "a"
+   namespace: "b"
+`,
+		},
+		{
+			name: "no context",
+			opts: typed.DiffOptions{LHSName: "live", RHSName: "config", Context: -1},
+			expected: `--- live
++++ config
+@@ -5 +5 @@
+-    image: "a:1"
++    image: "a:2"
+@@ -10 +10,2 @@
+-  args: []
++  args:
++  - "x"
+`,
+		},
+		{
+			name: "color",
+			opts: typed.DiffOptions{Context: 1, Color: true},
+			expected: "\x1b[1m--- lhs\x1b[0m\n\x1b[1m+++ rhs\x1b[0m\n" +
+				"\x1b[36m@@ -4,3 +4,3 @@\x1b[0m\n" +
+				`   - name: "a"` + "\n" +
+				"\x1b[31m-    image: \"a:1\"\x1b[0m\n" +
+				"\x1b[32m+    image: \"a:2\"\x1b[0m\n" +
+				"     ports:\n" +
+				"\x1b[36m@@ -9,3 +9,4 @@\x1b[0m\n" +
+				`     image: "b:1"` + "\n" +
+				"\x1b[31m-  args: []\x1b[0m\n" +
+				"\x1b[32m+  args:\x1b[0m\n" +
+				"\x1b[32m+  - \"x\"\x1b[0m\n" +
+				" metadata:\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := typed.UnifiedDiff(lhs, rhs, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected:\n%v\ngot:\n%v", tc.expected, got)
+			}
+		})
+	}
+
+	if got, err := typed.UnifiedDiff(lhs, lhs, typed.DiffOptions{}); err != nil || got != "" {
+		t.Errorf("expected no diff, got %q, %v", got, err)
+	}
+}
+
+func TestUnifiedDiffDeduced(t *testing.T) {
+	lines := func(n int, changed map[int]bool) map[string]interface{} {
+		m := map[string]interface{}{}
+		for i := 0; i < n; i++ {
+			v := "same"
+			if changed[i] {
+				v = "changed"
+			}
+			m[strings.Repeat("k", i+1)] = v
+		}
+		return m
+	}
+	lhs, err := typed.DeducedParseableType.FromUnstructured(lines(20, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := typed.DeducedParseableType.FromUnstructured(lines(20, map[int]bool{0: true, 19: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := typed.UnifiedDiff(lhs, rhs, typed.DiffOptions{Context: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `--- lhs
++++ rhs
+@@ -1,2 +1,2 @@
+-k: "same"
++k: "changed"
+ kk: "same"
+@@ -19,2 +19,2 @@
+ kkkkkkkkkkkkkkkkkkk: "same"
+-kkkkkkkkkkkkkkkkkkkk: "same"
++kkkkkkkkkkkkkkkkkkkk: "changed"
+`
+	if got != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+}
+
+func TestRenderYAMLPaths(t *testing.T) {
+	tv, err := diffParser.Type("deployment").FromYAML(`
+spec:
+  containers:
+  - {image: "a:1", name: a}
+  args: [x]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		".spec",
+		".spec.containers",
+		`.spec.containers[name="a"].name`,
+		`.spec.containers[name="a"].image`,
+		".spec.args",
+		".spec.args[0]",
+	}
+	lines := tv.RenderYAML()
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %v lines, got %v", len(expected), lines)
+	}
+	for i, l := range lines {
+		if got := l.Path.String(); got != expected[i] {
+			t.Errorf("line %q: expected path %v, got %v", l.Text, expected[i], got)
+		}
+	}
+}
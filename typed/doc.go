@@ -15,4 +15,16 @@ limitations under the License.
 */
 
 // Package typed contains logic for operating on values with given schemas.
+//
+// The outputs of the operations are deterministic, so that they can be
+// compared with golden files: the ValidationErrors they return are sorted
+// by path, then message, and Comparisons hold sets, which fieldpath
+// iterates and prints in a deterministic order. The order of the items of
+// the maps of merged values is that of Go maps, and only matters once
+// serialized; value.ToJSON and value.ToYAML sort them by key.
+//
+// Errors can be classified with errors.Is rather than by their messages:
+// ValidationErrors match ErrSchemaNotFound and ErrTypeMismatch when any of
+// their errors does, and operations running out of their budget fail with
+// ErrBudgetExceeded.
 package typed
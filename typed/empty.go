@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// EmptyContainers returns the paths of all the empty maps and lists in
+// the value, including the fields set to null whose type can only be a
+// map or a list. Atomic maps and lists, and anything inside of them, are
+// not included since their content is managed as a whole.
+func (tv TypedValue) EmptyContainers() (*fieldpath.Set, error) {
+	w := &emptyContainersWalker{
+		value:     tv.value,
+		schema:    tv.schema,
+		set:       fieldpath.NewSet(),
+		allocator: value.NewFreelistAllocator(),
+	}
+	if errs := resolveSchema(tv.schema, tv.typeRef, tv.value, w); len(errs) != 0 {
+		return nil, errs
+	}
+	return w.set, nil
+}
+
+type emptyContainersWalker struct {
+	value     value.Value
+	schema    *schema.Schema
+	path      fieldpath.Path
+	set       *fieldpath.Set
+	allocator value.Allocator
+}
+
+func (w *emptyContainersWalker) descend(pe fieldpath.PathElement, tr schema.TypeRef, val value.Value) ValidationErrors {
+	w2 := *w
+	w2.value = val
+	w2.path = append(w.path.Copy(), pe)
+	if val.IsNull() {
+		if a, ok := w.schema.Resolve(tr); ok && a.Scalar == nil && isGranular(a) {
+			w.set.Insert(w2.path)
+		}
+		return nil
+	}
+	return resolveSchema(w.schema, tr, val, &w2).WithLazyPrefix(w2.path.String)
+}
+
+func (w *emptyContainersWalker) doScalar(*schema.Scalar) ValidationErrors {
+	return nil
+}
+
+func (w *emptyContainersWalker) doList(t *schema.List) (errs ValidationErrors) {
+	list, _ := listValue(w.allocator, w.value)
+	if list != nil {
+		defer w.allocator.Free(list)
+	}
+	if list == nil || t.ElementRelationship == schema.Atomic {
+		return nil
+	}
+	if list.Length() == 0 {
+		w.set.Insert(w.path)
+		return nil
+	}
+	for i := 0; i < list.Length(); i++ {
+		child := list.At(i)
+		pe, err := listItemToPathElement(w.allocator, w.schema, t, child)
+		if err != nil {
+			errs = append(errs, kindErrorf(kindOf(err), "element %v: %v", i, err.Error())...)
+			continue
+		}
+		errs = append(errs, w.descend(pe, t.ElementType, child)...)
+	}
+	return errs
+}
+
+func (w *emptyContainersWalker) doMap(t *schema.Map) (errs ValidationErrors) {
+	m, _ := mapValue(w.allocator, w.value)
+	if m != nil {
+		defer w.allocator.Free(m)
+	}
+	if m == nil || t.ElementRelationship == schema.Atomic {
+		return nil
+	}
+	if m.Empty() {
+		w.set.Insert(w.path)
+		return nil
+	}
+	m.Iterate(func(key string, val value.Value) bool {
+		tr := t.ElementType
+		if sf, ok := t.FindField(key); ok {
+			tr = sf.Type
+		}
+		errs = append(errs, w.descend(fieldpath.PathElement{FieldName: &key}, tr, val)...)
+		return true
+	})
+	return errs
+}
+
+// isGranular returns true if the atom is a non-atomic map or list.
+func isGranular(a schema.Atom) bool {
+	if a.Map != nil && a.Map.ElementRelationship != schema.Atomic {
+		return true
+	}
+	return a.List != nil && a.List.ElementRelationship != schema.Atomic
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestEmptyContainers(t *testing.T) {
+	parser, err := typed.NewParser(typed.YAMLObject(associativeAndAtomicSchema))
+	if err != nil {
+		t.Fatalf("failed to create parser: %v", err)
+	}
+	table := []struct {
+		object   typed.YAMLObject
+		expected *fieldpath.Set
+	}{
+		{
+			object:   `{"list": [], "atomicList": [], "atomicMap": {}}`,
+			expected: fieldpath.NewSet(fieldpath.MakePathOrDie("list")),
+		},
+		{
+			object: `{"list": [{"key": "a", "id": 1, "value": {}}, {"key": "b", "id": 2, "value": {"a": "b"}}], "atomicMap": {"a": "b"}}`,
+			expected: fieldpath.NewSet(
+				fieldpath.MakePathOrDie("list", fieldpath.KeyByFields("key", "a", "id", 1), "value"),
+			),
+		},
+		{
+			object:   `{"list": null, "atomicMap": null}`,
+			expected: fieldpath.NewSet(fieldpath.MakePathOrDie("list")),
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(string(tt.object), func(t *testing.T) {
+			tv, err := parser.Type("myRoot").FromYAML(tt.object)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			got, err := tv.EmptyContainers()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equals(tt.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"reflect"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// EqualityFunc returns whether two scalars are semantically equal.
+type EqualityFunc func(lhs, rhs value.Value) bool
+
+// Equalities are the semantic equalities of the scalars whose different
+// representations can have the same meaning, e.g. the quantities "1" and
+// "1000m", so that comparisons don't report them as modified. They only
+// apply to the comparisons they are passed to, with Options, and not
+// within atomic lists and maps, which are compared with value.Equals.
+type Equalities struct {
+	// NamedTypes holds the equalities of the scalars of named types of
+	// schemas, e.g. "io.k8s.apimachinery.pkg.api.resource.Quantity",
+	// which apply to the fields whose type reference is the named type.
+	NamedTypes map[string]EqualityFunc
+	// GoTypes holds the equalities of the structured values of Go
+	// types, or pointers to them, wrapped by value.NewValueReflect,
+	// which apply when either of the compared scalars is one. They are
+	// given the values as converted to unstructured.
+	GoTypes map[reflect.Type]EqualityFunc
+}
+
+// of returns the equality of the scalars lhs and rhs, of the type tr, if
+// any.
+func (e *Equalities) of(tr schema.TypeRef, lhs, rhs value.Value) (EqualityFunc, bool) {
+	if e == nil {
+		return nil, false
+	}
+	if tr.NamedType != nil {
+		if eq, ok := e.NamedTypes[*tr.NamedType]; ok {
+			return eq, true
+		}
+	}
+	if len(e.GoTypes) == 0 {
+		return nil, false
+	}
+	for _, v := range []value.Value{lhs, rhs} {
+		if t := value.GoTypeOf(v); t != nil {
+			if eq, ok := e.GoTypes[t]; ok {
+				return eq, true
+			}
+		}
+	}
+	return nil, false
+}
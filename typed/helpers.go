@@ -19,6 +19,8 @@ package typed
 import (
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
@@ -26,10 +28,33 @@ import (
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
+var (
+	// ErrSchemaNotFound classifies the errors about types that can't be
+	// found in their schema.
+	ErrSchemaNotFound = errors.New("no type found matching")
+	// ErrTypeMismatch classifies the errors about values that don't
+	// match their type, and about operations on values of different
+	// types.
+	ErrTypeMismatch = errors.New("type mismatch")
+)
+
+// errorKind classifies a ValidationError. It is comparable, so that
+// ValidationErrors still are.
+type errorKind int
+
+const (
+	unclassified errorKind = iota
+	schemaNotFound
+	typeMismatch
+)
+
 // ValidationError reports an error about a particular field
 type ValidationError struct {
 	Path         string
 	ErrorMessage string
+
+	// kind classifies the error, if known.
+	kind errorKind
 }
 
 // Error returns a human readable error message.
@@ -40,6 +65,18 @@ func (ve ValidationError) Error() string {
 	return fmt.Sprintf("%s: %v", ve.Path, ve.ErrorMessage)
 }
 
+// Is returns whether ve is classified by target, ErrSchemaNotFound or
+// ErrTypeMismatch.
+func (ve ValidationError) Is(target error) bool {
+	switch target {
+	case ErrSchemaNotFound:
+		return ve.kind == schemaNotFound
+	case ErrTypeMismatch:
+		return ve.kind == typeMismatch
+	}
+	return false
+}
+
 // ValidationErrors accumulates multiple validation error messages.
 type ValidationErrors []ValidationError
 
@@ -56,6 +93,39 @@ func (errs ValidationErrors) Error() string {
 	return strings.Join(messages, "\n")
 }
 
+// Is returns whether any of the errors is target, so that errors.Is can
+// tell whether a validation failed because of, e.g., ErrTypeMismatch.
+func (errs ValidationErrors) Is(target error) bool {
+	for _, e := range errs {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target, as errors.As does.
+func (errs ValidationErrors) As(target interface{}) bool {
+	for _, e := range errs {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// sorted sorts the errors by path, then message, so that they don't depend
+// on the order in which the walkers visited the items of maps.
+func (errs ValidationErrors) sorted() ValidationErrors {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Path != errs[j].Path {
+			return errs[i].Path < errs[j].Path
+		}
+		return errs[i].ErrorMessage < errs[j].ErrorMessage
+	})
+	return errs
+}
+
 // Set the given path to all the validation errors.
 func (errs ValidationErrors) WithPath(p string) ValidationErrors {
 	for i := range errs {
@@ -96,6 +166,33 @@ func errorf(format string, args ...interface{}) ValidationErrors {
 	}}
 }
 
+// kindErrorf is like errorf, but the error is classified by kind.
+func kindErrorf(kind errorKind, format string, args ...interface{}) ValidationErrors {
+	return ValidationErrors{{
+		ErrorMessage: fmt.Sprintf(format, args...),
+		kind:         kind,
+	}}
+}
+
+// kindOf returns the kind of the ValidationErrors built from err.
+func kindOf(err error) errorKind {
+	if errors.Is(err, ErrTypeMismatch) {
+		return typeMismatch
+	}
+	return unclassified
+}
+
+// mismatchError is an error classified as ErrTypeMismatch.
+type mismatchError string
+
+func (e mismatchError) Error() string {
+	return string(e)
+}
+
+func (e mismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
 type atomHandler interface {
 	doScalar(*schema.Scalar) ValidationErrors
 	doList(*schema.List) ValidationErrors
@@ -109,7 +206,7 @@ func resolveSchema(s *schema.Schema, tr schema.TypeRef, v value.Value, ah atomHa
 		if tr.NamedType != nil {
 			typeName = *tr.NamedType
 		}
-		return errorf("schema error: no type found matching: %v", typeName)
+		return kindErrorf(schemaNotFound, "schema error: no type found matching: %v", typeName)
 	}
 
 	a = deduceAtom(a, v)
@@ -164,7 +261,7 @@ func listValue(a value.Allocator, val value.Value) (value.List, error) {
 		return nil, nil
 	}
 	if !val.IsList() {
-		return nil, fmt.Errorf("expected list, got %v", val)
+		return nil, mismatchError(fmt.Sprintf("expected list, got %v", val))
 	}
 	return val.AsListUsing(a), nil
 }
@@ -172,31 +269,18 @@ func listValue(a value.Allocator, val value.Value) (value.List, error) {
 // Returns the map, or an error. Reminder: nil is a valid map and might be returned.
 func mapValue(a value.Allocator, val value.Value) (value.Map, error) {
 	if val == nil {
-		return nil, fmt.Errorf("expected map, got nil")
+		return nil, mismatchError("expected map, got nil")
 	}
 	if val.IsNull() {
 		// Null is a valid map.
 		return nil, nil
 	}
 	if !val.IsMap() {
-		return nil, fmt.Errorf("expected map, got %v", val)
+		return nil, mismatchError(fmt.Sprintf("expected map, got %v", val))
 	}
 	return val.AsMapUsing(a), nil
 }
 
-func getAssociativeKeyDefault(s *schema.Schema, list *schema.List, fieldName string) (interface{}, error) {
-	atom, ok := s.Resolve(list.ElementType)
-	if !ok {
-		return nil, errors.New("invalid elementType for list")
-	}
-	if atom.Map == nil {
-		return nil, errors.New("associative list may not have non-map types")
-	}
-	// If the field is not found, we can assume there is no default.
-	field, _ := atom.Map.FindField(fieldName)
-	return field.Default, nil
-}
-
 func keyedAssociativeListItemToPathElement(a value.Allocator, s *schema.Schema, list *schema.List, child value.Value) (fieldpath.PathElement, error) {
 	pe := fieldpath.PathElement{}
 	if child.IsNull() {
@@ -206,25 +290,85 @@ func keyedAssociativeListItemToPathElement(a value.Allocator, s *schema.Schema,
 	if !child.IsMap() {
 		return pe, errors.New("associative list with keys may not have non-map elements")
 	}
+	element, elementErr := associativeListElement(s, list)
 	keyMap := value.FieldList{}
 	m := child.AsMapUsing(a)
 	defer a.Free(m)
-	for _, fieldName := range list.Keys {
-		if val, ok := m.Get(fieldName); ok {
-			keyMap = append(keyMap, value.Field{Name: fieldName, Value: val})
-		} else if def, err := getAssociativeKeyDefault(s, list, fieldName); err != nil {
-			return pe, fmt.Errorf("couldn't find default value for %v: %v", fieldName, err)
-		} else if def != nil {
-			keyMap = append(keyMap, value.Field{Name: fieldName, Value: value.NewValueInterface(def)})
-		} else {
-			return pe, fmt.Errorf("associative list with keys has an element that omits key field %q (and doesn't have default value)", fieldName)
+	for _, fieldName := range list.IdentityKeys() {
+		// If the field is not found, we can assume there is no default
+		// and no type to check.
+		var field schema.StructField
+		if element != nil {
+			field, _ = element.FindField(fieldName)
+		}
+		val, ok := m.Get(fieldName)
+		if !ok {
+			if elementErr != nil {
+				return pe, fmt.Errorf("couldn't find default value for %v: %v", fieldName, elementErr)
+			} else if field.Default == nil {
+				return pe, fmt.Errorf("associative list with keys has an element that omits key field %q (and doesn't have default value)", fieldName)
+			}
+			val = value.NewValueInterface(field.Default)
+			// Keys are compared and serialized as scalars, so other
+			// defaults would key the elements inconsistently.
+			if scalarOf(val) == "" {
+				return pe, fmt.Errorf("associative list with keys has an element that omits key field %q, whose default value is not a scalar", fieldName)
+			}
 		}
+		val, err := keyValue(s, field.Type, fieldName, val)
+		if err != nil {
+			return pe, err
+		}
+		keyMap = append(keyMap, value.Field{Name: fieldName, Value: val})
 	}
 	keyMap.Sort()
 	pe.Key = &keyMap
 	return pe, nil
 }
 
+func associativeListElement(s *schema.Schema, list *schema.List) (*schema.Map, error) {
+	atom, ok := s.Resolve(list.ElementType)
+	if !ok {
+		return nil, errors.New("invalid elementType for list")
+	}
+	if atom.Map == nil {
+		return nil, errors.New("associative list may not have non-map types")
+	}
+	return atom.Map, nil
+}
+
+// keyValue checks that the value of a key field is a scalar of the type of
+// the field, and canonicalizes it: numbers without a fractional part are
+// keyed as integers, so that 1 and 1.0 key the same element in the same way.
+func keyValue(s *schema.Schema, tr schema.TypeRef, fieldName string, v value.Value) (value.Value, error) {
+	kind := scalarOf(v)
+	if kind == "" {
+		return nil, mismatchError(fmt.Sprintf("key field %q must be a scalar, got %v", fieldName, value.ToString(v)))
+	}
+	if a, ok := s.Resolve(tr); ok && a.Scalar != nil && *a.Scalar != schema.Untyped && *a.Scalar != kind {
+		return nil, mismatchError(fmt.Sprintf("key field %q must be a %v, got %v", fieldName, *a.Scalar, value.ToString(v)))
+	}
+	if v.IsFloat() {
+		if f := v.AsFloat(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return value.NewValueInterface(int64(f)), nil
+		}
+	}
+	return v, nil
+}
+
+// scalarOf returns the scalar type of v, or "" if v isn't a scalar.
+func scalarOf(v value.Value) schema.Scalar {
+	switch {
+	case v.IsInt(), v.IsFloat():
+		return schema.Numeric
+	case v.IsString():
+		return schema.String
+	case v.IsBool():
+		return schema.Boolean
+	}
+	return ""
+}
+
 func setItemToPathElement(child value.Value) (fieldpath.PathElement, error) {
 	pe := fieldpath.PathElement{}
 	switch {
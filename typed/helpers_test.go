@@ -4,7 +4,7 @@ import (
 	"strings"
 	"testing"
 
-	"sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/smdtest"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
 
@@ -25,11 +25,11 @@ func TestInvalidOverride(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	sameVersionParser := fixture.SameVersionParser{T: parser.Type("type")}
+	sameVersionParser := smdtest.SameVersionParser{T: parser.Type("type")}
 
-	test := fixture.TestCase{
-		Ops: []fixture.Operation{
-			fixture.Apply{
+	test := smdtest.TestCase{
+		Ops: []smdtest.Operation{
+			smdtest.Apply{
 				Manager: "apply_one",
 				Object: `
                         field: 1
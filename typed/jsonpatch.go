@@ -0,0 +1,395 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// FieldSetFromJSONPatch creates a set containing every field touched by an
+// RFC 6902 JSON Patch document applied to tv. Unlike
+// fieldpath.SetFromJSONPatch, the list indices of the patch are resolved
+// against tv and its schema, so that the items of associative lists and
+// sets are identified by their keys or values, like in ToFieldSet, and
+// items appended with "-" are identified too. The operations are applied
+// one after the other, so indices refer to the object as modified by the
+// previous operations:
+//   - "add" and "replace" contribute the fields of their value, and of the
+//     value they replace, if any,
+//   - "remove" contributes the fields of the value it removes,
+//   - "move" contributes the fields of the value at both its from and its
+//     path, and "copy" those of the value at its path,
+//   - "test" doesn't modify anything and contributes nothing.
+//
+// Within atomic lists and maps, only the path of the list or map itself is
+// contributed.
+func (tv TypedValue) FieldSetFromJSONPatch(patch []byte) (*fieldpath.Set, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON patch: %v", err)
+	}
+	// Round-trip through JSON so that the document is made of the same
+	// types as the values of the patch, whatever tv was built from.
+	data, err := value.ToJSON(tv.value)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := value.FromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	p := jsonPatcher{
+		schema:  tv.schema,
+		typeRef: tv.typeRef,
+		doc:     doc.Unstructured(),
+		set:     fieldpath.NewSet(),
+	}
+	for i, op := range ops {
+		if err := p.apply(op); err != nil {
+			return nil, fmt.Errorf("operation %d: %v", i, err)
+		}
+	}
+	return p.set, nil
+}
+
+// jsonPatcher applies JSON patch operations to an unstructured copy of a
+// TypedValue and collects the fields they touch.
+type jsonPatcher struct {
+	schema  *schema.Schema
+	typeRef schema.TypeRef
+	doc     interface{}
+	set     *fieldpath.Set
+}
+
+func (p *jsonPatcher) apply(op jsonPatchOperation) error {
+	if op.Op == "test" {
+		return nil
+	}
+	segments, err := jsonPointerSegments(op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add", "replace":
+		if len(op.Value) == 0 {
+			return fmt.Errorf("%q requires a value", op.Op)
+		}
+		v, err := value.FromJSON(op.Value)
+		if err != nil {
+			return fmt.Errorf("failed to parse value: %v", err)
+		}
+		mode := replaceNode
+		if op.Op == "add" {
+			mode = insertNode
+			if old, err := getNode(p.doc, segments); err == nil && !p.isListItem(segments) {
+				if err := p.insertFields(segments, nil, old); err != nil {
+					return err
+				}
+			}
+		} else {
+			old, err := getNode(p.doc, segments)
+			if err != nil {
+				return err
+			}
+			if err := p.insertFields(segments, nil, old); err != nil {
+				return err
+			}
+		}
+		return p.patch(segments, mode, v.Unstructured())
+	case "remove":
+		old, err := getNode(p.doc, segments)
+		if err != nil {
+			return err
+		}
+		if err := p.insertFields(segments, nil, old); err != nil {
+			return err
+		}
+		return p.patch(segments, removeNode, nil)
+	case "move", "copy":
+		from, err := jsonPointerSegments(op.From)
+		if err != nil {
+			return err
+		}
+		v, err := getNode(p.doc, from)
+		if err != nil {
+			return err
+		}
+		if op.Op == "move" {
+			if err := p.insertFields(from, nil, v); err != nil {
+				return err
+			}
+			if err := p.patch(from, removeNode, nil); err != nil {
+				return err
+			}
+		}
+		return p.patch(segments, insertNode, v)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// patch inserts the fields of v at segments, and then modifies the
+// document accordingly.
+func (p *jsonPatcher) patch(segments []string, mode patchMode, v interface{}) error {
+	if mode != removeNode {
+		if err := p.insertFields(segments, v, v); err != nil {
+			return err
+		}
+	}
+	doc, err := patchNode(p.doc, segments, mode, v)
+	if err != nil {
+		return err
+	}
+	p.doc = doc
+	return nil
+}
+
+// isListItem returns true if segments points into a list of the document.
+func (p *jsonPatcher) isListItem(segments []string) bool {
+	if len(segments) == 0 {
+		return false
+	}
+	parent, err := getNode(p.doc, segments[:len(segments)-1])
+	if err != nil {
+		return false
+	}
+	_, ok := parent.([]interface{})
+	return ok
+}
+
+// insertFields inserts the fields of v, found at segments, into the set.
+// If item isn't nil, it is used instead of the item of the document to
+// identify the last segment, when it is a list item.
+func (p *jsonPatcher) insertFields(segments []string, item, v interface{}) error {
+	path, tr, atomic, err := p.resolve(segments, item)
+	if err != nil {
+		return err
+	}
+	p.set.Insert(path)
+	if atomic || v == nil {
+		return nil
+	}
+	tv := TypedValue{value: value.NewValueInterface(v), typeRef: tr, schema: p.schema}
+	fields, err := tv.ToFieldSet()
+	if err != nil {
+		return err
+	}
+	fields.Iterate(func(child fieldpath.Path) {
+		p.set.Insert(append(path.Copy(), child...))
+	})
+	return nil
+}
+
+// resolve converts segments into a path, using the schema to identify list
+// items, and returns the type found at that path. If the path goes through
+// an atomic list or map, it stops there and atomic is true.
+func (p *jsonPatcher) resolve(segments []string, item interface{}) (path fieldpath.Path, tr schema.TypeRef, atomic bool, err error) {
+	path = fieldpath.Path{}
+	tr = p.typeRef
+	node := p.doc
+	for i, segment := range segments {
+		a, ok := p.schema.Resolve(tr)
+		if !ok {
+			return nil, tr, false, fmt.Errorf("no type found at %v", path)
+		}
+		a = deduceAtom(a, value.NewValueInterface(node))
+		switch {
+		case a.Map != nil:
+			if a.Map.ElementRelationship == schema.Atomic {
+				return path, tr, true, nil
+			}
+			tr = a.Map.ElementType
+			if sf, ok := a.Map.FindField(segment); ok {
+				tr = sf.Type
+			}
+			name := segment
+			path = append(path, fieldpath.PathElement{FieldName: &name})
+			m, _ := node.(map[string]interface{})
+			node = m[segment]
+		case a.List != nil:
+			if a.List.ElementRelationship == schema.Atomic {
+				return path, tr, true, nil
+			}
+			l, _ := node.([]interface{})
+			index := len(l)
+			if segment != "-" {
+				if index, err = jsonPointerIndex(segment); err != nil {
+					return nil, tr, false, err
+				}
+			}
+			child := item
+			if i < len(segments)-1 || item == nil {
+				if index >= len(l) {
+					return nil, tr, false, fmt.Errorf("index %v out of range at %v", segment, path)
+				}
+				child = l[index]
+			}
+			pe := fieldpath.PathElement{Index: &index}
+			if a.List.ElementRelationship == schema.Associative {
+				if pe, err = listItemToPathElement(value.HeapAllocator, p.schema, a.List, value.NewValueInterface(child)); err != nil {
+					return nil, tr, false, fmt.Errorf("failed to identify item %v at %v: %v", segment, path, err)
+				}
+			}
+			path = append(path, pe)
+			tr = a.List.ElementType
+			node = child
+		default:
+			return nil, tr, false, fmt.Errorf("can't descend into scalar at %v", path)
+		}
+	}
+	return path, tr, false, nil
+}
+
+type patchMode int
+
+const (
+	replaceNode patchMode = iota
+	insertNode
+	removeNode
+)
+
+// patchNode returns a copy of node where the value at segments is replaced
+// by v, or v is inserted before it, or it is removed, depending on mode.
+// node itself is left unchanged.
+func patchNode(node interface{}, segments []string, mode patchMode, v interface{}) (interface{}, error) {
+	if len(segments) == 0 {
+		if mode == removeNode {
+			return nil, fmt.Errorf("can't remove the whole document")
+		}
+		return v, nil
+	}
+	segment := segments[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(n)+1)
+		for key, child := range n {
+			m[key] = child
+		}
+		child, ok := n[segment]
+		if !ok && (len(segments) > 1 || mode != insertNode) {
+			return nil, fmt.Errorf("field %q doesn't exist", segment)
+		}
+		if len(segments) > 1 {
+			child, err := patchNode(child, segments[1:], mode, v)
+			if err != nil {
+				return nil, err
+			}
+			m[segment] = child
+		} else if mode == removeNode {
+			delete(m, segment)
+		} else {
+			m[segment] = v
+		}
+		return m, nil
+	case []interface{}:
+		index := len(n)
+		if segment != "-" {
+			var err error
+			if index, err = jsonPointerIndex(segment); err != nil {
+				return nil, err
+			}
+		}
+		limit := len(n)
+		if len(segments) == 1 && mode == insertNode {
+			limit++
+		}
+		if index >= limit {
+			return nil, fmt.Errorf("index %v out of range", segment)
+		}
+		l := make([]interface{}, 0, len(n)+1)
+		switch {
+		case len(segments) > 1:
+			child, err := patchNode(n[index], segments[1:], mode, v)
+			if err != nil {
+				return nil, err
+			}
+			l = append(append(append(l, n[:index]...), child), n[index+1:]...)
+		case mode == insertNode:
+			l = append(append(append(l, n[:index]...), v), n[index:]...)
+		case mode == removeNode:
+			l = append(append(l, n[:index]...), n[index+1:]...)
+		default:
+			l = append(append(append(l, n[:index]...), v), n[index+1:]...)
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("can't descend into scalar with %q", segment)
+	}
+}
+
+// getNode returns the value at segments.
+func getNode(node interface{}, segments []string) (interface{}, error) {
+	for _, segment := range segments {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[segment]
+			if !ok {
+				return nil, fmt.Errorf("field %q doesn't exist", segment)
+			}
+			node = child
+		case []interface{}:
+			index, err := jsonPointerIndex(segment)
+			if err != nil {
+				return nil, err
+			}
+			if index >= len(n) {
+				return nil, fmt.Errorf("index %v out of range", segment)
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("can't descend into scalar with %q", segment)
+		}
+	}
+	return node, nil
+}
+
+// jsonPointerSegments splits an RFC 6901 JSON pointer into its unescaped
+// segments.
+func jsonPointerSegments(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with '/'", pointer)
+	}
+	segments := strings.Split(pointer[1:], "/")
+	for i := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[i])
+	}
+	return segments, nil
+}
+
+func jsonPointerIndex(segment string) (int, error) {
+	if segment == "" || (len(segment) > 1 && segment[0] == '0') || strings.TrimLeft(segment, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid list index %q", segment)
+	}
+	return strconv.Atoi(segment)
+}
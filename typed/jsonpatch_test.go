@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+var jsonPatchParser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: object
+  map:
+    fields:
+    - name: containers
+      type:
+        list:
+          elementType:
+            namedType: container
+          elementRelationship: associative
+          keys:
+          - name
+    - name: finalizers
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: args
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: spec
+      type:
+        map:
+          fields:
+          - name: a
+            type:
+              scalar: numeric
+          - name: b
+            type:
+              scalar: numeric
+- name: container
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: image
+      type:
+        scalar: string
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+func TestFieldSetFromJSONPatch(t *testing.T) {
+	live := `{"containers":[{"name":"a","image":"x"},{"name":"b","image":"y"}],"finalizers":["f","g"],"args":["--v"],"spec":{"a":1,"b":2}}`
+	table := []struct {
+		name      string
+		patch     string
+		expected  *fieldpath.Set
+		expectErr bool
+	}{{
+		name:  "keyed item by index",
+		patch: `[{"op":"replace","path":"/containers/1/image","value":"z"}]`,
+		expected: _NS(
+			_P("containers", _KBF("name", "b"), "image"),
+		),
+	}, {
+		name:  "append to keyed list",
+		patch: `[{"op":"add","path":"/containers/-","value":{"name":"c","image":"w"}}]`,
+		expected: _NS(
+			_P("containers", _KBF("name", "c")),
+			_P("containers", _KBF("name", "c"), "name"),
+			_P("containers", _KBF("name", "c"), "image"),
+		),
+	}, {
+		name:  "remove set item",
+		patch: `[{"op":"remove","path":"/finalizers/0"}]`,
+		expected: _NS(
+			_P("finalizers", _V("f")),
+		),
+	}, {
+		name:  "indices follow previous operations",
+		patch: `[{"op":"remove","path":"/containers/0"},{"op":"replace","path":"/containers/0/image","value":"z"}]`,
+		expected: _NS(
+			_P("containers", _KBF("name", "a")),
+			_P("containers", _KBF("name", "a"), "name"),
+			_P("containers", _KBF("name", "a"), "image"),
+			_P("containers", _KBF("name", "b"), "image"),
+		),
+	}, {
+		name:  "atomic list",
+		patch: `[{"op":"add","path":"/args/0","value":"--x"}]`,
+		expected: _NS(
+			_P("args"),
+		),
+	}, {
+		name:  "remove map",
+		patch: `[{"op":"remove","path":"/spec"}]`,
+		expected: _NS(
+			_P("spec"),
+			_P("spec", "a"),
+			_P("spec", "b"),
+		),
+	}, {
+		name:  "move and test",
+		patch: `[{"op":"test","path":"/spec/a","value":1},{"op":"move","from":"/spec/a","path":"/spec/b"}]`,
+		expected: _NS(
+			_P("spec", "a"),
+			_P("spec", "b"),
+		),
+	}, {
+		name:      "out of range",
+		patch:     `[{"op":"remove","path":"/containers/2"}]`,
+		expectErr: true,
+	}, {
+		name:      "unknown operation",
+		patch:     `[{"op":"frobnicate","path":"/spec"}]`,
+		expectErr: true,
+	}}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			tv, err := jsonPatchParser.Type("object").FromYAML(typed.YAMLObject(live))
+			if err != nil {
+				t.Fatalf("failed to parse live object: %v", err)
+			}
+			got, err := tv.FieldSetFromJSONPatch([]byte(tt.patch))
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equals(tt.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", tt.expected, got)
+			}
+		})
+	}
+}
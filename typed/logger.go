@@ -0,0 +1,25 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+// Logger receives the debug events of the library. Its method matches
+// the one of logr.Logger, so a logr.Logger at the verbosity of choice,
+// e.g. logger.V(4), can be used as is. Events are logged with a constant
+// message and key/value pairs.
+type Logger interface {
+	Info(msg string, keysAndValues ...interface{})
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+type recordingLogger []string
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	event := msg
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		event += fmt.Sprintf(" %v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	*l = append(*l, event)
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	parser, err := typed.NewParser(`types:
+- name: t
+  scalar: numeric
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Logger = logger
+	if _, err := parser.Type("t").FromYAML(`"a"`); err == nil {
+		t.Fatal("expected the object to fail to parse")
+	}
+	if parser.Type("u").IsValid() {
+		t.Fatal("expected type u not to exist")
+	}
+	if len(*logger) != 2 || !strings.HasPrefix((*logger)[0], `Failed to parse object type=t error=`) || (*logger)[1] != `Type not found in schema type=u` {
+		t.Errorf("unexpected events:\n%v", strings.Join(*logger, "\n"))
+	}
+}
+
+func TestDeduplicateSets(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: t
+  map:
+    fields:
+    - name: tags
+      type:
+        namedType: set
+    - name: items
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: name
+                type:
+                  scalar: string
+              - name: tags
+                type:
+                  namedType: set
+          elementRelationship: associative
+          keys: [name]
+- name: set
+  list:
+    elementType:
+      scalar: string
+    elementRelationship: associative
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := typed.YAMLObject(`{"tags": ["a", "b", "a"], "items": [{"name": "x", "tags": ["c", "c"]}, {"name": "y", "tags": ["d"]}]}`)
+	want := typed.YAMLObject(`{"tags": ["a", "b"], "items": [{"name": "x", "tags": ["c"]}, {"name": "y", "tags": ["d"]}]}`)
+
+	if _, err := parser.Type("t").FromYAML(object); err == nil {
+		t.Error("expected duplicates to fail the validation by default")
+	}
+
+	for _, opt := range []typed.ValidationOptions{typed.DeduplicateSets, typed.DeduplicateSetsWithWarnings} {
+		logger := &recordingLogger{}
+		parser.Logger = logger
+		got, err := parser.Type("t").FromYAML(object, opt)
+		if err != nil {
+			t.Fatalf("option %v: %v", opt, err)
+		}
+		expected, err := parser.Type("t").FromYAML(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !value.Equals(got.AsValue(), expected.AsValue()) {
+			t.Errorf("option %v: expected %v, got %v", opt, value.ToString(expected.AsValue()), value.ToString(got.AsValue()))
+		}
+		var events []string
+		if opt == typed.DeduplicateSetsWithWarnings {
+			events = []string{
+				`Removed duplicate set item path=.items[name="x"].tags[="c"] item="c"`,
+				`Removed duplicate set item path=.tags[="a"] item="a"`,
+			}
+		}
+		// Fields are walked in no particular order.
+		sort.Strings(*logger)
+		if strings.Join(*logger, "\n") != strings.Join(events, "\n") {
+			t.Errorf("option %v: expected events:\n%v\ngot:\n%v", opt, strings.Join(events, "\n"), strings.Join(*logger, "\n"))
+		}
+	}
+	// Warnings are returned without a Logger too.
+	parseable := parser.Type("t")
+	v, err := value.FromJSON([]byte(object))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range []typed.ValidationOptions{typed.DeduplicateSets, typed.DeduplicateSetsWithWarnings} {
+		_, warnings, err := typed.AsTypedWithWarnings(v, parseable.Schema, parseable.TypeRef, opt)
+		if err != nil {
+			t.Fatalf("option %v: %v", opt, err)
+		}
+		var expected []string
+		if opt == typed.DeduplicateSetsWithWarnings {
+			expected = []string{
+				`removed duplicate set item "a" at .tags[="a"]`,
+				`removed duplicate set item "c" at .items[name="x"].tags[="c"]`,
+			}
+		}
+		sort.Strings(warnings)
+		sort.Strings(expected)
+		if strings.Join(warnings, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("option %v: expected warnings:\n%v\ngot:\n%v", opt, strings.Join(expected, "\n"), strings.Join(warnings, "\n"))
+		}
+	}
+}
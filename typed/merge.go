@@ -48,6 +48,8 @@ type mergingWalker struct {
 	spareWalkers *[]*mergingWalker
 
 	allocator value.Allocator
+
+	walkOptions
 }
 
 // merge rules examine w.lhs and w.rhs (up to one of which may be nil) and
@@ -69,13 +71,27 @@ var (
 
 // merge sets w.out.
 func (w *mergingWalker) merge(prefixFn func() string) (errs ValidationErrors) {
+	if w.recoverPanics {
+		defer annotatePanic(w.path)
+	}
 	if w.lhs == nil && w.rhs == nil {
 		// check this condidition here instead of everywhere below.
 		return errorf("at least one of lhs and rhs must be provided")
 	}
+	if !w.budget.spend() {
+		return budgetExceeded()
+	}
+	if w.typeRef.MergePolicy != "" {
+		return w.doMergePolicy(w.typeRef.MergePolicy).WithLazyPrefix(prefixFn)
+	}
+	if w.shareUnchanged && (w.lhs == nil || w.rhs == nil) {
+		// Merging a subtree with nothing gives it back as is.
+		w.rule(w)
+		return nil
+	}
 	a, ok := w.schema.Resolve(w.typeRef)
 	if !ok {
-		return errorf("schema error: no type found matching: %v", *w.typeRef.NamedType)
+		return kindErrorf(schemaNotFound, "schema error: no type found matching: %v", *w.typeRef.NamedType)
 	}
 
 	alhs := deduceAtom(a, w.lhs)
@@ -160,7 +176,7 @@ func (w *mergingWalker) derefMap(prefix string, v value.Value) (value.Map, Valid
 	}
 	m, err := mapValue(w.allocator, v)
 	if err != nil {
-		return nil, errorf("%v: %v", prefix, err)
+		return nil, kindErrorf(kindOf(err), "%v: %v", prefix, err)
 	}
 	return m, nil
 }
@@ -178,7 +194,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 	if outLen < rLen {
 		outLen = rLen
 	}
-	out := make([]interface{}, 0, outLen)
+	items := make([]listItem, 0, outLen)
 
 	rhsPEs, observedRHS, rhsErrs := w.indexListPathElements(t, rhs, false)
 	errs = append(errs, rhsErrs...)
@@ -213,11 +229,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 				mergedRHS.Insert(pe, struct{}{})
 				lChild, _ := observedLHS.Get(pe) // may be nil if the PE is duplicaated.
 				rChild, _ := observedRHS.Get(pe)
-				mergeOut, errs := w.mergeListItem(t, pe, lChild, rChild)
-				errs = append(errs, errs...)
-				if mergeOut != nil {
-					out = append(out, *mergeOut)
-				}
+				items = append(items, listItem{pe: pe, lhs: lChild, rhs: rChild})
 				lI++
 				rI++
 
@@ -239,11 +251,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 			if _, ok := observedRHS.Get(pe); !ok {
 				// take LHS item using At to make sure we get the right item (observed may not contain the right item).
 				lChild := lhs.AtUsing(w.allocator, lI)
-				mergeOut, errs := w.mergeListItem(t, pe, lChild, nil)
-				errs = append(errs, errs...)
-				if mergeOut != nil {
-					out = append(out, *mergeOut)
-				}
+				items = append(items, listItem{pe: pe, lhs: lChild})
 				lI++
 				continue
 			} else if _, ok := mergedRHS.Get(pe); ok {
@@ -257,11 +265,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 			mergedRHS.Insert(pe, struct{}{})
 			lChild, _ := observedLHS.Get(pe) // may be nil if absent or duplicaated.
 			rChild, _ := observedRHS.Get(pe)
-			mergeOut, errs := w.mergeListItem(t, pe, lChild, rChild)
-			errs = append(errs, errs...)
-			if mergeOut != nil {
-				out = append(out, *mergeOut)
-			}
+			items = append(items, listItem{pe: pe, lhs: lChild, rhs: rChild})
 			rI++
 			// Advance nextShared, if we are merging nextShared.
 			if nextShared != nil && nextShared.Equals(pe) {
@@ -274,6 +278,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		}
 	}
 
+	out, errs := w.mergeListItems(t, items)
 	if len(out) > 0 {
 		i := interface{}(out)
 		w.out = &i
@@ -294,7 +299,7 @@ func (w *mergingWalker) indexListPathElements(t *schema.List, list value.List, a
 		child := list.At(i)
 		pe, err := listItemToPathElement(w.allocator, w.schema, t, child)
 		if err != nil {
-			errs = append(errs, errorf("element %v: %v", i, err.Error())...)
+			errs = append(errs, kindErrorf(kindOf(err), "element %v: %v", i, err.Error())...)
 			// If we can't construct the path element, we can't
 			// even report errors deeper in the schema, so bail on
 			// this element.
@@ -332,7 +337,7 @@ func (w *mergingWalker) derefList(prefix string, v value.Value) (value.List, Val
 	}
 	l, err := listValue(w.allocator, v)
 	if err != nil {
-		return nil, errorf("%v: %v", prefix, err)
+		return nil, kindErrorf(kindOf(err), "%v: %v", prefix, err)
 	}
 	return l, nil
 }
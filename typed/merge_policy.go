@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// MergePolicy merges the values of the fields whose schemas name it, see
+// schema.TypeRef.MergePolicy, so that embedders can try out merge
+// semantics, e.g. lists which are appended to or numbers which are added
+// up, without changing the walkers of this package.
+type MergePolicy interface {
+	// Merge returns the merge of lhs and rhs, up to one of which may be
+	// nil if the field is only set on the other side, or nil to leave
+	// the field unset. The merge replaces the one of the schema of the
+	// field, so it must conform to that schema; its children aren't
+	// merged, nor validated, by the walker.
+	Merge(lhs, rhs value.Value) (interface{}, error)
+}
+
+// MergePolicyFunc is a MergePolicy implemented by a function.
+type MergePolicyFunc func(lhs, rhs value.Value) (interface{}, error)
+
+// Merge implements MergePolicy.
+func (f MergePolicyFunc) Merge(lhs, rhs value.Value) (interface{}, error) {
+	return f(lhs, rhs)
+}
+
+var (
+	// mergePolicies holds the map[string]MergePolicy of the registered
+	// policies, copied on write.
+	mergePolicies   atomic.Value
+	mergePoliciesMu sync.Mutex
+)
+
+// RegisterMergePolicy registers p as the merge policy named name, which
+// schemas refer to with the mergePolicy of their type references.
+// Registering a nil p removes the policy.
+//
+// Policies only change how values are merged: the fields they merge are
+// owned, compared and removed the way their schemas say. Like equalities,
+// policies are process-wide, and are typically registered when the
+// program starts, before merging any value.
+func RegisterMergePolicy(name string, p MergePolicy) {
+	mergePoliciesMu.Lock()
+	defer mergePoliciesMu.Unlock()
+	old, _ := mergePolicies.Load().(map[string]MergePolicy)
+	m := make(map[string]MergePolicy, len(old)+1)
+	for k, v := range old {
+		m[k] = v
+	}
+	if p == nil {
+		delete(m, name)
+	} else {
+		m[name] = p
+	}
+	mergePolicies.Store(m)
+}
+
+// mergePolicyOf returns the merge policy registered as name, if any.
+func mergePolicyOf(name string) (MergePolicy, bool) {
+	m, _ := mergePolicies.Load().(map[string]MergePolicy)
+	p, ok := m[name]
+	return p, ok
+}
+
+// doMergePolicy merges w.lhs and w.rhs with the named policy, in place of
+// the merge rules of their type, which makes the field a leaf.
+func (w *mergingWalker) doMergePolicy(name string) ValidationErrors {
+	p, ok := mergePolicyOf(name)
+	if !ok {
+		return errorf("unknown merge policy %q", name)
+	}
+	w.inLeaf = true
+	out, err := p.Merge(w.lhs, w.rhs)
+	if err != nil {
+		return errorf("merge policy %q: %v", name, err)
+	}
+	if out != nil {
+		w.out = &out
+	}
+	return nil
+}
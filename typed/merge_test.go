@@ -18,8 +18,10 @@ package typed_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
@@ -530,3 +532,147 @@ func TestMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeWithReport(t *testing.T) {
+	pt := overridesParser.Type("type")
+	lhs, err := pt.FromYAML(`{"a": [{"name": "x", "value": 1}], "labels": {"k": "v"}, "name": "n"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		pso     typed.YAMLObject
+		changed *fieldpath.Set
+	}{{
+		pso:     `{"name": "n", "a": [{"name": "x", "value": 1}]}`,
+		changed: fieldpath.NewSet(),
+	}, {
+		pso: `{"name": "m", "a": [{"name": "x", "value": 2}, {"name": "y"}], "labels": {"k": "v"}}`,
+		changed: fieldpath.NewSet(
+			fieldpath.MakePathOrDie("name"),
+			fieldpath.MakePathOrDie("a", fieldpath.KeyByFields("name", "x"), "value"),
+			fieldpath.MakePathOrDie("a", fieldpath.KeyByFields("name", "y"), "name"),
+		),
+	}, {
+		pso: `{"labels": {"other": "v"}}`,
+		changed: fieldpath.NewSet(
+			fieldpath.MakePathOrDie("labels"),
+		),
+	}} {
+		pso, err := pt.FromYAML(tc.pso)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, changed, err := lhs.MergeWithReport(pso)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := lhs.Merge(pso)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !value.Equals(got.AsValue(), want.AsValue()) {
+			t.Errorf("%v: expected\n%v\nbut got\n%v", tc.pso, value.ToString(want.AsValue()), value.ToString(got.AsValue()))
+		}
+		if !changed.Equals(tc.changed) {
+			t.Errorf("%v: expected changed fields\n%v\nbut got\n%v", tc.pso, tc.changed, changed)
+		}
+	}
+}
+
+func TestMergePolicy(t *testing.T) {
+	typed.RegisterMergePolicy("sum", typed.MergePolicyFunc(func(lhs, rhs value.Value) (interface{}, error) {
+		var sum int64
+		for _, v := range []value.Value{lhs, rhs} {
+			if v == nil {
+				continue
+			}
+			if !v.IsInt() {
+				return nil, fmt.Errorf("expected an integer, got %v", value.ToString(v))
+			}
+			sum += v.AsInt()
+		}
+		return sum, nil
+	}))
+	defer typed.RegisterMergePolicy("sum", nil)
+	typed.RegisterMergePolicy("append", typed.MergePolicyFunc(func(lhs, rhs value.Value) (interface{}, error) {
+		var out []interface{}
+		for _, v := range []value.Value{lhs, rhs} {
+			if v == nil || v.IsNull() {
+				continue
+			}
+			for i := 0; i < v.AsList().Length(); i++ {
+				out = append(out, v.AsList().At(i).Unstructured())
+			}
+		}
+		return out, nil
+	}))
+	defer typed.RegisterMergePolicy("append", nil)
+
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: count
+      type:
+        scalar: numeric
+        mergePolicy: sum
+    - name: log
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+        mergePolicy: append
+    - name: name
+      type:
+        scalar: string
+    - name: other
+      type:
+        scalar: string
+        mergePolicy: unknown
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := parser.Type("type")
+	for _, tc := range []struct {
+		lhs, rhs, out typed.YAMLObject
+	}{{
+		lhs: `{"count": 1, "log": ["a"], "name": "a"}`,
+		rhs: `{"count": 2, "log": ["b", "a"], "name": "b"}`,
+		out: `{"count": 3, "log": ["a", "b", "a"], "name": "b"}`,
+	}, {
+		lhs: `{"count": 1}`,
+		rhs: `{"log": ["a"]}`,
+		out: `{"count": 1, "log": ["a"]}`,
+	}} {
+		lhs, err := pt.FromYAML(tc.lhs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rhs, err := pt.FromYAML(tc.rhs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := pt.FromYAML(tc.out, typed.AllowDuplicates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := lhs.Merge(rhs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !value.Equals(got.AsValue(), out.AsValue()) {
+			t.Errorf("expected\n%v\nbut got\n%v", value.ToString(out.AsValue()), value.ToString(got.AsValue()))
+		}
+	}
+
+	lhs, err := pt.FromYAML(`{"other": "a"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lhs.Merge(lhs); err == nil || !strings.Contains(err.Error(), `unknown merge policy "unknown"`) {
+		t.Errorf("expected an unknown merge policy error, got %v", err)
+	}
+}
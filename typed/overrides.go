@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+)
+
+// Granularity is how a map or a list is merged and owned.
+type Granularity int
+
+const (
+	// ForceAtomic makes a map or a list merged, owned and compared as a
+	// whole, like an `x-kubernetes-list-type: atomic` list.
+	ForceAtomic Granularity = iota + 1
+	// ForceGranular makes the items of a map or a list merged, owned and
+	// compared separately: maps become separable and lists associative.
+	// Lists of maps must then have keys in the schema.
+	ForceGranular
+)
+
+// FieldOverride forces the granularity of the field at Path, overriding
+// the element relationship of its schema. The items of lists are matched
+// by any path element but a field name, e.g. their index.
+type FieldOverride struct {
+	Path        fieldpath.Path
+	Granularity Granularity
+}
+
+// WithFieldOverrides returns a copy of p whose schema has the given
+// overrides, e.g. to work around an incorrect `x-kubernetes-list-type`
+// until the API is fixed. Only the fields at the paths of the overrides
+// are affected, even if their types are shared with other fields, and p
+// isn't modified.
+//
+// Values of the returned type can't be merged with or compared to values
+// of p, since their schemas differ.
+func (p ParseableType) WithFieldOverrides(overrides ...FieldOverride) (ParseableType, error) {
+	if len(overrides) == 0 {
+		return p, nil
+	}
+	s := &schema.Schema{Types: p.Schema.Types}
+	root := p.TypeRef
+	for _, o := range overrides {
+		if err := applyFieldOverride(s, &root, o); err != nil {
+			return ParseableType{}, fmt.Errorf("override of %v: %v", o.Path, err)
+		}
+	}
+	return ParseableType{Schema: s, TypeRef: root, logger: p.logger}, nil
+}
+
+// applyFieldOverride sets the element relationship of the reference to the
+// field at the path of the override. The types along the path are inlined
+// on the way, so that the override doesn't leak to other references to
+// them.
+func applyFieldOverride(s *schema.Schema, ref *schema.TypeRef, o FieldOverride) error {
+	for i, pe := range o.Path {
+		atom, err := inlineTypeRef(s, ref)
+		if err != nil {
+			return err
+		}
+		switch {
+		case pe.FieldName != nil:
+			if atom.Map == nil {
+				return fmt.Errorf("%v is not a map", o.Path[:i])
+			}
+			ref = fieldTypeRef(atom.Map, *pe.FieldName)
+			if ref == nil {
+				return fmt.Errorf("field %q is not declared in schema", *pe.FieldName)
+			}
+		default:
+			if atom.List == nil {
+				return fmt.Errorf("%v is not a list", o.Path[:i])
+			}
+			ref = &atom.List.ElementType
+		}
+	}
+
+	atom, ok := s.Resolve(*ref)
+	if !ok {
+		return fmt.Errorf("no type found matching: %v", ref)
+	}
+	var relationship schema.ElementRelationship
+	switch {
+	case o.Granularity == ForceAtomic && (atom.Map != nil || atom.List != nil):
+		relationship = schema.Atomic
+	case o.Granularity == ForceGranular && atom.Map != nil:
+		relationship = schema.Separable
+	case o.Granularity == ForceGranular && atom.List != nil:
+		relationship = schema.Associative
+	case atom.Map == nil && atom.List == nil:
+		return fmt.Errorf("only maps and lists can be overridden")
+	default:
+		return fmt.Errorf("unknown granularity: %v", o.Granularity)
+	}
+	ref.ElementRelationship = &relationship
+	return nil
+}
+
+// inlineTypeRef replaces ref with an inlined copy of the type it refers to,
+// and returns the copy, which can then be modified.
+func inlineTypeRef(s *schema.Schema, ref *schema.TypeRef) (schema.Atom, error) {
+	atom, ok := s.Resolve(*ref)
+	if !ok {
+		return schema.Atom{}, fmt.Errorf("no type found matching: %v", ref)
+	}
+	inlined := schema.Atom{Scalar: atom.Scalar}
+	if atom.Map != nil {
+		inlined.Map = &schema.Map{
+			Fields:              append([]schema.StructField(nil), atom.Map.Fields...),
+			Unions:              atom.Map.Unions,
+			ElementType:         atom.Map.ElementType,
+			ElementRelationship: atom.Map.ElementRelationship,
+			MapType:             atom.Map.MapType,
+			StructType:          atom.Map.StructType,
+		}
+	}
+	if atom.List != nil {
+		l := *atom.List
+		inlined.List = &l
+	}
+	// The element relationship of ref is already applied to the copy.
+	*ref = schema.TypeRef{Inlined: inlined}
+	return inlined, nil
+}
+
+// fieldTypeRef returns the reference to the type of the named field of m,
+// or to the type of its elements if the field isn't declared, or nil if
+// neither is.
+func fieldTypeRef(m *schema.Map, name string) *schema.TypeRef {
+	for i := range m.Fields {
+		if m.Fields[i].Name == name {
+			return &m.Fields[i].Type
+		}
+	}
+	if (m.ElementType != schema.TypeRef{}) {
+		return &m.ElementType
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var overridesParser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: a
+      type:
+        namedType: items
+    - name: b
+      type:
+        namedType: items
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: name
+      type:
+        scalar: string
+- name: items
+  list:
+    elementType:
+      map:
+        fields:
+        - name: name
+          type:
+            scalar: string
+        - name: value
+          type:
+            scalar: numeric
+    elementRelationship: associative
+    keys:
+    - name
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+func TestWithFieldOverrides(t *testing.T) {
+	pt, err := overridesParser.Type("type").WithFieldOverrides(
+		typed.FieldOverride{Path: fieldpath.MakePathOrDie("a"), Granularity: typed.ForceAtomic},
+		typed.FieldOverride{Path: fieldpath.MakePathOrDie("labels"), Granularity: typed.ForceGranular},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lhs, err := pt.FromYAML(`{"a": [{"name": "x"}], "b": [{"name": "x"}], "labels": {"k": "v"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := lhs.ToFieldSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fieldpath.NewSet(
+		fieldpath.MakePathOrDie("a"),
+		fieldpath.MakePathOrDie("b", fieldpath.KeyByFields("name", "x"), "name"),
+		fieldpath.MakePathOrDie("labels", "k"),
+	)
+	if !set.Leaves().Equals(want) {
+		t.Errorf("expected the leaves %v, got %v", want, set.Leaves())
+	}
+
+	rhs, err := pt.FromYAML(`{"a": [{"name": "y"}], "b": [{"name": "y"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := lhs.Merge(rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := pt.FromYAML(`{"a": [{"name": "y"}], "b": [{"name": "x"}, {"name": "y"}], "labels": {"k": "v"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := merged.Compare(expected); err != nil || !c.IsSame() {
+		t.Errorf("expected %v, got %v (%v)", value.ToString(expected.AsValue()), value.ToString(merged.AsValue()), err)
+	}
+
+	// The original type is untouched.
+	original, err := overridesParser.Type("type").FromYAML(`{"a": [{"name": "x"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set, err := original.ToFieldSet(); err != nil || !set.Has(fieldpath.MakePathOrDie("a", fieldpath.KeyByFields("name", "x"), "name")) {
+		t.Errorf("expected .a to stay granular, got %v (%v)", set, err)
+	}
+}
+
+func TestWithFieldOverridesErrors(t *testing.T) {
+	for _, o := range []typed.FieldOverride{
+		{Path: fieldpath.MakePathOrDie("missing"), Granularity: typed.ForceAtomic},
+		{Path: fieldpath.MakePathOrDie("name"), Granularity: typed.ForceAtomic},
+		{Path: fieldpath.MakePathOrDie("name", "x"), Granularity: typed.ForceAtomic},
+		{Path: fieldpath.MakePathOrDie("a"), Granularity: 0},
+	} {
+		if _, err := overridesParser.Type("type").WithFieldOverrides(o); err == nil {
+			t.Errorf("expected the override of %v to fail", o.Path)
+		}
+	}
+}
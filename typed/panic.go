@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// PanicError is returned instead of panicking by the operations run with
+// Options.RecoverPanics, e.g. on values or schemas that break invariants
+// the walkers rely on.
+type PanicError struct {
+	// Path is the path of the field that was being walked, if known.
+	Path fieldpath.Path
+	// Value is the value that was passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error returns a human readable error message.
+func (e *PanicError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("panic: %v", e.Value)
+	}
+	return fmt.Sprintf("panic at %v: %v", e.Path, e.Value)
+}
+
+// Unwrap returns the value passed to panic, if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// annotatePanic is deferred by the walkers recovering panics, so that the
+// innermost one attaches its path to the panic before letting it unwind.
+func annotatePanic(path fieldpath.Path) {
+	if r := recover(); r != nil {
+		if _, ok := r.(*PanicError); !ok {
+			r = &PanicError{Path: path.Copy(), Value: r, Stack: debug.Stack()}
+		}
+		panic(r)
+	}
+}
+
+// recoverPanic is deferred by the operations recovering panics, and sets
+// err to the panic, if any.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		pe, ok := r.(*PanicError)
+		if !ok {
+			pe = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+		*err = pe
+	}
+}
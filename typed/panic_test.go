@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// unsupported holds a channel deep down, which the value reflector panics
+// on.
+type unsupported struct {
+	Spec struct {
+		C chan int `json:"c"`
+	} `json:"spec"`
+}
+
+func unsupportedValue(t *testing.T) *typed.TypedValue {
+	obj := &unsupported{}
+	obj.Spec.C = make(chan int)
+	v, err := value.NewValueReflect(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typed.AsTypedUnvalidated(v, typed.DeducedParseableType.Schema, typed.DeducedParseableType.TypeRef)
+}
+
+func TestRecoverPanics(t *testing.T) {
+	lhs := unsupportedValue(t)
+	rhs, err := typed.DeducedParseableType.FromYAML(`{"spec": {"c": 1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := typed.Options{RecoverPanics: true}
+	_, mergeErr := lhs.MergeWithOptions(rhs, opts)
+	_, compareErr := lhs.CompareWithOptions(rhs, opts)
+	for _, err := range []error{mergeErr, compareErr} {
+		var pe *typed.PanicError
+		if !errors.As(err, &pe) {
+			t.Fatalf("expected a *PanicError, got %v", err)
+		}
+		if want := fieldpath.MakePathOrDie("spec"); !pe.Path.Equals(want) {
+			t.Errorf("expected the panic at %v, got %v", want, pe.Path)
+		}
+		if !errors.Is(err, value.ErrUnsupportedType) {
+			t.Errorf("expected %v to wrap the panic", err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected merges not recovering panics to panic")
+		}
+	}()
+	lhs.MergeWithOptions(rhs, typed.Options{})
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"runtime"
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// ListParallelism configures MergeParallel and CompareParallel to process
+// the items of large associative lists concurrently. The result is the
+// same as with Merge and Compare, in the same order.
+type ListParallelism struct {
+	// MinItems is the number of items from which a list is processed
+	// concurrently. Lists nested in the items of such a list are not.
+	MinItems int
+	// Workers is the maximum number of goroutines processing a list.
+	// Defaults to GOMAXPROCS.
+	Workers int
+}
+
+// MergeParallel is like Merge, except that the items of large associative
+// lists are merged concurrently.
+func (tv TypedValue) MergeParallel(pso *TypedValue, p ListParallelism) (*TypedValue, error) {
+	return mergeKeepRHS(&tv, pso, walkOptions{parallelism: &p})
+}
+
+// CompareParallel is like Compare, except that the items of large
+// associative lists are compared concurrently.
+func (tv TypedValue) CompareParallel(rhs *TypedValue, p ListParallelism) (*Comparison, error) {
+	return compare(&tv, rhs, walkOptions{parallelism: &p})
+}
+
+// listItem is an item of a list, paired with the corresponding item of the
+// other list, if any.
+type listItem struct {
+	pe       fieldpath.PathElement
+	lhs, rhs value.Value
+}
+
+// chunks splits n items into the ranges that should be processed
+// concurrently for a list of the given type, or returns nil if the list
+// should be processed serially.
+func (p *ListParallelism) chunks(t *schema.List, n int) [][2]int {
+	if p == nil || t.ElementRelationship != schema.Associative || n < p.MinItems || n < 2 {
+		return nil
+	}
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers < 2 {
+		return nil
+	}
+	chunks := make([][2]int, 0, workers)
+	for i := 0; i < workers; i++ {
+		chunks = append(chunks, [2]int{i * n / workers, (i + 1) * n / workers})
+	}
+	return chunks
+}
+
+// forEachChunk calls fn concurrently for each of the chunks, with the
+// index of the chunk, and waits for them to return. If any of them
+// panics, forEachChunk panics with the same value once they're done, so
+// that the panic can be recovered by the caller.
+func forEachChunk(chunks [][2]int, fn func(i, start, end int)) {
+	var wg sync.WaitGroup
+	panics := make([]interface{}, len(chunks))
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			defer func() { panics[i] = recover() }()
+			fn(i, start, end)
+		}(i, c[0], c[1])
+	}
+	wg.Wait()
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// mergeListItems merges the items in order, and returns the merged items
+// that aren't nil.
+func (w *mergingWalker) mergeListItems(t *schema.List, items []listItem) (out []interface{}, errs ValidationErrors) {
+	outs := make([]*interface{}, len(items))
+	if chunks := w.parallelism.chunks(t, len(items)); chunks != nil {
+		chunkErrs := make([]ValidationErrors, len(chunks))
+		forEachChunk(chunks, func(i, start, end int) {
+			w2 := *w
+			w2.path = w.path.Copy()
+			w2.spareWalkers = nil
+			w2.allocator = value.NewFreelistAllocator()
+			w2.parallelism = nil
+			for j := start; j < end; j++ {
+				var itemErrs ValidationErrors
+				outs[j], itemErrs = w2.mergeListItem(t, items[j].pe, items[j].lhs, items[j].rhs)
+				chunkErrs[i] = append(chunkErrs[i], itemErrs...)
+			}
+		})
+		for _, e := range chunkErrs {
+			errs = append(errs, e...)
+		}
+	} else {
+		for j, item := range items {
+			var itemErrs ValidationErrors
+			outs[j], itemErrs = w.mergeListItem(t, item.pe, item.lhs, item.rhs)
+			errs = append(errs, itemErrs...)
+		}
+	}
+	out = make([]interface{}, 0, len(outs))
+	for _, o := range outs {
+		if o != nil {
+			out = append(out, *o)
+		}
+	}
+	return out, errs
+}
+
+// compareListItems compares the items, and adds the result to the
+// comparison of the walker.
+func (w *compareWalker) compareListItems(t *schema.List, items []listItem) (errs ValidationErrors) {
+	chunks := w.parallelism.chunks(t, len(items))
+	if chunks == nil {
+		for _, item := range items {
+			errs = append(errs, w.compareListItem(t, item.pe, item.lhs, item.rhs)...)
+		}
+		return errs
+	}
+	chunkErrs := make([]ValidationErrors, len(chunks))
+	comparisons := make([]*Comparison, len(chunks))
+	forEachChunk(chunks, func(i, start, end int) {
+		w2 := *w
+		w2.path = w.path.Copy()
+		w2.spareWalkers = nil
+		w2.allocator = value.NewFreelistAllocator()
+		w2.parallelism = nil
+		w2.comparison = &Comparison{
+			Removed:  fieldpath.NewSet(),
+			Modified: fieldpath.NewSet(),
+			Added:    fieldpath.NewSet(),
+		}
+		for j := start; j < end; j++ {
+			chunkErrs[i] = append(chunkErrs[i], w2.compareListItem(t, items[j].pe, items[j].lhs, items[j].rhs)...)
+		}
+		comparisons[i] = w2.comparison
+	})
+	for i := range chunks {
+		errs = append(errs, chunkErrs[i]...)
+		w.comparison.Removed = w.comparison.Removed.Union(comparisons[i].Removed)
+		w.comparison.Modified = w.comparison.Modified.Union(comparisons[i].Modified)
+		w.comparison.Added = w.comparison.Added.Union(comparisons[i].Added)
+	}
+	return errs
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var endpointsParser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: endpoints
+  map:
+    fields:
+    - name: ports
+      type:
+        list:
+          elementType:
+            namedType: port
+          elementRelationship: associative
+          keys:
+          - name
+- name: port
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: port
+      type:
+        scalar: numeric
+    - name: addresses
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+// endpoints returns an object with the ports in the range, each with a
+// port number and addresses depending on offset.
+func endpoints(t *testing.T, from, to, offset int) *typed.TypedValue {
+	var ports []interface{}
+	for i := from; i < to; i++ {
+		ports = append(ports, map[string]interface{}{
+			"name":      fmt.Sprintf("port-%d", i),
+			"port":      i + offset,
+			"addresses": []interface{}{fmt.Sprintf("10.0.0.%d", (i+offset)%256), "10.0.1.1"},
+		})
+	}
+	tv, err := endpointsParser.Type("endpoints").FromUnstructured(map[string]interface{}{"ports": ports})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tv
+}
+
+func TestParallelListItems(t *testing.T) {
+	lhs := endpoints(t, 0, 1000, 0)
+	rhs := endpoints(t, 500, 1500, 0)
+	for i, other := range []*typed.TypedValue{rhs, endpoints(t, 0, 1000, 1), endpoints(t, 250, 750, 3)} {
+		p := typed.ListParallelism{MinItems: 100, Workers: 7}
+
+		expected, err := lhs.Merge(other)
+		if err != nil {
+			t.Fatalf("%d: failed to merge: %v", i, err)
+		}
+		got, err := lhs.MergeParallel(other, p)
+		if err != nil {
+			t.Fatalf("%d: failed to merge in parallel: %v", i, err)
+		}
+		if !value.Equals(expected.AsValue(), got.AsValue()) {
+			t.Errorf("%d: expected merged object:\n%v\ngot:\n%v", i, value.ToString(expected.AsValue()), value.ToString(got.AsValue()))
+		}
+
+		expectedComparison, err := lhs.Compare(other)
+		if err != nil {
+			t.Fatalf("%d: failed to compare: %v", i, err)
+		}
+		comparison, err := lhs.CompareParallel(other, p)
+		if err != nil {
+			t.Fatalf("%d: failed to compare in parallel: %v", i, err)
+		}
+		if !expectedComparison.Added.Equals(comparison.Added) ||
+			!expectedComparison.Modified.Equals(comparison.Modified) ||
+			!expectedComparison.Removed.Equals(comparison.Removed) {
+			t.Errorf("%d: expected comparison:\n%v\ngot:\n%v", i, expectedComparison, comparison)
+		}
+		if expectedComparison.IsSame() {
+			t.Errorf("%d: expected the objects to differ", i)
+		}
+	}
+}
@@ -30,19 +30,27 @@ type YAMLObject string
 // Parser implements YAMLParser and allows introspecting the schema.
 type Parser struct {
 	Schema schema.Schema
+
+	// Logger, if set, is given the types that aren't found in the
+	// schema, and the objects of the ParseableTypes of the parser that
+	// fail to parse.
+	Logger Logger
 }
 
-// create builds an unvalidated parser.
+// create builds an unvalidated parser, with the markers of the schema
+// applied.
 func create(s YAMLObject) (*Parser, error) {
 	p := Parser{}
-	err := yaml.Unmarshal([]byte(s), &p.Schema)
-	return &p, err
+	if err := yaml.Unmarshal([]byte(s), &p.Schema); err != nil {
+		return &p, err
+	}
+	return &p, schema.ApplyMarkers(&p.Schema)
 }
 
 func createOrDie(schema YAMLObject) *Parser {
 	p, err := create(schema)
 	if err != nil {
-		panic(fmt.Errorf("failed to create parser: %v", err))
+		panic(fmt.Errorf("failed to create parser: %w", err))
 	}
 	return p
 }
@@ -53,7 +61,7 @@ var ssParser = createOrDie(YAMLObject(schema.SchemaSchemaYAML))
 func NewParser(schema YAMLObject) (*Parser, error) {
 	_, err := ssParser.Type("schema").FromYAML(schema)
 	if err != nil {
-		return nil, fmt.Errorf("unable to validate schema: %v", err)
+		return nil, fmt.Errorf("unable to validate schema: %w", err)
 	}
 	p, err := create(schema)
 	if err != nil {
@@ -73,16 +81,35 @@ func (p *Parser) TypeNames() (names []string) {
 // Type returns a helper which can produce objects of the given type. Any
 // errors are deferred until a further function is called.
 func (p *Parser) Type(name string) ParseableType {
-	return ParseableType{
+	pt := ParseableType{
 		Schema:  &p.Schema,
 		TypeRef: schema.TypeRef{NamedType: &name},
+		logger:  p.Logger,
+	}
+	if p.Logger != nil && !pt.IsValid() {
+		p.Logger.Info("Type not found in schema", "type", name)
 	}
+	return pt
 }
 
 // ParseableType allows for easy production of typed objects.
 type ParseableType struct {
 	TypeRef schema.TypeRef
 	Schema  *schema.Schema
+
+	logger Logger
+}
+
+// logFailure logs that an object failed to parse, if there's a logger.
+func (p ParseableType) logFailure(err error) {
+	if p.logger == nil {
+		return
+	}
+	name := ""
+	if p.TypeRef.NamedType != nil {
+		name = *p.TypeRef.NamedType
+	}
+	p.logger.Info("Failed to parse object", "type", name, "error", err)
 }
 
 // IsValid return true if p's schema and typename are valid.
@@ -97,9 +124,30 @@ func (p ParseableType) FromYAML(object YAMLObject, opts ...ValidationOptions) (*
 	var v interface{}
 	err := yaml.Unmarshal([]byte(object), &v)
 	if err != nil {
+		p.logFailure(err)
+		return nil, err
+	}
+	return p.asTyped(value.NewValueInterface(v), opts)
+}
+
+// FromYAMLWithLimits is like FromYAML, but fails with a *value.LimitError
+// if the object exceeds the limits, which are checked while it's parsed,
+// see value.FromYAMLWithLimits.
+func (p ParseableType) FromYAMLWithLimits(object YAMLObject, limits value.Limits, opts ...ValidationOptions) (*TypedValue, error) {
+	v, err := value.FromYAMLWithLimits([]byte(object), limits)
+	if err != nil {
+		p.logFailure(err)
 		return nil, err
 	}
-	return AsTyped(value.NewValueInterface(v), p.Schema, p.TypeRef, opts...)
+	return p.asTyped(v, opts)
+}
+
+func (p ParseableType) asTyped(v value.Value, opts []ValidationOptions) (*TypedValue, error) {
+	tv, _, err := asTyped(v, p.Schema, p.TypeRef, opts, p.logger)
+	if err != nil {
+		p.logFailure(err)
+	}
+	return tv, err
 }
 
 // FromUnstructured converts a go "interface{}" type, typically an
@@ -109,7 +157,7 @@ func (p ParseableType) FromYAML(object YAMLObject, opts ...ValidationOptions) (*
 // map[interface{}]interface{}, []interface{}, int types, float types,
 // string or boolean. Nested interface{} must also be one of these types.
 func (p ParseableType) FromUnstructured(in interface{}, opts ...ValidationOptions) (*TypedValue, error) {
-	return AsTyped(value.NewValueInterface(in), p.Schema, p.TypeRef, opts...)
+	return p.asTyped(value.NewValueInterface(in), opts)
 }
 
 // FromStructured converts a go "interface{}" type, typically an structured object in
@@ -120,9 +168,11 @@ func (p ParseableType) FromUnstructured(in interface{}, opts ...ValidationOption
 func (p ParseableType) FromStructured(in interface{}, opts ...ValidationOptions) (*TypedValue, error) {
 	v, err := value.NewValueReflect(in)
 	if err != nil {
-		return nil, fmt.Errorf("error creating struct value reflector: %v", err)
+		err = fmt.Errorf("error creating struct value reflector: %w", err)
+		p.logFailure(err)
+		return nil, err
 	}
-	return AsTyped(v, p.Schema, p.TypeRef, opts...)
+	return p.asTyped(v, opts)
 }
 
 // DeducedParseableType is a ParseableType that deduces the type from
@@ -103,3 +103,47 @@ func BenchmarkConvertUnstructured(b *testing.B) {
 		})
 	}
 }
+
+func TestParserMarkers(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: items
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: name
+                type:
+                  scalar: string
+          listType: map
+          listMapKeys: [name]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tv, err := parser.Type("type").FromYAML(`{"items": [{"name": "a"}, {"name": "b"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := tv.ToFieldSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(set.String(), `.items[name="a"].name`) {
+		t.Errorf("expected the items to be keyed by name, got %v", set)
+	}
+
+	_, err = typed.NewParser(`types:
+- name: type
+  list:
+    elementType:
+      scalar: string
+    listType: map
+`)
+	if err == nil || !strings.Contains(err.Error(), "requires listMapKeys") {
+		t.Errorf("expected incoherent markers to fail, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// reconcileListKeys rewrites the path elements of the list items of the
+// field set that no longer identify them as the schema does, e.g. because
+// the keys of an associative list changed, or a set became an associative
+// list, by finding the items in the live object. The ownership of the items
+// that can't be found is dropped. Returns nil if no path element needs to
+// be rewritten.
+//
+// Whether any needs to be is found from the schema alone, so that the field
+// sets that are up to date are cheap to reconcile.
+func reconcileListKeys(fieldset *fieldpath.Set, tv *TypedValue) *fieldpath.Set {
+	r := listKeysReconciler{schema: tv.schema}
+	if !r.walk(tv.typeRef, nil, fieldset, nil, nil) {
+		return nil
+	}
+	out := fieldpath.NewSet()
+	r.walk(tv.typeRef, tv.value, fieldset, nil, out)
+	return out
+}
+
+type listKeysReconciler struct {
+	schema *schema.Schema
+}
+
+// walk visits the set of the field at prefix, whose type is tr and whose
+// live value is v, and returns whether any of its path elements is stale.
+// Without out, it returns as soon as it finds one; with out, it inserts the
+// rewritten paths of the set into out.
+func (r *listKeysReconciler) walk(tr schema.TypeRef, v value.Value, set *fieldpath.Set, prefix fieldpath.Path, out *fieldpath.Set) bool {
+	atom, ok := r.schema.Resolve(tr)
+	switch {
+	case ok && atom.List != nil:
+		var l value.List
+		if v != nil && v.IsList() {
+			l = v.AsList()
+		}
+		return r.walkElements(set, prefix, out, func(pe fieldpath.PathElement, needItem bool) (fieldpath.PathElement, schema.TypeRef, value.Value, bool, bool) {
+			newPE, item, stale, found := reconcileListElement(r.schema, atom.List, l, pe, needItem)
+			return newPE, atom.List.ElementType, item, stale, found
+		})
+	case ok && atom.Map != nil && !isUntypedDeducedMap(atom.Map):
+		var m value.Map
+		if v != nil && v.IsMap() {
+			m = v.AsMap()
+		}
+		return r.walkElements(set, prefix, out, func(pe fieldpath.PathElement, needItem bool) (fieldpath.PathElement, schema.TypeRef, value.Value, bool, bool) {
+			tr, _ := typeRefAtPath(atom.Map, pe)
+			var item value.Value
+			if needItem && m != nil && pe.FieldName != nil {
+				item, _ = m.Get(*pe.FieldName)
+			}
+			return pe, tr, item, false, true
+		})
+	}
+	if out != nil {
+		set.Iterate(func(p fieldpath.Path) {
+			out.Insert(append(prefix.Copy(), p...))
+		})
+	}
+	return false
+}
+
+// walkElements walks the path elements of the set, which element resolves
+// to their rewritten path element, type and live value, and tells whether
+// they were stale and whether they were found.
+func (r *listKeysReconciler) walkElements(set *fieldpath.Set, prefix fieldpath.Path, out *fieldpath.Set, element func(pe fieldpath.PathElement, needItem bool) (fieldpath.PathElement, schema.TypeRef, value.Value, bool, bool)) bool {
+	stale := false
+	visit := func(pe fieldpath.PathElement, isMember bool) {
+		if stale && out == nil {
+			return
+		}
+		children, hasChildren := set.Children.Get(pe)
+		newPE, tr, item, peStale, found := element(pe, out != nil)
+		stale = stale || peStale
+		if !found {
+			return
+		}
+		path := append(prefix.Copy(), newPE)
+		if isMember && out != nil {
+			out.Insert(path)
+		}
+		if hasChildren && (tr != schema.TypeRef{}) {
+			stale = r.walk(tr, item, children, path, out) || stale
+		} else if hasChildren && out != nil {
+			children.Iterate(func(p fieldpath.Path) {
+				out.Insert(append(path.Copy(), p...))
+			})
+		}
+	}
+	set.Members.Iterate(func(pe fieldpath.PathElement) {
+		visit(pe, true)
+	})
+	set.Children.Iterate(func(pe fieldpath.PathElement) {
+		if !set.Members.Has(pe) {
+			visit(pe, false)
+		}
+	})
+	return stale
+}
+
+// reconcileListElement returns the path element that identifies the item of the list
+// that pe identifies, and the item if needed, and whether pe was stale. The
+// item is only found if needed or if pe is stale.
+func reconcileListElement(s *schema.Schema, t *schema.List, l value.List, pe fieldpath.PathElement, needItem bool) (newPE fieldpath.PathElement, item value.Value, stale, found bool) {
+	if !isStaleListElement(t, pe) {
+		if needItem && l != nil {
+			item, _ = findListItem(l, func(item value.Value) bool {
+				itemPE, err := listItemToPathElement(value.HeapAllocator, s, t, item)
+				return err == nil && itemPE.Equals(pe)
+			})
+		}
+		return pe, item, false, true
+	}
+	if l == nil {
+		return pe, nil, true, false
+	}
+	switch {
+	case pe.Key != nil:
+		item, found = findListItem(l, func(item value.Value) bool {
+			if !item.IsMap() {
+				return false
+			}
+			m := item.AsMap()
+			for _, f := range *pe.Key {
+				v, ok := m.Get(f.Name)
+				if !ok || !value.Equals(v, f.Value) {
+					return false
+				}
+			}
+			return true
+		})
+	case pe.Value != nil:
+		item, found = findListItem(l, func(item value.Value) bool {
+			return value.Equals(item, *pe.Value)
+		})
+	case pe.Index != nil && *pe.Index < l.Length():
+		item, found = l.At(*pe.Index), true
+	}
+	if !found {
+		return pe, nil, true, false
+	}
+	newPE, err := listItemToPathElement(value.HeapAllocator, s, t, item)
+	return newPE, item, true, err == nil
+}
+
+// isStaleListElement returns whether pe doesn't identify the items of the
+// list as the schema does. Only the items of associative lists are
+// reconciled.
+func isStaleListElement(t *schema.List, pe fieldpath.PathElement) bool {
+	if t.ElementRelationship != schema.Associative {
+		return false
+	}
+	keys := t.IdentityKeys()
+	if len(keys) == 0 {
+		return pe.Value == nil
+	}
+	if pe.Key == nil || len(*pe.Key) != len(keys) {
+		return true
+	}
+	for _, f := range *pe.Key {
+		if !containsKey(keys, f.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKey(keys []string, name string) bool {
+	for _, k := range keys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+func findListItem(l value.List, match func(value.Value) bool) (value.Value, bool) {
+	for i := 0; i < l.Length(); i++ {
+		if item := l.At(i); match(item) {
+			return item, true
+		}
+	}
+	return nil, false
+}
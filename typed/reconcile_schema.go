@@ -116,7 +116,22 @@ func (v *reconcileWithSchemaWalker) finishDescent(v2 *reconcileWithSchemaWalker)
 // Supports:
 // - changing types from atomic to granular
 // - changing types from granular to atomic
+// - changing the keys of associative lists, or sets to associative lists,
+// in which case the items are found in tv, and the ownership of those
+// that can't be found is dropped
 func ReconcileFieldSetWithSchema(fieldset *fieldpath.Set, tv *TypedValue) (*fieldpath.Set, error) {
+	rekeyed := reconcileListKeys(fieldset, tv)
+	if rekeyed != nil {
+		fieldset = rekeyed
+	}
+	reconciled, err := reconcileFieldSetWithSchema(fieldset, tv)
+	if reconciled == nil && err == nil && rekeyed != nil {
+		return rekeyed, nil
+	}
+	return reconciled, err
+}
+
+func reconcileFieldSetWithSchema(fieldset *fieldpath.Set, tv *TypedValue) (*fieldpath.Set, error) {
 	v := fmPool.Get().(*reconcileWithSchemaWalker)
 	v.fieldSet = fieldset
 	v.value = tv
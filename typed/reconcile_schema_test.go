@@ -18,6 +18,7 @@ package typed_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
@@ -161,6 +162,11 @@ func granularSchema(version string) typed.YAMLObject {
 `, version))
 }
 
+// rekeyedSchema is granularSchema with objectList keyed by the given key.
+func rekeyedSchema(version, key string) typed.YAMLObject {
+	return typed.YAMLObject(strings.Replace(string(granularSchema(version)), "      - keyA\n      - keyB\n", "      - "+key+"\n", 1))
+}
+
 func atomicSchema(version string) typed.YAMLObject {
 	return typed.YAMLObject(fmt.Sprintf(`types:
 - name: %s
@@ -341,6 +347,37 @@ var reconcileCases = []reconcileTestCase{{
 		_P("unchanged", "numeric"),
 	),
 	fixedFields: nil, // indicates no change
+}, {
+	name:         "rekeyed-associative-list",
+	rootTypeName: "v1",
+	oldSchema:    granularSchema("v1"),
+	newSchema:    rekeyedSchema("v1", "keyA"),
+	liveObject:   basicLiveObject,
+	oldFields: _NS(
+		_P("objectList", _KBF("keyA", "a1", "keyB", "b1")),
+		_P("objectList", _KBF("keyA", "a1", "keyB", "b1"), "value"),
+		_P("objectList", _KBF("keyA", "gone", "keyB", "b3"), "value"),
+		_P("unchanged", "numeric"),
+	),
+	fixedFields: _NS(
+		_P("objectList", _KBF("keyA", "a1")),
+		_P("objectList", _KBF("keyA", "a1"), "value"),
+		_P("unchanged", "numeric"),
+	),
+}, {
+	name:         "associative-list-keyed-by-other-field",
+	rootTypeName: "v1",
+	oldSchema:    granularSchema("v1"),
+	newSchema:    rekeyedSchema("v1", "value"),
+	liveObject:   basicLiveObject,
+	oldFields: _NS(
+		_P("objectList", _KBF("keyA", "a2", "keyB", "b2"), "keyA"),
+		_P("list", _V("one")),
+	),
+	fixedFields: _NS(
+		_P("objectList", _KBF("value", "v2"), "keyA"),
+		_P("list", _V("one")),
+	),
 }, {
 	name:         "no-change-atomic",
 	rootTypeName: "v1",
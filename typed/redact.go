@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// Redact returns tv with the values at the paths of the set replaced with
+// the placeholder, like fieldpath.Redact, but matching the items of lists
+// with the path elements of the set as the schema keys them. The result
+// can be rendered, e.g. with RenderYAML or UnifiedDiff, to log or audit
+// objects without their secrets, but it usually doesn't conform to the
+// schema anymore, so it can't be merged or compared.
+func (tv TypedValue) Redact(set *fieldpath.Set, placeholder interface{}) *TypedValue {
+	if !set.Empty() {
+		tv.value = redactWithSchema(tv.value, set, placeholder, tv.schema, tv.typeRef)
+	}
+	return &tv
+}
+
+type redactingWalker struct {
+	value       value.Value
+	out         interface{}
+	schema      *schema.Schema
+	toRedact    *fieldpath.Set
+	placeholder interface{}
+}
+
+func redactWithSchema(val value.Value, toRedact *fieldpath.Set, placeholder interface{}, s *schema.Schema, typeRef schema.TypeRef) value.Value {
+	w := &redactingWalker{
+		value:       val,
+		out:         val.Unstructured(),
+		schema:      s,
+		toRedact:    toRedact,
+		placeholder: placeholder,
+	}
+	resolveSchema(s, typeRef, val, w)
+	return value.NewValueInterface(w.out)
+}
+
+// redactChild returns the value of the child at pe, redacted.
+func (w *redactingWalker) redactChild(pe fieldpath.PathElement, val value.Value, typeRef schema.TypeRef) interface{} {
+	if w.toRedact.Members.Has(pe) {
+		return w.placeholder
+	}
+	if subset, ok := w.toRedact.Children.Get(pe); ok {
+		return redactWithSchema(val, subset, w.placeholder, w.schema, typeRef).Unstructured()
+	}
+	return val.Unstructured()
+}
+
+func (w *redactingWalker) doScalar(t *schema.Scalar) ValidationErrors {
+	return nil
+}
+
+func (w *redactingWalker) doList(t *schema.List) ValidationErrors {
+	if !w.value.IsList() {
+		return nil
+	}
+	l := w.value.AsList()
+	out := make([]interface{}, l.Length())
+	for i := range out {
+		item := l.At(i)
+		pe := fieldpath.PathElement{Index: &i}
+		if t.ElementRelationship == schema.Associative {
+			// Items that can't be keyed are kept as they are.
+			var err error
+			if pe, err = listItemToPathElement(value.HeapAllocator, w.schema, t, item); err != nil {
+				out[i] = item.Unstructured()
+				continue
+			}
+		}
+		out[i] = w.redactChild(pe, item, t.ElementType)
+	}
+	w.out = out
+	return nil
+}
+
+func (w *redactingWalker) doMap(t *schema.Map) ValidationErrors {
+	if !w.value.IsMap() {
+		return nil
+	}
+	m := w.value.AsMap()
+	out := make(map[string]interface{}, m.Length())
+	m.Iterate(func(k string, val value.Value) bool {
+		fieldType := t.ElementType
+		if sf, ok := t.FindField(k); ok {
+			fieldType = sf.Type
+		}
+		out[k] = w.redactChild(fieldpath.PathElement{FieldName: &k}, val, fieldType)
+		return true
+	})
+	w.out = out
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestRedact(t *testing.T) {
+	tv, err := overridesParser.Type("type").FromYAML(`{"a": [{"name": "x", "value": 1}, {"name": "y", "value": 2}], "labels": {"k": "v"}, "name": "n"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted := tv.Redact(fieldpath.NewSet(
+		fieldpath.MakePathOrDie("a", fieldpath.KeyByFields("name", "y"), "value"),
+		fieldpath.MakePathOrDie("labels"),
+		fieldpath.MakePathOrDie("b", fieldpath.KeyByFields("name", "x")),
+	), "REDACTED")
+	got, err := value.ToJSON(redacted.AsValue())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"a":[{"name":"x","value":1},{"name":"y","value":"REDACTED"}],"labels":"REDACTED","name":"n"}`
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+	if err := tv.Validate(); err != nil {
+		t.Errorf("expected the input to be left valid: %v", err)
+	}
+	if len(redacted.RenderYAML()) == 0 {
+		t.Error("expected the redacted value to render")
+	}
+}
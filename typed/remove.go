@@ -26,6 +26,33 @@ type removingWalker struct {
 	toRemove      *fieldpath.Set
 	allocator     value.Allocator
 	shouldExtract bool
+	// dropKeyOnly drops the items of keyed lists left with only their
+	// keys.
+	dropKeyOnly bool
+}
+
+// RemoveOptions are the options of RemoveItems and ExtractItems.
+type RemoveOptions int
+
+const (
+	// DropKeyOnlyItems drops the items of associative lists that are left
+	// with nothing but their key fields once the fields of the set are
+	// removed from them, or extracted from them. By default such items are
+	// kept with their keys, which marks them as present, e.g. in an apply
+	// configuration, while with this option only the items with other
+	// fields are kept, e.g. to prune the items whose fields all belong to
+	// another manager. Items that are wholly in the set are extracted as
+	// usual.
+	DropKeyOnlyItems RemoveOptions = iota
+)
+
+func dropsKeyOnlyItems(opts []RemoveOptions) bool {
+	for _, opt := range opts {
+		if opt == DropKeyOnlyItems {
+			return true
+		}
+	}
+	return false
 }
 
 // removeItemsWithSchema will walk the given value and look for items from the toRemove set.
@@ -33,13 +60,19 @@ type removingWalker struct {
 // of the input value with either:
 // 1. only the items in the toRemove set (when shouldExtract is true) or
 // 2. the items from the toRemove set removed from the value (when shouldExtract is false).
-func removeItemsWithSchema(val value.Value, toRemove *fieldpath.Set, schema *schema.Schema, typeRef schema.TypeRef, shouldExtract bool) value.Value {
+// If dropKeyOnly is set, the items of keyed lists left with only their keys
+// are dropped. A nil allocator is replaced with a new one.
+func removeItemsWithSchema(a value.Allocator, val value.Value, toRemove *fieldpath.Set, schema *schema.Schema, typeRef schema.TypeRef, shouldExtract, dropKeyOnly bool) value.Value {
+	if a == nil {
+		a = value.NewFreelistAllocator()
+	}
 	w := &removingWalker{
 		value:         val,
 		schema:        schema,
 		toRemove:      toRemove,
-		allocator:     value.NewFreelistAllocator(),
+		allocator:     a,
 		shouldExtract: shouldExtract,
+		dropKeyOnly:   dropKeyOnly,
 	}
 	resolveSchema(schema, typeRef, val, w)
 	return value.NewValueInterface(w.out)
@@ -62,7 +95,7 @@ func (w *removingWalker) doList(t *schema.List) (errs ValidationErrors) {
 	}
 
 	// atomic lists should return everything in the case of extract
-	// and nothing in the case of remove (!w.shouldExtract)
+	// and nothing in the case of remove (!w.shouldExtract, w.dropKeyOnly)
 	if t.ElementRelationship == schema.Atomic {
 		if w.shouldExtract {
 			w.out = w.value.Unstructured()
@@ -79,16 +112,19 @@ func (w *removingWalker) doList(t *schema.List) (errs ValidationErrors) {
 		pe, _ := listItemToPathElement(w.allocator, w.schema, t, item)
 		path, _ := fieldpath.MakePath(pe)
 		// save items on the path when we shouldExtract
-		// but ignore them when we are removing (i.e. !w.shouldExtract)
+		// but ignore them when we are removing (i.e. !w.shouldExtract, w.dropKeyOnly)
 		if w.toRemove.Has(path) {
 			if w.shouldExtract {
-				newItems = append(newItems, removeItemsWithSchema(item, w.toRemove, w.schema, t.ElementType, w.shouldExtract).Unstructured())
+				newItems = append(newItems, removeItemsWithSchema(w.allocator, item, w.toRemove, w.schema, t.ElementType, w.shouldExtract, w.dropKeyOnly).Unstructured())
 			} else {
 				continue
 			}
 		}
 		if subset := w.toRemove.WithPrefix(pe); !subset.Empty() {
-			item = removeItemsWithSchema(item, subset, w.schema, t.ElementType, w.shouldExtract)
+			item = removeItemsWithSchema(w.allocator, item, subset, w.schema, t.ElementType, w.shouldExtract, w.dropKeyOnly)
+			if w.dropKeyOnly && len(t.Keys) > 0 && hasOnlyKeys(w.allocator, item, t.Keys) {
+				continue
+			}
 		} else {
 			// don't save items not on the path when we shouldExtract.
 			if w.shouldExtract {
@@ -103,6 +139,23 @@ func (w *removingWalker) doList(t *schema.List) (errs ValidationErrors) {
 	return nil
 }
 
+// hasOnlyKeys returns whether v has no fields other than the given keys.
+func hasOnlyKeys(a value.Allocator, v value.Value, keys []string) bool {
+	if v.IsNull() || !v.IsMap() {
+		return v.IsNull()
+	}
+	m := v.AsMapUsing(a)
+	defer a.Free(m)
+	return m.Iterate(func(k string, _ value.Value) bool {
+		for _, key := range keys {
+			if k == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 func (w *removingWalker) doMap(t *schema.Map) ValidationErrors {
 	if !w.value.IsMap() {
 		return nil
@@ -117,7 +170,7 @@ func (w *removingWalker) doMap(t *schema.Map) ValidationErrors {
 	}
 
 	// atomic maps should return everything in the case of extract
-	// and nothing in the case of remove (!w.shouldExtract)
+	// and nothing in the case of remove (!w.shouldExtract, w.dropKeyOnly)
 	if t.ElementRelationship == schema.Atomic {
 		if w.shouldExtract {
 			w.out = w.value.Unstructured()
@@ -139,16 +192,16 @@ func (w *removingWalker) doMap(t *schema.Map) ValidationErrors {
 			fieldType = ft
 		}
 		// save values on the path when we shouldExtract
-		// but ignore them when we are removing (i.e. !w.shouldExtract)
+		// but ignore them when we are removing (i.e. !w.shouldExtract, w.dropKeyOnly)
 		if w.toRemove.Has(path) {
 			if w.shouldExtract {
-				newMap[k] = removeItemsWithSchema(val, w.toRemove, w.schema, fieldType, w.shouldExtract).Unstructured()
+				newMap[k] = removeItemsWithSchema(w.allocator, val, w.toRemove, w.schema, fieldType, w.shouldExtract, w.dropKeyOnly).Unstructured()
 
 			}
 			return true
 		}
 		if subset := w.toRemove.WithPrefix(pe); !subset.Empty() {
-			val = removeItemsWithSchema(val, subset, w.schema, fieldType, w.shouldExtract)
+			val = removeItemsWithSchema(w.allocator, val, subset, w.schema, fieldType, w.shouldExtract, w.dropKeyOnly)
 		} else {
 			// don't save values not on the path when we shouldExtract.
 			if w.shouldExtract {
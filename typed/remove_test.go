@@ -906,3 +906,64 @@ func TestReversibleExtract(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveDropKeyOnlyItems(t *testing.T) {
+	parser, err := typed.NewParser(typed.YAMLObject(associativeAndAtomicSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := parser.Type("myRoot")
+	tv, err := pt.FromYAML(`{"list": [{"key": "a", "id": 1, "nv": 2}, {"key": "b", "id": 2, "nv": 3, "bv": true}, {"key": "c", "id": 3, "nv": 4}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := _P("list", _KBF("key", "a", "id", 1))
+	b := _P("list", _KBF("key", "b", "id", 2))
+	c := _P("list", _KBF("key", "c", "id", 3))
+	set := _NS(
+		append(a.Copy(), _P("nv")...),
+		append(b.Copy(), _P("bv")...),
+		c,
+	)
+	toExtract := _NS(
+		append(a.Copy(), _P("key")...),
+		append(a.Copy(), _P("id")...),
+		append(b.Copy(), _P("key")...),
+		append(b.Copy(), _P("id")...),
+		append(b.Copy(), _P("bv")...),
+		append(c.Copy(), _P("key")...),
+		append(c.Copy(), _P("id")...),
+		append(c.Copy(), _P("nv")...),
+	)
+
+	cases := []struct {
+		name   string
+		got    *typed.TypedValue
+		expect typed.YAMLObject
+	}{{
+		name:   "remove keeping keys",
+		got:    tv.RemoveItems(set),
+		expect: `{"list": [{"key": "a", "id": 1}, {"key": "b", "id": 2, "nv": 3}]}`,
+	}, {
+		name:   "remove dropping key-only items",
+		got:    tv.RemoveItems(set, typed.DropKeyOnlyItems),
+		expect: `{"list": [{"key": "b", "id": 2, "nv": 3}]}`,
+	}, {
+		name:   "extract keeping keys",
+		got:    tv.ExtractItems(toExtract),
+		expect: `{"list": [{"key": "a", "id": 1}, {"key": "b", "id": 2, "bv": true}, {"key": "c", "id": 3, "nv": 4}]}`,
+	}, {
+		name:   "extract dropping key-only items",
+		got:    tv.ExtractItems(toExtract, typed.DropKeyOnlyItems),
+		expect: `{"list": [{"key": "b", "id": 2, "bv": true}, {"key": "c", "id": 3, "nv": 4}]}`,
+	}}
+	for _, tc := range cases {
+		expect, err := pt.FromYAML(tc.expect)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.name, err)
+		}
+		if !value.Equals(tc.got.AsValue(), expect.AsValue()) {
+			t.Errorf("%v: expected\n%v\nbut got\n%v", tc.name, value.ToString(expect.AsValue()), value.ToString(tc.got.AsValue()))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// YAMLLine is a line of the YAML representation of a TypedValue.
+type YAMLLine struct {
+	// Text is the text of the line, without newline.
+	Text string
+	// Path is the path of the value the line holds. The first line of
+	// a list item holds both the item and its first field, and has the
+	// path of the field.
+	Path fieldpath.Path
+}
+
+// RenderYAML returns the lines of the YAML representation of tv, as used
+// by UnifiedDiff: the fields of structs are written in the order of the
+// schema, and the keys of other maps are sorted.
+func (tv TypedValue) RenderYAML() []YAMLLine {
+	var lines []YAMLLine
+	renderNode(&lines, tv.schema, tv.typeRef, "", "", fieldpath.Path{}, tv.value)
+	return lines
+}
+
+func lineTexts(lines []YAMLLine) []string {
+	texts := make([]string, len(lines))
+	for i := range lines {
+		texts[i] = lines[i].Text
+	}
+	return texts
+}
+
+// renderNode appends the lines of v, of type tr at the path, to lines.
+// prefix is either empty, for the root, "key: " or "- ".
+func renderNode(lines *[]YAMLLine, s *schema.Schema, tr schema.TypeRef, indent, prefix string, path fieldpath.Path, v value.Value) {
+	atom, _ := s.Resolve(tr)
+	switch {
+	case v == nil || v.IsNull():
+		*lines = append(*lines, YAMLLine{indent + prefix + "null", path})
+	case v.IsMap() && v.AsMap().Length() != 0:
+		start := len(*lines)
+		childIndent := indent
+		if prefix != "" {
+			childIndent += "  "
+		}
+		m := v.AsMap()
+		var keys []string
+		seen := map[string]bool{}
+		if atom.Map != nil {
+			for _, f := range atom.Map.Fields {
+				if m.Has(f.Name) {
+					keys = append(keys, f.Name)
+					seen[f.Name] = true
+				}
+			}
+		}
+		var others []string
+		m.Iterate(func(key string, _ value.Value) bool {
+			if !seen[key] {
+				others = append(others, key)
+			}
+			return true
+		})
+		sort.Strings(others)
+		for _, key := range append(keys, others...) {
+			var childType schema.TypeRef
+			if atom.Map != nil {
+				childType = atom.Map.ElementType
+				if f, ok := atom.Map.FindField(key); ok {
+					childType = f.Type
+				}
+			}
+			child, _ := m.Get(key)
+			key := key
+			childPath := append(path[:len(path):len(path)], fieldpath.PathElement{FieldName: &key})
+			renderNode(lines, s, childType, childIndent, key+": ", childPath, child)
+		}
+		nest(lines, start, indent, prefix, path)
+	case v.IsList() && v.AsList().Length() != 0:
+		start := len(*lines)
+		childIndent := indent
+		if prefix == "- " {
+			childIndent += "  "
+		}
+		var elementType schema.TypeRef
+		if atom.List != nil {
+			elementType = atom.List.ElementType
+		}
+		l := v.AsList()
+		for i := 0; i < l.Length(); i++ {
+			child := l.At(i)
+			i := i
+			pe := fieldpath.PathElement{Index: &i}
+			if atom.List != nil && atom.List.ElementRelationship == schema.Associative {
+				if keyed, err := listItemToPathElement(value.HeapAllocator, s, atom.List, child); err == nil {
+					pe = keyed
+				}
+			}
+			renderNode(lines, s, elementType, childIndent, "- ", append(path[:len(path):len(path)], pe), child)
+		}
+		nest(lines, start, indent, prefix, path)
+	case v.IsMap():
+		*lines = append(*lines, YAMLLine{indent + prefix + "{}", path})
+	case v.IsList():
+		*lines = append(*lines, YAMLLine{indent + prefix + "[]", path})
+	default:
+		*lines = append(*lines, YAMLLine{indent + prefix + value.ToString(v), path})
+	}
+}
+
+// nest places the lines of the container at the path from start under its
+// prefix: on a line of its own for keys, and on its first line for list
+// items.
+func nest(lines *[]YAMLLine, start int, indent, prefix string, path fieldpath.Path) {
+	switch prefix {
+	case "":
+	case "- ":
+		first := &(*lines)[start]
+		first.Text = indent + prefix + strings.TrimPrefix(first.Text, indent+"  ")
+	default:
+		*lines = append(*lines, YAMLLine{})
+		copy((*lines)[start+1:], (*lines)[start:])
+		(*lines)[start] = YAMLLine{indent + strings.TrimSuffix(prefix, " "), path}
+	}
+}
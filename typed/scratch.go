@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// The functions in this file are variants of the operations on TypedValues
+// that take the allocator of the scratch values used while walking their
+// inputs. By default, every operation uses its own allocator; passing the
+// same one, typically created with value.NewFreelistAllocator, to all the
+// operations serving a request lets them reuse each other's buffers.
+//
+// Allocators aren't safe for concurrent use, so they must not be shared by
+// concurrent operations. A nil allocator is the default.
+
+// Options are the options of the merges and comparisons of TypedValues.
+type Options struct {
+	// Budget, if set, bounds the number of nodes the operation can
+	// visit, as with MergeWithBudget and CompareWithBudget.
+	Budget *Budget
+	// Allocator, if set, allocates the scratch values of the operation.
+	Allocator value.Allocator
+	// ShareUnchanged makes merges return values that share the subtrees
+	// set by only one of their inputs with that input, instead of copies
+	// of them. This saves most of the allocations of merging a small
+	// configuration into a large object, but the result must then be
+	// treated as read-only, as must the inputs for as long as the result
+	// is used. Such subtrees are also not validated again. It has no
+	// effect on comparisons.
+	ShareUnchanged bool
+	// Limits, if set, bound the depth and the size of the lists and maps
+	// of the inputs, which are checked before the operation walks them.
+	// Exceeding them fails with a *value.LimitError.
+	Limits value.Limits
+	// RecoverPanics makes the operation return a *PanicError holding the
+	// path of the field being walked instead of panicking, so that a
+	// malformed input can't crash the process.
+	RecoverPanics bool
+	// Equalities, if set, are the semantic equalities of the scalars of
+	// comparisons. It has no effect on merges.
+	Equalities *Equalities
+}
+
+func (o Options) walkOptions() walkOptions {
+	return walkOptions{budget: o.Budget, scratch: o.Allocator, shareUnchanged: o.ShareUnchanged, limits: o.Limits, recoverPanics: o.RecoverPanics, equalities: o.Equalities}
+}
+
+// MergeWithOptions is like Merge, with the given options.
+func (tv TypedValue) MergeWithOptions(pso *TypedValue, opts Options) (*TypedValue, error) {
+	return mergeKeepRHS(&tv, pso, opts.walkOptions())
+}
+
+// CompareWithOptions is like Compare, with the given options.
+func (tv TypedValue) CompareWithOptions(rhs *TypedValue, opts Options) (*Comparison, error) {
+	return compare(&tv, rhs, opts.walkOptions())
+}
+
+// AsTypedUsing is like AsTyped, but validates the value with the given
+// allocator.
+func AsTypedUsing(a value.Allocator, v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts ...ValidationOptions) (*TypedValue, error) {
+	deduped, _ := deduplicated(v, s, typeRef, opts, nil)
+	tv := &TypedValue{
+		value:   deduped,
+		typeRef: typeRef,
+		schema:  s,
+	}
+	if err := tv.ValidateUsing(a, opts...); err != nil {
+		return nil, err
+	}
+	return tv, nil
+}
+
+// FromUnstructuredUsing is like FromUnstructured, but validates the value
+// with the given allocator.
+func (p ParseableType) FromUnstructuredUsing(a value.Allocator, in interface{}, opts ...ValidationOptions) (*TypedValue, error) {
+	return AsTypedUsing(a, value.NewValueInterface(in), p.Schema, p.TypeRef, opts...)
+}
+
+// ValidateUsing is like Validate, using the given allocator.
+func (tv TypedValue) ValidateUsing(a value.Allocator, opts ...ValidationOptions) error {
+	return tv.validateUsing(a, opts)
+}
+
+// ToFieldSetUsing is like ToFieldSet, using the given allocator.
+func (tv TypedValue) ToFieldSetUsing(a value.Allocator) (*fieldpath.Set, error) {
+	return tv.toFieldSetUsing(a)
+}
+
+// RemoveItemsUsing is like RemoveItems, using the given allocator.
+func (tv TypedValue) RemoveItemsUsing(a value.Allocator, items *fieldpath.Set, opts ...RemoveOptions) *TypedValue {
+	tv.value = removeItemsWithSchema(a, tv.value, items, tv.schema, tv.typeRef, false, dropsKeyOnlyItems(opts))
+	return &tv
+}
+
+// ExtractItemsUsing is like ExtractItems, using the given allocator.
+func (tv TypedValue) ExtractItemsUsing(a value.Allocator, items *fieldpath.Set, opts ...RemoveOptions) *TypedValue {
+	tv.value = removeItemsWithSchema(a, tv.value, items, tv.schema, tv.typeRef, true, dropsKeyOnlyItems(opts))
+	return &tv
+}
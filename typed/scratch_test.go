@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// countingAllocator counts the values given back to it.
+type countingAllocator struct {
+	value.Allocator
+	frees int
+}
+
+func (a *countingAllocator) Free(v interface{}) {
+	a.frees++
+	a.Allocator.Free(v)
+}
+
+func TestScratchAllocator(t *testing.T) {
+	a := &countingAllocator{Allocator: value.NewFreelistAllocator()}
+	lhs, err := typed.DeducedParseableType.FromUnstructuredUsing(a, map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": 2, "d": 3},
+		"l": []interface{}{1, 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := typed.DeducedParseableType.FromUnstructuredUsing(a, map[string]interface{}{
+		"a": 2,
+		"b": map[string]interface{}{"c": 2, "e": 4},
+		"l": []interface{}{3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := lhs.MergeWithOptions(rhs, typed.Options{Allocator: a})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	expected, err := lhs.Merge(rhs)
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if !value.Equals(merged.AsValue(), expected.AsValue()) {
+		t.Errorf("expected %v, got %v", value.ToString(expected.AsValue()), value.ToString(merged.AsValue()))
+	}
+
+	comparison, err := lhs.CompareWithOptions(rhs, typed.Options{Allocator: a})
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	expectedComparison, err := lhs.Compare(rhs)
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	if comparison.String() != expectedComparison.String() {
+		t.Errorf("expected %v, got %v", expectedComparison, comparison)
+	}
+
+	set, err := merged.ToFieldSetUsing(a)
+	if err != nil {
+		t.Fatalf("failed to get field set: %v", err)
+	}
+	expectedSet, err := merged.ToFieldSet()
+	if err != nil {
+		t.Fatalf("failed to get field set: %v", err)
+	}
+	if !set.Equals(expectedSet) {
+		t.Errorf("expected %v, got %v", expectedSet, set)
+	}
+
+	remove := fieldpath.NewSet(fieldpath.MakePathOrDie("b", "e"))
+	removed := merged.RemoveItemsUsing(a, remove)
+	if !value.Equals(removed.AsValue(), merged.RemoveItems(remove).AsValue()) {
+		t.Errorf("expected %v, got %v", value.ToString(merged.RemoveItems(remove).AsValue()), value.ToString(removed.AsValue()))
+	}
+	extracted := merged.ExtractItemsUsing(a, remove)
+	if !value.Equals(extracted.AsValue(), merged.ExtractItems(remove).AsValue()) {
+		t.Errorf("expected %v, got %v", value.ToString(merged.ExtractItems(remove).AsValue()), value.ToString(extracted.AsValue()))
+	}
+
+	if a.frees == 0 {
+		t.Errorf("expected the operations to use the given allocator")
+	}
+}
+
+func TestMergeShareUnchanged(t *testing.T) {
+	big := map[string]interface{}{}
+	for i := 0; i < 100; i++ {
+		big[fmt.Sprintf("key-%d", i)] = map[string]interface{}{"value": i, "list": []interface{}{i}}
+	}
+	lhs, err := typed.DeducedParseableType.FromUnstructured(map[string]interface{}{
+		"big":   big,
+		"small": map[string]interface{}{"a": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := typed.DeducedParseableType.FromUnstructured(map[string]interface{}{
+		"small": map[string]interface{}{"b": 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared, err := lhs.MergeWithOptions(rhs, typed.Options{ShareUnchanged: true})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	expected, err := lhs.Merge(rhs)
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if !value.Equals(shared.AsValue(), expected.AsValue()) {
+		t.Errorf("expected %v, got %v", value.ToString(expected.AsValue()), value.ToString(shared.AsValue()))
+	}
+	out := shared.AsValue().Unstructured().(map[string]interface{})
+	if reflect.ValueOf(out["big"]).Pointer() != reflect.ValueOf(big).Pointer() {
+		t.Errorf("expected the unchanged subtree to be shared with lhs")
+	}
+
+	sharedAllocs := testing.AllocsPerRun(10, func() {
+		lhs.MergeWithOptions(rhs, typed.Options{ShareUnchanged: true})
+	})
+	copiedAllocs := testing.AllocsPerRun(10, func() {
+		lhs.Merge(rhs)
+	})
+	if sharedAllocs*10 > copiedAllocs {
+		t.Errorf("expected sharing to save most allocations, got %v instead of %v", sharedAllocs, copiedAllocs)
+	}
+}
@@ -28,13 +28,18 @@ var tPool = sync.Pool{
 	New: func() interface{} { return &toFieldSetWalker{} },
 }
 
-func (tv TypedValue) toFieldSetWalker() *toFieldSetWalker {
+// toFieldSetWalker returns a walker using the allocator a, or a new one if
+// a is nil.
+func (tv TypedValue) toFieldSetWalker(a value.Allocator) *toFieldSetWalker {
 	v := tPool.Get().(*toFieldSetWalker)
 	v.value = tv.value
 	v.schema = tv.schema
 	v.typeRef = tv.typeRef
 	v.set = &fieldpath.Set{}
-	v.allocator = value.NewFreelistAllocator()
+	v.allocator = a
+	if v.allocator == nil {
+		v.allocator = value.NewFreelistAllocator()
+	}
 	return v
 }
 
@@ -43,6 +48,7 @@ func (v *toFieldSetWalker) finished() {
 	v.typeRef = schema.TypeRef{}
 	v.path = nil
 	v.set = nil
+	v.allocator = nil
 	tPool.Put(v)
 }
 
@@ -17,6 +17,7 @@ limitations under the License.
 package typed
 
 import (
+	"fmt"
 	"sync"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
@@ -30,21 +31,42 @@ type ValidationOptions int
 const (
 	// AllowDuplicates means that sets and associative lists can have duplicate similar items.
 	AllowDuplicates ValidationOptions = iota
+	// DeduplicateSets removes the duplicate items of sets of scalars when
+	// creating TypedValues, with AsTyped or the methods of ParseableType,
+	// instead of failing the validation. Validate can't modify the value,
+	// so it still reports them.
+	DeduplicateSets
+	// DeduplicateSetsWithWarnings is like DeduplicateSets, and also logs
+	// the removed items to the Logger of the Parser, if any. Use
+	// AsTypedWithWarnings to get them back.
+	DeduplicateSetsWithWarnings
 )
 
 // AsTyped accepts a value and a type and returns a TypedValue. 'v' must have
 // type 'typeName' in the schema. An error is returned if the v doesn't conform
 // to the schema.
 func AsTyped(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts ...ValidationOptions) (*TypedValue, error) {
+	tv, _, err := asTyped(v, s, typeRef, opts, nil)
+	return tv, err
+}
+
+// AsTypedWithWarnings is like AsTyped, and also returns one warning per
+// duplicate set item removed by DeduplicateSetsWithWarnings.
+func AsTypedWithWarnings(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts ...ValidationOptions) (*TypedValue, []string, error) {
+	return asTyped(v, s, typeRef, opts, nil)
+}
+
+func asTyped(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts []ValidationOptions, logger Logger) (*TypedValue, []string, error) {
+	deduped, warnings := deduplicated(v, s, typeRef, opts, logger)
 	tv := &TypedValue{
-		value:   v,
+		value:   deduped,
 		typeRef: typeRef,
 		schema:  s,
 	}
 	if err := tv.Validate(opts...); err != nil {
-		return nil, err
+		return nil, warnings, err
 	}
-	return tv, nil
+	return tv, warnings, nil
 }
 
 // AsTypeUnvalidated is just like AsTyped, but doesn't validate that the type
@@ -81,6 +103,12 @@ func (tv TypedValue) AsValue() value.Value {
 	return tv.value
 }
 
+// StringTruncated returns a human-readable representation of the value,
+// limited in depth and to maxBytes, as value.StringTruncated does.
+func (tv TypedValue) StringTruncated(maxBytes int) string {
+	return value.StringTruncated(tv.value, maxBytes)
+}
+
 // Schema gets the schema from the TypedValue.
 func (tv TypedValue) Schema() *schema.Schema {
 	return tv.schema
@@ -88,16 +116,25 @@ func (tv TypedValue) Schema() *schema.Schema {
 
 // Validate returns an error with a list of every spec violation.
 func (tv TypedValue) Validate(opts ...ValidationOptions) error {
+	return tv.validateUsing(nil, opts)
+}
+
+func (tv TypedValue) validateUsing(a value.Allocator, opts []ValidationOptions) error {
 	w := tv.walker()
+	defer w.finished()
+	if a != nil {
+		pooled := w.allocator
+		w.allocator = a
+		defer func() { w.allocator = pooled }()
+	}
 	for _, opt := range opts {
 		switch opt {
 		case AllowDuplicates:
 			w.allowDuplicates = true
 		}
 	}
-	defer w.finished()
 	if errs := w.validate(nil); len(errs) != 0 {
-		return errs
+		return errs.sorted()
 	}
 	return nil
 }
@@ -105,10 +142,14 @@ func (tv TypedValue) Validate(opts ...ValidationOptions) error {
 // ToFieldSet creates a set containing every leaf field and item mentioned, or
 // validation errors, if any were encountered.
 func (tv TypedValue) ToFieldSet() (*fieldpath.Set, error) {
-	w := tv.toFieldSetWalker()
+	return tv.toFieldSetUsing(nil)
+}
+
+func (tv TypedValue) toFieldSetUsing(a value.Allocator) (*fieldpath.Set, error) {
+	w := tv.toFieldSetWalker(a)
 	defer w.finished()
 	if errs := w.toFieldSet(); len(errs) != 0 {
-		return nil, errs
+		return nil, errs.sorted()
 	}
 	return w.set, nil
 }
@@ -126,7 +167,22 @@ func (tv TypedValue) ToFieldSet() (*fieldpath.Set, error) {
 // match), or an error will be returned. Validation errors will be returned if
 // the objects don't conform to the schema.
 func (tv TypedValue) Merge(pso *TypedValue) (*TypedValue, error) {
-	return merge(&tv, pso, ruleKeepRHS, nil)
+	return mergeKeepRHS(&tv, pso, walkOptions{})
+}
+
+// MergeWithReport is like Merge, but also returns the leaves whose values
+// differ between tv and the result, so that callers can skip writing the
+// result when the set is empty and the merge turned out to be a no-op.
+func (tv TypedValue) MergeWithReport(pso *TypedValue) (*TypedValue, *fieldpath.Set, error) {
+	out, err := mergeKeepRHS(&tv, pso, walkOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := tv.Compare(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.Added.Union(c.Modified).Union(c.Removed).Leaves(), nil
 }
 
 var cmpwPool = sync.Pool{
@@ -140,12 +196,72 @@ var cmpwPool = sync.Pool{
 // match), or an error will be returned. Validation errors will be returned if
 // the objects don't conform to the schema.
 func (tv TypedValue) Compare(rhs *TypedValue) (c *Comparison, err error) {
-	lhs := tv
-	if lhs.schema != rhs.schema {
-		return nil, errorf("expected objects with types from the same schema")
+	return compare(&tv, rhs, walkOptions{})
+}
+
+// CompareMany compares base to each of the candidates, like Compare does,
+// and returns the comparisons in the same order. This is cheaper than
+// calling Compare for each candidate, as the walker comparing them and its
+// scratch buffers are only set up once.
+//
+// The candidates must all be of the same type as base. If comparing any of
+// them fails, only the error is returned, with the paths of its validation
+// errors prefixed with the index of the candidate.
+func CompareMany(base *TypedValue, candidates []*TypedValue) ([]*Comparison, error) {
+	comparisons, i, err := compareMany(base, candidates, walkOptions{})
+	if errs, ok := err.(ValidationErrors); ok {
+		return nil, errs.WithPrefix(fmt.Sprintf("candidates[%d]", i))
 	}
-	if !lhs.typeRef.Equals(&rhs.typeRef) {
-		return nil, errorf("expected objects of the same type, but got %v and %v", lhs.typeRef, rhs.typeRef)
+	return comparisons, err
+}
+
+func compare(lhs, rhs *TypedValue, opts walkOptions) (*Comparison, error) {
+	comparisons, _, err := compareMany(lhs, []*TypedValue{rhs}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return comparisons[0], nil
+}
+
+// compareMany compares lhs to each of the candidates, and returns the
+// comparisons, or the index of the candidate that failed and its error.
+func compareMany(lhs *TypedValue, candidates []*TypedValue, opts walkOptions) (_ []*Comparison, _ int, err error) {
+	if opts.recoverPanics {
+		defer recoverPanic(&err)
+	}
+	for i, rhs := range candidates {
+		if err := checkSameType(lhs, rhs); err != nil {
+			return nil, i, err
+		}
+	}
+	if err := checkLimits(opts, lhs); err != nil {
+		return nil, 0, err
+	}
+	for i, rhs := range candidates {
+		if err := checkLimits(opts, rhs); err != nil {
+			return nil, i, err
+		}
+	}
+	if isDeduced(lhs, opts) {
+		a := opts.scratch
+		if a == nil {
+			a = value.NewFreelistAllocator()
+		}
+		comparisons := make([]*Comparison, 0, len(candidates))
+		for i, rhs := range candidates {
+			if lhs.value == nil && rhs.value == nil {
+				return nil, i, errorf("at least one of lhs and rhs must be provided")
+			}
+			c := &Comparison{
+				Removed:  fieldpath.NewSet(),
+				Modified: fieldpath.NewSet(),
+				Added:    fieldpath.NewSet(),
+				rhs:      rhs,
+			}
+			compareDeduced(a, lhs.value, rhs.value, nil, c)
+			comparisons = append(comparisons, c)
+		}
+		return comparisons, 0, nil
 	}
 
 	cmpw := cmpwPool.Get().(*compareWalker)
@@ -156,39 +272,55 @@ func (tv TypedValue) Compare(rhs *TypedValue) (c *Comparison, err error) {
 		cmpw.typeRef = schema.TypeRef{}
 		cmpw.comparison = nil
 		cmpw.inLeaf = false
+		cmpw.walkOptions = walkOptions{}
 
 		cmpwPool.Put(cmpw)
 	}()
 
-	cmpw.lhs = lhs.value
-	cmpw.rhs = rhs.value
 	cmpw.schema = lhs.schema
 	cmpw.typeRef = lhs.typeRef
-	cmpw.comparison = &Comparison{
-		Removed:  fieldpath.NewSet(),
-		Modified: fieldpath.NewSet(),
-		Added:    fieldpath.NewSet(),
-	}
+	cmpw.walkOptions = opts
 	if cmpw.allocator == nil {
 		cmpw.allocator = value.NewFreelistAllocator()
 	}
+	if opts.scratch != nil {
+		pooled := cmpw.allocator
+		cmpw.allocator = opts.scratch
+		defer func() { cmpw.allocator = pooled }()
+	}
 
-	errs := cmpw.compare(nil)
-	if len(errs) > 0 {
-		return nil, errs
+	comparisons := make([]*Comparison, 0, len(candidates))
+	for i, rhs := range candidates {
+		cmpw.lhs = lhs.value
+		cmpw.rhs = rhs.value
+		cmpw.inLeaf = false
+		cmpw.comparison = &Comparison{
+			Removed:  fieldpath.NewSet(),
+			Modified: fieldpath.NewSet(),
+			Added:    fieldpath.NewSet(),
+			rhs:      rhs,
+		}
+		errs := cmpw.compare(nil)
+		if opts.budget.exceeded() {
+			return nil, i, ErrBudgetExceeded
+		}
+		if len(errs) > 0 {
+			return nil, i, errs.sorted()
+		}
+		comparisons = append(comparisons, cmpw.comparison)
 	}
-	return cmpw.comparison, nil
+	return comparisons, 0, nil
 }
 
 // RemoveItems removes each provided list or map item from the value.
-func (tv TypedValue) RemoveItems(items *fieldpath.Set) *TypedValue {
-	tv.value = removeItemsWithSchema(tv.value, items, tv.schema, tv.typeRef, false)
+func (tv TypedValue) RemoveItems(items *fieldpath.Set, opts ...RemoveOptions) *TypedValue {
+	tv.value = removeItemsWithSchema(nil, tv.value, items, tv.schema, tv.typeRef, false, dropsKeyOnlyItems(opts))
 	return &tv
 }
 
 // ExtractItems returns a value with only the provided list or map items extracted from the value.
-func (tv TypedValue) ExtractItems(items *fieldpath.Set) *TypedValue {
-	tv.value = removeItemsWithSchema(tv.value, items, tv.schema, tv.typeRef, true)
+func (tv TypedValue) ExtractItems(items *fieldpath.Set, opts ...RemoveOptions) *TypedValue {
+	tv.value = removeItemsWithSchema(nil, tv.value, items, tv.schema, tv.typeRef, true, dropsKeyOnlyItems(opts))
 	return &tv
 }
 
@@ -201,12 +333,61 @@ var mwPool = sync.Pool{
 	New: func() interface{} { return &mergingWalker{} },
 }
 
-func merge(lhs, rhs *TypedValue, rule, postRule mergeRule) (*TypedValue, error) {
+// mergeKeepRHS merges lhs and rhs, keeping rhs on leaves, like Merge.
+func mergeKeepRHS(lhs, rhs *TypedValue, opts walkOptions) (*TypedValue, error) {
+	if err := checkSameType(lhs, rhs); err != nil {
+		return nil, err
+	}
+	if isDeduced(lhs, opts) {
+		if lhs.value == nil && rhs.value == nil {
+			return nil, errorf("at least one of lhs and rhs must be provided")
+		}
+		if err := checkLimits(opts, lhs, rhs); err != nil {
+			return nil, err
+		}
+		a := opts.scratch
+		if a == nil {
+			a = value.NewFreelistAllocator()
+		}
+		return &TypedValue{
+			value:   value.NewValueInterface(mergeDeduced(a, lhs.value, rhs.value, opts.shareUnchanged)),
+			schema:  lhs.schema,
+			typeRef: lhs.typeRef,
+		}, nil
+	}
+	return mergeWithOptions(lhs, rhs, ruleKeepRHS, nil, opts)
+}
+
+// checkLimits checks the values against the limits of the options, before
+// they are walked.
+func checkLimits(opts walkOptions, tvs ...*TypedValue) error {
+	for _, tv := range tvs {
+		if err := opts.limits.Check(tv.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSameType(lhs, rhs *TypedValue) ValidationErrors {
 	if lhs.schema != rhs.schema {
-		return nil, errorf("expected objects with types from the same schema")
+		return kindErrorf(typeMismatch, "expected objects with types from the same schema")
 	}
 	if !lhs.typeRef.Equals(&rhs.typeRef) {
-		return nil, errorf("expected objects of the same type, but got %v and %v", lhs.typeRef, rhs.typeRef)
+		return kindErrorf(typeMismatch, "expected objects of the same type, but got %v and %v", lhs.typeRef, rhs.typeRef)
+	}
+	return nil
+}
+
+func mergeWithOptions(lhs, rhs *TypedValue, rule, postRule mergeRule, opts walkOptions) (_ *TypedValue, err error) {
+	if opts.recoverPanics {
+		defer recoverPanic(&err)
+	}
+	if err := checkSameType(lhs, rhs); err != nil {
+		return nil, err
+	}
+	if err := checkLimits(opts, lhs, rhs); err != nil {
+		return nil, err
 	}
 
 	mw := mwPool.Get().(*mergingWalker)
@@ -219,6 +400,7 @@ func merge(lhs, rhs *TypedValue, rule, postRule mergeRule) (*TypedValue, error)
 		mw.postItemHook = nil
 		mw.out = nil
 		mw.inLeaf = false
+		mw.walkOptions = walkOptions{}
 
 		mwPool.Put(mw)
 	}()
@@ -229,13 +411,22 @@ func merge(lhs, rhs *TypedValue, rule, postRule mergeRule) (*TypedValue, error)
 	mw.typeRef = lhs.typeRef
 	mw.rule = rule
 	mw.postItemHook = postRule
+	mw.walkOptions = opts
 	if mw.allocator == nil {
 		mw.allocator = value.NewFreelistAllocator()
 	}
+	if opts.scratch != nil {
+		pooled := mw.allocator
+		mw.allocator = opts.scratch
+		defer func() { mw.allocator = pooled }()
+	}
 
 	errs := mw.merge(nil)
+	if opts.budget.exceeded() {
+		return nil, ErrBudgetExceeded
+	}
 	if len(errs) > 0 {
-		return nil, errs
+		return nil, errs.sorted()
 	}
 
 	out := &TypedValue{
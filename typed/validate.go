@@ -96,19 +96,19 @@ func validateScalar(t *schema.Scalar, v value.Value, prefix string) (errs Valida
 	case schema.Numeric:
 		if !v.IsFloat() && !v.IsInt() {
 			// TODO: should the schema separate int and float?
-			return errorf("%vexpected numeric (int or float), got %T", prefix, v.Unstructured())
+			return kindErrorf(typeMismatch, "%vexpected numeric (int or float), got %T", prefix, v.Unstructured())
 		}
 	case schema.String:
 		if !v.IsString() {
-			return errorf("%vexpected string, got %#v", prefix, v)
+			return kindErrorf(typeMismatch, "%vexpected string, got %#v", prefix, v)
 		}
 	case schema.Boolean:
 		if !v.IsBool() {
-			return errorf("%vexpected boolean, got %v", prefix, v)
+			return kindErrorf(typeMismatch, "%vexpected boolean, got %v", prefix, v)
 		}
 	case schema.Untyped:
 		if !v.IsFloat() && !v.IsInt() && !v.IsString() && !v.IsBool() {
-			return errorf("%vexpected any scalar, got %v", prefix, v)
+			return kindErrorf(typeMismatch, "%vexpected any scalar, got %v", prefix, v)
 		}
 	default:
 		return errorf("%vunexpected scalar type in schema: %v", prefix, *t)
@@ -135,7 +135,7 @@ func (v *validatingObjectWalker) visitListItems(t *schema.List, list value.List)
 			var err error
 			pe, err = listItemToPathElement(v.allocator, v.schema, t, child)
 			if err != nil {
-				errs = append(errs, errorf("element %v: %v", i, err.Error())...)
+				errs = append(errs, kindErrorf(kindOf(err), "element %v: %v", i, err.Error())...)
 				// If we can't construct the path element, we can't
 				// even report errors deeper in the schema, so bail on
 				// this element.
@@ -157,7 +157,7 @@ func (v *validatingObjectWalker) visitListItems(t *schema.List, list value.List)
 func (v *validatingObjectWalker) doList(t *schema.List) (errs ValidationErrors) {
 	list, err := listValue(v.allocator, v.value)
 	if err != nil {
-		return errorf(err.Error())
+		return kindErrorf(kindOf(err), "%v", err)
 	}
 
 	if list == nil {
@@ -177,8 +177,10 @@ func (v *validatingObjectWalker) visitMapItems(t *schema.Map, m value.Map) (errs
 		if sf, ok := t.FindField(key); ok {
 			tr = sf.Type
 		} else if (t.ElementType == schema.TypeRef{}) {
+			// Keep going, so that the errors don't depend on the order
+			// of the keys.
 			errs = append(errs, errorf("field not declared in schema").WithPrefix(pe.String())...)
-			return false
+			return true
 		}
 		v2 := v.prepareDescent(tr)
 		v2.value = val
@@ -193,7 +195,7 @@ func (v *validatingObjectWalker) visitMapItems(t *schema.Map, m value.Map) (errs
 func (v *validatingObjectWalker) doMap(t *schema.Map) (errs ValidationErrors) {
 	m, err := mapValue(v.allocator, v.value)
 	if err != nil {
-		return errorf(err.Error())
+		return kindErrorf(kindOf(err), "%v", err)
 	}
 	if m == nil {
 		return nil
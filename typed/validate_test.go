@@ -17,10 +17,12 @@ limitations under the License.
 package typed_test
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/schema"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
@@ -237,6 +239,93 @@ var validationCases = []validationTestCase{{
 	}, duplicatesObjects: []typed.YAMLObject{
 		`{"list":[{"key":"a","id":1},{"key":"a","id":1}]}`,
 	},
+}, {
+	name:         "associative list with key defaults",
+	rootTypeName: "myRoot",
+	schema: `types:
+- name: myRoot
+  map:
+    fields:
+    - name: list
+      type:
+        list:
+          elementType:
+            namedType: myElement
+          elementRelationship: associative
+          keys:
+          - key
+          - id
+          - tags
+- name: myElement
+  map:
+    fields:
+    - name: key
+      type:
+        scalar: string
+    - name: id
+      default: 1
+      type:
+        scalar: numeric
+    - name: tags
+      default: {}
+      type:
+        scalar: untyped
+`,
+	validObjects: []typed.YAMLObject{
+		`{"list":[{"key":"a","id":1,"tags":"x"}]}`,
+		`{"list":[{"key":"a","tags":"x"},{"key":"a","id":2,"tags":"x"}]}`,
+	},
+	invalidObjects: []typed.YAMLObject{
+		`{"list":[{"key":"a","tags":"x"},{"key":"a","id":1,"tags":"x"}]}`,
+		`{"list":[{"key":"a","id":1}]}`,
+	},
+}, {
+	name:         "associative list with typed and untyped keys",
+	rootTypeName: "myRoot",
+	schema: `types:
+- name: myRoot
+  map:
+    fields:
+    - name: list
+      type:
+        list:
+          elementType:
+            namedType: myElement
+          elementRelationship: associative
+          keys:
+          - id
+          - enabled
+          - any
+- name: myElement
+  map:
+    fields:
+    - name: id
+      type:
+        scalar: numeric
+    - name: enabled
+      type:
+        scalar: boolean
+    - name: any
+      type:
+        scalar: untyped
+`,
+	validObjects: []typed.YAMLObject{
+		`{"list":[{"id":1,"enabled":true,"any":"a"},{"id":1,"enabled":false,"any":"b"}]}`,
+		`{"list":[{"id":1.5,"enabled":true,"any":1},{"id":2,"enabled":true,"any":2.5}]}`,
+		`{"list":[{"id":1,"enabled":true,"any":true},{"id":1,"enabled":true,"any":false}]}`,
+		// Untyped keys, e.g. int-or-string ones, can mix types.
+		`{"list":[{"id":1,"enabled":true,"any":"1"},{"id":2,"enabled":true,"any":1}]}`,
+		`{"list":[{"id":1,"enabled":true,"any":true},{"id":2,"enabled":true,"any":"true"}]}`,
+	},
+	invalidObjects: []typed.YAMLObject{
+		`{"list":[{"id":"1","enabled":true,"any":"a"}]}`,
+		`{"list":[{"id":1,"enabled":"true","any":"a"}]}`,
+		`{"list":[{"id":1,"enabled":true,"any":[]}]}`,
+		`{"list":[{"id":1,"enabled":true,"any":"a"},{"id":"2","enabled":true,"any":"a"}]}`,
+	},
+	duplicatesObjects: []typed.YAMLObject{
+		`{"list":[{"id":1,"enabled":true,"any":1},{"id":1.0,"enabled":true,"any":1.0}]}`,
+	},
 }}
 
 func (tt validationTestCase) test(t *testing.T) {
@@ -307,6 +396,83 @@ func TestSchemaSchema(t *testing.T) {
 	}
 }
 
+func TestValidationErrorClasses(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: items
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: missing
+      type:
+        namedType: missing
+- name: other
+  scalar: string
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		object typed.YAMLObject
+		want   error
+	}{
+		{`{"name": 1}`, typed.ErrTypeMismatch},
+		{`{"items": {}}`, typed.ErrTypeMismatch},
+		{`{"missing": 1}`, typed.ErrSchemaNotFound},
+	} {
+		_, err := parser.Type("type").FromYAML(tc.object)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%v: expected an error wrapping %v, got %v", tc.object, tc.want, err)
+		}
+		var ve typed.ValidationError
+		if !errors.As(err, &ve) || ve.Path == "" {
+			t.Errorf("%v: expected a ValidationError with a path, got %v", tc.object, err)
+		}
+	}
+
+	lhs, err := parser.Type("type").FromYAML(`{"name": "a"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhs, err := parser.Type("other").FromYAML(`"a"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lhs.Merge(rhs); !errors.Is(err, typed.ErrTypeMismatch) {
+		t.Errorf("expected an error wrapping ErrTypeMismatch, got %v", err)
+	}
+	_, err = lhs.Compare(rhs)
+	if !errors.Is(err, typed.ErrTypeMismatch) {
+		t.Errorf("expected an error wrapping ErrTypeMismatch, got %v", err)
+	}
+	if errors.Is(err, typed.ErrSchemaNotFound) {
+		t.Errorf("expected %v not to be ErrSchemaNotFound", err)
+	}
+
+	// Errors are classified where they are built, not by their messages.
+	literal := typed.ValidationErrors{{Path: ".name", ErrorMessage: "expected string, got &value.valueUnstructured{Value:1}"}}
+	if errors.Is(literal, typed.ErrTypeMismatch) {
+		t.Errorf("expected %v not to be classified", literal)
+	}
+	_, err = parser.Type("type").FromYAML(`{"name": 1}`)
+	errs, ok := err.(typed.ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Error() != literal[0].Error() {
+		t.Fatalf("expected %v, got %v", literal, err)
+	}
+	// ValidationErrors stay comparable.
+	if _, again := parser.Type("type").FromYAML(`{"name": 1}`); errs[0] != again.(typed.ValidationErrors)[0] {
+		t.Errorf("expected equal errors for the same object")
+	}
+}
+
 func BenchmarkValidateStructured(b *testing.B) {
 	type Primitives struct {
 		s string
@@ -401,3 +567,111 @@ func BenchmarkValidateStructured(b *testing.B) {
 		})
 	}
 }
+
+func TestKeyTypes(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: list
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: id
+                type:
+                  scalar: numeric
+              - name: any
+                type:
+                  scalar: untyped
+          elementRelationship: associative
+          keys: [id, any]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := parser.Type("type")
+
+	tv, err := pt.FromYAML(`{"list": [{"id": 2.0, "any": 1.0}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := tv.ToFieldSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Integral numbers are keyed as integers, as they are when parsed from
+	// managed fields.
+	want := fieldpath.NewSet()
+	if err := want.FromJSON(strings.NewReader(`{"f:list":{"k:{\"any\":1,\"id\":2}":{".":{},"f:any":{},"f:id":{}}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !set.Equals(want) {
+		t.Errorf("expected %v, got %v", want, set)
+	}
+	var sawInt bool
+	set.Iterate(func(p fieldpath.Path) {
+		for _, pe := range p {
+			if pe.Key != nil {
+				for _, f := range *pe.Key {
+					sawInt = sawInt || f.Value.IsInt()
+				}
+			}
+		}
+	})
+	if !sawInt {
+		t.Errorf("expected integer keys in %v", set)
+	}
+
+	for _, object := range []typed.YAMLObject{
+		`{"list": [{"id": "2", "any": 1}]}`,
+		`{"list": [{"id": 1, "any": 1}, {"id": "2", "any": 1}]}`,
+	} {
+		if _, err := pt.FromYAML(object); !errors.Is(err, typed.ErrTypeMismatch) {
+			t.Errorf("%v: expected an error wrapping ErrTypeMismatch, got %v", object, err)
+		}
+	}
+}
+
+func TestIntOrStringKeys(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: service
+  map:
+    fields:
+    - name: ports
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: port
+                type:
+                  namedType: intOrString
+              - name: protocol
+                type:
+                  scalar: string
+          elementRelationship: associative
+          keys: [port]
+- name: intOrString
+  scalar: untyped
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tv, err := parser.Type("service").FromYAML(`{"ports": [{"port": 80, "protocol": "TCP"}, {"port": "http", "protocol": "TCP"}]}`)
+	if err != nil {
+		t.Fatalf("expected keys mixing numbers and strings to be valid, got %v", err)
+	}
+	set, err := tv.ToFieldSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fieldpath.NewSet()
+	if err := want.FromJSON(strings.NewReader(`{"f:ports":{"k:{\"port\":\"http\"}":{".":{},"f:port":{},"f:protocol":{}},"k:{\"port\":80}":{".":{},"f:port":{},"f:protocol":{}}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !set.Equals(want) {
+		t.Errorf("expected %v, got %v", want, set)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// ValidationCache remembers the outcome of validating values, keyed by
+// their schema, type and content hash, so that validating an identical
+// value again is a hash lookup instead of a walk of the schema. Hashing
+// still visits the whole value, but is much cheaper than validating it.
+//
+// Schemas are identified by their address, so they must not be modified
+// once used with the cache. Values of inlined types are not cached.
+//
+// A ValidationCache is safe for concurrent use.
+type ValidationCache struct {
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[validationKey]ValidationErrors
+}
+
+type validationKey struct {
+	schema          *schema.Schema
+	namedType       string
+	allowDuplicates bool
+	hash            [sha256.Size]byte
+}
+
+// NewValidationCache returns a cache holding up to maxEntries outcomes.
+// Once it is full, arbitrary entries are evicted to make room for new
+// ones.
+func NewValidationCache(maxEntries int) *ValidationCache {
+	return &ValidationCache{
+		maxEntries: maxEntries,
+		entries:    map[validationKey]ValidationErrors{},
+	}
+}
+
+// Len returns the number of outcomes in the cache.
+func (c *ValidationCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// Validate is like tv.Validate, but looks the outcome up in the cache
+// first, and records it otherwise.
+func (c *ValidationCache) Validate(tv *TypedValue, opts ...ValidationOptions) error {
+	if tv.typeRef.NamedType == nil || c.maxEntries <= 0 {
+		return tv.Validate(opts...)
+	}
+	key := validationKey{
+		schema:    tv.schema,
+		namedType: *tv.typeRef.NamedType,
+		hash:      hashValue(tv.value),
+	}
+	for _, opt := range opts {
+		if opt == AllowDuplicates {
+			key.allowDuplicates = true
+		}
+	}
+
+	c.lock.Lock()
+	errs, ok := c.entries[key]
+	c.lock.Unlock()
+	if !ok {
+		// Validate only ever fails with ValidationErrors.
+		errs, _ = tv.Validate(opts...).(ValidationErrors)
+		c.add(key, errs)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	// Copy the errors, since they can be modified by the callers.
+	return append(ValidationErrors(nil), errs...)
+}
+
+// AsTyped is like the AsTyped function, but validates the value with the
+// cache.
+func (c *ValidationCache) AsTyped(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts ...ValidationOptions) (*TypedValue, error) {
+	deduped, _ := deduplicated(v, s, typeRef, opts, nil)
+	tv := &TypedValue{
+		value:   deduped,
+		typeRef: typeRef,
+		schema:  s,
+	}
+	if err := c.Validate(tv, opts...); err != nil {
+		return nil, err
+	}
+	return tv, nil
+}
+
+func (c *ValidationCache) add(key validationKey, errs ValidationErrors) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for k := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+	c.entries[key] = errs
+}
+
+// hashValue returns a hash of the content of v that doesn't depend on the
+// order of its maps. A cryptographic hash is used so that values can't be
+// crafted to collide with a valid one and skip their validation.
+func hashValue(v value.Value) [sha256.Size]byte {
+	h := sha256.New()
+	writeValue(h, v)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func writeValue(h hash.Hash, v value.Value) {
+	var buf [9]byte
+	writeTagged := func(tag byte, n uint64) {
+		buf[0] = tag
+		binary.LittleEndian.PutUint64(buf[1:], n)
+		h.Write(buf[:])
+	}
+	switch {
+	case v == nil || v.IsNull():
+		h.Write([]byte{'n'})
+	case v.IsFloat():
+		writeTagged('f', math.Float64bits(v.AsFloat()))
+	case v.IsInt():
+		writeTagged('i', uint64(v.AsInt()))
+	case v.IsString():
+		s := v.AsString()
+		writeTagged('s', uint64(len(s)))
+		h.Write([]byte(s))
+	case v.IsBool():
+		var b uint64
+		if v.AsBool() {
+			b = 1
+		}
+		writeTagged('b', b)
+	case v.IsList():
+		l := v.AsList()
+		writeTagged('l', uint64(l.Length()))
+		for i := 0; i < l.Length(); i++ {
+			writeValue(h, l.At(i))
+		}
+	case v.IsMap():
+		m := v.AsMap()
+		keys := make([]string, 0, m.Length())
+		m.Iterate(func(k string, _ value.Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		sort.Strings(keys)
+		writeTagged('m', uint64(len(keys)))
+		for _, k := range keys {
+			writeTagged('k', uint64(len(k)))
+			h.Write([]byte(k))
+			child, _ := m.Get(k)
+			writeValue(h, child)
+		}
+	}
+}
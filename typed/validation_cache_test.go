@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func mustParseUnvalidated(t *testing.T, object typed.YAMLObject) value.Value {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(object), &v); err != nil {
+		t.Fatalf("failed to parse %v: %v", object, err)
+	}
+	return value.NewValueInterface(v)
+}
+
+func TestValidationCache(t *testing.T) {
+	for _, tt := range validationCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := typed.NewParser(tt.schema)
+			if err != nil {
+				t.Fatalf("failed to create schema: %v", err)
+			}
+			pt := parser.Type(tt.rootTypeName)
+			cache := typed.NewValidationCache(100)
+			check := func(object typed.YAMLObject, valid bool, opts ...typed.ValidationOptions) {
+				tv := typed.AsTypedUnvalidated(mustParseUnvalidated(t, object), pt.Schema, pt.TypeRef)
+				// The second time, the outcome comes from the cache.
+				for i := 0; i < 2; i++ {
+					err := cache.Validate(tv, opts...)
+					if valid && err != nil {
+						t.Errorf("expected %v to be valid, got %v", object, err)
+					}
+					if !valid && err == nil {
+						t.Errorf("expected %v to be invalid", object)
+					}
+				}
+			}
+			for _, object := range tt.validObjects {
+				check(object, true)
+			}
+			for _, object := range tt.invalidObjects {
+				check(object, false)
+			}
+			for _, object := range tt.duplicatesObjects {
+				check(object, false)
+				check(object, true, typed.AllowDuplicates)
+			}
+		})
+	}
+}
+
+func TestValidationCacheEntries(t *testing.T) {
+	parser, err := typed.NewParser(validationCases[0].schema)
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	pt := parser.Type(validationCases[0].rootTypeName)
+	cache := typed.NewValidationCache(2)
+	for _, object := range []typed.YAMLObject{
+		`{"key": "foo", "value": "bar"}`,
+		`{"value": "bar", "key": "foo"}`,
+	} {
+		if _, err := cache.AsTyped(mustParseUnvalidated(t, object), pt.Schema, pt.TypeRef); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", object, err)
+		}
+	}
+	if n := cache.Len(); n != 1 {
+		t.Errorf("expected identical objects to share their entry, got %v entries", n)
+	}
+
+	for _, object := range []typed.YAMLObject{
+		`{"key": "foo", "value": "baz"}`,
+		`{"key": "foo", "value": "qux"}`,
+	} {
+		if _, err := cache.AsTyped(mustParseUnvalidated(t, object), pt.Schema, pt.TypeRef); err != nil {
+			t.Fatalf("expected %v to be valid, got %v", object, err)
+		}
+	}
+	if n := cache.Len(); n != 2 {
+		t.Errorf("expected the cache to be bounded to 2 entries, got %v", n)
+	}
+}
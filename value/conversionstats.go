@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConversionStats are the statistics of the conversions of a Go type by
+// the ToUnstructured and FromUnstructured methods of its
+// TypeReflectCacheEntry, which convert the types that marshal themselves.
+type ConversionStats struct {
+	// ToUnstructured and FromUnstructured are the numbers of conversions
+	// of the type to and from unstructured values.
+	ToUnstructured, FromUnstructured uint64
+	// ToUnstructuredDuration and FromUnstructuredDuration are the total
+	// durations of these conversions.
+	ToUnstructuredDuration, FromUnstructuredDuration time.Duration
+	// JSONFallbacks is the number of conversions, in either direction,
+	// that round-tripped through JSON because the type doesn't implement
+	// UnstructuredConverter. These are the slow paths worth fixing.
+	JSONFallbacks uint64
+}
+
+// conversionCounters are the atomically updated ConversionStats of a type.
+type conversionCounters struct {
+	toUnstructured, fromUnstructured           uint64
+	toUnstructuredNanos, fromUnstructuredNanos int64
+	jsonFallbacks                              uint64
+}
+
+var (
+	// conversionStatsEnabled is 1 when conversions are counted.
+	conversionStatsEnabled int32
+	// conversionStats maps the reflect.Type of converted types to their
+	// *conversionCounters.
+	conversionStats sync.Map
+)
+
+// EnableConversionStats starts or stops counting the conversions of types
+// to and from unstructured values, as returned by ConversionStatsByType.
+// Counting is disabled by default, since it measures each conversion.
+func EnableConversionStats(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&conversionStatsEnabled, v)
+}
+
+// ConversionStatsByType returns the statistics of the conversions of each
+// type counted since EnableConversionStats or ResetConversionStats.
+func ConversionStatsByType() map[reflect.Type]ConversionStats {
+	stats := map[reflect.Type]ConversionStats{}
+	conversionStats.Range(func(k, v interface{}) bool {
+		c := v.(*conversionCounters)
+		stats[k.(reflect.Type)] = ConversionStats{
+			ToUnstructured:           atomic.LoadUint64(&c.toUnstructured),
+			FromUnstructured:         atomic.LoadUint64(&c.fromUnstructured),
+			ToUnstructuredDuration:   time.Duration(atomic.LoadInt64(&c.toUnstructuredNanos)),
+			FromUnstructuredDuration: time.Duration(atomic.LoadInt64(&c.fromUnstructuredNanos)),
+			JSONFallbacks:            atomic.LoadUint64(&c.jsonFallbacks),
+		}
+		return true
+	})
+	return stats
+}
+
+// ResetConversionStats forgets the conversions counted so far.
+func ResetConversionStats() {
+	conversionStats.Range(func(k, _ interface{}) bool {
+		conversionStats.Delete(k)
+		return true
+	})
+}
+
+func countingConversions() bool {
+	return atomic.LoadInt32(&conversionStatsEnabled) == 1
+}
+
+func conversionCountersOf(t reflect.Type) *conversionCounters {
+	if c, ok := conversionStats.Load(t); ok {
+		return c.(*conversionCounters)
+	}
+	c, _ := conversionStats.LoadOrStore(t, &conversionCounters{})
+	return c.(*conversionCounters)
+}
+
+func countToUnstructured(t reflect.Type, start time.Time, viaJSON bool) {
+	c := conversionCountersOf(t)
+	atomic.AddUint64(&c.toUnstructured, 1)
+	atomic.AddInt64(&c.toUnstructuredNanos, int64(time.Since(start)))
+	if viaJSON {
+		atomic.AddUint64(&c.jsonFallbacks, 1)
+	}
+}
+
+func countFromUnstructured(t reflect.Type, start time.Time) {
+	c := conversionCountersOf(t)
+	atomic.AddUint64(&c.fromUnstructured, 1)
+	atomic.AddInt64(&c.fromUnstructuredNanos, int64(time.Since(start)))
+	atomic.AddUint64(&c.jsonFallbacks, 1)
+}
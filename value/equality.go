@@ -0,0 +1,34 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import "reflect"
+
+// GoTypeOf returns the Go type of the structured value that v wraps, as
+// passed to NewValueReflect, before its conversion to unstructured, with
+// its pointers dereferenced. It returns nil for the other values.
+func GoTypeOf(v Value) reflect.Type {
+	r, ok := v.(*valueReflect)
+	if !ok || r.goType == nil {
+		return nil
+	}
+	t := r.goType
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -19,6 +19,7 @@ package value_test
 import (
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"gopkg.in/yaml.v2"
@@ -86,3 +87,26 @@ func BenchmarkEquals(b *testing.B) {
 		})
 	}
 }
+
+type quantity string
+
+func TestGoTypeOf(t *testing.T) {
+	type resources struct {
+		Memory *quantity `json:"memory"`
+	}
+	mem := quantity("1")
+	structured, err := value.NewValueReflect(&resources{Memory: &mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := value.GoTypeOf(structured); got != reflect.TypeOf(resources{}) {
+		t.Errorf("expected %v, got %v", reflect.TypeOf(resources{}), got)
+	}
+	field, _ := structured.AsMap().Get("memory")
+	if got := value.GoTypeOf(field); got != reflect.TypeOf(quantity("")) {
+		t.Errorf("expected %v, got %v", reflect.TypeOf(quantity("")), got)
+	}
+	if got := value.GoTypeOf(value.NewValueInterface("1")); got != nil {
+		t.Errorf("expected no Go type for unstructured values, got %v", got)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// HashOptions are the options of Hash and Hash128.
+type HashOptions struct {
+	// IgnoreListOrder makes lists that have the same items in different
+	// orders hash the same, as is appropriate when all the lists of the
+	// value are sets or associative lists.
+	IgnoreListOrder bool
+}
+
+// Hash returns a 64-bit hash of the content of v, e.g. to detect changes
+// or to bucket objects without serializing them. It doesn't depend on the
+// order of the fields of maps, nor on how the value is represented, and
+// numbers that are equal hash the same whether they are integers or
+// floats, so that values that are Equal have the same hash. The hash is
+// stable across processes and versions of the library.
+//
+// The hash isn't cryptographic: it must not be relied on when values can
+// be crafted to collide.
+func Hash(v Value, opts HashOptions) uint64 {
+	sum := Hash128(v, opts)
+	return binary.BigEndian.Uint64(sum[8:])
+}
+
+// Hash128 is like Hash, with a 128-bit hash.
+func Hash128(v Value, opts HashOptions) [16]byte {
+	h := fnv.New128a()
+	hashValue(h, v, opts)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func hashValue(h hash.Hash, v Value, opts HashOptions) {
+	var buf [9]byte
+	writeTagged := func(tag byte, n uint64) {
+		buf[0] = tag
+		binary.BigEndian.PutUint64(buf[1:], n)
+		h.Write(buf[:])
+	}
+	switch {
+	case v == nil || v.IsNull():
+		h.Write([]byte{'n'})
+	case v.IsFloat():
+		f := v.AsFloat()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			// Integral floats hash like the integers they are equal to.
+			writeTagged('i', uint64(int64(f)))
+		} else {
+			writeTagged('f', math.Float64bits(f))
+		}
+	case v.IsInt():
+		writeTagged('i', uint64(v.AsInt()))
+	case v.IsString():
+		s := v.AsString()
+		writeTagged('s', uint64(len(s)))
+		h.Write([]byte(s))
+	case v.IsBool():
+		var b uint64
+		if v.AsBool() {
+			b = 1
+		}
+		writeTagged('b', b)
+	case v.IsList():
+		l := v.AsList()
+		writeTagged('l', uint64(l.Length()))
+		if !opts.IgnoreListOrder {
+			for i := 0; i < l.Length(); i++ {
+				hashValue(h, l.At(i), opts)
+			}
+			return
+		}
+		// The hashes of the items are written in order instead.
+		sums := make([][16]byte, l.Length())
+		for i := range sums {
+			sums[i] = Hash128(l.At(i), opts)
+		}
+		sort.Slice(sums, func(i, j int) bool {
+			return bytes.Compare(sums[i][:], sums[j][:]) < 0
+		})
+		for i := range sums {
+			h.Write(sums[i][:])
+		}
+	case v.IsMap():
+		m := v.AsMap()
+		keys := make([]string, 0, m.Length())
+		m.Iterate(func(k string, _ Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		sort.Strings(keys)
+		writeTagged('m', uint64(len(keys)))
+		for _, k := range keys {
+			writeTagged('k', uint64(len(k)))
+			h.Write([]byte(k))
+			child, _ := m.Get(k)
+			hashValue(h, child, opts)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	parse := func(s string) Value {
+		v, err := FromJSON([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	type object struct {
+		Name  string   `json:"name"`
+		Items []int64  `json:"items"`
+		Ratio *float64 `json:"ratio"`
+	}
+	reflected, err := NewValueReflect(&object{Name: "a", Items: []int64{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	same := [][2]Value{
+		{parse(`{"a": 1, "b": [true, null]}`), parse(`{"b": [true, null], "a": 1.0}`)},
+		{parse(`{"name": "a", "items": [1, 2], "ratio": null}`), reflected},
+		{parse(`1e3`), NewValueInterface(int64(1000))},
+	}
+	different := [][2]Value{
+		{parse(`{"a": 1}`), parse(`{"a": "1"}`)},
+		{parse(`{"a": 1}`), parse(`{"a": 1, "b": null}`)},
+		{parse(`{"ab": "c"}`), parse(`{"a": "bc"}`)},
+		{parse(`[1.5]`), parse(`[1]`)},
+		{parse(`[[1], []]`), parse(`[[], [1]]`)},
+		{parse(`[1, 2]`), parse(`[2, 1]`)},
+	}
+	for _, opts := range []HashOptions{{}, {IgnoreListOrder: true}} {
+		for _, pair := range same {
+			if Hash(pair[0], opts) != Hash(pair[1], opts) || Hash128(pair[0], opts) != Hash128(pair[1], opts) {
+				t.Errorf("%+v: expected %v and %v to hash the same", opts, ToString(pair[0]), ToString(pair[1]))
+			}
+		}
+		for i, pair := range different {
+			if opts.IgnoreListOrder && i >= len(different)-2 {
+				continue
+			}
+			if Hash(pair[0], opts) == Hash(pair[1], opts) {
+				t.Errorf("%+v: expected %v and %v to hash differently", opts, ToString(pair[0]), ToString(pair[1]))
+			}
+		}
+	}
+
+	unordered := HashOptions{IgnoreListOrder: true}
+	if Hash(parse(`{"l": [1, {"a": 2}, "3"]}`), unordered) != Hash(parse(`{"l": ["3", 1, {"a": 2}]}`), unordered) {
+		t.Error("expected lists with the same items to hash the same when ignoring their order")
+	}
+	if Hash(parse(`[[1, 2], [3]]`), unordered) != Hash(parse(`[[3], [2, 1]]`), unordered) {
+		t.Error("expected nested lists to ignore their order too")
+	}
+	if Hash(parse(`[1, 1, 2]`), unordered) == Hash(parse(`[1, 2, 2]`), unordered) {
+		t.Error("expected the number of occurrences of items to matter")
+	}
+}
+
+// TestHashStable pins the hashes, which must not change across versions.
+func TestHashStable(t *testing.T) {
+	v, err := FromJSON([]byte(`{"name": "a", "items": [1, 2.5, true, null, {"k": "v"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		opts HashOptions
+		want uint64
+	}{
+		{HashOptions{}, 0x3904a36b7308d2da},
+		{HashOptions{IgnoreListOrder: true}, 0x45647d52adaed00a},
+	} {
+		if got := Hash(v, tc.opts); got != tc.want {
+			t.Errorf("%+v: expected %#x, got %#x", tc.opts, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+)
+
+// IntoGo decodes v into the Go value ptr points to, as decoding the JSON of
+// v with encoding/json would, but without serializing v: struct fields are
+// found with the reflect cache used by NewValueReflect, following their
+// json tags, unknown fields are ignored and null leaves the values that
+// aren't pointers, maps, slices or interfaces unchanged. Interfaces are
+// given a copy of the unstructured form of their value, whose numbers are
+// int64 or float64. Only the types implementing json.Unmarshaler, and the
+// raw types described by RawJSONConverter, are given the JSON of their
+// value.
+//
+// Decoding into types that have no JSON representation, e.g. channels,
+// fails with an error wrapping ErrUnsupportedType.
+func IntoGo(v Value, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: can only decode into a non-nil pointer, got %T", ErrUnsupportedType, ptr)
+	}
+	return intoGo(v, rv.Elem(), "")
+}
+
+func intoGo(v Value, dv reflect.Value, path string) error {
+	null := v == nil || v.IsNull()
+	if null {
+		switch dv.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			dv.Set(reflect.Zero(dv.Type()))
+		}
+		return nil
+	}
+	entry := TypeReflectEntryOf(dv.Type())
+	if entry.ptrIsRawJSON || entry.isRawMessage {
+		data, err := ToJSON(v)
+		if err != nil {
+			return fmt.Errorf("%v: error encoding to json: %v", describePath(path), err)
+		}
+		if entry.setRawJSON(dv, data) {
+			return nil
+		}
+	}
+	if unmarshaler, ok := entry.getJsonUnmarshaler(dv); ok {
+		data, err := ToJSON(v)
+		if err != nil {
+			return fmt.Errorf("%v: error encoding to json: %v", describePath(path), err)
+		}
+		return unmarshaler.UnmarshalJSON(data)
+	}
+
+	switch dv.Kind() {
+	case reflect.Ptr:
+		if dv.IsNil() {
+			dv.Set(reflect.New(dv.Type().Elem()))
+		}
+		return intoGo(v, dv.Elem(), path)
+	case reflect.Interface:
+		if dv.NumMethod() != 0 {
+			break
+		}
+		dv.Set(reflect.ValueOf(copyUnstructured(v)))
+		return nil
+	case reflect.Struct:
+		if !v.IsMap() {
+			return mismatch(v, dv, path)
+		}
+		fields := entry.Fields()
+		var err error
+		v.AsMap().Iterate(func(k string, child Value) bool {
+			f, ok := fields[k]
+			if !ok {
+				return true
+			}
+			err = intoGo(child, f.setterOf(dv), path+"."+k)
+			return err == nil
+		})
+		return err
+	case reflect.Map:
+		if !v.IsMap() {
+			return mismatch(v, dv, path)
+		}
+		t := dv.Type()
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if dv.IsNil() {
+			dv.Set(reflect.MakeMap(t))
+		}
+		var err error
+		v.AsMap().Iterate(func(k string, child Value) bool {
+			elem := reflect.New(t.Elem()).Elem()
+			if err = intoGo(child, elem, path+"."+k); err != nil {
+				return false
+			}
+			dv.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+			return true
+		})
+		return err
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() == reflect.Uint8 && v.IsString() {
+			// []byte is encoded as a base64 string.
+			b, err := base64.StdEncoding.DecodeString(v.AsString())
+			if err != nil {
+				return fmt.Errorf("%v: %v", describePath(path), err)
+			}
+			dv.SetBytes(b)
+			return nil
+		}
+		if !v.IsList() {
+			return mismatch(v, dv, path)
+		}
+		l := v.AsList()
+		dv.Set(reflect.MakeSlice(dv.Type(), l.Length(), l.Length()))
+		for i := 0; i < l.Length(); i++ {
+			if err := intoGo(l.At(i), dv.Index(i), fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Array:
+		if !v.IsList() {
+			return mismatch(v, dv, path)
+		}
+		l := v.AsList()
+		for i := 0; i < dv.Len(); i++ {
+			if i >= l.Length() {
+				dv.Index(i).Set(reflect.Zero(dv.Type().Elem()))
+			} else if err := intoGo(l.At(i), dv.Index(i), fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		if !v.IsString() {
+			return mismatch(v, dv, path)
+		}
+		dv.SetString(v.AsString())
+		return nil
+	case reflect.Bool:
+		if !v.IsBool() {
+			return mismatch(v, dv, path)
+		}
+		dv.SetBool(v.AsBool())
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := integer(v)
+		if !ok || dv.OverflowInt(i) {
+			return mismatch(v, dv, path)
+		}
+		dv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := integer(v)
+		if !ok || i < 0 || dv.OverflowUint(uint64(i)) {
+			return mismatch(v, dv, path)
+		}
+		dv.SetUint(uint64(i))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch {
+		case v.IsFloat():
+			f = v.AsFloat()
+		case v.IsInt():
+			f = float64(v.AsInt())
+		default:
+			return mismatch(v, dv, path)
+		}
+		if dv.OverflowFloat(f) {
+			return mismatch(v, dv, path)
+		}
+		dv.SetFloat(f)
+		return nil
+	}
+	return fmt.Errorf("%w: %v: can't decode into %v", ErrUnsupportedType, describePath(path), dv.Type())
+}
+
+// copyUnstructured returns a copy of the unstructured form of v, which
+// doesn't share its maps and slices with v.
+func copyUnstructured(v Value) interface{} {
+	switch {
+	case v == nil || v.IsNull():
+		return nil
+	case v.IsList():
+		l := v.AsList()
+		out := make([]interface{}, l.Length())
+		for i := range out {
+			out[i] = copyUnstructured(l.At(i))
+		}
+		return out
+	case v.IsMap():
+		m := v.AsMap()
+		out := make(map[string]interface{}, m.Length())
+		m.Iterate(func(k string, child Value) bool {
+			out[k] = copyUnstructured(child)
+			return true
+		})
+		return out
+	}
+	return v.Unstructured()
+}
+
+// integer returns the value of v if it's an integral number.
+func integer(v Value) (int64, bool) {
+	switch {
+	case v.IsInt():
+		return v.AsInt(), true
+	case v.IsFloat():
+		f := v.AsFloat()
+		if f != float64(int64(f)) {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	return 0, false
+}
+
+func mismatch(v Value, dv reflect.Value, path string) error {
+	return fmt.Errorf("%v: can't decode %v into %v", describePath(path), ToString(v), dv.Type())
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+// setterOf returns the field of structVal, allocating the inline structs
+// it is nested in that are nil pointers.
+func (f *FieldCacheEntry) setterOf(structVal reflect.Value) reflect.Value {
+	for i, elem := range f.fieldPath {
+		if i > 0 && structVal.Kind() == reflect.Ptr {
+			if structVal.IsNil() {
+				structVal.Set(reflect.New(structVal.Type().Elem()))
+			}
+			structVal = structVal.Elem()
+		}
+		structVal = structVal.FieldByIndex(elem)
+	}
+	return structVal
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type intoGoUpper string
+
+func (u *intoGoUpper) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*u = intoGoUpper(strings.ToUpper(s))
+	return nil
+}
+
+type intoGoInline struct {
+	Inlined string `json:"inlined"`
+}
+
+type intoGoStruct struct {
+	intoGoInline `json:",inline"`
+	Name         string                  `json:"name"`
+	Count        *int32                  `json:"count,omitempty"`
+	Ratio        float32                 `json:"ratio"`
+	Enabled      bool                    `json:"enabled"`
+	Size         uint8                   `json:"size"`
+	Data         []byte                  `json:"data"`
+	Labels       map[string]string       `json:"labels"`
+	Children     []intoGoStruct          `json:"children"`
+	ByName       map[string]intoGoStruct `json:"byName"`
+	Any          interface{}             `json:"any"`
+	Upper        intoGoUpper             `json:"upper"`
+	Ignored      string                  `json:"-"`
+}
+
+func TestIntoGo(t *testing.T) {
+	seven := int32(7)
+	in := intoGoStruct{
+		intoGoInline: intoGoInline{Inlined: "inline"},
+		Name:         "root",
+		Count:        &seven,
+		Ratio:        0.5,
+		Enabled:      true,
+		Size:         255,
+		Data:         []byte("bytes"),
+		Labels:       map[string]string{"k": "v"},
+		Children:     []intoGoStruct{{Name: "child", Upper: "UP"}},
+		ByName:       map[string]intoGoStruct{"x": {Name: "x"}},
+		Any:          map[string]interface{}{"list": []interface{}{int64(1), "s"}},
+		Upper:        "ALREADY",
+	}
+	v, err := NewValueReflect(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out intoGoStruct
+	if err := IntoGo(v, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("expected\n%#v\ngot\n%#v", in, out)
+	}
+
+	// Unstructured values, with unknown fields, nulls and unmarshalers.
+	u := NewValueInterface(map[string]interface{}{
+		"name":    "n",
+		"unknown": 1,
+		"count":   nil,
+		"ratio":   int64(2),
+		"upper":   "lower",
+		"labels":  nil,
+	})
+	out = intoGoStruct{Count: &seven, Labels: map[string]string{"a": "b"}, Ignored: "kept"}
+	if err := IntoGo(u, &out); err != nil {
+		t.Fatal(err)
+	}
+	expected := intoGoStruct{Name: "n", Ratio: 2, Upper: "LOWER", Ignored: "kept"}
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected\n%#v\ngot\n%#v", expected, out)
+	}
+
+	pair := [2]string{"x", "y"}
+	if err := IntoGo(NewValueInterface([]interface{}{"a"}), &pair); err != nil {
+		t.Fatal(err)
+	}
+	if pair != [2]string{"a", ""} {
+		t.Errorf("expected [a ], got %v", pair)
+	}
+
+	// Interfaces don't share the maps of the value.
+	shared := map[string]interface{}{"k": "v"}
+	var any interface{}
+	if err := IntoGo(NewValueInterface(shared), &any); err != nil {
+		t.Fatal(err)
+	}
+	any.(map[string]interface{})["k"] = "changed"
+	if shared["k"] != "v" {
+		t.Error("expected the decoded interface not to share the map of the value")
+	}
+}
+
+func TestIntoGoErrors(t *testing.T) {
+	var s intoGoStruct
+	for _, tc := range []struct {
+		object interface{}
+		err    string
+	}{
+		{map[string]interface{}{"name": 1}, `.name: can't decode 1 into string`},
+		{map[string]interface{}{"size": int64(256)}, `.size: can't decode 256 into uint8`},
+		{map[string]interface{}{"size": 1.5}, `.size: can't decode 1.5 into uint8`},
+		{map[string]interface{}{"children": []interface{}{map[string]interface{}{"enabled": "yes"}}}, `.children[0].enabled: can't decode "yes" into bool`},
+		{"string", `<root>: can't decode "string" into value.intoGoStruct`},
+	} {
+		err := IntoGo(NewValueInterface(tc.object), &s)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+
+	var c chan int
+	if err := IntoGo(NewValueInterface(1), &c); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected an error wrapping ErrUnsupportedType, got %v", err)
+	}
+	if err := IntoGo(NewValueInterface(1), s); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected an error wrapping ErrUnsupportedType, got %v", err)
+	}
+}
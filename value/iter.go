@@ -0,0 +1,59 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import "iter"
+
+// MapAll returns an iterator over the keys and values of m, for use in
+// range-over-func loops; breaking out of the loop stops the iteration.
+// Like with Iterate, the values may be reused by the following iterations
+// and must not be retained past them.
+func MapAll(m Map) iter.Seq2[string, Value] {
+	return MapAllUsing(HeapAllocator, m)
+}
+
+// MapAllUsing is like MapAll, using the provided allocator.
+func MapAllUsing(a Allocator, m Map) iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		m.IterateUsing(a, yield)
+	}
+}
+
+// ListAll returns an iterator over the indexes and items of l, for use in
+// range-over-func loops; breaking out of the loop stops the iteration.
+// Like with Range, the items may be reused by the following iterations
+// and must not be retained past them.
+func ListAll(l List) iter.Seq2[int, Value] {
+	return ListAllUsing(HeapAllocator, l)
+}
+
+// ListAllUsing is like ListAll, using the provided allocator, to which
+// the range over the list is given back when the iteration stops.
+func ListAllUsing(a Allocator, l List) iter.Seq2[int, Value] {
+	return func(yield func(int, Value) bool) {
+		r := l.RangeUsing(a)
+		defer a.Free(r)
+		for r.Next() {
+			if !yield(r.Item()) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//go:build go1.23
+// +build go1.23
+
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapAll(t *testing.T) {
+	v, err := FromJSON([]byte(`{"a": "x", "b": "y", "c": "z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for k, v := range MapAll(v.AsMap()) {
+		got[k] = v.AsString()
+	}
+	if want := map[string]string{"a": "x", "b": "y", "c": "z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	n := 0
+	for range MapAllUsing(NewFreelistAllocator(), v.AsMap()) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("expected the iteration to stop after 1 item, got %v", n)
+	}
+}
+
+func TestListAll(t *testing.T) {
+	type object struct {
+		Items []string `json:"items"`
+	}
+	reflected, _ := MustReflect(&object{Items: []string{"a", "b", "c"}}).AsMap().Get("items")
+	for _, v := range []Value{NewValueInterface([]interface{}{"a", "b", "c"}), reflected} {
+		var got []string
+		for i, item := range ListAllUsing(NewFreelistAllocator(), v.AsList()) {
+			if i == 2 {
+				break
+			}
+			got = append(got, item.AsString())
+		}
+		if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"errors"
+	"io"
+	"sort"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// WriteJSONChunked writes v to w as JSON, as ToJSON serializes it, but
+// while walking it: w is written to whenever the pending output reaches
+// chunkSize bytes, and every Write is given at most chunkSize bytes. The
+// memory used is then bounded by chunkSize, plus the size of the largest
+// scalar, instead of the size of the serialization, so that huge values
+// can be streamed, e.g. through an io.Pipe or to a response.
+//
+// Writes stop at the first error of w, which is returned.
+func WriteJSONChunked(w io.Writer, v Value, chunkSize int) error {
+	if chunkSize <= 0 {
+		return errors.New("chunk size must be positive")
+	}
+	stream := writePool.BorrowStream(&chunkWriter{w: w, size: chunkSize})
+	defer writePool.ReturnStream(stream)
+	c := chunkedEncoder{stream: stream, size: chunkSize}
+	c.write(v)
+	if c.stream.Error == nil {
+		c.flush()
+	}
+	return c.stream.Error
+}
+
+type chunkedEncoder struct {
+	stream *jsoniter.Stream
+	size   int
+}
+
+// flushFull writes the pending output once it reaches the chunk size.
+func (c *chunkedEncoder) flushFull() bool {
+	if c.stream.Buffered() >= c.size {
+		c.flush()
+	}
+	return c.stream.Error == nil
+}
+
+func (c *chunkedEncoder) flush() {
+	b := c.stream.Buffer()
+	if c.stream.Flush() == nil {
+		// Reuse the buffer, which Flush otherwise slides past what it
+		// wrote, as ToJSON does.
+		c.stream.SetBuffer(b[:0])
+	}
+}
+
+func (c *chunkedEncoder) write(v Value) {
+	switch {
+	case v == nil || v.IsNull():
+		c.stream.WriteNil()
+	case v.IsList():
+		l := v.AsList()
+		c.stream.WriteArrayStart()
+		for i := 0; i < l.Length(); i++ {
+			if i > 0 {
+				// Unlike WriteMore, this doesn't flush.
+				c.stream.WriteRaw(",")
+			}
+			c.write(l.At(i))
+			if !c.flushFull() {
+				return
+			}
+		}
+		c.stream.WriteArrayEnd()
+	case v.IsMap():
+		m := v.AsMap()
+		// Keys are sorted, as ToJSON sorts them.
+		keys := make([]string, 0, m.Length())
+		m.Iterate(func(k string, _ Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		sort.Strings(keys)
+		c.stream.WriteObjectStart()
+		for i, k := range keys {
+			if i > 0 {
+				c.stream.WriteRaw(",")
+			}
+			// Keys are escaped like the strings of the values.
+			c.stream.WriteVal(k)
+			c.stream.WriteRaw(":")
+			child, _ := m.Get(k)
+			c.write(child)
+			if !c.flushFull() {
+				return
+			}
+		}
+		c.stream.WriteObjectEnd()
+	default:
+		c.stream.WriteVal(v.Unstructured())
+	}
+}
+
+// chunkWriter splits the writes to w into chunks of at most size bytes.
+type chunkWriter struct {
+	w    io.Writer
+	size int
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > c.size {
+			chunk = chunk[:c.size]
+		}
+		n, err := c.w.Write(chunk)
+		written += n
+		if err == nil && n < len(chunk) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
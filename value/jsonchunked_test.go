@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type recordingWriter struct {
+	bytes.Buffer
+	writes   int
+	maxWrite int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if len(p) > w.maxWrite {
+		w.maxWrite = len(p)
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriteJSONChunked(t *testing.T) {
+	type item struct {
+		Name  string            `json:"name"`
+		Tags  map[string]string `json:"tags,omitempty"`
+		Count *int              `json:"count"`
+	}
+	three := 3
+	reflected, err := NewValueReflect(&[]item{{Name: "a<b>", Tags: map[string]string{"&": "x", "b": "y"}}, {Name: "c", Count: &three}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := []Value{
+		NewValueInterface(nil),
+		NewValueInterface("a string with \"quotes\" and <html>"),
+		NewValueInterface(1.5),
+		NewValueInterface(map[string]interface{}{}),
+		NewValueInterface([]interface{}{}),
+		NewValueInterface(map[string]interface{}{
+			"z": []interface{}{int64(1), 2.5, true, nil, "s"},
+			"a": map[string]interface{}{"<key>": strings.Repeat("x", 100), "b": false},
+			"m": []interface{}{map[string]interface{}{"k": "v"}, []interface{}{}},
+		}),
+		reflected,
+	}
+	for _, v := range values {
+		want, err := ToJSON(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range []int{1, 7, 64, 1 << 20} {
+			w := &recordingWriter{}
+			if err := WriteJSONChunked(w, v, size); err != nil {
+				t.Fatalf("%s: %v", want, err)
+			}
+			if got := w.Bytes(); !bytes.Equal(got, want) {
+				t.Errorf("chunk size %v: expected\n%s\ngot\n%s", size, want, got)
+			}
+			if w.maxWrite > size {
+				t.Errorf("chunk size %v: got a write of %v bytes", size, w.maxWrite)
+			}
+			if size >= len(want) && w.writes != 1 {
+				t.Errorf("chunk size %v: expected a single write of %s, got %v", size, want, w.writes)
+			}
+		}
+	}
+}
+
+type failingWriter struct {
+	left int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.left < len(p) {
+		return 0, errWrite
+	}
+	w.left -= len(p)
+	return len(p), nil
+}
+
+func TestWriteJSONChunkedErrors(t *testing.T) {
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = map[string]interface{}{"name": "item", "index": int64(i)}
+	}
+	v := NewValueInterface(items)
+	if err := WriteJSONChunked(&failingWriter{left: 100}, v, 10); err != errWrite {
+		t.Errorf("expected the error of the writer, got %v", err)
+	}
+	if err := WriteJSONChunked(&bytes.Buffer{}, v, 0); err == nil {
+		t.Error("expected an error for a chunk size of 0")
+	}
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+	"unsafe"
+)
+
+var errUnexpectedEOF = errors.New("unexpected end of JSON input")
+
+// maxJSONDepth is the deepest nesting of objects and arrays that the
+// reader accepts, the same as jsoniter's, so that deeply nested input
+// fails instead of overflowing the stack.
+const maxJSONDepth = 10000
+
+// FromJSONNoCopy is like FromJSON, except that the strings of the returned
+// value, including the keys of its maps, share their memory with the input
+// instead of being copied out of it, unless they contain escape sequences.
+// This saves an allocation for almost every string of the document.
+//
+// This is unsafe: the input must not be modified for as long as the value,
+// or any string obtained from it, is in use. Modifying the input would
+// change strings, which Go otherwise guarantees to be immutable, and would
+// corrupt any map that they are keys of. Also, as long as any such string
+// is reachable, the whole input is kept in memory.
+//
+// Unlike FromJSON, anything but whitespace after the JSON document is an
+// error.
+func FromJSONNoCopy(input []byte) (Value, error) {
+	r := noCopyReader{buf: input}
+	return r.readDocument()
+}
+
+// noCopyReader decodes the same objects as jsoniter's Iterator.Read,
+// aliasing the strings to its buffer unless strict is set.
+type noCopyReader struct {
+	buf []byte
+	pos int
+	// strict rejects duplicate keys and invalid UTF-8, including lone
+	// surrogates, and copies strings.
+	strict bool
+	// depth is the number of objects and arrays being read.
+	depth int
+}
+
+// readDocument reads the whole buffer as a single JSON document.
+func (r *noCopyReader) readDocument() (Value, error) {
+	v, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	r.skipWhitespace()
+	if r.pos != len(r.buf) {
+		return nil, r.errorf("unexpected data after the JSON document")
+	}
+	return NewValueInterface(v), nil
+}
+
+func (r *noCopyReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), r.pos)
+}
+
+func (r *noCopyReader) skipWhitespace() {
+	for r.pos < len(r.buf) {
+		switch r.buf[r.pos] {
+		case ' ', '\t', '\n', '\r':
+			r.pos++
+		default:
+			return
+		}
+	}
+}
+
+// next skips whitespace and returns the next byte, without consuming it.
+func (r *noCopyReader) next() (byte, error) {
+	r.skipWhitespace()
+	if r.pos == len(r.buf) {
+		return 0, errUnexpectedEOF
+	}
+	return r.buf[r.pos], nil
+}
+
+func (r *noCopyReader) expect(c byte) error {
+	n, err := r.next()
+	if err != nil {
+		return err
+	}
+	if n != c {
+		return r.errorf("expected %q, found %q", c, n)
+	}
+	r.pos++
+	return nil
+}
+
+func (r *noCopyReader) read() (interface{}, error) {
+	c, err := r.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c == '{', c == '[':
+		if r.depth == maxJSONDepth {
+			return nil, r.errorf("exceeded max depth")
+		}
+		r.depth++
+		defer func() { r.depth-- }()
+		if c == '{' {
+			return r.readObject()
+		}
+		return r.readArray()
+	case c == '"':
+		return r.readString()
+	case c == 't':
+		return true, r.readLiteral("true")
+	case c == 'f':
+		return false, r.readLiteral("false")
+	case c == 'n':
+		return nil, r.readLiteral("null")
+	case c == '-' || (c >= '0' && c <= '9'):
+		return r.readNumber()
+	}
+	return nil, r.errorf("unexpected character %q", c)
+}
+
+func (r *noCopyReader) readObject() (interface{}, error) {
+	r.pos++
+	m := map[string]interface{}{}
+	if c, err := r.next(); err != nil {
+		return nil, err
+	} else if c == '}' {
+		r.pos++
+		return m, nil
+	}
+	for {
+		if c, err := r.next(); err != nil {
+			return nil, err
+		} else if c != '"' {
+			return nil, r.errorf("expected a string key, found %q", c)
+		}
+		keyPos := r.pos
+		key, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok && r.strict {
+			r.pos = keyPos
+			return nil, r.errorf("duplicate key %q", key)
+		}
+		if err := r.expect(':'); err != nil {
+			return nil, err
+		}
+		if m[key], err = r.read(); err != nil {
+			return nil, err
+		}
+		c, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		r.pos++
+		switch c {
+		case ',':
+		case '}':
+			return m, nil
+		default:
+			r.pos--
+			return nil, r.errorf("expected ',' or '}', found %q", c)
+		}
+	}
+}
+
+func (r *noCopyReader) readArray() (interface{}, error) {
+	r.pos++
+	l := []interface{}{}
+	if c, err := r.next(); err != nil {
+		return nil, err
+	} else if c == ']' {
+		r.pos++
+		return l, nil
+	}
+	for {
+		v, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+		c, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		r.pos++
+		switch c {
+		case ',':
+		case ']':
+			return l, nil
+		default:
+			r.pos--
+			return nil, r.errorf("expected ',' or ']', found %q", c)
+		}
+	}
+}
+
+// readString reads the string starting at the current position. Strings
+// without escape sequences alias the buffer; the others are decoded by
+// jsoniter.
+func (r *noCopyReader) readString() (string, error) {
+	start := r.pos
+	r.pos++
+	for r.pos < len(r.buf) {
+		switch c := r.buf[r.pos]; {
+		case c == '"':
+			r.pos++
+			raw := r.buf[start+1 : r.pos-1]
+			if r.strict {
+				if !utf8.Valid(raw) {
+					r.pos = start
+					return "", r.errorf("invalid UTF-8 in string")
+				}
+				return string(raw), nil
+			}
+			return bytesToString(raw), nil
+		case c == '\\':
+			return r.readEscapedString(start)
+		case c < ' ':
+			return "", r.errorf("invalid control character %q in string", c)
+		}
+		r.pos++
+	}
+	return "", errUnexpectedEOF
+}
+
+func (r *noCopyReader) readEscapedString(start int) (string, error) {
+	for r.pos < len(r.buf) {
+		switch r.buf[r.pos] {
+		case '\\':
+			r.pos++
+		case '"':
+			r.pos++
+			if r.strict {
+				if err := checkStrictString(r.buf[start+1 : r.pos-1]); err != nil {
+					r.pos = start
+					return "", r.errorf("%v", err)
+				}
+			}
+			iter := readPool.BorrowIterator(r.buf[start:r.pos])
+			defer readPool.ReturnIterator(iter)
+			s := iter.ReadString()
+			if iter.Error != nil {
+				return "", iter.Error
+			}
+			return s, nil
+		}
+		r.pos++
+	}
+	return "", errUnexpectedEOF
+}
+
+func (r *noCopyReader) readLiteral(literal string) error {
+	if len(r.buf)-r.pos < len(literal) {
+		return errUnexpectedEOF
+	}
+	if string(r.buf[r.pos:r.pos+len(literal)]) != literal {
+		return r.errorf("invalid literal, expected %q", literal)
+	}
+	r.pos += len(literal)
+	return nil
+}
+
+// readNumber reads a number as a float64, which is what jsoniter does when
+// not configured to use json.Number.
+func (r *noCopyReader) readNumber() (interface{}, error) {
+	start := r.pos
+	if r.buf[r.pos] == '-' {
+		r.pos++
+	}
+	switch {
+	case r.pos < len(r.buf) && r.buf[r.pos] == '0':
+		r.pos++
+	case !r.skipDigits():
+		return nil, r.errorf("invalid number")
+	}
+	if r.pos < len(r.buf) && r.buf[r.pos] == '.' {
+		r.pos++
+		if !r.skipDigits() {
+			return nil, r.errorf("invalid number")
+		}
+	}
+	if r.pos < len(r.buf) && (r.buf[r.pos] == 'e' || r.buf[r.pos] == 'E') {
+		r.pos++
+		if r.pos < len(r.buf) && (r.buf[r.pos] == '+' || r.buf[r.pos] == '-') {
+			r.pos++
+		}
+		if !r.skipDigits() {
+			return nil, r.errorf("invalid number")
+		}
+	}
+	f, err := strconv.ParseFloat(bytesToString(r.buf[start:r.pos]), 64)
+	if err != nil {
+		return nil, r.errorf("invalid number: %v", err)
+	}
+	return f, nil
+}
+
+// skipDigits skips the digits at the current position, and returns false
+// if there weren't any.
+func (r *noCopyReader) skipDigits() bool {
+	start := r.pos
+	for r.pos < len(r.buf) && r.buf[r.pos] >= '0' && r.buf[r.pos] <= '9' {
+		r.pos++
+	}
+	return r.pos > start
+}
+
+// bytesToString returns a string sharing its memory with b.
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestFromJSONNoCopy(t *testing.T) {
+	valid := []string{
+		`null`,
+		`true`,
+		` false `,
+		`0`,
+		`-1.5e3`,
+		`12345678901234567890`,
+		`""`,
+		`"abc"`,
+		`"tab\tquote\"unicodeé"`,
+		`"é"`,
+		`[]`,
+		`[1, "a", [null], {}]`,
+		`{}`,
+		`{"a": {"b\n": [true, false]}, "c": "d", "a": 1}`,
+	}
+	for _, input := range valid {
+		expected, err := value.FromJSON([]byte(input))
+		if err != nil {
+			t.Fatalf("FromJSON(%v) failed: %v", input, err)
+		}
+		got, err := value.FromJSONNoCopy([]byte(input))
+		if err != nil {
+			t.Errorf("FromJSONNoCopy(%v) failed: %v", input, err)
+			continue
+		}
+		if !value.Equals(expected, got) {
+			t.Errorf("FromJSONNoCopy(%v) = %v, expected %v", input, value.ToString(got), value.ToString(expected))
+		}
+	}
+
+	invalid := []string{
+		``,
+		`   `,
+		`nul`,
+		`tru`,
+		`01`,
+		`-`,
+		`1.`,
+		`1e`,
+		`+1`,
+		`.5`,
+		`"abc`,
+		`"a\"`,
+		`"\x"`,
+		"\"a\nb\"",
+		`[1 2]`,
+		`[1,]`,
+		`{"a" 1}`,
+		`{"a": 1,}`,
+		`{1: 1}`,
+		`{"a": 1} x`,
+	}
+	for _, input := range invalid {
+		if v, err := value.FromJSONNoCopy([]byte(input)); err == nil {
+			t.Errorf("FromJSONNoCopy(%v) = %v, expected an error", input, value.ToString(v))
+		}
+	}
+}
+
+func TestFromJSONNoCopyAliasing(t *testing.T) {
+	input := []byte(`{"key": "value", "escaped": "a\nb"}`)
+	v, err := value.FromJSONNoCopy(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	within := func(s string) bool {
+		p := (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+		start := uintptr(unsafe.Pointer(&input[0]))
+		return p >= start && p < start+uintptr(len(input))
+	}
+	for k, v := range v.Unstructured().(map[string]interface{}) {
+		if !within(k) {
+			t.Errorf("expected key %q to alias the input", k)
+		}
+		if s := v.(string); within(s) != (k == "key") {
+			t.Errorf("unexpected aliasing of %q: %v", s, within(s))
+		}
+	}
+}
+
+func BenchmarkFromJSON(b *testing.B) {
+	input := []byte(`{"f:metadata": {"f:labels": {"f:app": {}, "f:tier": {}}, "f:name": {}}, "f:spec": {"k:{\"name\":\"a\"}": {".": {}, "f:image": {}}}}`)
+	b.Run("Copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := value.FromJSON(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("NoCopy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := value.FromJSONNoCopy(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestFromJSONNoCopyDepth(t *testing.T) {
+	nested := func(depth int) []byte {
+		return []byte(strings.Repeat(`[`, depth) + strings.Repeat(`]`, depth))
+	}
+	for name, from := range map[string]func([]byte) (value.Value, error){
+		"FromJSON":       value.FromJSON,
+		"FromJSONNoCopy": value.FromJSONNoCopy,
+		"FromJSONStrict": value.FromJSONStrict,
+	} {
+		if _, err := from(nested(10000)); err != nil {
+			t.Errorf("%v: failed to read 10000 nested arrays: %v", name, err)
+		}
+		for _, depth := range []int{10001, 1000000} {
+			if _, err := from(nested(depth)); err == nil || !strings.Contains(err.Error(), "exceeded max depth") {
+				t.Errorf("%v: expected reading %v nested arrays to exceed the max depth, got %v", name, depth, err)
+			}
+		}
+		if _, err := from([]byte(strings.Repeat(`{"a":`, 10001) + `1` + strings.Repeat(`}`, 10001))); err == nil {
+			t.Errorf("%v: expected reading 10001 nested objects to fail", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// FromJSONStrict is like FromJSON, but rejects the documents that FromJSON
+// would silently reinterpret: objects with duplicate keys, of which
+// FromJSON keeps the last value, anything but whitespace after the JSON
+// document, which FromJSON ignores, and strings with invalid UTF-8 or lone
+// UTF-16 surrogates, which FromJSON replaces with U+FFFD. It's meant for
+// paths that must not accept malformed client input, such as admission.
+func FromJSONStrict(input []byte) (Value, error) {
+	r := noCopyReader{buf: input, strict: true}
+	return r.readDocument()
+}
+
+var errLoneSurrogate = errors.New("lone surrogate in string")
+
+// checkStrictString returns an error if the raw content of a string, with
+// escape sequences, isn't valid UTF-8 or has \u escapes of lone surrogates.
+// The other escape sequences are checked when the string is decoded.
+func checkStrictString(raw []byte) error {
+	if !utf8.Valid(raw) {
+		return errors.New("invalid UTF-8 in string")
+	}
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		i++
+		if i == len(raw) || raw[i] != 'u' {
+			continue
+		}
+		r, ok := hexRune(raw[i+1:])
+		if !ok {
+			// Left to the decoder.
+			continue
+		}
+		i += 4
+		if !utf16.IsSurrogate(r) {
+			continue
+		}
+		// A high surrogate must be followed by a low one.
+		if r >= 0xdc00 || i+2 >= len(raw) || raw[i+1] != '\\' || raw[i+2] != 'u' {
+			return errLoneSurrogate
+		}
+		low, ok := hexRune(raw[i+3:])
+		if !ok || utf16.DecodeRune(r, low) == utf8.RuneError {
+			return errLoneSurrogate
+		}
+		i += 6
+	}
+	return nil
+}
+
+// hexRune decodes the 4 hexadecimal digits at the start of b.
+func hexRune(b []byte) (rune, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(b[:4]), 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
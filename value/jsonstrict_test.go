@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestFromJSONStrict(t *testing.T) {
+	valid := []string{
+		`null`,
+		` 1 `,
+		`"tab\tquote\"unicodeéé"`,
+		`"😀 and \ud83d\ude00"`,
+		`"\\ud800"`,
+		`[1, "a", [null], {}]`,
+		`{"a": {"b\n": [true, false]}, "c": "d", "b\n": 1}`,
+	}
+	for _, input := range valid {
+		expected, err := value.FromJSON([]byte(input))
+		if err != nil {
+			t.Fatalf("FromJSON(%v) failed: %v", input, err)
+		}
+		got, err := value.FromJSONStrict([]byte(input))
+		if err != nil {
+			t.Errorf("FromJSONStrict(%v) failed: %v", input, err)
+			continue
+		}
+		if !value.Equals(expected, got) {
+			t.Errorf("FromJSONStrict(%v) = %v, expected %v", input, value.ToString(got), value.ToString(expected))
+		}
+	}
+
+	invalid := []string{
+		`{"a": 1, "a": 2}`,
+		`{"a": 1, "\u0061": 2}`,
+		`{"a": {"b": 1, "c": 2, "b": 3}}`,
+		`{"a": 1} {"b": 2}`,
+		`[1] x`,
+		`"\ud800"`,
+		`"\udc00"`,
+		`"\ud800A"`,
+		`"\ud800x"`,
+		"\"\xff\"",
+		"\"\xed\xa0\x80\"",
+	}
+	for _, input := range invalid {
+		if _, err := value.FromJSONStrict([]byte(input)); err == nil {
+			t.Errorf("FromJSONStrict(%q) succeeded, expected an error", input)
+		}
+	}
+}
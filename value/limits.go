@@ -0,0 +1,293 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Limits bound the shape of values, so that values from untrusted sources
+// can be rejected before recursive code, such as the parsers, walkers and
+// comparisons, runs out of stack or spends a quadratic time on them. Zero
+// fields are unlimited.
+type Limits struct {
+	// MaxDepth bounds the nesting of lists and maps: the items of the
+	// top-level list or map are at depth 1, their items at depth 2, and
+	// so on.
+	MaxDepth int
+	// MaxElements bounds the number of items of each list and map.
+	MaxElements int
+}
+
+// IsZero returns whether the limits are all unlimited.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// LimitError is returned when a value exceeds its Limits.
+type LimitError struct {
+	// Limit is the exceeded limit, "depth" or "elements".
+	Limit string
+	// Max is its value.
+	Max int
+	// Path is the path of the list or map exceeding the limit, like
+	// .spec.containers[1], if known.
+	Path string
+}
+
+func (e *LimitError) Error() string {
+	msg := fmt.Sprintf("value exceeds the maximum %v of %v", e.Limit, e.Max)
+	if e.Path == "" {
+		return msg
+	}
+	return fmt.Sprintf("%v: %v", e.Path, msg)
+}
+
+func (l Limits) depthError(path string) *LimitError {
+	return &LimitError{Limit: "depth", Max: l.MaxDepth, Path: path}
+}
+
+func (l Limits) elementsError(path string) *LimitError {
+	return &LimitError{Limit: "elements", Max: l.MaxElements, Path: path}
+}
+
+// limitFrame is a value to check, linked to its parent to build its path
+// only when it exceeds the limits.
+type limitFrame struct {
+	v      Value
+	depth  int
+	parent *limitFrame
+	key    string
+	index  int
+}
+
+func (f *limitFrame) path() string {
+	var elements []string
+	for ; f.parent != nil; f = f.parent {
+		if f.index >= 0 {
+			elements = append(elements, "["+strconv.Itoa(f.index)+"]")
+		} else {
+			elements = append(elements, "."+f.key)
+		}
+	}
+	for i, j := 0, len(elements)-1; i < j; i, j = i+1, j-1 {
+		elements[i], elements[j] = elements[j], elements[i]
+	}
+	return strings.Join(elements, "")
+}
+
+// Check returns a *LimitError if v exceeds the limits. It doesn't recurse,
+// so that it's safe on values of any depth.
+func (l Limits) Check(v Value) error {
+	if l.IsZero() {
+		return nil
+	}
+	stack := []*limitFrame{{v: v, index: -1}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch {
+		case f.v == nil || f.v.IsNull():
+		case f.v.IsList():
+			list := f.v.AsList()
+			if err := l.checkContainer(f, list.Length()); err != nil {
+				return err
+			}
+			for i := 0; i < list.Length(); i++ {
+				stack = append(stack, &limitFrame{v: list.At(i), depth: f.depth + 1, parent: f, index: i})
+			}
+		case f.v.IsMap():
+			m := f.v.AsMap()
+			if err := l.checkContainer(f, m.Length()); err != nil {
+				return err
+			}
+			// Iterate reuses the values it passes, so they are looked
+			// up again to be kept on the stack.
+			m.Iterate(func(key string, _ Value) bool {
+				child, _ := m.Get(key)
+				stack = append(stack, &limitFrame{v: child, depth: f.depth + 1, parent: f, key: key, index: -1})
+				return true
+			})
+		}
+	}
+	return nil
+}
+
+func (l Limits) checkContainer(f *limitFrame, length int) error {
+	if length == 0 {
+		return nil
+	}
+	if l.MaxDepth > 0 && f.depth+1 > l.MaxDepth {
+		return l.depthError(f.path())
+	}
+	if l.MaxElements > 0 && length > l.MaxElements {
+		return l.elementsError(f.path())
+	}
+	return nil
+}
+
+// FromJSONWithLimits is like FromJSON, but fails with a *LimitError if the
+// document exceeds the limits. They are checked on the input, before it's
+// parsed.
+func FromJSONWithLimits(input []byte, l Limits) (Value, error) {
+	if err := l.scanJSON(input); err != nil {
+		return nil, err
+	}
+	return FromJSON(input)
+}
+
+// scanJSON checks the limits on the brackets and commas of a JSON document,
+// ignoring those of its strings. Malformed documents are left to the
+// parser.
+func (l Limits) scanJSON(input []byte) error {
+	if l.IsZero() {
+		return nil
+	}
+	// counts holds the number of items of the open lists and maps.
+	var counts []int
+	opened := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		if opened {
+			opened = false
+			if c != ']' && c != '}' {
+				if l.MaxDepth > 0 && len(counts) > l.MaxDepth {
+					return l.depthError("")
+				}
+				counts[len(counts)-1] = 1
+			}
+		}
+		switch c {
+		case '"':
+			for i++; i < len(input) && input[i] != '"'; i++ {
+				if input[i] == '\\' {
+					i++
+				}
+			}
+		case '[', '{':
+			counts = append(counts, 0)
+			opened = true
+		case ']', '}':
+			if len(counts) > 0 {
+				counts = counts[:len(counts)-1]
+			}
+		case ',':
+			if len(counts) > 0 {
+				counts[len(counts)-1]++
+				if l.MaxElements > 0 && counts[len(counts)-1] > l.MaxElements {
+					return l.elementsError("")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// maxYAMLDepth is the deepest nesting of YAML documents, the same as the
+// one of the YAML parser, which only bounds the nesting of the document
+// before its aliases are expanded.
+const maxYAMLDepth = 10000
+
+// FromYAMLWithLimits is like unmarshaling a YAML document into an
+// interface{}, but fails with a *LimitError if the document exceeds the
+// limits. The document is decoded one level at a time, and the limits are
+// checked on each list and map before their items are decoded, so that
+// neither deep nesting nor the expansion of aliases builds more than the
+// limits allow.
+func FromYAMLWithLimits(input []byte, l Limits) (Value, error) {
+	if l.IsZero() {
+		var v interface{}
+		if err := yaml.Unmarshal(input, &v); err != nil {
+			return nil, err
+		}
+		return NewValueInterface(v), nil
+	}
+	var root yamlNode
+	if err := yaml.Unmarshal(input, &root); err != nil {
+		return nil, err
+	}
+	v, err := l.decodeYAML(&limitFrame{index: -1}, root.unmarshal)
+	if err != nil {
+		return nil, err
+	}
+	return NewValueInterface(v), nil
+}
+
+// yamlNode holds a node of a YAML document without decoding it.
+type yamlNode struct {
+	unmarshal func(interface{}) error
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (n *yamlNode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	n.unmarshal = unmarshal
+	return nil
+}
+
+// decodeYAML decodes the node of f, checking the limits on its items
+// before they are decoded, as yaml.Unmarshal would into an interface{}.
+func (l Limits) decodeYAML(f *limitFrame, unmarshal func(interface{}) error) (interface{}, error) {
+	if unmarshal == nil {
+		return nil, nil
+	}
+	if f.depth > maxYAMLDepth {
+		return nil, fmt.Errorf("yaml: exceeded max depth of %d", maxYAMLDepth)
+	}
+	var m map[interface{}]yamlNode
+	if err := unmarshal(&m); err == nil {
+		if m == nil {
+			return nil, nil
+		}
+		if err := l.checkContainer(f, len(m)); err != nil {
+			return nil, err
+		}
+		out := make(map[interface{}]interface{}, len(m))
+		for k, n := range m {
+			child := &limitFrame{depth: f.depth + 1, parent: f, key: fmt.Sprint(k), index: -1}
+			if out[k], err = l.decodeYAML(child, n.unmarshal); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	var items []yamlNode
+	if err := unmarshal(&items); err == nil {
+		if err := l.checkContainer(f, len(items)); err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, len(items))
+		for i, n := range items {
+			child := &limitFrame{depth: f.depth + 1, parent: f, index: i}
+			if out[i], err = l.decodeYAML(child, n.unmarshal); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	// Only scalars are left.
+	var v interface{}
+	err := unmarshal(&v)
+	return v, err
+}
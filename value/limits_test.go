@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestLimits(t *testing.T) {
+	limits := Limits{MaxDepth: 3, MaxElements: 3}
+	cases := []struct {
+		json string
+		// err is the expected error, empty if the value is within the
+		// limits.
+		err string
+	}{
+		{json: `1`},
+		{json: `{}`},
+		{json: `{"a": {"b": [1, 2, 3]}}`},
+		{json: `{"a": {"b": [[]]}}`},
+		{json: `{"a": [{}, {"b": "[[[[,,,,"}]}`},
+		{json: `{"a": {"b": [[1]]}}`, err: "depth"},
+		{json: `{"a": [1, 2, 3, 4]}`, err: "elements"},
+		{json: `{"a": 1, "b": 2, "c": 3, "d": 4}`, err: "elements"},
+	}
+	for _, c := range cases {
+		t.Run(c.json, func(t *testing.T) {
+			check := func(err error, path string) {
+				if c.err == "" {
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				le, ok := err.(*LimitError)
+				if !ok {
+					t.Fatalf("expected a *LimitError, got %v", err)
+				}
+				if le.Limit != c.err {
+					t.Errorf("expected the %v limit to be exceeded, got %v", c.err, le)
+				}
+				if le.Path != path {
+					t.Errorf("expected the path %q, got %q", path, le.Path)
+				}
+			}
+			_, err := FromJSONWithLimits([]byte(c.json), limits)
+			check(err, "")
+
+			v, err := FromJSON([]byte(c.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := ""
+			if c.err == "depth" {
+				path = ".a.b[0]"
+			} else if strings.Contains(c.json, `"a": [`) {
+				path = ".a"
+			}
+			check(limits.Check(v), path)
+
+			_, err = FromYAMLWithLimits([]byte(c.json), limits)
+			check(err, path)
+		})
+	}
+}
+
+func TestLimitsDeepValue(t *testing.T) {
+	// Neither check recurses, so they handle values deep enough to
+	// overflow the stack of a recursive parser.
+	deep := strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	_, err := FromJSONWithLimits([]byte(deep), Limits{MaxDepth: 100})
+	if le, ok := err.(*LimitError); !ok || le.Limit != "depth" {
+		t.Errorf("expected the depth limit to be exceeded, got %v", err)
+	}
+
+	var v interface{}
+	for i := 0; i < 100000; i++ {
+		v = []interface{}{v}
+	}
+	err = Limits{MaxDepth: 100}.Check(NewValueInterface(v))
+	if le, ok := err.(*LimitError); !ok || le.Limit != "depth" {
+		t.Errorf("expected the depth limit to be exceeded, got %v", err)
+	}
+}
+
+func TestFromYAMLWithLimits(t *testing.T) {
+	limits := Limits{MaxDepth: 5, MaxElements: 5}
+	for _, doc := range []string{
+		``,
+		`null`,
+		`a`,
+		`[]`,
+		`{}`,
+		"a: 1\nb: [x, {c: null}]\nd: {}\ne: []\nf: ~",
+		"base: &base {a: 1, b: 2}\nderived:\n  <<: *base\n  b: 3\nlist: [*base, *base]",
+		"1: one\ntrue: yes",
+	} {
+		var expected interface{}
+		if err := yaml.Unmarshal([]byte(doc), &expected); err != nil {
+			t.Fatal(err)
+		}
+		got, err := FromYAMLWithLimits([]byte(doc), limits)
+		if err != nil {
+			t.Errorf("%q: failed to parse: %v", doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Unstructured(), expected) {
+			t.Errorf("%q: expected %#v, got %#v", doc, expected, got.Unstructured())
+		}
+	}
+
+	// Chains of aliases, aliases of themselves and deep documents stop
+	// being decoded at the depth limit.
+	var doc strings.Builder
+	doc.WriteString("a0: &a0 [1]\n")
+	for i := 1; i < 20; i++ {
+		doc.WriteString("a" + strconv.Itoa(i) + ": &a" + strconv.Itoa(i) + " [*a" + strconv.Itoa(i-1) + "]\n")
+	}
+	for _, doc := range []string{doc.String(), "a: &a [*a]", strings.Repeat("[", 5000) + strings.Repeat("]", 5000)} {
+		_, err := FromYAMLWithLimits([]byte(doc), Limits{MaxDepth: 5, MaxElements: 100})
+		if le, ok := err.(*LimitError); !ok || le.Limit != "depth" {
+			t.Errorf("expected the depth limit to be exceeded, got %v", err)
+		}
+	}
+
+	// Only the expansion of its aliases makes b exceed the limit.
+	_, err := FromYAMLWithLimits([]byte("a: &a [1, 2, 3]\nb: [*a, *a]"), Limits{MaxDepth: 2})
+	if le, ok := err.(*LimitError); !ok || le.Limit != "depth" || le.Path != ".b[0]" {
+		t.Errorf("expected the depth limit to be exceeded at .b[0], got %v", err)
+	}
+}
@@ -19,13 +19,30 @@ package value
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// ErrUnsupportedType classifies the errors, and panics, about Go types
+// that can't be converted to or from unstructured values.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// unsupportedTypeError is an error classified as ErrUnsupportedType.
+type unsupportedTypeError string
+
+func (e unsupportedTypeError) Error() string {
+	return string(e)
+}
+
+func (e unsupportedTypeError) Is(target error) bool {
+	return target == ErrUnsupportedType
+}
+
 // UnstructuredConverter defines how a type can be converted directly to unstructured.
 // Types that implement json.Marshaler may also optionally implement this interface to provide a more
 // direct and more efficient conversion. All types that choose to implement this interface must still
@@ -37,6 +54,25 @@ type UnstructuredConverter interface {
 	ToUnstructured() interface{}
 }
 
+// RawJSONConverter can be implemented by types that hold serialized JSON,
+// like RawExtension, so that their JSON is decoded directly when converting
+// them to unstructured, and captured as is when converting them from
+// unstructured, instead of being copied through json.Marshaler and
+// json.Unmarshaler. json.RawMessage is converted this way too. Since
+// SetRawJSON modifies the value, the interface is usually implemented by
+// pointers to the type.
+type RawJSONConverter interface {
+	json.Marshaler // require that json.Marshaler is implemented
+
+	// RawJSON returns the serialized JSON held by the value, which must
+	// not be modified, or nil if it doesn't hold any, e.g. because it
+	// holds a decoded object, in which case MarshalJSON is used.
+	RawJSON() []byte
+	// SetRawJSON makes the value hold the serialized JSON, which it may
+	// retain.
+	SetRawJSON([]byte)
+}
+
 // TypeReflectCacheEntry keeps data gathered using reflection about how a type is converted to/from unstructured.
 type TypeReflectCacheEntry struct {
 	isJsonMarshaler        bool
@@ -45,6 +81,9 @@ type TypeReflectCacheEntry struct {
 	ptrIsJsonUnmarshaler   bool
 	isStringConvertable    bool
 	ptrIsStringConvertable bool
+	isRawJSON              bool
+	ptrIsRawJSON           bool
+	isRawMessage           bool
 
 	structFields        map[string]*FieldCacheEntry
 	orderedStructFields []*FieldCacheEntry
@@ -82,14 +121,29 @@ func (f *FieldCacheEntry) GetFrom(structVal reflect.Value) reflect.Value {
 var marshalerType = reflect.TypeOf(new(json.Marshaler)).Elem()
 var unmarshalerType = reflect.TypeOf(new(json.Unmarshaler)).Elem()
 var unstructuredConvertableType = reflect.TypeOf(new(UnstructuredConverter)).Elem()
+var rawJSONConverterType = reflect.TypeOf(new(RawJSONConverter)).Elem()
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
 var defaultReflectCache = newReflectCache()
 
+// The lookups of TypeReflectEntryOf that found their type in the cache,
+// and those that didn't.
+var reflectCacheHits, reflectCacheMisses uint64
+
+// ReflectCacheStats returns the number of lookups of types in the cache of
+// TypeReflectEntryOf, used by the conversions of structured objects, that
+// hit and missed the cache since the program started.
+func ReflectCacheStats() (hits, misses uint64) {
+	return atomic.LoadUint64(&reflectCacheHits), atomic.LoadUint64(&reflectCacheMisses)
+}
+
 // TypeReflectEntryOf returns the TypeReflectCacheEntry of the provided reflect.Type.
 func TypeReflectEntryOf(t reflect.Type) *TypeReflectCacheEntry {
 	cm := defaultReflectCache.get()
 	if record, ok := cm[t]; ok {
+		atomic.AddUint64(&reflectCacheHits, 1)
 		return record
 	}
+	atomic.AddUint64(&reflectCacheMisses, 1)
 	updates := reflectCacheMap{}
 	result := typeReflectEntryOf(cm, t, updates)
 	if len(updates) > 0 {
@@ -113,6 +167,9 @@ func typeReflectEntryOf(cm reflectCacheMap, t reflect.Type, updates reflectCache
 		isJsonUnmarshaler:      reflect.PtrTo(t).Implements(unmarshalerType),
 		isStringConvertable:    t.Implements(unstructuredConvertableType),
 		ptrIsStringConvertable: reflect.PtrTo(t).Implements(unstructuredConvertableType),
+		isRawJSON:              t.Implements(rawJSONConverterType),
+		ptrIsRawJSON:           reflect.PtrTo(t).Implements(rawJSONConverterType),
+		isRawMessage:           t == rawMessageType,
 	}
 	if t.Kind() == reflect.Struct {
 		fieldEntries := map[string]*FieldCacheEntry{}
@@ -181,6 +238,23 @@ func (e TypeReflectCacheEntry) CanConvertToUnstructured() bool {
 
 // ToUnstructured converts the provided value to unstructured and returns it.
 func (e TypeReflectCacheEntry) ToUnstructured(sv reflect.Value) (interface{}, error) {
+	if !countingConversions() {
+		return e.toUnstructured(sv)
+	}
+	start := time.Now()
+	u, err := e.toUnstructured(sv)
+	viaJSON := false
+	if sv.Kind() != reflect.Ptr || !sv.IsNil() {
+		_, converts := e.getUnstructuredConverter(sv)
+		raw, _ := e.getRawJSON(sv)
+		_, marshals := e.getJsonMarshaler(sv)
+		viaJSON = !converts && len(raw) == 0 && marshals
+	}
+	countToUnstructured(sv.Type(), start, viaJSON)
+	return u, err
+}
+
+func (e TypeReflectCacheEntry) toUnstructured(sv reflect.Value) (interface{}, error) {
 	// This is based on https://github.com/kubernetes/kubernetes/blob/82c9e5c814eb7acc6cc0a090c057294d0667ad66/staging/src/k8s.io/apimachinery/pkg/runtime/converter.go#L505
 	// and is intended to replace it.
 
@@ -194,76 +268,88 @@ func (e TypeReflectCacheEntry) ToUnstructured(sv reflect.Value) (interface{}, er
 	if converter, ok := e.getUnstructuredConverter(sv); ok {
 		return converter.ToUnstructured(), nil
 	}
+	// Decode the JSON held by raw types directly.
+	if raw, ok := e.getRawJSON(sv); ok && len(raw) > 0 {
+		return unstructuredFromJSON(raw)
+	}
 	// Check if the object has a custom JSON marshaller/unmarshaller.
 	if marshaler, ok := e.getJsonMarshaler(sv); ok {
 		data, err := marshaler.MarshalJSON()
 		if err != nil {
 			return nil, err
 		}
-		switch {
-		case len(data) == 0:
-			return nil, fmt.Errorf("error decoding from json: empty value")
+		return unstructuredFromJSON(data)
+	}
 
-		case bytes.Equal(data, nullBytes):
-			// We're done - we don't need to store anything.
-			return nil, nil
+	return nil, unsupportedTypeError(fmt.Sprintf("provided type cannot be converted: %v", sv.Type()))
+}
 
-		case bytes.Equal(data, trueBytes):
-			return true, nil
+// unstructuredFromJSON decodes serialized JSON into an unstructured value.
+func unstructuredFromJSON(data []byte) (interface{}, error) {
+	switch {
+	case len(data) == 0:
+		return nil, fmt.Errorf("error decoding from json: empty value")
 
-		case bytes.Equal(data, falseBytes):
-			return false, nil
+	case bytes.Equal(data, nullBytes):
+		// We're done - we don't need to store anything.
+		return nil, nil
 
-		case data[0] == '"':
-			var result string
-			err := unmarshal(data, &result)
-			if err != nil {
-				return nil, fmt.Errorf("error decoding string from json: %v", err)
-			}
-			return result, nil
+	case bytes.Equal(data, trueBytes):
+		return true, nil
 
-		case data[0] == '{':
-			result := make(map[string]interface{})
-			err := unmarshal(data, &result)
-			if err != nil {
-				return nil, fmt.Errorf("error decoding object from json: %v", err)
-			}
-			return result, nil
+	case bytes.Equal(data, falseBytes):
+		return false, nil
 
-		case data[0] == '[':
-			result := make([]interface{}, 0)
-			err := unmarshal(data, &result)
-			if err != nil {
-				return nil, fmt.Errorf("error decoding array from json: %v", err)
-			}
-			return result, nil
-
-		default:
-			var (
-				resultInt   int64
-				resultFloat float64
-				err         error
-			)
-			if err = unmarshal(data, &resultInt); err == nil {
-				return resultInt, nil
-			} else if err = unmarshal(data, &resultFloat); err == nil {
-				return resultFloat, nil
-			} else {
-				return nil, fmt.Errorf("error decoding number from json: %v", err)
-			}
+	case data[0] == '"':
+		var result string
+		err := unmarshal(data, &result)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding string from json: %v", err)
 		}
-	}
+		return result, nil
+
+	case data[0] == '{':
+		result := make(map[string]interface{})
+		err := unmarshal(data, &result)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding object from json: %v", err)
+		}
+		return result, nil
 
-	return nil, fmt.Errorf("provided type cannot be converted: %v", sv.Type())
+	case data[0] == '[':
+		result := make([]interface{}, 0)
+		err := unmarshal(data, &result)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding array from json: %v", err)
+		}
+		return result, nil
+
+	default:
+		var (
+			resultInt   int64
+			resultFloat float64
+			err         error
+		)
+		if err = unmarshal(data, &resultInt); err == nil {
+			return resultInt, nil
+		} else if err = unmarshal(data, &resultFloat); err == nil {
+			return resultFloat, nil
+		} else {
+			return nil, fmt.Errorf("error decoding number from json: %v", err)
+		}
+	}
 }
 
 // CanConvertFromUnstructured returns true if this TypeReflectCacheEntry can convert objects of the type from unstructured.
 func (e TypeReflectCacheEntry) CanConvertFromUnstructured() bool {
-	return e.isJsonUnmarshaler
+	return e.isJsonUnmarshaler || e.ptrIsRawJSON
 }
 
 // FromUnstructured converts the provided source value from unstructured into the provided destination value.
 func (e TypeReflectCacheEntry) FromUnstructured(sv, dv reflect.Value) error {
+	if countingConversions() {
+		defer countFromUnstructured(dv.Type(), time.Now())
+	}
 	// TODO: this could be made much more efficient using direct conversions like
 	// UnstructuredConverter.ToUnstructured provides.
 	st := dv.Type()
@@ -271,6 +357,10 @@ func (e TypeReflectCacheEntry) FromUnstructured(sv, dv reflect.Value) error {
 	if err != nil {
 		return fmt.Errorf("error encoding %s to json: %v", st.String(), err)
 	}
+	// The JSON was just encoded, so raw types can retain it.
+	if e.setRawJSON(dv, data) {
+		return nil
+	}
 	if unmarshaler, ok := e.getJsonUnmarshaler(dv); ok {
 		return unmarshaler.UnmarshalJSON(data)
 	}
@@ -304,6 +394,33 @@ func (e TypeReflectCacheEntry) getJsonUnmarshaler(v reflect.Value) (json.Unmarsh
 	return v.Addr().Interface().(json.Unmarshaler), true
 }
 
+// getRawJSON returns the JSON held by v if its type is a raw type.
+func (e TypeReflectCacheEntry) getRawJSON(v reflect.Value) ([]byte, bool) {
+	if e.isRawMessage {
+		return v.Bytes(), true
+	}
+	if e.isRawJSON {
+		return v.Interface().(RawJSONConverter).RawJSON(), true
+	}
+	if e.ptrIsRawJSON && v.Kind() != reflect.Ptr && v.CanAddr() {
+		return v.Addr().Interface().(RawJSONConverter).RawJSON(), true
+	}
+	return nil, false
+}
+
+// setRawJSON makes v hold data, if its type is a raw type.
+func (e TypeReflectCacheEntry) setRawJSON(v reflect.Value, data []byte) bool {
+	if e.isRawMessage && v.CanSet() {
+		v.SetBytes(data)
+		return true
+	}
+	if e.ptrIsRawJSON && v.CanAddr() {
+		v.Addr().Interface().(RawJSONConverter).SetRawJSON(data)
+		return true
+	}
+	return false
+}
+
 func (e TypeReflectCacheEntry) getUnstructuredConverter(v reflect.Value) (UnstructuredConverter, bool) {
 	if e.isStringConvertable {
 		return v.Interface().(UnstructuredConverter), true
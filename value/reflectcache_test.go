@@ -17,6 +17,8 @@ limitations under the License.
 package value
 
 import (
+	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -199,3 +201,133 @@ func TestTypeReflectEntryOf(t *testing.T) {
 		})
 	}
 }
+
+func TestUnsupportedType(t *testing.T) {
+	type plain struct{ A int }
+	entry := TypeReflectEntryOf(reflect.TypeOf(plain{}))
+	if _, err := entry.ToUnstructured(reflect.ValueOf(plain{})); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected an error wrapping ErrUnsupportedType, got %v", err)
+	}
+	var dst plain
+	if err := entry.FromUnstructured(reflect.ValueOf(map[string]interface{}{}), reflect.ValueOf(&dst).Elem()); err == nil || errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected an unmarshaling error, got %v", err)
+	} else if err.Error() != "unable to unmarshal map[string]interface {} into value.plain" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestConversionStats(t *testing.T) {
+	EnableConversionStats(true)
+	defer EnableConversionStats(false)
+	ResetConversionStats()
+	defer ResetConversionStats()
+
+	custom := reflect.ValueOf(CustomValue{data: []byte(`"a"`)})
+	converted := reflect.ValueOf(Time{time.Now()})
+	for i := 0; i < 3; i++ {
+		if _, err := TypeReflectEntryOf(custom.Type()).ToUnstructured(custom); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := TypeReflectEntryOf(converted.Type()).ToUnstructured(converted); err != nil {
+		t.Fatal(err)
+	}
+	var upper intoGoUpper
+	dst := reflect.ValueOf(&upper).Elem()
+	if err := TypeReflectEntryOf(dst.Type()).FromUnstructured(reflect.ValueOf("b"), dst); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := ConversionStatsByType()
+	if s := stats[custom.Type()]; s.ToUnstructured != 3 || s.JSONFallbacks != 3 || s.FromUnstructured != 0 {
+		t.Errorf("unexpected stats of %v: %+v", custom.Type(), s)
+	}
+	if s := stats[converted.Type()]; s.ToUnstructured != 1 || s.JSONFallbacks != 0 {
+		t.Errorf("unexpected stats of %v: %+v", converted.Type(), s)
+	}
+	if s := stats[dst.Type()]; s.FromUnstructured != 1 || s.JSONFallbacks != 1 || s.ToUnstructured != 0 {
+		t.Errorf("unexpected stats of %v: %+v", dst.Type(), s)
+	}
+
+	EnableConversionStats(false)
+	ResetConversionStats()
+	if _, err := TypeReflectEntryOf(custom.Type()).ToUnstructured(custom); err != nil {
+		t.Fatal(err)
+	}
+	if stats := ConversionStatsByType(); len(stats) != 0 {
+		t.Errorf("expected no stats when disabled, got %v", stats)
+	}
+}
+
+// rawExtension mimics https://github.com/kubernetes/apimachinery/blob/master/pkg/runtime/types.go.
+type rawExtension struct {
+	Raw []byte
+	// marshaled and unmarshaled count the calls to MarshalJSON and
+	// UnmarshalJSON.
+	marshaled, unmarshaled int
+}
+
+func (r *rawExtension) MarshalJSON() ([]byte, error) {
+	r.marshaled++
+	if r.Raw == nil {
+		return []byte(`{"object":true}`), nil
+	}
+	return r.Raw, nil
+}
+
+func (r *rawExtension) UnmarshalJSON(data []byte) error {
+	r.unmarshaled++
+	r.Raw = append(r.Raw[:0], data...)
+	return nil
+}
+
+func (r *rawExtension) RawJSON() []byte { return r.Raw }
+
+func (r *rawExtension) SetRawJSON(data []byte) { r.Raw = data }
+
+func TestRawJSON(t *testing.T) {
+	type object struct {
+		Ext     rawExtension    `json:"ext"`
+		Empty   rawExtension    `json:"empty"`
+		Message json.RawMessage `json:"message"`
+	}
+	obj := &object{
+		Ext:     rawExtension{Raw: []byte(`{"a":[1,"b"]}`)},
+		Message: json.RawMessage(`"c"`),
+	}
+	got, err := ToJSON(MustReflect(obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"empty":{"object":true},"ext":{"a":[1,"b"]},"message":"c"}`; string(got) != want {
+		t.Errorf("expected %v, got %v", want, string(got))
+	}
+	if obj.Ext.marshaled != 0 || obj.Empty.marshaled != 1 {
+		t.Errorf("expected only the extension without raw JSON to be marshaled, got %v and %v calls", obj.Ext.marshaled, obj.Empty.marshaled)
+	}
+
+	var dst object
+	dv := reflect.ValueOf(&dst).Elem()
+	src := reflect.ValueOf(map[string]interface{}{"d": int64(1)})
+	if err := TypeReflectEntryOf(reflect.TypeOf(rawExtension{})).FromUnstructured(src, dv.Field(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := TypeReflectEntryOf(reflect.TypeOf(json.RawMessage{})).FromUnstructured(src, dv.Field(2)); err != nil {
+		t.Fatal(err)
+	}
+	if string(dst.Ext.Raw) != `{"d":1}` || string(dst.Message) != `{"d":1}` || dst.Ext.unmarshaled != 0 {
+		t.Errorf("expected the raw JSON to be captured, got %+v", dst)
+	}
+
+	dst = object{}
+	v, err := FromJSON([]byte(`{"ext": [true], "message": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := IntoGo(v, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if string(dst.Ext.Raw) != `[true]` || dst.Ext.unmarshaled != 0 || dst.Message != nil {
+		t.Errorf("expected the raw JSON to be captured, got %+v", dst)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncatedDepth is the depth below which StringTruncated elides the
+// content of maps and lists.
+const truncatedDepth = 8
+
+// StringTruncated returns a human-readable, JSON-like representation of
+// v that is at most maxBytes long, e.g. to include snippets of objects in
+// debug logs. The fields of maps are sorted, maps and lists nested deeper
+// than a few levels are elided as {...} and [...], and output longer than
+// maxBytes is cut and ends with "...". A maxBytes of zero or less doesn't
+// limit the size.
+func StringTruncated(v Value, maxBytes int) string {
+	p := truncatingPrinter{limit: maxBytes}
+	p.print(v, 0)
+	out := p.b.String()
+	if maxBytes <= 0 || len(out) <= maxBytes {
+		return out
+	}
+	const ellipsis = "..."
+	if maxBytes <= len(ellipsis) {
+		return ellipsis[:maxBytes]
+	}
+	n := maxBytes - len(ellipsis)
+	// Don't cut runes in half.
+	for n > 0 && !utf8.RuneStart(out[n]) {
+		n--
+	}
+	return out[:n] + ellipsis
+}
+
+type truncatingPrinter struct {
+	b     strings.Builder
+	limit int
+}
+
+// full returns whether the printer has printed more than its limit, after
+// which the rest of the value doesn't need to be visited.
+func (p *truncatingPrinter) full() bool {
+	return p.limit > 0 && p.b.Len() > p.limit
+}
+
+func (p *truncatingPrinter) print(v Value, depth int) {
+	switch {
+	case v == nil || v.IsNull():
+		p.b.WriteString("null")
+	case v.IsFloat():
+		p.b.WriteString(strconv.FormatFloat(v.AsFloat(), 'g', -1, 64))
+	case v.IsInt():
+		p.b.WriteString(strconv.FormatInt(v.AsInt(), 10))
+	case v.IsString():
+		p.b.WriteString(strconv.Quote(v.AsString()))
+	case v.IsBool():
+		p.b.WriteString(strconv.FormatBool(v.AsBool()))
+	case v.IsList():
+		l := v.AsList()
+		if l.Length() > 0 && depth >= truncatedDepth {
+			p.b.WriteString("[...]")
+			return
+		}
+		p.b.WriteByte('[')
+		for i := 0; i < l.Length() && !p.full(); i++ {
+			if i > 0 {
+				p.b.WriteByte(',')
+			}
+			p.print(l.At(i), depth+1)
+		}
+		p.b.WriteByte(']')
+	case v.IsMap():
+		m := v.AsMap()
+		if m.Length() > 0 && depth >= truncatedDepth {
+			p.b.WriteString("{...}")
+			return
+		}
+		keys := make([]string, 0, m.Length())
+		m.Iterate(func(k string, _ Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		sort.Strings(keys)
+		p.b.WriteByte('{')
+		for i, k := range keys {
+			if p.full() {
+				break
+			}
+			if i > 0 {
+				p.b.WriteByte(',')
+			}
+			p.b.WriteString(strconv.Quote(k))
+			p.b.WriteByte(':')
+			child, _ := m.Get(k)
+			p.print(child, depth+1)
+		}
+		p.b.WriteByte('}')
+	}
+}
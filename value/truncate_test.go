@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringTruncated(t *testing.T) {
+	deep := strings.Repeat(`{"a":`, 10) + `[1]` + strings.Repeat(`}`, 10)
+	cases := []struct {
+		json     string
+		maxBytes int
+		want     string
+	}{
+		{json: `null`, maxBytes: 10, want: `null`},
+		{json: `{"b": [1, 2.5, true], "a": "x"}`, maxBytes: 0, want: `{"a":"x","b":[1,2.5,true]}`},
+		{json: `{"b": [1, 2.5, true], "a": "x"}`, maxBytes: 26, want: `{"a":"x","b":[1,2.5,true]}`},
+		{json: `{"b": [1, 2.5, true], "a": "x"}`, maxBytes: 25, want: `{"a":"x","b":[1,2.5,tr...`},
+		{json: `{"b": [1, 2.5, true], "a": "x"}`, maxBytes: 2, want: `..`},
+		{json: `"ééé"`, maxBytes: 7, want: `"é...`},
+		{json: `{"a": {}, "b": []}`, maxBytes: 0, want: `{"a":{},"b":[]}`},
+		{json: deep, maxBytes: 0, want: strings.Repeat(`{"a":`, 8) + `{...}` + strings.Repeat(`}`, 8)},
+	}
+	for _, tc := range cases {
+		v, err := FromJSON([]byte(tc.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := StringTruncated(v, tc.maxBytes); got != tc.want {
+			t.Errorf("%v truncated to %v bytes: expected %v, got %v", tc.json, tc.maxBytes, tc.want, got)
+		}
+	}
+
+	// Large values are only visited until the limit is reached.
+	items := make([]interface{}, 100000)
+	for i := range items {
+		items[i] = int64(i)
+	}
+	if got := StringTruncated(NewValueInterface(items), 20); got != "[0,1,2,3,4,5,6,7,..." {
+		t.Errorf("unexpected truncated list %v", got)
+	}
+}
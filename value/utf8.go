@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// UTF8Policy is how invalid UTF-8 in strings, including the keys of maps,
+// is handled by the readers and writers taking a policy. Consumers have
+// conflicting requirements: some must preserve bytes exactly, some must
+// never store or emit invalid text.
+//
+// Without a policy, FromJSON and FromJSONNoCopy pass invalid bytes through,
+// FromJSONStrict rejects them, ToJSON replaces them and ToYAML emits the
+// strings holding them as !!binary.
+type UTF8Policy int
+
+const (
+	// UTF8PassThrough keeps invalid bytes as they are. JSON writers
+	// emit them unescaped, which doesn't produce valid UTF-8 but reads
+	// back to the same bytes, and YAML writers emit the strings holding
+	// them as !!binary, which YAML readers decode to the same bytes.
+	UTF8PassThrough UTF8Policy = iota
+	// UTF8Replace replaces each invalid sequence with U+FFFD.
+	UTF8Replace
+	// UTF8Reject fails with an *InvalidUTF8Error.
+	UTF8Reject
+)
+
+// InvalidUTF8Error is returned for values holding invalid UTF-8 under the
+// UTF8Reject policy, or for maps whose keys would collide once replaced
+// under the UTF8Replace policy.
+type InvalidUTF8Error struct {
+	// Path is the path of the string or the map, like .metadata.name.
+	Path string
+	// Collision is set if replacing invalid UTF-8 made keys collide.
+	Collision bool
+}
+
+func (e *InvalidUTF8Error) Error() string {
+	msg := "invalid UTF-8"
+	if e.Collision {
+		msg = "keys collide once invalid UTF-8 is replaced"
+	}
+	if e.Path == "" {
+		return msg
+	}
+	return fmt.Sprintf("%v: %v", e.Path, msg)
+}
+
+// Apply returns v with the policy applied to its strings: as is if they
+// are all valid or the policy is UTF8PassThrough, a copy with the invalid
+// sequences replaced with UTF8Replace, or an error with UTF8Reject.
+func (p UTF8Policy) Apply(v Value) (Value, error) {
+	if p == UTF8PassThrough || v == nil {
+		return v, nil
+	}
+	u := v.Unstructured()
+	path := validUTF8(u, "")
+	if path == nil {
+		return v, nil
+	}
+	if p == UTF8Reject {
+		return nil, &InvalidUTF8Error{Path: *path}
+	}
+	fixed, err := replaceInvalidUTF8(u, "")
+	if err != nil {
+		return nil, err
+	}
+	return NewValueInterface(fixed), nil
+}
+
+// validUTF8 returns the path of the first string of u with invalid UTF-8,
+// in the order of the sorted keys of maps, or nil if there is none.
+func validUTF8(u interface{}, path string) *string {
+	switch u := u.(type) {
+	case string:
+		if !utf8.ValidString(u) {
+			return &path
+		}
+	case []interface{}:
+		for i, item := range u {
+			if p := validUTF8(item, path+"["+strconv.Itoa(i)+"]"); p != nil {
+				return p
+			}
+		}
+	case map[string]interface{}, map[interface{}]interface{}:
+		m := stringMap(u)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !utf8.ValidString(k) {
+				return &path
+			}
+			if p := validUTF8(m[k], path+"."+k); p != nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// stringMap returns the map with the string keys of u, a map, ignoring the
+// others as the Map of u does.
+func stringMap(u interface{}) map[string]interface{} {
+	if m, ok := u.(map[string]interface{}); ok {
+		return m
+	}
+	m := map[string]interface{}{}
+	for k, v := range u.(map[interface{}]interface{}) {
+		if ks, ok := k.(string); ok {
+			m[ks] = v
+		}
+	}
+	return m
+}
+
+// replaceInvalidUTF8 returns a copy of u with the invalid sequences of its
+// strings replaced.
+func replaceInvalidUTF8(u interface{}, path string) (interface{}, error) {
+	switch u := u.(type) {
+	case string:
+		return strings.ToValidUTF8(u, string(utf8.RuneError)), nil
+	case []interface{}:
+		out := make([]interface{}, len(u))
+		for i, item := range u {
+			var err error
+			if out[i], err = replaceInvalidUTF8(item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case map[string]interface{}, map[interface{}]interface{}:
+		m := stringMap(u)
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			fixedKey := strings.ToValidUTF8(k, string(utf8.RuneError))
+			if _, ok := out[fixedKey]; ok {
+				return nil, &InvalidUTF8Error{Path: path, Collision: true}
+			}
+			fixed, err := replaceInvalidUTF8(v, path+"."+fixedKey)
+			if err != nil {
+				return nil, err
+			}
+			out[fixedKey] = fixed
+		}
+		return out, nil
+	}
+	return u, nil
+}
+
+// passThroughPool writes strings without validating them, which is what
+// ConfigCompatibleWithStandardLibrary does only without HTML escaping.
+var passThroughPool = jsoniter.NewStream(jsoniter.Config{
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+}.Froze(), nil, 1024).Pool()
+
+// FromJSONWithUTF8Policy is like FromJSON, applying the policy to the
+// strings of the document.
+func FromJSONWithUTF8Policy(input []byte, p UTF8Policy) (Value, error) {
+	v, err := FromJSON(input)
+	if err != nil {
+		return nil, err
+	}
+	return p.Apply(v)
+}
+
+// ToJSONWithUTF8Policy is like ToJSON, applying the policy to the strings
+// of the value. With UTF8PassThrough, <, > and & aren't escaped either.
+func ToJSONWithUTF8Policy(v Value, p UTF8Policy) ([]byte, error) {
+	if p != UTF8PassThrough {
+		var err error
+		if v, err = p.Apply(v); err != nil {
+			return nil, err
+		}
+		return ToJSON(v)
+	}
+	buf := bytes.Buffer{}
+	stream := passThroughPool.BorrowStream(&buf)
+	defer passThroughPool.ReturnStream(stream)
+	WriteJSONStream(v, stream)
+	err := stream.Flush()
+	return buf.Bytes(), err
+}
+
+// ToYAMLWithUTF8Policy is like ToYAML, applying the policy to the strings
+// of the value.
+func ToYAMLWithUTF8Policy(v Value, p UTF8Policy) ([]byte, error) {
+	v, err := p.Apply(v)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(v.Unstructured())
+}
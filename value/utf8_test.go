@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value_test
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestUTF8Policy(t *testing.T) {
+	input := []byte("{\"a\": [\"ok\", \"b\xffc\"], \"k\xfe\": 1}")
+
+	v, err := value.FromJSONWithUTF8Policy(input, value.UTF8PassThrough)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.AsMap().Length(); got != 2 {
+		t.Errorf("expected 2 keys, got %v", got)
+	}
+	j, err := value.ToJSONWithUTF8Policy(v, value.UTF8PassThrough)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{\"a\":[\"ok\",\"b\xffc\"],\"k\xfe\":1}"; string(j) != expected {
+		t.Errorf("expected the bytes to be passed through, got %q", j)
+	}
+	y, err := value.ToYAMLWithUTF8Policy(v, value.UTF8PassThrough)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roundTripped interface{}
+	if err := yaml.Unmarshal(y, &roundTripped); err != nil {
+		t.Fatal(err)
+	}
+	if !value.Equals(v, value.NewValueInterface(roundTripped)) {
+		t.Errorf("expected %q to read back as %v", y, value.ToString(v))
+	}
+
+	v, err = value.FromJSONWithUTF8Policy(input, value.UTF8Replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err = value.ToJSONWithUTF8Policy(v, value.UTF8Replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"a":["ok","b` + "�" + `c"],"k` + "�" + `":1}`; string(j) != expected {
+		t.Errorf("expected %q, got %q", expected, j)
+	}
+
+	_, err = value.FromJSONWithUTF8Policy(input, value.UTF8Reject)
+	if e, ok := err.(*value.InvalidUTF8Error); !ok || e.Path != ".a[1]" {
+		t.Errorf("expected an invalid UTF-8 error at .a[1], got %v", err)
+	}
+	v, err = value.FromJSON(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := value.ToYAMLWithUTF8Policy(v, value.UTF8Reject); err == nil {
+		t.Error("expected writing invalid UTF-8 to be rejected")
+	}
+
+	colliding, err := value.FromJSON([]byte("{\"k\xfe\": 1, \"k\xff\": 2}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := value.UTF8Replace.Apply(colliding); err == nil {
+		t.Error("expected colliding keys to be rejected")
+	}
+
+	valid, err := value.FromJSON([]byte(`{"a": ["é"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []value.UTF8Policy{value.UTF8PassThrough, value.UTF8Replace, value.UTF8Reject} {
+		if got, err := p.Apply(valid); err != nil || got != valid {
+			t.Errorf("expected policy %v to keep valid values as is, got %v, %v", p, got, err)
+		}
+	}
+}
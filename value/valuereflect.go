@@ -65,6 +65,7 @@ func (r *valueReflect) reuse(value reflect.Value, cacheEntry *TypeReflectCacheEn
 	if cacheEntry == nil {
 		cacheEntry = TypeReflectEntryOf(value.Type())
 	}
+	r.goType = value.Type()
 	if cacheEntry.CanConvertToUnstructured() {
 		u, err := cacheEntry.ToUnstructured(value)
 		if err != nil {
@@ -106,6 +107,9 @@ type valueReflect struct {
 	ParentMapKey *reflect.Value
 	Value        reflect.Value
 	kind         reflectType
+	// goType is the Go type of the wrapped value, before its conversion
+	// to unstructured, if any.
+	goType reflect.Type
 }
 
 func (r valueReflect) IsMap() bool {
@@ -186,9 +190,9 @@ func kind(v reflect.Value) reflectType {
 		if v.IsNil() {
 			return nullType
 		}
-		panic(fmt.Sprintf("unsupported type: %v", v.Type()))
+		panic(fmt.Errorf("%w: %v", ErrUnsupportedType, v.Type()))
 	default:
-		panic(fmt.Sprintf("unsupported type: %v", v.Type()))
+		panic(fmt.Errorf("%w: %v", ErrUnsupportedType, v.Type()))
 	}
 }
 
@@ -289,6 +293,6 @@ func (r valueReflect) Unstructured() interface{} {
 	case r.IsFloat():
 		return r.AsFloat()
 	default:
-		panic(fmt.Sprintf("value of type %s is not a supported by value reflector", val.Type()))
+		panic(unsupportedTypeError(fmt.Sprintf("value of type %s is not a supported by value reflector", val.Type())))
 	}
 }
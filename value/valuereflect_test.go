@@ -760,3 +760,47 @@ func TestMapZip(t *testing.T) {
 		})
 	}
 }
+
+func TestReflectRawMessage(t *testing.T) {
+	raw := json.RawMessage(`{"a":[1,"b",null]}`)
+	type object struct {
+		Message json.RawMessage            `json:"message"`
+		Pointer *json.RawMessage           `json:"pointer"`
+		List    []json.RawMessage          `json:"list"`
+		Map     map[string]json.RawMessage `json:"map"`
+		Empty   json.RawMessage            `json:"empty,omitempty"`
+		Nil     json.RawMessage            `json:"nil"`
+	}
+	obj := &object{
+		Message: raw,
+		Pointer: &raw,
+		List:    []json.RawMessage{raw, json.RawMessage(`2.5`)},
+		Map:     map[string]json.RawMessage{"k": json.RawMessage(`"v"`)},
+	}
+
+	// Raw messages are the values they hold, as with encoding/json, not
+	// their base64-encoded bytes.
+	got, err := ToJSON(MustReflect(obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotObj, wantObj interface{}
+	if err := json.Unmarshal(got, &gotObj); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(want, &wantObj); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotObj, wantObj) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	message, _ := MustReflect(obj).AsMap().Get("message")
+	if !message.IsMap() {
+		t.Errorf("expected the message to be a map, got %v", message)
+	}
+}